@@ -0,0 +1,196 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+// Package chat posts the events as message cards to the bots of the chat
+// platforms: Feishu, WeCom, DingTalk and Slack.
+package chat
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/webhook"
+)
+
+// the chat platforms
+const (
+	PlatformFeishu   = "feishu"
+	PlatformWecom    = "wecom"
+	PlatformDingtalk = "dingtalk"
+	PlatformSlack    = "slack"
+)
+
+// DefaultButtonText is the label of the button opening the source url.
+const DefaultButtonText = "查看详情"
+
+// the part of the response read and quoted in the errors
+const maxResponseLen = 64 << 10
+
+// Card is a message card, the button opens SourceUrl and is left out when it
+// is empty.
+type Card struct {
+	Title      string `json:"title"`
+	Summary    string `json:"summary"`
+	SourceUrl  string `json:"source_url"`
+	ButtonText string `json:"button_text"`
+}
+
+// Bot is the incoming webhook of a bot, WeCom and Slack carry the key in URL
+// and have no Secret.
+type Bot struct {
+	Platform string
+	URL      string
+	Secret   string
+}
+
+// platform renders the cards and reads the responses of a chat platform.
+type platform interface {
+	// request returns the url and the body posting card to bot at now.
+	request(bot Bot, card Card, now time.Time) (string, []byte, error)
+	// check returns the error the platform responded in a 2xx body.
+	check(body []byte) error
+	// signs tells whether the platform signs the posts with a secret.
+	signs() bool
+}
+
+var platforms = map[string]platform{
+	PlatformFeishu:   feishu{},
+	PlatformWecom:    wecom{},
+	PlatformDingtalk: dingtalk{},
+	PlatformSlack:    slack{},
+}
+
+// Platforms returns the supported platforms in order.
+func Platforms() []string {
+	names := make([]string, 0, len(platforms))
+	for name := range platforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateBot checks the bot at url of platform, secret may only be set for
+// the platforms signing the posts.
+func ValidateBot(platformName, url, secret string) error {
+	p, ok := platforms[platformName]
+	if !ok {
+		return xerrors.Errorf("unknown chat platform %s, the supported ones are %s",
+			platformName, strings.Join(Platforms(), ", "))
+	}
+	if err := webhook.ValidateURL(url); err != nil {
+		return err
+	}
+	if secret != "" && !p.signs() {
+		return xerrors.Errorf("%s does not sign the posts, the key is in the url",
+			platformName)
+	}
+	if len(secret) > webhook.MaxSecretLen {
+		return xerrors.Errorf("the chat secret is longer than %d", webhook.MaxSecretLen)
+	}
+	return nil
+}
+
+// permanentError is a post which fails the same when sent again.
+type permanentError struct {
+	error
+}
+
+func (e permanentError) Unwrap() error {
+	return e.error
+}
+
+// platformError is an error code a platform responded.
+type platformError struct {
+	platform  string
+	code      int
+	msg       string
+	temporary bool
+}
+
+func (e *platformError) Error() string {
+	return e.platform + " responded error " + strconv.Itoa(e.code) + ": " + e.msg
+}
+
+// IsPermanent reports whether err is a failure sending again will not change,
+// the rate limits are not.
+func IsPermanent(err error) bool {
+	var permanent permanentError
+	var e *platformError
+	if errors.As(err, &e) {
+		return !e.temporary
+	}
+	return errors.As(err, &permanent) || webhook.IsPermanent(err)
+}
+
+// NewClient returns the client posting to the bots as cfg.
+func NewClient(cfg webhook.Config) *Client {
+	return &Client{client: webhook.NewHTTPClient(cfg), now: time.Now}
+}
+
+type Client struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+// Send posts card to bot.
+func (c *Client) Send(bot Bot, card Card) error {
+	p, ok := platforms[bot.Platform]
+	if !ok {
+		return permanentError{xerrors.Errorf("unknown chat platform %s", bot.Platform)}
+	}
+	if err := webhook.ValidateURL(bot.URL); err != nil {
+		return permanentError{err}
+	}
+	if card.ButtonText == "" {
+		card.ButtonText = DefaultButtonText
+	}
+	url, body, err := p.request(bot, card, c.now())
+	if err != nil {
+		return permanentError{err}
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url,
+		bytes.NewReader(body))
+	if err != nil {
+		return permanentError{xerrors.Errorf("new %s request failed, err:%v", bot.Platform,
+			err)}
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return xerrors.Errorf("post %s bot failed, err:%w", bot.Platform, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseLen))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err := xerrors.Errorf("%s responded %d: %s", bot.Platform, resp.StatusCode,
+			truncate(string(bytes.TrimSpace(respBody)), 256))
+		if webhook.IsTemporaryStatus(resp.StatusCode) {
+			return err
+		}
+		return permanentError{err}
+	}
+	return p.check(respBody)
+}
+
+// truncate returns the first n runes of s, marking the cut.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,182 @@
+package chat_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/opensourceways/message-manager/common/chat"
+	"github.com/opensourceways/message-manager/common/chat/chattest"
+	"github.com/opensourceways/message-manager/common/webhook"
+)
+
+const testSecret = "SECa1b2c3d4e5f6"
+
+var testCard = Card{
+	Title:     "PR #1 merged",
+	Summary:   "alice merged <infrastructure#1> & closed it",
+	SourceUrl: "https://gitee.com/openeuler/infrastructure/pulls/1",
+}
+
+var testClient = NewClient(webhook.Config{AllowPrivateNetwork: true})
+
+// lookup returns the value at the path of keys and indexes in v.
+func lookup(v interface{}, path ...interface{}) interface{} {
+	for _, p := range path {
+		switch k := p.(type) {
+		case string:
+			m, _ := v.(map[string]interface{})
+			v = m[k]
+		case int:
+			l, _ := v.([]interface{})
+			if k >= len(l) {
+				return nil
+			}
+			v = l[k]
+		}
+	}
+	return v
+}
+
+func TestSend(t *testing.T) {
+	for platform, check := range map[string]func(body map[string]interface{}){
+		PlatformFeishu: func(body map[string]interface{}) {
+			assert.Equal(t, "interactive", body["msg_type"])
+			assert.Equal(t, testCard.Title,
+				lookup(body, "card", "header", "title", "content"))
+			assert.Equal(t, testCard.Summary,
+				lookup(body, "card", "elements", 0, "text", "content"))
+			button := lookup(body, "card", "elements", 1, "actions", 0)
+			assert.Equal(t, testCard.SourceUrl, lookup(button, "url"))
+			assert.Equal(t, DefaultButtonText, lookup(button, "text", "content"))
+		},
+		PlatformDingtalk: func(body map[string]interface{}) {
+			assert.Equal(t, "actionCard", body["msgtype"])
+			assert.Equal(t, testCard.Title, lookup(body, "actionCard", "title"))
+			assert.Contains(t, lookup(body, "actionCard", "text"), testCard.Summary)
+			assert.Equal(t, testCard.SourceUrl, lookup(body, "actionCard", "singleURL"))
+		},
+		PlatformWecom: func(body map[string]interface{}) {
+			assert.Equal(t, "template_card", body["msgtype"])
+			card := lookup(body, "template_card")
+			assert.Equal(t, "text_notice", lookup(card, "card_type"))
+			assert.Equal(t, testCard.Title, lookup(card, "main_title", "title"))
+			assert.Equal(t, testCard.SourceUrl, lookup(card, "card_action", "url"))
+		},
+		PlatformSlack: func(body map[string]interface{}) {
+			assert.Equal(t, testCard.Title, body["text"])
+			assert.Equal(t, "header", lookup(body, "blocks", 0, "type"))
+			// mrkdwn escapes the control characters
+			assert.Equal(t, "alice merged &lt;infrastructure#1&gt; &amp; closed it",
+				lookup(body, "blocks", 1, "text", "text"))
+			assert.Equal(t, testCard.SourceUrl,
+				lookup(body, "blocks", 2, "elements", 0, "url"))
+		},
+	} {
+		server := chattest.NewServer(platform, testSecret)
+		assert.NoError(t, testClient.Send(server.Bot(), testCard), platform)
+		messages := server.Messages()
+		if assert.Len(t, messages, 1, platform) {
+			check(messages[0].Body)
+		}
+		server.Close()
+	}
+}
+
+func TestSendWithoutSourceUrl(t *testing.T) {
+	card := Card{Title: "Meeting", Summary: "sig-infra meets at 10:00"}
+	for platform, check := range map[string]func(body map[string]interface{}){
+		PlatformFeishu: func(body map[string]interface{}) {
+			assert.Nil(t, lookup(body, "card", "elements", 1))
+		},
+		PlatformDingtalk: func(body map[string]interface{}) {
+			assert.Equal(t, "markdown", body["msgtype"])
+		},
+		PlatformWecom: func(body map[string]interface{}) {
+			assert.Equal(t, "markdown", body["msgtype"])
+			assert.Contains(t, lookup(body, "markdown", "content"), card.Summary)
+		},
+		PlatformSlack: func(body map[string]interface{}) {
+			assert.Nil(t, lookup(body, "blocks", 2))
+		},
+	} {
+		server := chattest.NewServer(platform, testSecret)
+		assert.NoError(t, testClient.Send(server.Bot(), card), platform)
+		if messages := server.Messages(); assert.Len(t, messages, 1, platform) {
+			check(messages[0].Body)
+		}
+		server.Close()
+	}
+}
+
+func TestSendFailed(t *testing.T) {
+	for _, c := range []struct {
+		platform  string
+		code      int
+		permanent bool
+	}{
+		{PlatformFeishu, 11232, false},
+		{PlatformFeishu, 19024, true},
+		{PlatformDingtalk, 130101, false},
+		{PlatformDingtalk, 300001, true},
+		{PlatformWecom, 45009, false},
+		{PlatformWecom, 93000, true},
+		{PlatformSlack, http.StatusTooManyRequests, false},
+		{PlatformSlack, http.StatusInternalServerError, false},
+		{PlatformSlack, http.StatusGone, true},
+	} {
+		server := chattest.NewServer(c.platform, testSecret)
+		server.Fail(c.code)
+		err := testClient.Send(server.Bot(), testCard)
+		if assert.Error(t, err, c.platform) {
+			assert.Equal(t, c.permanent, IsPermanent(err), c.platform, c.code)
+			assert.Contains(t, err.Error(), c.platform)
+		}
+		assert.Empty(t, server.Messages())
+		server.Close()
+	}
+}
+
+func TestSendWrongSecret(t *testing.T) {
+	for _, platform := range Platforms() {
+		server := chattest.NewServer(platform, testSecret)
+		bot := server.Bot()
+		if bot.Secret != "" {
+			bot.Secret = "SECwrong"
+		} else {
+			bot.URL = strings.Replace(bot.URL, testSecret, "wrong", 1)
+		}
+		err := testClient.Send(bot, testCard)
+		assert.Error(t, err, platform)
+		assert.True(t, IsPermanent(err), platform)
+		server.Close()
+	}
+}
+
+func TestSendPrivateNetwork(t *testing.T) {
+	server := chattest.NewServer(PlatformSlack, testSecret)
+	defer server.Close()
+
+	err := NewClient(webhook.Config{}).Send(server.Bot(), testCard)
+	assert.Error(t, err)
+	assert.True(t, IsPermanent(err))
+	assert.Empty(t, server.Messages())
+}
+
+func TestValidateBot(t *testing.T) {
+	assert.Equal(t, []string{PlatformDingtalk, PlatformFeishu, PlatformSlack, PlatformWecom},
+		Platforms())
+	assert.NoError(t, ValidateBot(PlatformFeishu,
+		"https://open.feishu.cn/open-apis/bot/v2/hook/token", testSecret))
+	assert.NoError(t, ValidateBot(PlatformDingtalk,
+		"https://oapi.dingtalk.com/robot/send?access_token=token", ""))
+	assert.NoError(t, ValidateBot(PlatformSlack, "https://hooks.slack.com/services/T/B/X", ""))
+
+	assert.Error(t, ValidateBot("teams", "https://example.com/hook", ""))
+	assert.Error(t, ValidateBot(PlatformWecom, "wecom", ""))
+	// the key of WeCom is in the url
+	assert.Error(t, ValidateBot(PlatformWecom,
+		"https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=key", testSecret))
+}
@@ -0,0 +1,189 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+// Package chattest runs local stand-ins of the bot webhooks of the chat
+// platforms in the tests, checking the signatures as the platforms do.
+package chattest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/opensourceways/message-manager/common/chat"
+)
+
+// the codes the platforms respond to a bad signature or key
+const (
+	feishuSignMismatch   = 19021
+	dingtalkSignMismatch = 310000
+	wecomInvalidKey      = 93000
+)
+
+// the signatures are accepted within the hour
+const signatureWindow = time.Hour
+
+// Message is a post the server accepted, Body is its JSON decoded.
+type Message struct {
+	Body map[string]interface{}
+}
+
+// Server is the bot webhook of a platform, it accepts the posts unless told to
+// fail.
+type Server struct {
+	platform string
+	secret   string
+	server   *httptest.Server
+
+	lock     sync.Mutex
+	messages []Message
+	failures []int
+}
+
+// NewServer starts the bot webhook of platform, secret signs the posts or is
+// the key in the url.
+func NewServer(platform, secret string) *Server {
+	s := &Server{platform: platform, secret: secret}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Bot returns the bot posting to s.
+func (s *Server) Bot() chat.Bot {
+	bot := chat.Bot{Platform: s.platform, URL: s.server.URL}
+	switch s.platform {
+	case chat.PlatformFeishu:
+		bot.URL += "/open-apis/bot/v2/hook/token"
+		bot.Secret = s.secret
+	case chat.PlatformDingtalk:
+		bot.URL += "/robot/send?access_token=token"
+		bot.Secret = s.secret
+	case chat.PlatformWecom:
+		bot.URL += "/cgi-bin/webhook/send?key=" + s.secret
+	case chat.PlatformSlack:
+		bot.URL += "/services/" + s.secret
+	}
+	return bot
+}
+
+// Messages returns the accepted posts in order.
+func (s *Server) Messages() []Message {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]Message{}, s.messages...)
+}
+
+// Fail makes the server reject the next posts with the error codes of the
+// platform, or the http statuses for Slack.
+func (s *Server) Fail(codes ...int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.failures = append(s.failures, codes...)
+}
+
+// Close stops s.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	b, err := io.ReadAll(r.Body)
+	var body map[string]interface{}
+	if err != nil || json.Unmarshal(b, &body) != nil {
+		s.reject(w, http.StatusBadRequest, "invalid_payload")
+		return
+	}
+	if code := s.authenticate(r, body); code != 0 {
+		s.reject(w, code, "unauthorized")
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.failures) != 0 {
+		code := s.failures[0]
+		s.failures = s.failures[1:]
+		s.reject(w, code, "failed")
+		return
+	}
+	delete(body, "sign")
+	s.messages = append(s.messages, Message{Body: body})
+	s.respond(w, http.StatusOK, 0, "ok")
+}
+
+// authenticate returns the code rejecting r, 0 when it is signed or keyed
+// right.
+func (s *Server) authenticate(r *http.Request, body map[string]interface{}) int {
+	switch s.platform {
+	case chat.PlatformFeishu:
+		timestamp, _ := body["timestamp"].(string)
+		sign, _ := body["sign"].(string)
+		mac := hmac.New(sha256.New, []byte(timestamp+"\n"+s.secret))
+		if !recent(timestamp, time.Second) ||
+			sign != base64.StdEncoding.EncodeToString(mac.Sum(nil)) {
+			return feishuSignMismatch
+		}
+	case chat.PlatformDingtalk:
+		timestamp, sign := r.URL.Query().Get("timestamp"), r.URL.Query().Get("sign")
+		mac := hmac.New(sha256.New, []byte(s.secret))
+		mac.Write([]byte(timestamp + "\n" + s.secret))
+		if !recent(timestamp, time.Millisecond) ||
+			sign != base64.StdEncoding.EncodeToString(mac.Sum(nil)) {
+			return dingtalkSignMismatch
+		}
+	case chat.PlatformWecom:
+		if r.URL.Query().Get("key") != s.secret {
+			return wecomInvalidKey
+		}
+	case chat.PlatformSlack:
+		if r.URL.Path != "/services/"+s.secret {
+			return http.StatusNotFound
+		}
+	}
+	return 0
+}
+
+// reject responds the failure code as the platform does.
+func (s *Server) reject(w http.ResponseWriter, code int, msg string) {
+	if s.platform == chat.PlatformSlack {
+		s.respond(w, code, 0, msg)
+	} else {
+		s.respond(w, http.StatusOK, code, msg)
+	}
+}
+
+func (s *Server) respond(w http.ResponseWriter, status, code int, msg string) {
+	if s.platform == chat.PlatformSlack {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(msg))
+		return
+	}
+	resp := map[string]interface{}{"errcode": code, "errmsg": msg}
+	if s.platform == chat.PlatformFeishu {
+		resp = map[string]interface{}{"code": code, "msg": msg}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// recent reports whether the timestamp in unit is within the window.
+func recent(timestamp string, unit time.Duration) bool {
+	n, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	d := time.Since(time.Unix(0, n*int64(unit)))
+	return d < signatureWindow && d > -signatureWindow
+}
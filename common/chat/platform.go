@@ -0,0 +1,256 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package chat
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// the error codes of the rate limits, the posts are tried again later
+const (
+	feishuRateLimited   = 11232
+	dingtalkRateLimited = 130101
+	wecomRateLimited    = 45009
+)
+
+// the limits of the texts, longer ones are cut
+const (
+	maxTitleLen   = 150
+	maxSummaryLen = 2000
+)
+
+// codeResponse is the response of Feishu, code 0 is a success.
+type codeResponse struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+// errcodeResponse is the response of DingTalk and WeCom, errcode 0 is a
+// success.
+type errcodeResponse struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// codeError is the error of the code a platform responded, nil for 0.
+func codeError(name string, code int, msg string, rateLimited int) error {
+	if code == 0 {
+		return nil
+	}
+	return &platformError{platform: name, code: code, msg: msg, temporary: code == rateLimited}
+}
+
+// feishu posts interactive cards, the secret signs the timestamp in the body.
+type feishu struct{}
+
+func (feishu) signs() bool { return true }
+
+func (feishu) request(bot Bot, card Card, now time.Time) (string, []byte, error) {
+	elements := []interface{}{map[string]interface{}{
+		"tag": "div",
+		"text": map[string]string{
+			"tag":     "lark_md",
+			"content": truncate(card.Summary, maxSummaryLen),
+		},
+	}}
+	if card.SourceUrl != "" {
+		elements = append(elements, map[string]interface{}{
+			"tag": "action",
+			"actions": []interface{}{map[string]interface{}{
+				"tag":  "button",
+				"text": map[string]string{"tag": "plain_text", "content": card.ButtonText},
+				"type": "primary",
+				"url":  card.SourceUrl,
+			}},
+		})
+	}
+	msg := map[string]interface{}{
+		"msg_type": "interactive",
+		"card": map[string]interface{}{
+			"header": map[string]interface{}{
+				"title": map[string]string{
+					"tag":     "plain_text",
+					"content": truncate(card.Title, maxTitleLen),
+				},
+				"template": "blue",
+			},
+			"elements": elements,
+		},
+	}
+	if bot.Secret != "" {
+		timestamp := strconv.FormatInt(now.Unix(), 10)
+		msg["timestamp"] = timestamp
+		msg["sign"] = feishuSign(bot.Secret, timestamp)
+	}
+	body, err := json.Marshal(msg)
+	return bot.URL, body, err
+}
+
+func (feishu) check(body []byte) error {
+	var resp codeResponse
+	if json.Unmarshal(body, &resp) != nil {
+		return nil
+	}
+	return codeError(PlatformFeishu, resp.Code, resp.Msg, feishuRateLimited)
+}
+
+// feishuSign is the HMAC-SHA256 of nothing keyed by the timestamp, a line
+// break and the secret, in base64.
+func feishuSign(secret, timestamp string) string {
+	mac := hmac.New(sha256.New, []byte(timestamp+"\n"+secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// dingtalk posts action cards, the secret signs the timestamp in the query.
+type dingtalk struct{}
+
+func (dingtalk) signs() bool { return true }
+
+func (dingtalk) request(bot Bot, card Card, now time.Time) (string, []byte, error) {
+	title := truncate(card.Title, maxTitleLen)
+	text := "### " + title + "\n\n" + truncate(card.Summary, maxSummaryLen)
+	var msg map[string]interface{}
+	if card.SourceUrl != "" {
+		msg = map[string]interface{}{
+			"msgtype": "actionCard",
+			"actionCard": map[string]string{
+				"title":       title,
+				"text":        text,
+				"singleTitle": card.ButtonText,
+				"singleURL":   card.SourceUrl,
+			},
+		}
+	} else {
+		msg = map[string]interface{}{
+			"msgtype":  "markdown",
+			"markdown": map[string]string{"title": title, "text": text},
+		}
+	}
+	body, err := json.Marshal(msg)
+	if err != nil || bot.Secret == "" {
+		return bot.URL, body, err
+	}
+
+	u, err := url.Parse(bot.URL)
+	if err != nil {
+		return "", nil, xerrors.Errorf("invalid dingtalk url, err:%v", err)
+	}
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	query := u.Query()
+	query.Set("timestamp", timestamp)
+	query.Set("sign", dingtalkSign(bot.Secret, timestamp))
+	u.RawQuery = query.Encode()
+	return u.String(), body, nil
+}
+
+func (dingtalk) check(body []byte) error {
+	return checkErrcode(PlatformDingtalk, body, dingtalkRateLimited)
+}
+
+// dingtalkSign is the HMAC-SHA256 of the timestamp, a line break and the
+// secret keyed by the secret, in base64.
+func dingtalkSign(secret, timestamp string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// checkErrcode reads the response of DingTalk and WeCom.
+func checkErrcode(name string, body []byte, rateLimited int) error {
+	var resp errcodeResponse
+	if json.Unmarshal(body, &resp) != nil {
+		return nil
+	}
+	return codeError(name, resp.ErrCode, resp.ErrMsg, rateLimited)
+}
+
+// wecom posts text notice cards, the key of the bot is in the url.
+type wecom struct{}
+
+func (wecom) signs() bool { return false }
+
+func (wecom) request(bot Bot, card Card, _ time.Time) (string, []byte, error) {
+	title := truncate(card.Title, maxTitleLen)
+	summary := truncate(card.Summary, maxSummaryLen)
+	var msg map[string]interface{}
+	if card.SourceUrl != "" {
+		// a text notice card must have an action
+		msg = map[string]interface{}{
+			"msgtype": "template_card",
+			"template_card": map[string]interface{}{
+				"card_type":      "text_notice",
+				"main_title":     map[string]string{"title": title},
+				"sub_title_text": summary,
+				"jump_list": []interface{}{map[string]interface{}{
+					"type": 1, "title": card.ButtonText, "url": card.SourceUrl,
+				}},
+				"card_action": map[string]interface{}{"type": 1, "url": card.SourceUrl},
+			},
+		}
+	} else {
+		msg = map[string]interface{}{
+			"msgtype":  "markdown",
+			"markdown": map[string]string{"content": "**" + title + "**\n" + summary},
+		}
+	}
+	body, err := json.Marshal(msg)
+	return bot.URL, body, err
+}
+
+func (wecom) check(body []byte) error {
+	return checkErrcode(PlatformWecom, body, wecomRateLimited)
+}
+
+// slack posts Block Kit messages to the incoming webhooks, whose urls carry
+// the key. The errors are responded as http statuses.
+type slack struct{}
+
+func (slack) signs() bool { return false }
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func (slack) request(bot Bot, card Card, _ time.Time) (string, []byte, error) {
+	title := truncate(card.Title, maxTitleLen)
+	blocks := []interface{}{
+		map[string]interface{}{
+			"type": "header",
+			"text": map[string]string{"type": "plain_text", "text": title},
+		},
+	}
+	if card.Summary != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": map[string]string{
+				"type": "mrkdwn",
+				"text": slackEscaper.Replace(truncate(card.Summary, maxSummaryLen)),
+			},
+		})
+	}
+	if card.SourceUrl != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "actions",
+			"elements": []interface{}{map[string]interface{}{
+				"type": "button",
+				"text": map[string]string{"type": "plain_text", "text": card.ButtonText},
+				"url":  card.SourceUrl,
+			}},
+		})
+	}
+	body, err := json.Marshal(map[string]interface{}{"text": title, "blocks": blocks})
+	return bot.URL, body, err
+}
+
+func (slack) check([]byte) error {
+	return nil
+}
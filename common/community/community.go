@@ -0,0 +1,246 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+// Package community is the registry of the communities sharing the message
+// center.
+package community
+
+import (
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/source"
+)
+
+// DefaultHeader is the request header naming the community, it wins over the
+// host of the request.
+const DefaultHeader = "X-Community"
+
+// userKeySeparator separates the community from the user name in the keys of
+// the users of the communities other than the default one.
+const userKeySeparator = "/"
+
+var idRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Community is a tenant of the message center, Id is the community its users
+// sign in to.
+type Community struct {
+	Id          string `json:"id"           required:"true"`
+	DisplayName string `json:"display_name"`
+	// Hosts are the hosts the requests of the community are sent to.
+	Hosts []string `json:"hosts"`
+	// AppId and AppSecret sign in to the account service, the ones of the
+	// user config are used when they are empty.
+	AppId     string `json:"app_id"`
+	AppSecret string `json:"app_secret"`
+	// Sources are the ids of the sources the community reads, empty means
+	// all of them.
+	Sources []string `json:"sources"`
+	// CalendarProduct and CalendarName are the product id and the name of
+	// the meeting calendar of the community.
+	CalendarProduct string `json:"calendar_product"`
+	CalendarName    string `json:"calendar_name"`
+}
+
+// Calendar returns the product id and the name of the meeting calendar, they
+// are made of the name of the community when they are not configured.
+func (c *Community) Calendar() (string, string) {
+	name := c.DisplayName
+	if name == "" {
+		name = c.Id
+	}
+	product, calendarName := c.CalendarProduct, c.CalendarName
+	if product == "" {
+		product = "-//" + name + "//Message Center//CN"
+	}
+	if calendarName == "" {
+		calendarName = name + " meetings"
+	}
+	return product, calendarName
+}
+
+// HasSource reports whether the community reads the source id.
+func (c *Community) HasSource(id string) bool {
+	if len(c.Sources) == 0 {
+		return true
+	}
+	for _, s := range c.Sources {
+		if s == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Config lists the communities, Default is the one of the requests naming
+// none.
+type Config struct {
+	Default     string      `json:"default"`
+	Header      string      `json:"header"`
+	Communities []Community `json:"communities"`
+}
+
+func (cfg *Config) Validate() error {
+	ids := map[string]bool{}
+	hosts := map[string]bool{}
+	for i := range cfg.Communities {
+		c := &cfg.Communities[i]
+		if !idRegex.MatchString(c.Id) {
+			return xerrors.Errorf("the id of community %d is invalid, id:%s", i, c.Id)
+		}
+		if ids[c.Id] {
+			return xerrors.Errorf("community %s is duplicated", c.Id)
+		}
+		ids[c.Id] = true
+		for _, h := range c.Hosts {
+			h = strings.ToLower(h)
+			if hosts[h] {
+				return xerrors.Errorf("host %s of community %s is duplicated", h, c.Id)
+			}
+			hosts[h] = true
+		}
+		for _, s := range c.Sources {
+			if _, ok := source.Get(s); !ok {
+				return xerrors.Errorf("unknown source %s of community %s", s, c.Id)
+			}
+		}
+	}
+	if cfg.Default != "" && len(cfg.Communities) != 0 && !ids[cfg.Default] {
+		return xerrors.Errorf("the default community %s is not configured", cfg.Default)
+	}
+	return nil
+}
+
+var (
+	lock        sync.RWMutex
+	header      = DefaultHeader
+	defaultId   string
+	communities []Community
+)
+
+// Init registers the communities of cfg after the sources, fallback is the
+// default community when cfg names none.
+func Init(cfg *Config, fallback string) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	id := fallback
+	if cfg.Default != "" {
+		id = cfg.Default
+	}
+	if !idRegex.MatchString(id) {
+		return xerrors.Errorf("the default community is invalid, id:%s", id)
+	}
+
+	all := append([]Community{}, cfg.Communities...)
+	found := false
+	for i := range all {
+		found = found || all[i].Id == id
+	}
+	if !found {
+		all = append(all, Community{Id: id})
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	header = DefaultHeader
+	if cfg.Header != "" {
+		header = cfg.Header
+	}
+	defaultId = id
+	communities = all
+	return nil
+}
+
+// All returns the registered communities.
+func All() []Community {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	return append([]Community{}, communities...)
+}
+
+// Get returns the community of id.
+func Get(id string) (Community, bool) {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	for i := range communities {
+		if communities[i].Id == id {
+			return communities[i], true
+		}
+	}
+	return Community{}, false
+}
+
+// Default returns the default community.
+func Default() Community {
+	lock.RLock()
+	id := defaultId
+	lock.RUnlock()
+
+	if c, ok := Get(id); ok {
+		return c
+	}
+	return Community{Id: id}
+}
+
+// DefaultId returns the id of the default community.
+func DefaultId() string {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	return defaultId
+}
+
+// FromRequest returns the community named by the header of r, or else the
+// one of its host, or else the default one.
+func FromRequest(r *http.Request) (Community, error) {
+	lock.RLock()
+	name := header
+	lock.RUnlock()
+
+	if id := strings.ToLower(strings.TrimSpace(r.Header.Get(name))); id != "" {
+		if c, ok := Get(id); ok {
+			return c, nil
+		}
+		return Community{}, xerrors.Errorf("unknown community %s", id)
+	}
+
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	for _, c := range All() {
+		for _, h := range c.Hosts {
+			if strings.EqualFold(h, host) {
+				return c, nil
+			}
+		}
+	}
+	return Default(), nil
+}
+
+// UserKey returns the key of the data of userName in the community id, the
+// users of the default community keep their user name.
+func UserKey(id, userName string) string {
+	if id == "" || id == DefaultId() {
+		return userName
+	}
+	return id + userKeySeparator + userName
+}
+
+// SplitUserKey returns the community and the user name of key.
+func SplitUserKey(key string) (string, string) {
+	if id, userName, ok := strings.Cut(key, userKeySeparator); ok {
+		return id, userName
+	}
+	return DefaultId(), key
+}
@@ -0,0 +1,93 @@
+package community
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/opensourceways/message-manager/common/source"
+)
+
+func TestInit(t *testing.T) {
+	defer func() { assert.NoError(t, Init(&Config{}, "openeuler")) }()
+
+	assert.Error(t, Init(&Config{}, ""))
+	assert.Error(t, Init(&Config{Communities: []Community{{Id: "Open Gauss"}}}, "openeuler"))
+	assert.Error(t, Init(&Config{Communities: []Community{{Id: "a"}, {Id: "a"}}}, "openeuler"))
+	assert.Error(t, Init(&Config{Communities: []Community{
+		{Id: "a", Hosts: []string{"h"}}, {Id: "b", Hosts: []string{"H"}}}}, "openeuler"))
+	assert.Error(t, Init(&Config{Communities: []Community{
+		{Id: "a", Sources: []string{"unknown"}}}}, "openeuler"))
+	assert.Error(t, Init(&Config{Default: "b", Communities: []Community{{Id: "a"}}}, "openeuler"))
+
+	assert.NoError(t, Init(&Config{}, "openeuler"))
+	assert.Equal(t, "openeuler", DefaultId())
+	assert.Len(t, All(), 1)
+
+	assert.NoError(t, Init(&Config{Default: "opengauss", Communities: []Community{
+		{Id: "openeuler"}, {Id: "opengauss"}}}, "openeuler"))
+	assert.Equal(t, "opengauss", Default().Id)
+	assert.Len(t, All(), 2)
+}
+
+func TestFromRequest(t *testing.T) {
+	assert.NoError(t, Init(&Config{Communities: []Community{
+		{Id: "opengauss", Hosts: []string{"message.opengauss.org"},
+			Sources: []string{source.IdGitee}},
+	}}, "openeuler"))
+	defer func() { assert.NoError(t, Init(&Config{}, "openeuler")) }()
+
+	request := func(host, header string) *http.Request {
+		r, _ := http.NewRequest(http.MethodGet, "http://"+host+"/message_center/sources", nil)
+		if header != "" {
+			r.Header.Set(DefaultHeader, header)
+		}
+		return r
+	}
+
+	c, err := FromRequest(request("message.opengauss.org:8080", ""))
+	assert.NoError(t, err)
+	assert.Equal(t, "opengauss", c.Id)
+	assert.True(t, c.HasSource(source.IdGitee))
+	assert.False(t, c.HasSource(source.IdEur))
+
+	c, err = FromRequest(request("message.opengauss.org", "OpenEuler"))
+	assert.NoError(t, err)
+	assert.Equal(t, "openeuler", c.Id)
+	assert.True(t, c.HasSource(source.IdEur))
+
+	c, err = FromRequest(request("localhost", ""))
+	assert.NoError(t, err)
+	assert.Equal(t, "openeuler", c.Id)
+
+	_, err = FromRequest(request("localhost", "unknown"))
+	assert.Error(t, err)
+}
+
+func TestUserKey(t *testing.T) {
+	assert.NoError(t, Init(&Config{}, "openeuler"))
+
+	assert.Equal(t, "alice", UserKey("openeuler", "alice"))
+	assert.Equal(t, "alice", UserKey("", "alice"))
+	assert.Equal(t, "opengauss/alice", UserKey("opengauss", "alice"))
+
+	id, userName := SplitUserKey("alice")
+	assert.Equal(t, "openeuler", id)
+	assert.Equal(t, "alice", userName)
+	id, userName = SplitUserKey("opengauss/alice")
+	assert.Equal(t, "opengauss", id)
+	assert.Equal(t, "alice", userName)
+}
+
+func TestCalendar(t *testing.T) {
+	c := Community{Id: "mindspore"}
+	product, name := c.Calendar()
+	assert.Equal(t, "-//mindspore//Message Center//CN", product)
+	assert.Equal(t, "mindspore meetings", name)
+
+	c = Community{Id: "openeuler", DisplayName: "openEuler", CalendarName: "openEuler 会议"}
+	product, name = c.Calendar()
+	assert.Equal(t, "-//openEuler//Message Center//CN", product)
+	assert.Equal(t, "openEuler 会议", name)
+}
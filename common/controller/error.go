@@ -13,6 +13,7 @@ const (
 	errorSystemError     = "system_error"
 	errorBadRequestParam = "bad_request_param"
 	errorUnauthorized    = "unauthorized"
+	errorForbidden       = "forbidden"
 )
 
 type errorCode interface {
@@ -39,6 +39,15 @@ func SendUnauthorized(ctx *gin.Context, err error) {
 	}
 }
 
+// SendForbidden return 403
+func SendForbidden(ctx *gin.Context, err error) {
+	_ = ctx.Error(err)
+	ctx.JSON(
+		http.StatusForbidden,
+		newResponseCodeMsg(errorForbidden, err.Error()),
+	)
+}
+
 // SendBadRequestParam return the 400 about param invalid
 func SendBadRequestParam(ctx *gin.Context, err error) {
 	if _, ok := err.(errorCode); ok {
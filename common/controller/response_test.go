@@ -25,6 +25,19 @@ func TestSendUnauthorized(t *testing.T) {
 	assert.JSONEq(t, `{"code":"unauthorized","msg":"unauthorized access","data":null}`, w.Body.String())
 }
 
+// 测试 SendForbidden
+func TestSendForbidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	SendForbidden(c, errors.New("not an admin"))
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.JSONEq(t, `{"code":"forbidden","msg":"not an admin","data":null}`, w.Body.String())
+}
+
 // 测试 SendBadRequestParam
 func TestSendBadRequestParam(t *testing.T) {
 	gin.SetMode(gin.TestMode)
@@ -0,0 +1,121 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package directory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type cacheEntry struct {
+	value     []string
+	fetchedAt time.Time
+}
+
+// call is a lookup in flight, the readers of the same key wait for it.
+type call struct {
+	done  chan struct{}
+	value []string
+	err   error
+}
+
+// NewCache caches the results of d for ttl and refreshes the ones older than
+// refresh in background, an expired result is used when d fails.
+func NewCache(d Directory, ttl, refresh time.Duration) *cache {
+	return &cache{
+		directory: d,
+		ttl:       ttl,
+		refresh:   refresh,
+		now:       time.Now,
+		entries:   map[string]cacheEntry{},
+		calls:     map[string]*call{},
+	}
+}
+
+type cache struct {
+	directory Directory
+	ttl       time.Duration
+	refresh   time.Duration
+	now       func() time.Time
+
+	lock      sync.Mutex
+	entries   map[string]cacheEntry
+	calls     map[string]*call
+	lastSweep time.Time
+}
+
+func (c *cache) GetUserSig(communityId, userName string) ([]string, error) {
+	return c.get("sig:"+communityId+"/"+userName, func() ([]string, error) {
+		return c.directory.GetUserSig(communityId, userName)
+	})
+}
+
+func (c *cache) GetUserAdminRepos(login string) ([]string, error) {
+	return c.get("admin:"+login, func() ([]string, error) {
+		return c.directory.GetUserAdminRepos(login)
+	})
+}
+
+func (c *cache) get(key string, fetch func() ([]string, error)) ([]string, error) {
+	c.lock.Lock()
+	entry, ok := c.entries[key]
+	age := c.now().Sub(entry.fetchedAt)
+	if ok && age < c.ttl {
+		if age >= c.refresh {
+			c.start(key, fetch)
+		}
+		c.lock.Unlock()
+		return entry.value, nil
+	}
+	cl := c.start(key, fetch)
+	c.lock.Unlock()
+
+	<-cl.done
+	if cl.err != nil && ok {
+		logrus.Warnf("refresh directory %s failed, the expired result is used, err:%v", key,
+			cl.err)
+		return entry.value, nil
+	}
+	return cl.value, cl.err
+}
+
+// start fetches key unless it is fetched already, c.lock must be held.
+func (c *cache) start(key string, fetch func() ([]string, error)) *call {
+	if cl, ok := c.calls[key]; ok {
+		return cl
+	}
+	cl := &call{done: make(chan struct{})}
+	c.calls[key] = cl
+
+	go func() {
+		cl.value, cl.err = fetch()
+
+		c.lock.Lock()
+		delete(c.calls, key)
+		if cl.err == nil {
+			c.entries[key] = cacheEntry{value: cl.value, fetchedAt: c.now()}
+			c.sweep()
+		}
+		c.lock.Unlock()
+		close(cl.done)
+	}()
+	return cl
+}
+
+// sweep drops the entries expired for long, once per ttl, c.lock must be held.
+func (c *cache) sweep() {
+	now := c.now()
+	if now.Sub(c.lastSweep) < c.ttl {
+		return
+	}
+	c.lastSweep = now
+	for key, entry := range c.entries {
+		if now.Sub(entry.fetchedAt) >= 2*c.ttl {
+			delete(c.entries, key)
+		}
+	}
+}
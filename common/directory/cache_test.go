@@ -0,0 +1,121 @@
+package directory
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/xerrors"
+)
+
+// fakeDirectory is a local directory, it fails while err is set.
+type fakeDirectory struct {
+	lock  sync.Mutex
+	sigs  []string
+	err   error
+	calls int
+	block chan struct{}
+}
+
+func (d *fakeDirectory) GetUserSig(communityId, userName string) ([]string, error) {
+	if d.block != nil {
+		<-d.block
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.calls++
+	return d.sigs, d.err
+}
+
+func (d *fakeDirectory) GetUserAdminRepos(login string) ([]string, error) {
+	return []string{login + "/repo"}, nil
+}
+
+func (d *fakeDirectory) set(sigs []string, err error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.sigs, d.err = sigs, err
+}
+
+func (d *fakeDirectory) count() int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return d.calls
+}
+
+func TestCache(t *testing.T) {
+	fake := &fakeDirectory{sigs: []string{"Infra"}}
+	c := NewCache(fake, time.Hour, 10*time.Minute)
+	var clock sync.Mutex
+	now := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time {
+		clock.Lock()
+		defer clock.Unlock()
+		return now
+	}
+	advance := func(d time.Duration) {
+		clock.Lock()
+		defer clock.Unlock()
+		now = now.Add(d)
+	}
+
+	sigs, err := c.GetUserSig("openeuler", "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Infra"}, sigs)
+
+	// a fresh result is not fetched again
+	fake.set([]string{"Kernel"}, nil)
+	sigs, _ = c.GetUserSig("openeuler", "alice")
+	assert.Equal(t, []string{"Infra"}, sigs)
+	assert.Equal(t, 1, fake.count())
+
+	// an old result is used and refreshed in background
+	advance(20 * time.Minute)
+	sigs, _ = c.GetUserSig("openeuler", "alice")
+	assert.Equal(t, []string{"Infra"}, sigs)
+	assert.Eventually(t, func() bool {
+		sigs, _ := c.GetUserSig("openeuler", "alice")
+		return len(sigs) == 1 && sigs[0] == "Kernel"
+	}, time.Second, 10*time.Millisecond)
+
+	// an expired result is used while the directory fails
+	fake.set(nil, xerrors.New("unavailable"))
+	advance(2 * time.Hour)
+	sigs, err = c.GetUserSig("openeuler", "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Kernel"}, sigs)
+
+	// without any result the failure is returned
+	_, err = c.GetUserSig("openeuler", "bob")
+	assert.Error(t, err)
+
+	repos, err := c.GetUserAdminRepos("alice")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice/repo"}, repos)
+}
+
+func TestCache_Concurrent(t *testing.T) {
+	fake := &fakeDirectory{sigs: []string{"Infra"}, block: make(chan struct{})}
+	c := NewCache(fake, time.Hour, 10*time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sigs, err := c.GetUserSig("openeuler", "alice")
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"Infra"}, sigs)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(fake.block)
+	wg.Wait()
+
+	// the readers of the same user share one lookup
+	assert.Equal(t, 1, fake.count())
+}
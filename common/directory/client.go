@@ -0,0 +1,158 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package directory
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+// maxBackoff bounds the wait between two attempts.
+const maxBackoff = 30 * time.Second
+
+type sigResponse struct {
+	Data struct {
+		Sig []string `json:"sig"`
+	} `json:"data"`
+}
+
+type giteeRepo struct {
+	FullName   string `json:"full_name"`
+	Permission struct {
+		Admin bool `json:"admin"`
+	} `json:"permission"`
+}
+
+// NewClient returns the directory calling the upstreams of cfg, cfg has its
+// defaults set.
+func NewClient(cfg Config) *client {
+	return &client{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: seconds(cfg.Timeout)},
+		sleep:      time.Sleep,
+	}
+}
+
+type client struct {
+	cfg        Config
+	httpClient *http.Client
+	sleep      func(time.Duration)
+}
+
+func (c *client) GetUserSig(communityId, userName string) ([]string, error) {
+	query := url.Values{}
+	query.Set("community", communityId)
+	query.Set("user", userName)
+
+	var data sigResponse
+	found, err := c.getJSON(strings.TrimSuffix(c.cfg.SigUrl, "/")+"/query/user/ownertype?"+
+		query.Encode(), &data)
+	if err != nil || !found || data.Data.Sig == nil {
+		return []string{}, err
+	}
+	return data.Data.Sig, nil
+}
+
+func (c *client) GetUserAdminRepos(login string) ([]string, error) {
+	repos := []string{}
+	for page := 1; page <= c.cfg.MaxPages; page++ {
+		query := url.Values{}
+		query.Set("type", "all")
+		query.Set("sort", "full_name")
+		query.Set("page", fmt.Sprint(page))
+		query.Set("per_page", fmt.Sprint(defaultPerPage))
+		if c.cfg.GiteeToken != "" {
+			query.Set("access_token", c.cfg.GiteeToken)
+		}
+
+		var members []giteeRepo
+		found, err := c.getJSON(fmt.Sprintf("%s/users/%s/repos?%s",
+			strings.TrimSuffix(c.cfg.GiteeUrl, "/"), url.PathEscape(login), query.Encode()),
+			&members)
+		if err != nil {
+			return nil, err
+		}
+		for _, repo := range members {
+			if repo.Permission.Admin {
+				repos = append(repos, repo.FullName)
+			}
+		}
+		if !found || len(members) < defaultPerPage {
+			return repos, nil
+		}
+	}
+	logrus.Warnf("the repos of %s exceed %d pages", login, c.cfg.MaxPages)
+	return repos, nil
+}
+
+// getJSON reads the json at rawUrl into v, it reports false when there is
+// nothing at rawUrl. Failed calls and server errors are retried.
+func (c *client) getJSON(rawUrl string, v interface{}) (bool, error) {
+	var err error
+	for attempt := 0; attempt < c.cfg.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			c.sleep(backoff(seconds(c.cfg.RetryInterval), attempt))
+		}
+		var retry bool
+		var found bool
+		if found, retry, err = c.get(rawUrl, v); err == nil || !retry {
+			return found, err
+		}
+	}
+	return false, err
+}
+
+func (c *client) get(rawUrl string, v interface{}) (found, retry bool, err error) {
+	resp, err := c.httpClient.Get(rawUrl)
+	if err != nil {
+		return false, true, xerrors.Errorf("request directory failed, err:%v", redact(err))
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return false, true, xerrors.Errorf("directory is unavailable, status:%d",
+			resp.StatusCode)
+	case resp.StatusCode >= 300:
+		return false, false, xerrors.Errorf("directory refused, status:%d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, true, xerrors.Errorf("read directory failed, err:%v", err)
+	}
+	if err = json.Unmarshal(body, v); err != nil {
+		return false, false, xerrors.Errorf("invalid directory response, err:%v", err)
+	}
+	return true, false, nil
+}
+
+// backoff returns the wait before the attempt, base doubled for every attempt
+// after the second one.
+func backoff(base time.Duration, attempt int) time.Duration {
+	d := base
+	for i := 1; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
+
+// redact drops the url of err, it may carry the access token.
+func redact(err error) error {
+	if e, ok := err.(*url.Error); ok {
+		return xerrors.Errorf("%s: %v", e.Op, e.Err)
+	}
+	return err
+}
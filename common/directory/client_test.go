@@ -0,0 +1,128 @@
+package directory
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(url string) *client {
+	cfg := Config{SigUrl: url, GiteeUrl: url + "/api/v5/", GiteeToken: "token", MaxPages: 2}
+	cfg.SetDefault()
+	c := NewClient(cfg)
+	c.sleep = func(time.Duration) {}
+	return c
+}
+
+func TestGetUserSig(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the first call fails, the retry succeeds
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		assert.Equal(t, "/query/user/ownertype", r.URL.Path)
+		assert.Equal(t, "opengauss", r.URL.Query().Get("community"))
+		switch r.URL.Query().Get("user") {
+		case "alice":
+			fmt.Fprint(w, `{"data":{"sig":["Infra","Kernel"]}}`)
+		case "bob":
+			fmt.Fprint(w, `{"data":{}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	c := newTestClient(server.URL)
+
+	sigs, err := c.GetUserSig("opengauss", "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Infra", "Kernel"}, sigs)
+	assert.Equal(t, int32(2), calls)
+
+	sigs, err = c.GetUserSig("opengauss", "bob")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, sigs)
+
+	sigs, err = c.GetUserSig("opengauss", "unknown")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, sigs)
+}
+
+func TestGetUserSig_Unavailable(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	c := newTestClient(server.URL)
+
+	_, err := c.GetUserSig("openeuler", "alice")
+	assert.Error(t, err)
+	assert.Equal(t, int32(defaultMaxAttempts), calls)
+
+	// a refused call is not retried
+	calls = 0
+	forbidden := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer forbidden.Close()
+	_, err = newTestClient(forbidden.URL).GetUserSig("openeuler", "alice")
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), calls)
+}
+
+func TestGetUserAdminRepos(t *testing.T) {
+	var pages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v5/users/alice/repos", r.URL.Path)
+		assert.Equal(t, "token", r.URL.Query().Get("access_token"))
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+
+		// every page is full, the pages stop at MaxPages
+		fmt.Fprint(w, "[")
+		for i := 0; i < defaultPerPage; i++ {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"full_name":"alice/repo-%s-%d","permission":{"admin":%t}}`, page, i,
+				i == 0)
+		}
+		fmt.Fprint(w, "]")
+	}))
+	defer server.Close()
+
+	repos, err := newTestClient(server.URL).GetUserAdminRepos("alice")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice/repo-1-0", "alice/repo-2-0"}, repos)
+	assert.Equal(t, []string{"1", "2"}, pages)
+}
+
+func TestGetUserAdminRepos_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+	c := newTestClient(server.URL)
+	c.cfg.MaxAttempts = 1
+	c.httpClient.Timeout = 50 * time.Millisecond
+
+	_, err := c.GetUserAdminRepos("alice")
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "token")
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, backoff(time.Second, 1))
+	assert.Equal(t, 4*time.Second, backoff(time.Second, 3))
+	assert.Equal(t, maxBackoff, backoff(time.Second, 10))
+}
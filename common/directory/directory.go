@@ -0,0 +1,142 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+// Package directory looks up the sigs and the administered repositories of
+// the users in the upstream directories.
+package directory
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	defaultSigUrl          = "https://dsapi.osinfra.cn"
+	defaultGiteeUrl        = "https://gitee.com/api/v5"
+	defaultTimeout         = 10
+	defaultMaxAttempts     = 3
+	defaultRetryInterval   = 1
+	defaultCacheTTL        = 3600
+	defaultRefreshInterval = 600
+	defaultMaxPages        = 20
+	defaultPerPage         = 100
+
+	membershipDefaultInterval = 3600
+	membershipDefaultTimeout  = 1800
+)
+
+// Directory looks up the users.
+type Directory interface {
+	// GetUserSig returns the sigs userName maintains in the community
+	// communityId.
+	GetUserSig(communityId, userName string) ([]string, error)
+	// GetUserAdminRepos returns the full names of the Gitee repositories
+	// login administers.
+	GetUserAdminRepos(login string) ([]string, error)
+}
+
+// Config configures the directories, the durations are in seconds.
+type Config struct {
+	SigUrl     string `json:"sig_url"`
+	GiteeUrl   string `json:"gitee_url"`
+	GiteeToken string `json:"gitee_token"`
+	// Timeout bounds every call, a failed call is tried MaxAttempts times,
+	// waiting RetryInterval, then twice as long and so on in between.
+	Timeout       int `json:"timeout"`
+	MaxAttempts   int `json:"max_attempts"`
+	RetryInterval int `json:"retry_interval"`
+	// CacheTTL is how long a result is used, a result older than
+	// RefreshInterval is refreshed in background when it is read.
+	CacheTTL        int `json:"cache_ttl"`
+	RefreshInterval int `json:"refresh_interval"`
+	// MaxPages bounds the pages of the repositories of a user.
+	MaxPages int `json:"max_pages"`
+}
+
+func (cfg *Config) SetDefault() {
+	if cfg.SigUrl == "" {
+		cfg.SigUrl = defaultSigUrl
+	}
+	if cfg.GiteeUrl == "" {
+		cfg.GiteeUrl = defaultGiteeUrl
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultMaxAttempts
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = defaultRetryInterval
+	}
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = defaultCacheTTL
+	}
+	if cfg.RefreshInterval <= 0 || cfg.RefreshInterval > cfg.CacheTTL {
+		cfg.RefreshInterval = min(defaultRefreshInterval, cfg.CacheTTL)
+	}
+	if cfg.MaxPages <= 0 {
+		cfg.MaxPages = defaultMaxPages
+	}
+}
+
+// MembershipConfig configures the sync of the memberships of the recipients,
+// the durations are in seconds.
+type MembershipConfig struct {
+	Enable   bool `json:"enable"`
+	Interval int  `json:"interval"`
+	Timeout  int  `json:"timeout"`
+}
+
+func (cfg *MembershipConfig) SetDefault() {
+	if cfg.Interval <= 0 {
+		cfg.Interval = membershipDefaultInterval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = membershipDefaultTimeout
+	}
+}
+
+func (cfg *MembershipConfig) IntervalDuration() time.Duration {
+	return seconds(cfg.Interval)
+}
+
+func (cfg *MembershipConfig) TimeoutDuration() time.Duration {
+	return seconds(cfg.Timeout)
+}
+
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
+
+var (
+	lock      sync.RWMutex
+	directory Directory
+)
+
+// Init builds the directory of cfg, its results are cached.
+func Init(cfg *Config) {
+	c := *cfg
+	c.SetDefault()
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	directory = NewCache(NewClient(c), seconds(c.CacheTTL), seconds(c.RefreshInterval))
+}
+
+// Get returns the directory, the uncached one of the default config before
+// Init.
+func Get() Directory {
+	lock.RLock()
+	d := directory
+	lock.RUnlock()
+
+	if d != nil {
+		return d
+	}
+	cfg := Config{}
+	cfg.SetDefault()
+	return NewClient(cfg)
+}
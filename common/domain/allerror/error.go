@@ -103,6 +103,18 @@ func NewInvalidParam(msg string) errorImpl {
 	return New(errorCodeInvalidParam, msg)
 }
 
+// IsInvalidParam check the error is InvalidParam
+func IsInvalidParam(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var e errorImpl
+	ok := errors.As(err, &e)
+
+	return ok && e.ErrorCode() == errorCodeInvalidParam
+}
+
 // limitRateError
 type limitRateError struct {
 	errorImpl
@@ -113,6 +125,18 @@ func NewOverLimit(code string, msg string) limitRateError {
 	return limitRateError{errorImpl: New(code, msg)}
 }
 
+// IsOverLimit check the error is OverLimit
+func IsOverLimit(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var limitRateError limitRateError
+	ok := errors.As(err, &limitRateError)
+
+	return ok
+}
+
 // IsErrorCodeEmptyRepo checks if an error has an error code of ErrorCodeEmptyRepo
 func IsErrorCodeEmptyRepo(err error) bool {
 	if err == nil {
@@ -52,6 +52,20 @@ func TestNewInvalidParam(t *testing.T) {
 	assert.Equal(t, errorCodeInvalidParam, err.ErrorCode())
 }
 
+func TestIsInvalidParam(t *testing.T) {
+	assert.True(t, IsInvalidParam(NewInvalidParam("Invalid parameter provided")))
+	assert.False(t, IsInvalidParam(New("custom_error", "")))
+	assert.False(t, IsInvalidParam(errors.New("some other error")))
+}
+
+func TestIsOverLimit(t *testing.T) {
+	err := NewOverLimit("rate_limit_exceeded", "Rate limit exceeded")
+	assert.True(t, IsOverLimit(err))
+
+	normalErr := errors.New("some other error")
+	assert.False(t, IsOverLimit(normalErr))
+}
+
 func TestNewOverLimit(t *testing.T) {
 	err := NewOverLimit("rate_limit_exceeded", "Rate limit exceeded")
 	assert.Equal(t, "Rate limit exceeded", err.Error())
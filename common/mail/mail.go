@@ -0,0 +1,191 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+// Package mail renders the mails of the events from templates and sends them
+// through an SMTP server.
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// the ways the connections to the SMTP server are secured
+const (
+	// TLSStartTLS upgrades the connection with STARTTLS, a server not
+	// offering it is refused.
+	TLSStartTLS = "starttls"
+	// TLSImplicit connects with TLS, usually on port 465.
+	TLSImplicit = "tls"
+	// TLSNone sends in plain text, only for local servers.
+	TLSNone = "none"
+)
+
+const (
+	defaultPort    = 587
+	defaultTimeout = 10
+)
+
+// Config configures the SMTP server, Timeout is in seconds and bounds a whole
+// delivery. From may carry a display name, such as "openEuler <a@b.org>".
+type Config struct {
+	Host               string `json:"host"`
+	Port               int    `json:"port"`
+	Username           string `json:"username"`
+	Password           string `json:"password"`
+	From               string `json:"from"`
+	TLS                string `json:"tls"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+	Timeout            int    `json:"timeout"`
+}
+
+func (cfg *Config) SetDefault() {
+	if cfg.Port <= 0 {
+		cfg.Port = defaultPort
+	}
+	if cfg.TLS == "" {
+		cfg.TLS = TLSStartTLS
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+}
+
+func (cfg *Config) Validate() error {
+	if cfg.Host == "" {
+		return xerrors.Errorf("the smtp host is empty")
+	}
+	if _, err := mail.ParseAddress(cfg.From); err != nil {
+		return xerrors.Errorf("invalid mail sender %s, err:%v", cfg.From, err)
+	}
+	switch cfg.TLS {
+	case TLSStartTLS, TLSImplicit, TLSNone:
+	default:
+		return xerrors.Errorf("unknown smtp tls mode %s", cfg.TLS)
+	}
+	return nil
+}
+
+// Content is a rendered mail, at least one of Text and HTML is set.
+type Content struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
+
+// Message is a mail to send.
+type Message struct {
+	To []string
+	Content
+}
+
+// invalidError is a message which cannot be sent as it is.
+type invalidError struct {
+	error
+}
+
+// IsPermanent reports whether err is an invalid message or a rejection of the
+// SMTP server, which sending again will not change.
+func IsPermanent(err error) bool {
+	var e *textproto.Error
+	var invalid invalidError
+	return errors.As(err, &invalid) || (errors.As(err, &e) && e.Code >= 500)
+}
+
+// build returns the MIME message of msg to the addresses to, the alternative
+// text and HTML bodies are quoted-printable.
+func (msg Message) build(from *mail.Address, to []*mail.Address, date time.Time) ([]byte,
+	error) {
+	if strings.ContainsAny(msg.Subject, "\r\n") {
+		return nil, xerrors.Errorf("the subject has a line break")
+	}
+	if msg.Text == "" && msg.HTML == "" {
+		return nil, xerrors.Errorf("the mail has no body")
+	}
+
+	recipients := make([]string, len(to))
+	for i := range to {
+		recipients[i] = to[i].String()
+	}
+	var buf bytes.Buffer
+	header := []string{
+		"From: " + from.String(),
+		"To: " + strings.Join(recipients, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date: " + date.Format(time.RFC1123Z),
+		"Message-ID: " + messageId(from.Address),
+		"MIME-Version: 1.0",
+	}
+	for _, h := range header {
+		buf.WriteString(h + "\r\n")
+	}
+
+	if msg.Text == "" || msg.HTML == "" {
+		contentType, body := "text/plain", msg.Text
+		if msg.Text == "" {
+			contentType, body = "text/html", msg.HTML
+		}
+		buf.WriteString("Content-Type: " + contentType + "; charset=utf-8\r\n")
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuoted(&buf, body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	w := multipart.NewWriter(&buf)
+	buf.WriteString(fmt.Sprintf("Content-Type: multipart/alternative; boundary=%q\r\n\r\n",
+		w.Boundary()))
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	} {
+		pw, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuoted(pw, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeQuoted(w io.Writer, body string) error {
+	qw := quotedprintable.NewWriter(w)
+	if _, err := qw.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qw.Close()
+}
+
+// messageId returns a new Message-ID in the domain of the address from.
+func messageId(from string) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	domain := "localhost"
+	if i := strings.LastIndex(from, "@"); i >= 0 {
+		domain = from[i+1:]
+	}
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
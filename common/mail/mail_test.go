@@ -0,0 +1,168 @@
+package mail_test
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/opensourceways/message-manager/common/mail"
+	"github.com/opensourceways/message-manager/common/mail/mailtest"
+)
+
+var testContent = Content{
+	Subject: "【openEuler】PR 已合入",
+	Text:    "infrastructure#1 merged, see https://gitee.com/openeuler/infrastructure/pulls/1",
+	HTML:    `<p>infrastructure#1 <b>merged</b></p>`,
+}
+
+// readMessage parses data, returning the decoded subject and the bodies by
+// content type.
+func readMessage(t *testing.T, data string) (*mail.Message, string, map[string]string) {
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	assert.NoError(t, err)
+
+	bodies := map[string]string{}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		b, _ := io.ReadAll(quotedprintable.NewReader(msg.Body))
+		// the last line break ends the data
+		bodies[mediaType] = strings.TrimSuffix(string(b), "\n")
+		return msg, subject, bodies
+	}
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := r.NextPart()
+		if err != nil {
+			break
+		}
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		// the reader decodes quoted-printable
+		b, _ := io.ReadAll(part)
+		bodies[partType] = string(b)
+	}
+	return msg, subject, bodies
+}
+
+func TestSendWithTLSModes(t *testing.T) {
+	for _, mode := range []string{TLSStartTLS, TLSImplicit, TLSNone} {
+		server := mailtest.NewServer(mode)
+		cfg := server.Config()
+		cfg.Username, cfg.Password = "robot", "secret"
+
+		err := NewSender(cfg).Send(Message{To: []string{"Alice <alice@example.com>"},
+			Content: testContent})
+		server.Close()
+		if !assert.NoError(t, err, mode) {
+			continue
+		}
+
+		messages := server.Messages()
+		if !assert.Len(t, messages, 1, mode) {
+			continue
+		}
+		assert.Equal(t, "noreply@example.com", messages[0].From)
+		assert.Equal(t, []string{"alice@example.com"}, messages[0].To)
+		assert.Equal(t, "robot", messages[0].Username)
+		assert.Equal(t, mode != TLSNone, messages[0].TLS, mode)
+
+		msg, subject, bodies := readMessage(t, messages[0].Data)
+		assert.Equal(t, testContent.Subject, subject)
+		assert.Contains(t, msg.Header.Get("To"), "alice@example.com")
+		assert.Contains(t, msg.Header.Get("Message-ID"), "@example.com>")
+		assert.Equal(t, testContent.Text, bodies["text/plain"])
+		assert.Equal(t, testContent.HTML, bodies["text/html"])
+	}
+}
+
+func TestSendSinglePart(t *testing.T) {
+	server := mailtest.NewServer(TLSNone)
+	defer server.Close()
+
+	err := NewSender(server.Config()).Send(Message{To: []string{"alice@example.com"},
+		Content: Content{Subject: "hello", HTML: "<p>hi</p>"}})
+	assert.NoError(t, err)
+
+	_, _, bodies := readMessage(t, server.Messages()[0].Data)
+	assert.Equal(t, map[string]string{"text/html": "<p>hi</p>"}, bodies)
+}
+
+func TestSendRefusesDowngrade(t *testing.T) {
+	// a server without STARTTLS is refused rather than sent to in plain text
+	server := mailtest.NewServer(TLSNone)
+	defer server.Close()
+
+	cfg := server.Config()
+	cfg.TLS = TLSStartTLS
+	err := NewSender(cfg).Send(Message{To: []string{"alice@example.com"},
+		Content: testContent})
+	assert.ErrorContains(t, err, "STARTTLS")
+	assert.Empty(t, server.Messages())
+}
+
+func TestSendRejected(t *testing.T) {
+	server := mailtest.NewServer(TLSNone)
+	defer server.Close()
+	server.Fail(451, 550)
+
+	sender := NewSender(server.Config())
+	msg := Message{To: []string{"alice@example.com"}, Content: testContent}
+
+	err := sender.Send(msg)
+	assert.Error(t, err)
+	assert.False(t, IsPermanent(err))
+
+	err = sender.Send(msg)
+	assert.Error(t, err)
+	assert.True(t, IsPermanent(err))
+
+	assert.NoError(t, sender.Send(msg))
+	assert.Len(t, server.Messages(), 1)
+}
+
+func TestSendInvalidMessage(t *testing.T) {
+	server := mailtest.NewServer(TLSNone)
+	defer server.Close()
+	sender := NewSender(server.Config())
+
+	for _, msg := range []Message{
+		{Content: testContent},
+		{To: []string{"not an address"}, Content: testContent},
+		{To: []string{"alice@example.com"}, Content: Content{Subject: "a\r\nBcc: b@c.d",
+			Text: "x"}},
+		{To: []string{"alice@example.com"}, Content: Content{Subject: "empty"}},
+	} {
+		err := sender.Send(msg)
+		assert.Error(t, err)
+		assert.True(t, IsPermanent(err))
+	}
+	assert.Empty(t, server.Messages())
+}
+
+func TestConfig(t *testing.T) {
+	cfg := Config{Host: "smtp.example.com", From: "noreply@example.com"}
+	cfg.SetDefault()
+	assert.Equal(t, 587, cfg.Port)
+	assert.Equal(t, TLSStartTLS, cfg.TLS)
+	assert.NoError(t, cfg.Validate())
+
+	for _, modify := range []func(c *Config){
+		func(c *Config) { c.Host = "" },
+		func(c *Config) { c.From = "noreply" },
+		func(c *Config) { c.TLS = "ssl" },
+	} {
+		c := cfg
+		modify(&c)
+		assert.Error(t, c.Validate())
+	}
+}
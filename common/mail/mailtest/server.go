@@ -0,0 +1,276 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+// Package mailtest runs a local SMTP server standing in for the real one in
+// the tests.
+package mailtest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opensourceways/message-manager/common/mail"
+)
+
+// Message is a mail the server accepted.
+type Message struct {
+	From string
+	To   []string
+	Data string
+	// Username is the user the client authenticated as, TLS tells whether
+	// the session was secured.
+	Username string
+	TLS      bool
+}
+
+// Server is a local SMTP server, it accepts the mails unless told to fail.
+type Server struct {
+	// Addr is the address the server listens on.
+	Addr string
+
+	mode      string
+	listener  net.Listener
+	tlsConfig *tls.Config
+	wg        sync.WaitGroup
+
+	lock     sync.Mutex
+	messages []Message
+	failures []int
+}
+
+// NewServer starts a server secured as the mail.TLS* mode, with a self-signed
+// certificate. Close stops it.
+func NewServer(mode string) *Server {
+	s := &Server{mode: mode, tlsConfig: &tls.Config{
+		Certificates: []tls.Certificate{selfSigned()},
+		MinVersion:   tls.VersionTLS12,
+	}}
+
+	var err error
+	if mode == mail.TLSImplicit {
+		s.listener, err = tls.Listen("tcp", "127.0.0.1:0", s.tlsConfig)
+	} else {
+		s.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	if err != nil {
+		panic("mailtest: listen failed: " + err.Error())
+	}
+	s.Addr = s.listener.Addr().String()
+
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Config returns the config of a sender to s, trusting its certificate.
+func (s *Server) Config() mail.Config {
+	host, port, _ := net.SplitHostPort(s.Addr)
+	n, _ := strconv.Atoi(port)
+	return mail.Config{
+		Host:               host,
+		Port:               n,
+		From:               "Message Center <noreply@example.com>",
+		TLS:                s.mode,
+		InsecureSkipVerify: true,
+		Timeout:            5,
+	}
+}
+
+// Messages returns the accepted mails in order.
+func (s *Server) Messages() []Message {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]Message{}, s.messages...)
+}
+
+// Fail makes the server reject the next mails with the SMTP codes, such as
+// 451 for a temporary failure or 550 for a permanent one.
+func (s *Server) Fail(codes ...int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.failures = append(s.failures, codes...)
+}
+
+// Close stops s and waits for its sessions.
+func (s *Server) Close() {
+	_ = s.listener.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+
+			_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+			s.session(conn)
+		}()
+	}
+}
+
+// session is the SMTP dialog of a connection.
+func (s *Server) session(conn net.Conn) {
+	_, secure := conn.(*tls.Conn)
+	tp := textproto.NewConn(conn)
+	var msg Message
+	reply := func(code int, text string) bool {
+		return tp.PrintfLine("%d %s", code, text) == nil
+	}
+
+	if !reply(220, "mailtest ESMTP") {
+		return
+	}
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		ok := true
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			lines := []string{"mailtest", "AUTH PLAIN"}
+			if s.mode == mail.TLSStartTLS && !secure {
+				lines = append(lines, "STARTTLS")
+			}
+			for i, l := range lines {
+				sep := "-"
+				if i == len(lines)-1 {
+					sep = " "
+				}
+				ok = ok && tp.PrintfLine("250%s%s", sep, l) == nil
+			}
+		case "STARTTLS":
+			if s.mode != mail.TLSStartTLS || secure {
+				ok = reply(502, "not supported")
+				break
+			}
+			if !reply(220, "ready") {
+				return
+			}
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if tlsConn.Handshake() != nil {
+				return
+			}
+			conn, secure = tlsConn, true
+			tp = textproto.NewConn(conn)
+			msg = Message{}
+		case "AUTH":
+			msg.Username = plainUsername(arg)
+			ok = reply(235, "authenticated")
+		case "MAIL":
+			msg.From = address(arg)
+			msg.To = nil
+			ok = reply(250, "ok")
+		case "RCPT":
+			msg.To = append(msg.To, address(arg))
+			ok = reply(250, "ok")
+		case "DATA":
+			if !reply(354, "go ahead") {
+				return
+			}
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.Data, msg.TLS = string(data), secure
+			if code := s.accept(msg); code != 0 {
+				ok = reply(code, "rejected")
+			} else {
+				ok = reply(250, "queued")
+			}
+		case "RSET", "NOOP":
+			ok = reply(250, "ok")
+		case "QUIT":
+			_ = reply(221, "bye")
+			return
+		default:
+			ok = reply(502, "unknown command")
+		}
+		if !ok {
+			return
+		}
+	}
+}
+
+// accept stores msg unless a failure is due, whose code it returns.
+func (s *Server) accept(msg Message) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.failures) != 0 {
+		code := s.failures[0]
+		s.failures = s.failures[1:]
+		return code
+	}
+	s.messages = append(s.messages, msg)
+	return 0
+}
+
+// address returns the address of "FROM:<a@b> BODY=8BITMIME" and the like.
+func address(arg string) string {
+	start, end := strings.Index(arg, "<"), strings.Index(arg, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return arg[start+1 : end]
+}
+
+// plainUsername returns the user of "PLAIN <base64 of \0user\0password>".
+func plainUsername(arg string) string {
+	_, resp, _ := strings.Cut(arg, " ")
+	b, err := base64.StdEncoding.DecodeString(resp)
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(string(b), "\x00")
+	if len(parts) != 3 {
+		return ""
+	}
+	return parts[1]
+}
+
+func selfSigned() tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic("mailtest: generate key failed: " + err.Error())
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "mailtest"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		DNSNames:     []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		panic("mailtest: create certificate failed: " + err.Error())
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package mail
+
+import (
+	"crypto/tls"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// Sender sends the mails.
+type Sender interface {
+	Send(msg Message) error
+}
+
+// NewSender returns the sender through the SMTP server of cfg.
+func NewSender(cfg Config) *smtpSender {
+	cfg.SetDefault()
+	return &smtpSender{cfg: cfg}
+}
+
+type smtpSender struct {
+	cfg Config
+}
+
+// Send delivers msg in a session of its own. The password is only sent over
+// TLS or to a local server.
+func (s *smtpSender) Send(msg Message) error {
+	from, err := mail.ParseAddress(s.cfg.From)
+	if err != nil {
+		return xerrors.Errorf("invalid mail sender %s, err:%v", s.cfg.From, err)
+	}
+	if len(msg.To) == 0 {
+		return invalidError{xerrors.Errorf("the mail has no recipient")}
+	}
+	to := make([]*mail.Address, len(msg.To))
+	for i := range msg.To {
+		if to[i], err = mail.ParseAddress(msg.To[i]); err != nil {
+			return invalidError{xerrors.Errorf("invalid mail recipient %s, err:%v", msg.To[i],
+				err)}
+		}
+	}
+	body, err := msg.build(from, to, time.Now())
+	if err != nil {
+		return invalidError{err}
+	}
+
+	c, err := s.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if s.cfg.Username != "" {
+		auth := smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+		if err := c.Auth(auth); err != nil {
+			return xerrors.Errorf("smtp auth failed, err:%w", err)
+		}
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return xerrors.Errorf("smtp mail from failed, err:%w", err)
+	}
+	for _, addr := range to {
+		if err := c.Rcpt(addr.Address); err != nil {
+			return xerrors.Errorf("smtp rcpt %s failed, err:%w", addr.Address, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return xerrors.Errorf("smtp data failed, err:%w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return xerrors.Errorf("smtp write failed, err:%w", err)
+	}
+	if err := w.Close(); err != nil {
+		return xerrors.Errorf("smtp data failed, err:%w", err)
+	}
+	return c.Quit()
+}
+
+// dial opens a session secured as configured, the deadline of the connection
+// bounds the whole session.
+func (s *smtpSender) dial() (*smtp.Client, error) {
+	timeout := time.Duration(s.cfg.Timeout) * time.Second
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	tlsConfig := &tls.Config{
+		ServerName:         s.cfg.Host,
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: s.cfg.InsecureSkipVerify, // #nosec G402
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	var err error
+	if s.cfg.TLS == TLSImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("connect smtp server %s failed, err:%v", addr, err)
+	}
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	c, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, xerrors.Errorf("connect smtp server %s failed, err:%w", addr, err)
+	}
+	if s.cfg.TLS != TLSStartTLS {
+		return c, nil
+	}
+	if ok, _ := c.Extension("STARTTLS"); !ok {
+		_ = c.Close()
+		return nil, xerrors.Errorf("smtp server %s does not support STARTTLS", addr)
+	}
+	if err := c.StartTLS(tlsConfig); err != nil {
+		_ = c.Close()
+		return nil, xerrors.Errorf("smtp starttls failed, err:%v", err)
+	}
+	return c, nil
+}
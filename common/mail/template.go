@@ -0,0 +1,174 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package mail
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// the template of the events no configured template is for
+const (
+	defaultSubject = `{{if .Title}}{{.Title}}{{else}}[{{.SourceId}}] {{.Type}}{{end}}`
+	defaultText    = `{{.Summary}}
+{{if .SourceUrl}}
+{{.SourceUrl}}
+{{end}}`
+	defaultHTML = `<p>{{.Summary}}</p>
+{{if .SourceUrl}}<p><a href="{{.SourceUrl}}">{{.SourceUrl}}</a></p>{{end}}`
+)
+
+// a missing key of the data fails rather than renders "<no value>"
+const missingKey = "missingkey=error"
+
+// Template is the mail of the events of Source and EventType, empty for any,
+// its templates are executed on an Event.
+type Template struct {
+	Source    string `json:"source"`
+	EventType string `json:"event_type"`
+	Subject   string `json:"subject"`
+	Text      string `json:"text"`
+	HTML      string `json:"html"`
+}
+
+// Event is what the templates are executed on, Data is the data of the
+// CloudEvent as decoded from JSON.
+type Event struct {
+	Id          string
+	Source      string
+	SourceId    string
+	Type        string
+	Time        time.Time
+	User        string
+	Title       string
+	Summary     string
+	SourceUrl   string
+	SourceGroup string
+	Community   string
+	Data        interface{}
+}
+
+type templateKey struct {
+	source    string
+	eventType string
+}
+
+type parsedTemplate struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// Renderer renders the mails of the events.
+type Renderer struct {
+	templates map[templateKey]parsedTemplate
+}
+
+// NewRenderer parses templates. A template for no source and no type replaces
+// the built-in default one.
+func NewRenderer(templates []Template) (*Renderer, error) {
+	r := &Renderer{templates: map[templateKey]parsedTemplate{}}
+	t, err := parseTemplate(Template{Subject: defaultSubject, Text: defaultText,
+		HTML: defaultHTML})
+	if err != nil {
+		return nil, err
+	}
+	r.templates[templateKey{}] = t
+
+	seen := map[templateKey]bool{}
+	for _, item := range templates {
+		key := templateKey{source: item.Source, eventType: item.EventType}
+		if seen[key] {
+			return nil, xerrors.Errorf("duplicate mail template of source %s and type %s",
+				item.Source, item.EventType)
+		}
+		seen[key] = true
+
+		if r.templates[key], err = parseTemplate(item); err != nil {
+			return nil, xerrors.Errorf("invalid mail template of source %s and type %s, err:%v",
+				item.Source, item.EventType, err)
+		}
+	}
+	return r, nil
+}
+
+func parseTemplate(item Template) (parsedTemplate, error) {
+	var t parsedTemplate
+	var err error
+	if strings.TrimSpace(item.Subject) == "" {
+		return t, xerrors.Errorf("the subject is empty")
+	}
+	if item.Text == "" && item.HTML == "" {
+		return t, xerrors.Errorf("the body is empty")
+	}
+	t.subject, err = texttemplate.New("subject").Option(missingKey).Parse(item.Subject)
+	if err != nil {
+		return t, err
+	}
+	if item.Text != "" {
+		t.text, err = texttemplate.New("text").Option(missingKey).Parse(item.Text)
+		if err != nil {
+			return t, err
+		}
+	}
+	if item.HTML != "" {
+		t.html, err = htmltemplate.New("html").Option(missingKey).Parse(item.HTML)
+		if err != nil {
+			return t, err
+		}
+	}
+	return t, nil
+}
+
+// lookup returns the template of the source and the type of e, then the one
+// of its source, the one of its type and the default one.
+func (r *Renderer) lookup(e Event) parsedTemplate {
+	for _, key := range []templateKey{
+		{source: e.SourceId, eventType: e.Type},
+		{source: e.SourceId},
+		{eventType: e.Type},
+	} {
+		if t, ok := r.templates[key]; ok {
+			return t
+		}
+	}
+	return r.templates[templateKey{}]
+}
+
+// Render returns the mail of e, the lines of the subject are joined.
+func (r *Renderer) Render(e Event) (Content, error) {
+	var c Content
+	t := r.lookup(e)
+
+	var buf bytes.Buffer
+	if err := t.subject.Execute(&buf, e); err != nil {
+		return c, xerrors.Errorf("render mail subject of event %s failed, err:%v", e.Id, err)
+	}
+	c.Subject = strings.Join(strings.Fields(buf.String()), " ")
+	if c.Subject == "" {
+		return c, xerrors.Errorf("the mail subject of event %s is empty", e.Id)
+	}
+
+	if t.text != nil {
+		buf.Reset()
+		if err := t.text.Execute(&buf, e); err != nil {
+			return c, xerrors.Errorf("render mail text of event %s failed, err:%v", e.Id, err)
+		}
+		c.Text = buf.String()
+	}
+	if t.html != nil {
+		buf.Reset()
+		if err := t.html.Execute(&buf, e); err != nil {
+			return c, xerrors.Errorf("render mail html of event %s failed, err:%v", e.Id, err)
+		}
+		c.HTML = buf.String()
+	}
+	return c, nil
+}
@@ -0,0 +1,83 @@
+package mail
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderLookup(t *testing.T) {
+	r, err := NewRenderer([]Template{
+		{Source: "gitee", EventType: "pr", Subject: "gitee pr {{.Title}}", Text: "pr"},
+		{Source: "gitee", Subject: "gitee {{.Title}}", Text: "gitee"},
+		{EventType: "pr", Subject: "any pr {{.Title}}", Text: "any pr"},
+	})
+	assert.NoError(t, err)
+
+	cases := []struct {
+		sourceId, eventType, subject string
+	}{
+		{"gitee", "pr", "gitee pr t"},
+		{"gitee", "issue", "gitee t"},
+		{"github", "pr", "any pr t"},
+		{"github", "issue", "t"},
+	}
+	for _, c := range cases {
+		content, err := r.Render(Event{SourceId: c.sourceId, Type: c.eventType, Title: "t"})
+		assert.NoError(t, err)
+		assert.Equal(t, c.subject, content.Subject, c.sourceId+" "+c.eventType)
+	}
+}
+
+func TestRenderDefault(t *testing.T) {
+	r, err := NewRenderer(nil)
+	assert.NoError(t, err)
+
+	content, err := r.Render(Event{SourceId: "gitee", Type: "pr",
+		Summary: `<script>alert(1)</script>`, SourceUrl: "javascript:alert(1)"})
+	assert.NoError(t, err)
+	assert.Equal(t, "[gitee] pr", content.Subject)
+	assert.Contains(t, content.Text, "<script>")
+	assert.NotContains(t, content.HTML, "<script>")
+	assert.NotContains(t, content.HTML, `href="javascript:`)
+
+	content, err = r.Render(Event{Title: "PR merged", Summary: "merged",
+		SourceUrl: "https://gitee.com/a/b/pulls/1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "PR merged", content.Subject)
+	assert.Contains(t, content.HTML, `<a href="https://gitee.com/a/b/pulls/1">`)
+}
+
+func TestRenderData(t *testing.T) {
+	r, err := NewRenderer([]Template{{
+		Subject: "{{.Data.repo}}\n  #{{.Data.number}}",
+		HTML:    `<p>{{.Data.title}}</p>`,
+	}})
+	assert.NoError(t, err)
+
+	content, err := r.Render(Event{Data: map[string]interface{}{"repo": "infra",
+		"number": 1, "title": "a < b"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "infra #1", content.Subject)
+	assert.Equal(t, "", content.Text)
+	assert.Equal(t, "<p>a &lt; b</p>", content.HTML)
+
+	// the missing fields of the data fail rather than send a broken mail
+	r, err = NewRenderer([]Template{{Subject: "{{.Data.pr.title}}", Text: "x"}})
+	assert.NoError(t, err)
+	_, err = r.Render(Event{Data: map[string]interface{}{}})
+	assert.Error(t, err)
+}
+
+func TestNewRendererInvalid(t *testing.T) {
+	for _, templates := range [][]Template{
+		{{Subject: "", Text: "x"}},
+		{{Subject: "x"}},
+		{{Subject: "{{.Title", Text: "x"}},
+		{{Subject: "x", HTML: "{{end}}"}},
+		{{Source: "gitee", Subject: "a", Text: "a"}, {Source: "gitee", Subject: "b", Text: "b"}},
+	} {
+		_, err := NewRenderer(templates)
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+// Package phone parses the phone numbers into E.164, the numbers of the
+// countries with a rule are checked against it, the others only against E.164.
+package phone
+
+import (
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// the limits of E.164, the country code included
+const (
+	minDigits = 7
+	maxDigits = 15
+)
+
+// Number is a phone number, CountryCode is empty when its country has no rule
+// and National holds every digit then.
+type Number struct {
+	CountryCode string
+	National    string
+}
+
+// E164 returns the number as stored, such as +8613800138000.
+func (n Number) E164() string {
+	return "+" + n.CountryCode + n.National
+}
+
+// Format returns the number as displayed, such as +86 138 0013 8000.
+func (n Number) Format() string {
+	r, ok := rules[n.CountryCode]
+	if !ok {
+		return n.E164()
+	}
+	parts := []string{"+" + n.CountryCode}
+	rest := n.National
+	for _, size := range r.groups {
+		if len(rest) <= size {
+			break
+		}
+		parts = append(parts, rest[:size])
+		rest = rest[size:]
+	}
+	return strings.Join(append(parts, rest), " ")
+}
+
+// Parse parses a number starting with + or 00, ignoring the separators and a
+// trunk prefix written as (0).
+func Parse(s string) (Number, error) {
+	digits, ok := clean(strings.ReplaceAll(strings.TrimSpace(s), "(0)", ""))
+	if !ok {
+		return Number{}, xerrors.Errorf("the phone number is invalid, phone:%s", s)
+	}
+	switch {
+	case strings.HasPrefix(digits, "+"):
+		digits = digits[1:]
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+	default:
+		return Number{}, xerrors.Errorf("the phone number has no country code, phone:%s", s)
+	}
+	// the country codes are prefix free, so one matches at most
+	for i := 1; i <= 3 && i < len(digits); i++ {
+		if r, ok := rules[digits[:i]]; ok {
+			return r.parse(digits[:i], digits[i:], s)
+		}
+	}
+	return parseOther(digits, s)
+}
+
+// ParseWithCountryCode parses a national number of the country of code, such as
+// +86, or an international one.
+func ParseWithCountryCode(code, s string) (Number, error) {
+	code = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(code), "+"), "00")
+	national := strings.TrimSpace(s)
+	if code == "" || strings.HasPrefix(national, "+") {
+		return Parse(national)
+	}
+	digits, ok := clean(national)
+	if !ok || strings.HasPrefix(digits, "+") || !isDigits(code) {
+		return Number{}, xerrors.Errorf("the phone number is invalid, code:%s, phone:%s",
+			code, s)
+	}
+	if r, ok := rules[code]; ok {
+		return r.parse(code, digits, s)
+	}
+	return parseOther(code+digits, s)
+}
+
+// Normalize returns the number s in international format as stored.
+func Normalize(s string) (string, error) {
+	n, err := Parse(s)
+	if err != nil {
+		return "", err
+	}
+	return n.E164(), nil
+}
+
+// Format returns the stored number s as displayed, or s itself when it is not
+// valid.
+func Format(s string) string {
+	n, err := Parse(s)
+	if err != nil {
+		return s
+	}
+	return n.Format()
+}
+
+// parse checks the national number of the country of rule r, dropping the
+// trunk prefix it may start with.
+func (r rule) parse(code, national, s string) (Number, error) {
+	if r.trunk != "" && len(national) > r.maxLen {
+		national = strings.TrimPrefix(national, r.trunk)
+	}
+	if !r.pattern.MatchString(national) {
+		return Number{}, xerrors.Errorf("the phone number is invalid for country code %s, "+
+			"phone:%s", code, s)
+	}
+	return Number{CountryCode: code, National: national}, nil
+}
+
+// parseOther checks a number of a country with no rule against E.164.
+func parseOther(digits, s string) (Number, error) {
+	if len(digits) < minDigits || len(digits) > maxDigits || digits[0] == '0' ||
+		!isDigits(digits) {
+		return Number{}, xerrors.Errorf("the phone number is invalid, phone:%s", s)
+	}
+	return Number{National: digits}, nil
+}
+
+// clean drops the separators of s, false when there is anything else.
+func clean(s string) (string, bool) {
+	var b strings.Builder
+	for i, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			b.WriteRune(c)
+		case c == '+' && i == 0:
+			b.WriteRune(c)
+		case strings.ContainsRune(" .-()", c):
+		default:
+			return "", false
+		}
+	}
+	return b.String(), b.Len() != 0
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,93 @@
+package phone
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	for _, c := range []struct {
+		input   string
+		e164    string
+		display string
+	}{
+		{"+8613800138000", "+8613800138000", "+86 138 0013 8000"},
+		{"+86 138-0013-8000", "+8613800138000", "+86 138 0013 8000"},
+		{"008613800138000", "+8613800138000", "+86 138 0013 8000"},
+		{"+1 (650) 253-0000", "+16502530000", "+1 650 253 0000"},
+		{"+44 (0)7400 123456", "+447400123456", "+44 7400 123456"},
+		{"+4407400123456", "+447400123456", "+44 7400 123456"},
+		{"+33 6 12 34 56 78", "+33612345678", "+33 6 12 34 56 78"},
+		{"+49 151 23456789", "+4915123456789", "+49 151 23456789"},
+		{"+81 90-1234-5678", "+819012345678", "+81 90 1234 5678"},
+		{"+852 9123 4567", "+85291234567", "+852 9123 4567"},
+		{"+91 98765 43210", "+919876543210", "+91 98765 43210"},
+		// the countries with no rule are checked against E.164 only
+		{"+212 612-345678", "+212612345678", "+212612345678"},
+	} {
+		n, err := Parse(c.input)
+		if assert.NoError(t, err, c.input) {
+			assert.Equal(t, c.e164, n.E164(), c.input)
+			assert.Equal(t, c.display, n.Format(), c.input)
+		}
+	}
+
+	for _, input := range []string{
+		"",
+		"13800138000",       // no country code
+		"+8612800138000",    // not a mobile number of CN
+		"+861380013800",     // too short
+		"+86138001380000",   // too long
+		"+1 (150) 253-0000", // no area code starts with 1
+		"+86 138 0013 800a",
+		"+212 123",
+		"+0123456789",
+		"+1234567890123456",
+		"86+13800138000",
+	} {
+		_, err := Parse(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestParseWithCountryCode(t *testing.T) {
+	for _, c := range []struct {
+		code  string
+		input string
+		e164  string
+	}{
+		{"86", "13800138000", "+8613800138000"},
+		{"+86", "138 0013 8000", "+8613800138000"},
+		{"0086", "13800138000", "+8613800138000"},
+		{"44", "07400 123456", "+447400123456"},
+		{"7", "8 916 123 45 67", "+79161234567"},
+		{"212", "612345678", "+212612345678"},
+		// the number has its own country code
+		{"86", "+16502530000", "+16502530000"},
+		{"", "+8613800138000", "+8613800138000"},
+	} {
+		n, err := ParseWithCountryCode(c.code, c.input)
+		if assert.NoError(t, err, c.code+" "+c.input) {
+			assert.Equal(t, c.e164, n.E164(), c.code+" "+c.input)
+		}
+	}
+
+	for _, c := range [][2]string{{"86", "12800138000"}, {"8a", "13800138000"}, {"", "13800138000"},
+		{"86", "+86+13800138000"}} {
+		_, err := ParseWithCountryCode(c[0], c[1])
+		assert.Error(t, err, c[0]+" "+c[1])
+	}
+}
+
+func TestNormalizeAndFormat(t *testing.T) {
+	s, err := Normalize("+86 138 0013 8000")
+	assert.NoError(t, err)
+	assert.Equal(t, "+8613800138000", s)
+	_, err = Normalize("13800138000")
+	assert.Error(t, err)
+
+	assert.Equal(t, "+86 138 0013 8000", Format("+8613800138000"))
+	// the numbers saved before the rules are displayed as they are
+	assert.Equal(t, "12345", Format("12345"))
+}
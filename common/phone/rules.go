@@ -0,0 +1,67 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package phone
+
+import (
+	"regexp"
+)
+
+// rule is how the mobile numbers of a country are written, groups are the
+// sizes of their leading digit groups as displayed.
+type rule struct {
+	pattern *regexp.Regexp
+	maxLen  int
+	trunk   string
+	groups  []int
+}
+
+func newRule(pattern string, maxLen int, trunk string, groups ...int) rule {
+	return rule{
+		pattern: regexp.MustCompile(`^(?:` + pattern + `)$`),
+		maxLen:  maxLen,
+		trunk:   trunk,
+		groups:  groups,
+	}
+}
+
+// rules are the rules of the countries by country code, the numbers reached by
+// SMS are the mobile ones.
+var rules = map[string]rule{
+	"1":   newRule(`[2-9]\d{2}[2-9]\d{6}`, 10, "1", 3, 3), // US, CA and the NANP
+	"7":   newRule(`[79]\d{9}`, 10, "8", 3, 3, 2),         // RU, KZ
+	"20":  newRule(`1[0-25]\d{8}`, 10, "0", 3, 3),         // EG
+	"27":  newRule(`[6-8]\d{8}`, 9, "0", 2, 3),            // ZA
+	"31":  newRule(`6\d{8}`, 9, "0", 1),                   // NL
+	"33":  newRule(`[67]\d{8}`, 9, "0", 1, 2, 2, 2),       // FR
+	"34":  newRule(`[67]\d{8}`, 9, "", 3, 3),              // ES
+	"39":  newRule(`3\d{8,9}`, 10, "", 3),                 // IT
+	"41":  newRule(`7[5-9]\d{7}`, 9, "0", 2, 3, 2),        // CH
+	"44":  newRule(`7[1-57-9]\d{8}`, 10, "0", 4),          // GB
+	"46":  newRule(`7[02369]\d{7}`, 9, "0", 2, 3, 2),      // SE
+	"48":  newRule(`[4-8]\d{8}`, 9, "", 3, 3),             // PL
+	"49":  newRule(`1[5-7]\d{8,9}`, 11, "0", 3),           // DE
+	"52":  newRule(`[1-9]\d{9}`, 10, "", 2, 4),            // MX
+	"55":  newRule(`[1-9]{2}9\d{8}`, 11, "0", 2, 5),       // BR
+	"60":  newRule(`1\d{8,9}`, 10, "0", 2, 4),             // MY
+	"61":  newRule(`4\d{8}`, 9, "0", 3, 3),                // AU
+	"62":  newRule(`8\d{8,11}`, 12, "0", 3, 4),            // ID
+	"63":  newRule(`9\d{9}`, 10, "0", 3, 3),               // PH
+	"64":  newRule(`2\d{7,9}`, 10, "0", 2, 3),             // NZ
+	"65":  newRule(`[89]\d{7}`, 8, "", 4),                 // SG
+	"66":  newRule(`[689]\d{8}`, 9, "0", 2, 3),            // TH
+	"81":  newRule(`[7-9]0\d{8}`, 10, "0", 2, 4),          // JP
+	"82":  newRule(`1[016-9]\d{7,8}`, 10, "0", 2, 4),      // KR
+	"84":  newRule(`[35789]\d{8}`, 9, "0", 2, 3),          // VN
+	"86":  newRule(`1[3-9]\d{9}`, 11, "0", 3, 4),          // CN
+	"90":  newRule(`5\d{9}`, 10, "0", 3, 3, 2),            // TR
+	"91":  newRule(`[6-9]\d{9}`, 10, "0", 5),              // IN
+	"234": newRule(`[7-9][01]\d{8}`, 10, "0", 3, 3),       // NG
+	"353": newRule(`8[3-9]\d{7}`, 9, "0", 2, 3),           // IE
+	"380": newRule(`[3-9]\d{8}`, 9, "0", 2, 3, 2),         // UA
+	"852": newRule(`[4-79]\d{7}`, 8, "", 4),               // HK
+	"853": newRule(`6\d{7}`, 8, "", 4),                    // MO
+	"886": newRule(`9\d{8}`, 9, "0", 3, 3),                // TW
+	"971": newRule(`5[02-8]\d{7}`, 9, "0", 2, 3),          // AE
+}
@@ -15,9 +15,11 @@ import (
 var (
 	sqlDb *sql.DB
 	db    *gorm.DB
+	dsn   string
 )
 
 func Init(cfg *Config) (err error) {
+	dsn = cfg.dsn()
 	db, err = gorm.Open(
 		postgres.New(postgres.Config{
 			DSN:                  cfg.dsn(),
@@ -0,0 +1,61 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// JobLock is an advisory lock electing the replica running a periodic job, it
+// is held on a connection of its own.
+type JobLock struct {
+	name string
+	lock sync.Mutex
+	conn *sql.Conn
+}
+
+func NewJobLock(name string) *JobLock {
+	return &JobLock{name: name}
+}
+
+// Hold tells whether this replica holds the lock, it takes the lock when the
+// lock is free.
+func (l *JobLock) Hold() bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	ctx := context.Background()
+	if l.conn != nil {
+		if err := l.conn.PingContext(ctx); err == nil {
+			return true
+		}
+		_ = l.conn.Close()
+		l.conn = nil
+	}
+	if sqlDb == nil {
+		return false
+	}
+
+	conn, err := sqlDb.Conn(ctx)
+	if err != nil {
+		logrus.Errorf("get connection of job lock %s failed, err:%v", l.name, err)
+		return false
+	}
+	held := false
+	if err = conn.QueryRowContext(ctx, "select pg_try_advisory_lock(hashtext($1))", l.name).
+		Scan(&held); err != nil {
+		logrus.Errorf("take job lock %s failed, err:%v", l.name, err)
+	}
+	if !held {
+		_ = conn.Close()
+		return false
+	}
+	l.conn = conn
+	return true
+}
@@ -0,0 +1,17 @@
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestJobLock(t *testing.T) {
+	convey.Convey("test Hold failed, not initialized", t, func() {
+		saved := sqlDb
+		sqlDb = nil
+		defer func() { sqlDb = saved }()
+
+		convey.So(NewJobLock("test").Hold(), convey.ShouldBeFalse)
+	})
+}
@@ -0,0 +1,38 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package postgresql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Listen calls handler with the payload of every notification of channel on a
+// dedicated connection until ctx is done or the connection is broken.
+func Listen(ctx context.Context, channel string, handler func(payload string)) error {
+	if dsn == "" {
+		return errors.New("postgresql is not initialized")
+	}
+
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err = conn.Exec(ctx, "listen "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		handler(notification.Payload)
+	}
+}
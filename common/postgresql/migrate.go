@@ -0,0 +1,64 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package postgresql
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Migration is a change of the schema, which is applied once by its version.
+type Migration struct {
+	Version string
+	Sql     string
+}
+
+const migrationSql = `
+create table if not exists message_center.schema_migration (
+    version    text primary key,
+    applied_at timestamptz not null default now()
+)`
+
+// Migrate applies the new migrations in order in one transaction, under an
+// advisory lock so that the replicas apply each of them once.
+func Migrate(migrations []Migration) error {
+	if db == nil {
+		return errors.New("postgresql is not initialized")
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("select pg_advisory_xact_lock(hashtext('schema_migration'))").
+			Error; err != nil {
+			return fmt.Errorf("lock schema migration failed, err:%v", err)
+		}
+		if err := tx.Exec(migrationSql).Error; err != nil {
+			return fmt.Errorf("create schema migration failed, err:%v", err)
+		}
+		var applied []string
+		if err := tx.Table("message_center.schema_migration").
+			Pluck("version", &applied).Error; err != nil {
+			return fmt.Errorf("get schema migration failed, err:%v", err)
+		}
+
+		for _, m := range migrations {
+			if slices.Contains(applied, m.Version) {
+				continue
+			}
+			if err := tx.Exec(m.Sql).Error; err != nil {
+				return fmt.Errorf("apply migration %s failed, err:%v", m.Version, err)
+			}
+			if err := tx.Exec("insert into message_center.schema_migration (version) values (?)",
+				m.Version).Error; err != nil {
+				return fmt.Errorf("save migration %s failed, err:%v", m.Version, err)
+			}
+			logrus.Infof("migration %s is applied", m.Version)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,18 @@
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestMigrate(t *testing.T) {
+	convey.Convey("test Migrate failed, not initialized", t, func() {
+		saved := db
+		db = nil
+		defer func() { db = saved }()
+
+		convey.So(Migrate([]Migration{{Version: "test", Sql: "select 1"}}),
+			convey.ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,271 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+// Package repopattern matches the full names of repositories against globs
+// such as src-openeuler/** or !*/test-*, ignoring case.
+package repopattern
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	excludePrefix = "!"
+	separator     = "/"
+	anySegments   = "**"
+	// everything is how a pattern matching every repository is written
+	everything = "*"
+)
+
+// Pattern is a normalized pattern.
+type Pattern struct {
+	Exclude  bool
+	segments []string
+}
+
+// Parse normalizes s: it is trimmed and lower-cased, empty segments are dropped
+// and the runs of "**" are collapsed.
+func Parse(s string) (Pattern, error) {
+	var p Pattern
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, excludePrefix) {
+		p.Exclude = true
+		s = strings.TrimSpace(s[len(excludePrefix):])
+	}
+	s = strings.ToLower(s)
+
+	for _, seg := range strings.Split(s, separator) {
+		seg = strings.TrimSpace(seg)
+		switch {
+		case seg == "":
+			continue
+		case seg == anySegments:
+			if n := len(p.segments); n != 0 && p.segments[n-1] == anySegments {
+				continue
+			}
+		case strings.Contains(seg, anySegments):
+			// "**" inside a segment is no more than "*"
+			for strings.Contains(seg, anySegments) {
+				seg = strings.ReplaceAll(seg, anySegments, "*")
+			}
+		}
+		if _, err := path.Match(seg, ""); err != nil {
+			return Pattern{}, xerrors.Errorf("invalid repository pattern %s, err:%v", s, err)
+		}
+		p.segments = append(p.segments, seg)
+	}
+
+	if len(p.segments) == 0 {
+		return Pattern{}, xerrors.Errorf("empty repository pattern")
+	}
+	if len(p.segments) == 1 && p.segments[0] == everything {
+		p.segments = []string{anySegments}
+	}
+	return p, nil
+}
+
+// String returns the normalized text of p.
+func (p Pattern) String() string {
+	s := strings.Join(p.segments, separator)
+	if s == anySegments {
+		s = everything
+	}
+	if p.Exclude {
+		return excludePrefix + s
+	}
+	return s
+}
+
+// Match reports whether the repository fullName matches p, whether p
+// excludes it or not.
+func (p Pattern) Match(fullName string) bool {
+	var names []string
+	for _, seg := range strings.Split(strings.ToLower(strings.TrimSpace(fullName)), separator) {
+		if seg != "" {
+			names = append(names, seg)
+		}
+	}
+	return matchSegments(p.segments, names)
+}
+
+func matchSegments(patterns, names []string) bool {
+	if len(patterns) == 0 {
+		return len(names) == 0
+	}
+	if patterns[0] == anySegments {
+		for i := 0; i <= len(names); i++ {
+			if matchSegments(patterns[1:], names[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(names) == 0 {
+		return false
+	}
+	ok, _ := path.Match(patterns[0], names[0])
+	return ok && matchSegments(patterns[1:], names[1:])
+}
+
+// Covers reports whether every repository matching q matches p too, it may
+// miss some cases but is never wrong.
+func (p Pattern) Covers(q Pattern) bool {
+	return coverSegments(p.segments, q.segments)
+}
+
+func coverSegments(p, q []string) bool {
+	if len(p) == 0 {
+		return len(q) == 0
+	}
+	if p[0] == anySegments {
+		// "**" takes no segment of q, or takes the first one and stays
+		return coverSegments(p[1:], q) || (len(q) != 0 && coverSegments(p, q[1:]))
+	}
+	if len(q) == 0 || q[0] == anySegments {
+		return false
+	}
+	return coverSegment(p[0], q[0]) && coverSegments(p[1:], q[1:])
+}
+
+// coverSegment reports whether the glob p matches every segment the glob q
+// matches.
+func coverSegment(p, q string) bool {
+	if p == q || p == "*" {
+		return true
+	}
+	if strings.ContainsAny(q, `*?[\`) {
+		return false
+	}
+	ok, _ := path.Match(p, q)
+	return ok
+}
+
+// Set matches the repositories matching one of its including patterns, or any
+// when there is none, and none of its excluding ones.
+type Set []Pattern
+
+// ParseSet parses patterns, the empty ones are skipped.
+func ParseSet(patterns []string) (Set, error) {
+	var set Set
+	for _, s := range patterns {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		p, err := Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, p)
+	}
+	return set, nil
+}
+
+// Match reports whether the repository fullName matches s.
+func (s Set) Match(fullName string) bool {
+	included, hasInclude := false, false
+	for _, p := range s {
+		if p.Exclude {
+			if p.Match(fullName) {
+				return false
+			}
+			continue
+		}
+		hasInclude = true
+		included = included || p.Match(fullName)
+	}
+	return included || !hasInclude
+}
+
+// Strings returns the normalized texts of s.
+func (s Set) Strings() []string {
+	result := make([]string, len(s))
+	for i := range s {
+		result[i] = s[i].String()
+	}
+	return result
+}
+
+// Merge drops the duplicates and the patterns covered by another one, the
+// including patterns come first, each sorted.
+func (s Set) Merge() Set {
+	var includes, excludes Set
+	seen := map[string]bool{}
+	for _, p := range s {
+		if key := p.String(); !seen[key] {
+			seen[key] = true
+			if p.Exclude {
+				excludes = append(excludes, p)
+			} else {
+				includes = append(includes, p)
+			}
+		}
+	}
+	includes = dropCovered(includes)
+	excludes = dropCovered(excludes)
+
+	// an included pattern every repository of which is excluded adds nothing,
+	// unless it is the last one, as no included pattern means every repository
+	var kept Set
+	for _, p := range includes {
+		if !coveredBy(p, excludes) {
+			kept = append(kept, p)
+		}
+	}
+	if len(kept) != 0 {
+		includes = kept
+	}
+	if len(excludes) != 0 && len(includes) == 1 && includes[0].String() == everything {
+		includes = nil
+	}
+	return append(includes, excludes...)
+}
+
+// dropCovered drops the patterns covered by another one of s, of two patterns
+// covering each other the one sorted first is kept.
+func dropCovered(s Set) Set {
+	sort.Slice(s, func(i, j int) bool { return s[i].String() < s[j].String() })
+
+	var result Set
+	for i, p := range s {
+		covered := false
+		for j, q := range s {
+			if i != j && q.Covers(p) && (!p.Covers(q) || j < i) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+func coveredBy(p Pattern, s Set) bool {
+	for _, q := range s {
+		if q.Covers(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Merge parses and merges patterns.
+func Merge(patterns []string) ([]string, error) {
+	set, err := ParseSet(patterns)
+	if err != nil {
+		return nil, err
+	}
+	return set.Merge().Strings(), nil
+}
+
+// Split returns the patterns of a list separated by commas, such as the repos
+// parameter of the message lists.
+func Split(s string) []string {
+	return strings.Split(s, ",")
+}
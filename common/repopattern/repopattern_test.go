@@ -0,0 +1,243 @@
+package repopattern
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	cases := map[string]string{
+		"openeuler/infrastructure":    "openeuler/infrastructure",
+		" OpenEuler/Infra ":           "openeuler/infra",
+		"/src-openeuler//python-*/":   "src-openeuler/python-*",
+		"!openeuler/docs":             "!openeuler/docs",
+		"! openeuler/docs":            "!openeuler/docs",
+		"*":                           "*",
+		"**":                          "*",
+		"**/**":                       "*",
+		"openeuler/**/**/docs":        "openeuler/**/docs",
+		"openeuler/a**b":              "openeuler/a*b",
+		"!*":                          "!*",
+		"src-openeuler/python-?[0-9]": "src-openeuler/python-?[0-9]",
+	}
+	for input, want := range cases {
+		p, err := Parse(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, want, p.String(), input)
+	}
+
+	for _, input := range []string{"", " ", "!", "//", "openeuler/[a-"} {
+		_, err := Parse(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern string
+		repo    string
+		want    bool
+	}{
+		{"*", "openeuler/infrastructure", true},
+		{"*", "group/sub/repo", true},
+		{"openeuler/*", "openeuler/infrastructure", true},
+		{"openeuler/*", "OpenEuler/Infrastructure", true},
+		{"openeuler/*", "openeuler/group/repo", false},
+		{"openeuler/**", "openeuler/group/repo", true},
+		{"openeuler/**", "src-openeuler/repo", false},
+		{"src-openeuler/python-*", "src-openeuler/python-requests", true},
+		{"src-openeuler/python-*", "src-openeuler/perl-json", false},
+		{"**/docs", "openeuler/docs", true},
+		{"**/docs", "group/sub/docs", true},
+		{"openeuler/**/docs", "openeuler/docs", true},
+		{"*/infra?", "openeuler/infra1", true},
+		{"!openeuler/docs", "openeuler/docs", true},
+	}
+	for _, c := range cases {
+		p, err := Parse(c.pattern)
+		assert.NoError(t, err)
+		assert.Equal(t, c.want, p.Match(c.repo), c.pattern+" "+c.repo)
+	}
+}
+
+func TestSetMatch(t *testing.T) {
+	set, err := ParseSet([]string{"openeuler/*", "src-openeuler/python-*", "!openeuler/docs", ""})
+	assert.NoError(t, err)
+	assert.True(t, set.Match("openeuler/infrastructure"))
+	assert.True(t, set.Match("src-openeuler/python-requests"))
+	assert.False(t, set.Match("openeuler/docs"))
+	assert.False(t, set.Match("opengauss/server"))
+
+	// excluding alone keeps every other repository
+	set, err = ParseSet([]string{"!openeuler/docs"})
+	assert.NoError(t, err)
+	assert.True(t, set.Match("opengauss/server"))
+	assert.False(t, set.Match("openeuler/docs"))
+
+	assert.True(t, Set{}.Match("opengauss/server"))
+
+	_, err = ParseSet([]string{"openeuler/[a-"})
+	assert.Error(t, err)
+}
+
+func TestMerge(t *testing.T) {
+	cases := []struct {
+		input []string
+		want  []string
+	}{
+		{[]string{"path1/*", "path2/*", "*", "path3/*", "path1/subpath"}, []string{"*"}},
+		{[]string{"path1/*", "path2/*", "path1/subpath"}, []string{"path1/*", "path2/*"}},
+		{[]string{"openeuler/**", "openeuler/*", "openeuler/group/repo"},
+			[]string{"openeuler/**"}},
+		{[]string{"src-openeuler/python-*", "src-openeuler/python-requests", "OpenEuler/Docs",
+			"openeuler/docs"}, []string{"openeuler/docs", "src-openeuler/python-*"}},
+		{[]string{"!openeuler/docs", "*"}, []string{"!openeuler/docs"}},
+		{[]string{"openeuler/*", "!openeuler/docs", "!openeuler/**"},
+			[]string{"openeuler/*", "!openeuler/**"}},
+		{[]string{"openeuler/docs", "opengauss/docs", "!openeuler/*"},
+			[]string{"opengauss/docs", "!openeuler/*"}},
+		{[]string{"", " "}, []string{}},
+	}
+	for _, c := range cases {
+		got, err := Merge(c.input)
+		assert.NoError(t, err)
+		assert.Equal(t, c.want, got, strings.Join(c.input, ","))
+	}
+
+	_, err := Merge([]string{"openeuler/[a-"})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"a/b", " !c/d"}, Split("a/b, !c/d"))
+}
+
+// patternList is a random list of patterns over a small alphabet, so that the
+// patterns overlap and the random repositories match them often.
+type patternList []string
+
+var patternSegments = []string{"a", "b", "ab", "a*", "*b", "*", "**", "?b", "[ab]"}
+
+func (patternList) Generate(r *rand.Rand, _ int) reflect.Value {
+	list := make(patternList, r.Intn(6))
+	for i := range list {
+		segs := make([]string, 1+r.Intn(3))
+		for j := range segs {
+			segs[j] = patternSegments[r.Intn(len(patternSegments))]
+		}
+		list[i] = strings.Join(segs, "/")
+		if r.Intn(3) == 0 {
+			list[i] = "!" + list[i]
+		}
+		if r.Intn(5) == 0 {
+			list[i] = strings.ToUpper(list[i])
+		}
+	}
+	return reflect.ValueOf(list)
+}
+
+type repoName string
+
+var repoSegments = []string{"a", "b", "ab", "bb", "ba", "aab"}
+
+func (repoName) Generate(r *rand.Rand, _ int) reflect.Value {
+	segs := make([]string, 1+r.Intn(4))
+	for i := range segs {
+		segs[i] = repoSegments[r.Intn(len(repoSegments))]
+	}
+	return reflect.ValueOf(repoName(strings.Join(segs, "/")))
+}
+
+func quickConfig() *quick.Config {
+	return &quick.Config{MaxCount: 5000, Rand: rand.New(rand.NewSource(42))}
+}
+
+func mustParseSet(t *testing.T, patterns []string) Set {
+	set, err := ParseSet(patterns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return set
+}
+
+func TestPropertyMergeKeepsMatches(t *testing.T) {
+	f := func(list patternList, repos [8]repoName) bool {
+		set := mustParseSet(t, list)
+		merged, err := Merge(list)
+		if err != nil {
+			return false
+		}
+		mergedSet := mustParseSet(t, merged)
+		for _, repo := range repos {
+			if set.Match(string(repo)) != mergedSet.Match(string(repo)) {
+				t.Logf("%v merged to %v differs at %s", list, merged, repo)
+				return false
+			}
+		}
+		return true
+	}
+	assert.NoError(t, quick.Check(f, quickConfig()))
+}
+
+func TestPropertyMergeIsIdempotentAndOrderFree(t *testing.T) {
+	f := func(list patternList, seed int64) bool {
+		merged, err := Merge(list)
+		if err != nil {
+			return false
+		}
+		again, err := Merge(merged)
+		if err != nil || !reflect.DeepEqual(merged, again) {
+			t.Logf("%v merged to %v then %v", list, merged, again)
+			return false
+		}
+
+		shuffled := append(patternList{}, list...)
+		rand.New(rand.NewSource(seed)).Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+		other, err := Merge(shuffled)
+		return err == nil && reflect.DeepEqual(merged, other)
+	}
+	assert.NoError(t, quick.Check(f, quickConfig()))
+}
+
+func TestPropertyNormalizationIsStable(t *testing.T) {
+	f := func(list patternList, repo repoName) bool {
+		for _, s := range list {
+			p, err := Parse(s)
+			if err != nil {
+				return false
+			}
+			q, err := Parse(p.String())
+			if err != nil || q.String() != p.String() ||
+				q.Match(string(repo)) != p.Match(string(repo)) {
+				return false
+			}
+		}
+		return true
+	}
+	assert.NoError(t, quick.Check(f, quickConfig()))
+}
+
+func TestPropertyCoversIsSound(t *testing.T) {
+	f := func(list patternList, repos [8]repoName) bool {
+		set := mustParseSet(t, list)
+		for _, p := range set {
+			for _, q := range set {
+				if !p.Covers(q) {
+					continue
+				}
+				for _, repo := range repos {
+					if q.Match(string(repo)) && !p.Match(string(repo)) {
+						t.Logf("%s covers %s but not %s", p, q, repo)
+						return false
+					}
+				}
+			}
+		}
+		return true
+	}
+	assert.NoError(t, quick.Check(f, quickConfig()))
+}
@@ -0,0 +1,91 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+// Package sms sends the text messages through an HTTP gateway.
+package sms
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	defaultTimeout = 10
+	// the part of the response quoted in the errors
+	maxResponseQuote = 256
+)
+
+// Config configures the gateway, the messages are posted to URL as JSON with
+// Token as bearer token when it is set. Timeout is in seconds.
+type Config struct {
+	URL     string `json:"url"`
+	Token   string `json:"token"`
+	Timeout int    `json:"timeout"`
+}
+
+func (cfg *Config) SetDefault() {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+}
+
+// Gateway sends the text messages to the phones.
+type Gateway interface {
+	Send(phone, text string) error
+}
+
+// NewGateway returns the gateway of cfg, or nil when no URL is configured.
+func NewGateway(cfg Config) Gateway {
+	if cfg.URL == "" {
+		return nil
+	}
+	cfg.SetDefault()
+	return &httpGateway{
+		cfg:    cfg,
+		client: &http.Client{Timeout: time.Duration(cfg.Timeout) * time.Second},
+	}
+}
+
+type httpGateway struct {
+	cfg    Config
+	client *http.Client
+}
+
+type request struct {
+	Phone string `json:"phone"`
+	Text  string `json:"text"`
+}
+
+// Send posts text to the gateway, any status but 2xx is a failure.
+func (g *httpGateway) Send(phone, text string) error {
+	body, err := json.Marshal(request{Phone: phone, Text: text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, g.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return xerrors.Errorf("invalid sms gateway, err:%v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.cfg.Token)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return xerrors.Errorf("send sms failed, err:%v", err)
+	}
+	defer resp.Body.Close()
+	quote, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseQuote))
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return xerrors.Errorf("send sms failed, status:%d, response:%s", resp.StatusCode,
+			quote)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package sms
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSend(t *testing.T) {
+	var got request
+	var authorization string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("quota exceeded"))
+	}))
+	defer server.Close()
+
+	gateway := NewGateway(Config{URL: server.URL, Token: "secret"})
+	assert.NoError(t, gateway.Send("+8613800000000", "code 123456"))
+	assert.Equal(t, request{Phone: "+8613800000000", Text: "code 123456"}, got)
+	assert.Equal(t, "Bearer secret", authorization)
+
+	status = http.StatusTooManyRequests
+	err := gateway.Send("+8613800000000", "code 123456")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "quota exceeded")
+	}
+}
+
+func TestNewGateway(t *testing.T) {
+	assert.Nil(t, NewGateway(Config{}))
+
+	cfg := Config{URL: "http://localhost"}
+	cfg.SetDefault()
+	assert.Equal(t, defaultTimeout, cfg.Timeout)
+}
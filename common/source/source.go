@@ -0,0 +1,524 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+// Package source is the registry of the upstreams sending messages.
+package source
+
+import (
+	"sync"
+
+	"golang.org/x/xerrors"
+)
+
+// Ids of the built-in sources.
+const (
+	IdForum   = "forum"
+	IdEur     = "eur"
+	IdCve     = "cve"
+	IdGitee   = "gitee"
+	IdGithub  = "github"
+	IdGitcode = "gitcode"
+	IdMeeting = "meeting"
+)
+
+// Forges are the code hosting platforms whose logins the recipients have.
+const (
+	ForgeGitee   = "gitee"
+	ForgeGithub  = "github"
+	ForgeGitcode = "gitcode"
+)
+
+var forges = []string{ForgeGitee, ForgeGithub, ForgeGitcode}
+
+// Categories are the message lists a source feeds.
+const (
+	CategoryFollow  = "follow"
+	CategoryRelated = "related"
+	CategoryTodo    = "todo"
+)
+
+// Urls of the built-in sources, the cloud events carry them as source.
+const (
+	DefaultForumUrl   = "forum"
+	DefaultEurUrl     = "https://eur.openeuler.openatom.cn"
+	DefaultCveUrl     = "cve"
+	DefaultGiteeUrl   = "https://gitee.com"
+	DefaultGithubUrl  = "https://github.com"
+	DefaultGitcodeUrl = "https://gitcode.com"
+	DefaultMeetingUrl = "https://www.openEuler.org/meeting"
+)
+
+// Rule tells how the recipients of an event of a type are found in its
+// data_json, every path is a dotted path.
+type Rule struct {
+	Type string `json:"type"`
+	// SenderPath is the login of the sender, who is never related to their own
+	// event.
+	SenderPath string `json:"sender_path"`
+	// RelatedPaths are logins related to the event.
+	RelatedPaths []string `json:"related_paths"`
+	// MentionPaths are texts whose @login mentions are related to the event.
+	MentionPaths []string `json:"mention_paths"`
+	// TodoPaths are logins who have to act on the event.
+	TodoPaths []string `json:"todo_paths"`
+	// TodoSigMembers gives todos to the maintainers of the sigs at the sig
+	// filter as well, as the membership is synced locally.
+	TodoSigMembers bool `json:"todo_sig_members"`
+	// BusinessIdPath identifies the item the todo is about, the source_url
+	// of the event is used if it is empty.
+	BusinessIdPath string `json:"business_id_path"`
+	// DonePath and DoneValues tell whether the item is done.
+	DonePath   string   `json:"done_path"`
+	DoneValues []string `json:"done_values"`
+	// ExpireMinutes closes the todos this many minutes after the time of
+	// their latest event, a meeting is done once it has passed.
+	ExpireMinutes int `json:"expire_minutes"`
+	// Filters maps the names a subscription filters by, such as pr_state, to
+	// the paths of the data of the forge.
+	Filters map[string]string `json:"filters"`
+}
+
+// Source is an upstream sending messages, its events carry Url as source.
+type Source struct {
+	Id          string   `json:"id"           required:"true"`
+	Url         string   `json:"url"          required:"true"`
+	DisplayName string   `json:"display_name"`
+	Categories  []string `json:"categories"`
+	// EventTypes are the types of the events of the source, empty means any.
+	EventTypes []string `json:"event_types"`
+	// Schedule todos, such as meetings, leave the todo list when they start
+	// and are counted apart.
+	Schedule bool `json:"schedule"`
+	// Forge is the platform of the logins in the events, they are looked up
+	// among the logins of the recipients on it.
+	Forge string `json:"forge"`
+	// Bots are the logins of the bots sending the events of the source.
+	Bots  []string `json:"bots"`
+	Rules []Rule   `json:"rules"`
+}
+
+// HasCategory reports whether the source feeds the list category.
+func (s *Source) HasCategory(category string) bool {
+	for _, c := range s.Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// HasEventType reports whether the source sends events of eventType.
+func (s *Source) HasEventType(eventType string) bool {
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Config lists the sources, the built-in ones are used when it is empty.
+type Config struct {
+	Sources []Source `json:"sources"`
+}
+
+func (cfg *Config) Validate() error {
+	ids := map[string]bool{}
+	urls := map[string]bool{}
+	for i := range cfg.Sources {
+		s := &cfg.Sources[i]
+		if s.Id == "" || s.Url == "" {
+			return xerrors.Errorf("the id and url of source %d are required", i)
+		}
+		if ids[s.Id] || urls[s.Url] {
+			return xerrors.Errorf("source %s is duplicated", s.Id)
+		}
+		ids[s.Id] = true
+		urls[s.Url] = true
+		for _, c := range s.Categories {
+			if c != CategoryFollow && c != CategoryRelated && c != CategoryTodo {
+				return xerrors.Errorf("unknown category %s of source %s", c, s.Id)
+			}
+		}
+		if s.Forge != "" && !IsForge(s.Forge) {
+			return xerrors.Errorf("unknown forge %s of source %s", s.Forge, s.Id)
+		}
+	}
+	return nil
+}
+
+var (
+	lock    sync.RWMutex
+	sources = DefaultSources()
+)
+
+func Init(cfg *Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	if len(cfg.Sources) == 0 {
+		sources = DefaultSources()
+	} else {
+		sources = append([]Source{}, cfg.Sources...)
+	}
+	return nil
+}
+
+// Forges returns the known forges.
+func Forges() []string {
+	return append([]string{}, forges...)
+}
+
+// IsForge reports whether forge is a known forge.
+func IsForge(forge string) bool {
+	for _, f := range forges {
+		if f == forge {
+			return true
+		}
+	}
+	return false
+}
+
+// All returns the registered sources in their configured order.
+func All() []Source {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	return append([]Source{}, sources...)
+}
+
+// Get returns the source of id.
+func Get(id string) (Source, bool) {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	for i := range sources {
+		if sources[i].Id == id {
+			return sources[i], true
+		}
+	}
+	return Source{}, false
+}
+
+// GetByUrl returns the source whose events carry url as source.
+func GetByUrl(url string) (Source, bool) {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	for i := range sources {
+		if sources[i].Url == url {
+			return sources[i], true
+		}
+	}
+	return Source{}, false
+}
+
+// Url returns the url of the source id, or "" if it is not registered. A
+// query on "" matches no message.
+func Url(id string) string {
+	s, _ := Get(id)
+	return s.Url
+}
+
+// Urls returns the urls of the sources feeding category, a nil schedule
+// includes the sources of schedule todos and the others.
+func Urls(category string, schedule *bool) []string {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	urls := []string{}
+	for i := range sources {
+		if !sources[i].HasCategory(category) {
+			continue
+		}
+		if schedule != nil && sources[i].Schedule != *schedule {
+			continue
+		}
+		urls = append(urls, sources[i].Url)
+	}
+	return urls
+}
+
+// DefaultSources are the sources sending messages to the openEuler message
+// center.
+func DefaultSources() []Source {
+	return []Source{
+		{
+			Id:          IdForum,
+			Url:         DefaultForumUrl,
+			DisplayName: "论坛",
+			Categories:  []string{CategoryFollow, CategoryRelated, CategoryTodo},
+		},
+		{
+			Id:          IdEur,
+			Url:         DefaultEurUrl,
+			DisplayName: "EUR",
+			Categories:  []string{CategoryFollow},
+		},
+		{
+			Id:          IdCve,
+			Url:         DefaultCveUrl,
+			DisplayName: "CVE",
+			Categories:  []string{CategoryFollow, CategoryTodo},
+			EventTypes:  []string{"cve"},
+			Forge:       ForgeGitee,
+			Rules: []Rule{{
+				Type:           "cve",
+				TodoPaths:      []string{"IssueEvent.Issue.Assignee.Login"},
+				RelatedPaths:   []string{"IssueEvent.Issue.Assignee.Login"},
+				BusinessIdPath: "IssueEvent.Issue.HtmlUrl",
+				DonePath:       "IssueEvent.Issue.State",
+				DoneValues:     []string{"closed", "rejected", "fixed"},
+				Filters: map[string]string{
+					"cve_component": "CVEComponent",
+					"cve_state":     "IssueEvent.Issue.State",
+					"cve_affected":  "CVEAffectVersion",
+					"sig":           "SigGroupName",
+					"my_sig":        "SigMaintainers",
+				},
+			}},
+		},
+		{
+			Id:          IdGitee,
+			Url:         DefaultGiteeUrl,
+			DisplayName: "Gitee",
+			Categories:  []string{CategoryFollow, CategoryRelated, CategoryTodo},
+			EventTypes:  []string{"issue", "pr", "note"},
+			Forge:       ForgeGitee,
+			Bots:        []string{"openeuler-ci-bot", "ci-robot", "openeuler-sync-bot"},
+			Rules: []Rule{
+				{
+					Type:       "issue",
+					SenderPath: "IssueEvent.Sender.Login",
+					RelatedPaths: []string{"IssueEvent.Assignee.Login",
+						"IssueEvent.Issue.User.Login"},
+					MentionPaths:   []string{"IssueEvent.Issue.Body"},
+					TodoPaths:      []string{"IssueEvent.Assignee.Login"},
+					BusinessIdPath: "IssueEvent.Issue.HtmlUrl",
+					DonePath:       "IssueEvent.Issue.State",
+					DoneValues:     []string{"closed", "rejected"},
+					Filters: withForgeFilters(map[string]string{
+						"repo_name":      "IssueEvent.Repository.FullName",
+						"is_bot":         "IssueEvent.Sender.Name",
+						"namespace":      "IssueEvent.Repository.Namespace",
+						"issue_state":    "IssueEvent.Issue.State",
+						"issue_creator":  "IssueEvent.Issue.User.Login",
+						"issue_assignee": "IssueEvent.Assignee.Login",
+					}),
+				},
+				{
+					Type:       "pr",
+					SenderPath: "PullRequestEvent.Sender.Login",
+					RelatedPaths: []string{"PullRequestEvent.PullRequest.User.Login",
+						"PullRequestEvent.PullRequest.Assignees.Login"},
+					MentionPaths:   []string{"PullRequestEvent.PullRequest.Body"},
+					TodoPaths:      []string{"PullRequestEvent.PullRequest.Assignees.Login"},
+					BusinessIdPath: "PullRequestEvent.PullRequest.HtmlUrl",
+					DonePath:       "PullRequestEvent.PullRequest.State",
+					DoneValues:     []string{"merged", "closed"},
+					Filters: withForgeFilters(map[string]string{
+						"repo_name":       "PullRequestEvent.Repository.FullName",
+						"is_bot":          "PullRequestEvent.Sender.Name",
+						"namespace":       "PullRequestEvent.Repository.Namespace",
+						"pr_state":        "PullRequestEvent.PullRequest.State",
+						"pr_action":       "PullRequestEvent.Action",
+						"pr_merge_status": "PullRequestEvent.MergeStatus",
+						"pr_creator":      "PullRequestEvent.PullRequest.User.Login",
+						"pr_assignee":     "PullRequestEvent.PullRequest.Assignee.Login",
+					}),
+				},
+				{
+					Type:       "note",
+					SenderPath: "NoteEvent.Comment.User.Login",
+					RelatedPaths: []string{"NoteEvent.Issue.User.Login",
+						"NoteEvent.PullRequest.User.Login"},
+					MentionPaths: []string{"NoteEvent.Comment.Body"},
+					Filters: withForgeFilters(map[string]string{
+						"repo_name": "NoteEvent.Repository.FullName",
+						"is_bot":    "NoteEvent.Sender.Name",
+						"namespace": "NoteEvent.Repository.Namespace",
+						"note_type": "NoteEvent.NoteableType",
+						"about":     "NoteEvent.Comment.Body",
+					}),
+				},
+			},
+		},
+		{
+			Id:          IdGithub,
+			Url:         DefaultGithubUrl,
+			DisplayName: "GitHub",
+			Categories:  []string{CategoryFollow, CategoryRelated, CategoryTodo},
+			EventTypes:  []string{"issue", "pr", "note"},
+			Forge:       ForgeGithub,
+			Rules:       githubRules(),
+		},
+		{
+			Id:          IdGitcode,
+			Url:         DefaultGitcodeUrl,
+			DisplayName: "GitCode",
+			Categories:  []string{CategoryFollow, CategoryRelated, CategoryTodo},
+			EventTypes:  []string{"issue", "pr", "note"},
+			Forge:       ForgeGitcode,
+			Rules:       gitcodeRules(),
+		},
+		{
+			Id:          IdMeeting,
+			Url:         DefaultMeetingUrl,
+			DisplayName: "会议",
+			Categories:  []string{CategoryTodo},
+			EventTypes:  []string{"meeting"},
+			Schedule:    true,
+			Forge:       ForgeGitee,
+			Rules: []Rule{{
+				Type:           "meeting",
+				TodoPaths:      []string{"SigMaintainers"},
+				TodoSigMembers: true,
+				BusinessIdPath: "Msg.Id",
+				DonePath:       "Action",
+				DoneValues:     []string{"delete"},
+				ExpireMinutes:  60,
+				Filters: map[string]string{
+					"meeting_action": "Action",
+					"meeting_sig":    "Msg.GroupName",
+					"meeting_date":   "Msg.Date",
+					"sig":            "Msg.GroupName",
+					"my_sig":         "SigMaintainers",
+				},
+			}},
+		},
+	}
+}
+
+// withForgeFilters adds the filters of the data message-collect adds to the
+// events of every forge.
+func withForgeFilters(filters map[string]string) map[string]string {
+	filters["sig"] = "SigGroupName"
+	filters["my_sig"] = "SigMaintainers"
+	filters["my_management"] = "RepoAdmins"
+	filters["event_time"] = "EventTime"
+	return filters
+}
+
+// githubRules read the webhook payloads of GitHub, the kind of the sender
+// account such as Bot is filtered by sender_type.
+func githubRules() []Rule {
+	return []Rule{
+		{
+			Type:           "issue",
+			SenderPath:     "sender.login",
+			RelatedPaths:   []string{"issue.user.login", "issue.assignees.login"},
+			MentionPaths:   []string{"issue.body"},
+			TodoPaths:      []string{"issue.assignees.login"},
+			BusinessIdPath: "issue.html_url",
+			DonePath:       "issue.state",
+			DoneValues:     []string{"closed"},
+			Filters: withForgeFilters(map[string]string{
+				"repo_name":      "repository.full_name",
+				"is_bot":         "sender.login",
+				"sender_type":    "sender.type",
+				"namespace":      "repository.owner.login",
+				"issue_state":    "issue.state",
+				"issue_creator":  "issue.user.login",
+				"issue_assignee": "issue.assignees.login",
+			}),
+		},
+		{
+			Type:       "pr",
+			SenderPath: "sender.login",
+			RelatedPaths: []string{"pull_request.user.login", "pull_request.assignees.login",
+				"pull_request.requested_reviewers.login"},
+			MentionPaths: []string{"pull_request.body"},
+			TodoPaths: []string{"pull_request.assignees.login",
+				"pull_request.requested_reviewers.login"},
+			BusinessIdPath: "pull_request.html_url",
+			DonePath:       "pull_request.state",
+			DoneValues:     []string{"closed"},
+			Filters: withForgeFilters(map[string]string{
+				"repo_name":       "repository.full_name",
+				"is_bot":          "sender.login",
+				"sender_type":     "sender.type",
+				"namespace":       "repository.owner.login",
+				"pr_state":        "pull_request.state",
+				"pr_action":       "action",
+				"pr_merge_status": "pull_request.merged",
+				"pr_creator":      "pull_request.user.login",
+				"pr_assignee":     "pull_request.assignees.login",
+			}),
+		},
+		{
+			Type:         "note",
+			SenderPath:   "comment.user.login",
+			RelatedPaths: []string{"issue.user.login", "pull_request.user.login"},
+			MentionPaths: []string{"comment.body"},
+			Filters: withForgeFilters(map[string]string{
+				"repo_name":   "repository.full_name",
+				"is_bot":      "sender.login",
+				"sender_type": "sender.type",
+				"namespace":   "repository.owner.login",
+				"about":       "comment.body",
+			}),
+		},
+	}
+}
+
+// gitcodeRules read the webhook payloads of GitCode, which follow GitLab.
+func gitcodeRules() []Rule {
+	return []Rule{
+		{
+			Type:           "issue",
+			SenderPath:     "user.username",
+			RelatedPaths:   []string{"assignees.username"},
+			MentionPaths:   []string{"object_attributes.description"},
+			TodoPaths:      []string{"assignees.username"},
+			BusinessIdPath: "object_attributes.url",
+			DonePath:       "object_attributes.state",
+			DoneValues:     []string{"closed"},
+			Filters: withForgeFilters(map[string]string{
+				"repo_name":      "project.path_with_namespace",
+				"is_bot":         "user.username",
+				"namespace":      "project.namespace",
+				"issue_state":    "object_attributes.state",
+				"issue_assignee": "assignees.username",
+			}),
+		},
+		{
+			Type:           "pr",
+			SenderPath:     "user.username",
+			RelatedPaths:   []string{"assignees.username", "reviewers.username"},
+			MentionPaths:   []string{"object_attributes.description"},
+			TodoPaths:      []string{"assignees.username", "reviewers.username"},
+			BusinessIdPath: "object_attributes.url",
+			DonePath:       "object_attributes.state",
+			DoneValues:     []string{"merged", "closed"},
+			Filters: withForgeFilters(map[string]string{
+				"repo_name":       "project.path_with_namespace",
+				"is_bot":          "user.username",
+				"namespace":       "project.namespace",
+				"pr_state":        "object_attributes.state",
+				"pr_action":       "object_attributes.action",
+				"pr_merge_status": "object_attributes.merge_status",
+				"pr_assignee":     "assignees.username",
+			}),
+		},
+		{
+			Type:         "note",
+			SenderPath:   "user.username",
+			MentionPaths: []string{"object_attributes.note"},
+			Filters: withForgeFilters(map[string]string{
+				"repo_name": "project.path_with_namespace",
+				"is_bot":    "user.username",
+				"namespace": "project.namespace",
+				"note_type": "object_attributes.noteable_type",
+				"about":     "object_attributes.note",
+			}),
+		},
+	}
+}
@@ -0,0 +1,66 @@
+package source
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultSources(t *testing.T) {
+	assert.NoError(t, Init(&Config{}))
+
+	schedule, other := true, false
+	assert.Equal(t, []string{DefaultForumUrl, DefaultEurUrl, DefaultCveUrl, DefaultGiteeUrl,
+		DefaultGithubUrl, DefaultGitcodeUrl}, Urls(CategoryFollow, nil))
+	assert.Equal(t, []string{DefaultForumUrl, DefaultGiteeUrl, DefaultGithubUrl,
+		DefaultGitcodeUrl}, Urls(CategoryRelated, nil))
+	assert.Equal(t, []string{DefaultForumUrl, DefaultCveUrl, DefaultGiteeUrl, DefaultGithubUrl,
+		DefaultGitcodeUrl}, Urls(CategoryTodo, &other))
+	assert.Equal(t, []string{DefaultMeetingUrl}, Urls(CategoryTodo, &schedule))
+
+	s, ok := GetByUrl(DefaultGiteeUrl)
+	assert.True(t, ok)
+	assert.Equal(t, IdGitee, s.Id)
+	assert.True(t, s.HasEventType("pr"))
+	assert.False(t, s.HasEventType("meeting"))
+	assert.Equal(t, DefaultCveUrl, Url(IdCve))
+	assert.Equal(t, "", Url("unknown"))
+}
+
+func TestInit(t *testing.T) {
+	defer func() { assert.NoError(t, Init(&Config{})) }()
+
+	assert.Error(t, Init(&Config{Sources: []Source{{Id: "a"}}}))
+	assert.Error(t, Init(&Config{Sources: []Source{{Id: "a", Url: "u"}, {Id: "a", Url: "v"}}}))
+	assert.Error(t, Init(&Config{Sources: []Source{
+		{Id: "a", Url: "u", Categories: []string{"unknown"}}}}))
+	assert.Error(t, Init(&Config{Sources: []Source{{Id: "a", Url: "u", Forge: "unknown"}}}))
+
+	assert.NoError(t, Init(&Config{Sources: []Source{
+		{Id: "a", Url: "u", Categories: []string{CategoryFollow}}}}))
+	assert.Equal(t, []string{"u"}, Urls(CategoryFollow, nil))
+	assert.Empty(t, Urls(CategoryTodo, nil))
+	_, ok := Get(IdGitee)
+	assert.False(t, ok)
+}
+
+func TestForgeSources(t *testing.T) {
+	assert.NoError(t, Init(&Config{}))
+
+	for _, id := range []string{IdGitee, IdGithub, IdGitcode} {
+		s, ok := Get(id)
+		assert.True(t, ok)
+		assert.True(t, IsForge(s.Forge))
+		for _, rule := range s.Rules {
+			assert.NotEmpty(t, rule.Filters["repo_name"], id+" "+rule.Type)
+			// the bot filters name the sender on every forge
+			assert.NotEmpty(t, rule.Filters["is_bot"], id+" "+rule.Type)
+			if id == IdGithub {
+				assert.Equal(t, "sender.login", rule.Filters["is_bot"], rule.Type)
+				assert.Equal(t, "sender.type", rule.Filters["sender_type"], rule.Type)
+			}
+		}
+	}
+	assert.False(t, IsForge(""))
+	assert.Equal(t, []string{ForgeGitee, ForgeGithub, ForgeGitcode}, Forges())
+}
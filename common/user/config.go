@@ -4,6 +4,8 @@ Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
 
 package user
 
+// Config is the account service, EulerCommunity is the default community and
+// the app signs in for the communities without their own.
 type Config struct {
 	AuthorHost     string `json:"author_host"       required:"true"`
 	EulerCommunity string `json:"euler_community" required:"true"`
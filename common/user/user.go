@@ -14,6 +14,7 @@ import (
 	"regexp"
 
 	"github.com/gin-gonic/gin"
+	"github.com/opensourceways/message-manager/common/community"
 	"github.com/opensourceways/message-manager/common/postgresql"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/xerrors"
@@ -93,23 +94,33 @@ func getManagerToken(appId string, appSecret string) (string, error) {
 	return data.ManagerToken, nil
 }
 
+// GetSystemUserName returns the key of the user signed in to the community
+// of the request, see community.UserKey.
 func GetSystemUserName(ctx *gin.Context) (string, error) {
+	c, err := community.FromRequest(ctx.Request)
+	if err != nil {
+		return "", err
+	}
 	token := ctx.Request.Header.Get("token")
 	YGCookie, err := extractYGCookie(ctx.Request.Header.Get("Cookie"))
 	if err != nil {
 		return "", err
 	}
-	managerToken, err := getManagerToken(config.EulerAppId, config.EulerAppSecret)
+	appId, appSecret := c.AppId, c.AppSecret
+	if appId == "" {
+		appId, appSecret = config.EulerAppId, config.EulerAppSecret
+	}
+	managerToken, err := getManagerToken(appId, appSecret)
 	if err != nil {
 		logrus.Errorf("get manager token failed, err:%v", err)
 		return "", err
 	}
-	userName, err := fetchUserName(managerToken, token, YGCookie)
+	userName, err := fetchUserName(managerToken, token, YGCookie, c.Id)
 	if err != nil {
 		logrus.Errorf("get user name failed, err:%v", err)
 		return "", err
 	}
-	return userName, nil
+	return community.UserKey(c.Id, userName), nil
 }
 
 func extractYGCookie(cookieHeader string) (string, error) {
@@ -123,8 +134,9 @@ func extractYGCookie(cookieHeader string) (string, error) {
 	return "", xerrors.Errorf("YG cookie not found")
 }
 
-func fetchUserName(managerToken, userToken, YGCookie string) (string, error) {
-	url := fmt.Sprintf("%s/oneid/manager/personal/center/user?community=%s", config.AuthorHost, config.EulerCommunity)
+func fetchUserName(managerToken, userToken, YGCookie, communityId string) (string, error) {
+	url := fmt.Sprintf("%s/oneid/manager/personal/center/user?community=%s", config.AuthorHost,
+		communityId)
 
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
@@ -155,7 +167,12 @@ func fetchUserName(managerToken, userToken, YGCookie string) (string, error) {
 
 func GetThirdUserName(userName string) (string, error) {
 	var thirdUsername string
-	query := `select gitee_user_name from recipient_config where user_id = ?`
+	// only a verified login, the ones the user linked are not vouched for
+	query := `select ri.login from message_center.recipient_identity ri
+		join message_center.recipient_config rc on rc.id = ri.recipient_id
+		where rc.user_id = ? and not rc.is_deleted and ri.provider = 'gitee'
+		and ri.verified
+		order by ri.updated_at desc limit 1`
 	if result := postgresql.DB().Raw(query, userName).Scan(&thirdUsername); result.Error != nil {
 		return "", result.Error
 	}
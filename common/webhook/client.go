@@ -0,0 +1,141 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	defaultTimeout = 10
+	userAgent      = "message-manager-webhook"
+	// the part of the response quoted in the errors
+	maxResponseQuote = 256
+)
+
+// Config configures the posts, Timeout is in seconds. The webhooks point to
+// public addresses only unless AllowPrivateNetwork is set.
+type Config struct {
+	Timeout             int  `json:"timeout"`
+	AllowPrivateNetwork bool `json:"allow_private_network"`
+}
+
+func (cfg *Config) SetDefault() {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+}
+
+// Result is the outcome of a post which reached the receiver.
+type Result struct {
+	StatusCode int
+	Duration   time.Duration
+}
+
+// NewClient returns the client posting as cfg.
+func NewClient(cfg Config) *Client {
+	return &Client{client: NewHTTPClient(cfg), now: time.Now}
+}
+
+// NewHTTPClient returns the http client posting to the addresses cfg allows,
+// without redirects nor proxy.
+func NewHTTPClient(cfg Config) *http.Client {
+	cfg.SetDefault()
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	dialer := &net.Dialer{Timeout: timeout}
+	if !cfg.AllowPrivateNetwork {
+		dialer.Control = checkAddress
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConnsPerHost: 2,
+			IdleConnTimeout:     time.Minute,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+type Client struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+// Send posts the structured CloudEvent body to rawURL, signed with secret
+// unless it is empty. A 408, a 429 or a 5xx failure is temporary.
+func (c *Client) Send(rawURL, secret string, body []byte) (Result, error) {
+	var result Result
+	if err := ValidateURL(rawURL); err != nil {
+		return result, permanentError{err}
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, rawURL,
+		bytes.NewReader(body))
+	if err != nil {
+		return result, permanentError{xerrors.Errorf("new webhook request failed, err:%v", err)}
+	}
+	now := c.now()
+	req.Header.Set("Content-Type", ContentType)
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	if secret != "" {
+		req.Header.Set(HeaderSignature, Sign(secret, now.Unix(), body))
+	}
+
+	resp, err := c.client.Do(req)
+	result.Duration = c.now().Sub(now)
+	if err != nil {
+		return result, xerrors.Errorf("post webhook failed, err:%w", err)
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	quote, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseQuote))
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return result, nil
+	}
+
+	err = xerrors.Errorf("webhook responded %d: %s", resp.StatusCode, bytes.TrimSpace(quote))
+	if IsTemporaryStatus(resp.StatusCode) {
+		return result, err
+	}
+	return result, permanentError{err}
+}
+
+// IsTemporaryStatus reports whether a response of the status code may change
+// when posting again.
+func IsTemporaryStatus(code int) bool {
+	return code == http.StatusRequestTimeout || code == http.StatusTooManyRequests ||
+		code >= 500
+}
+
+// checkAddress refuses to connect to the loopback, private, link local and
+// unspecified addresses, it is called with the resolved address.
+func checkAddress(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return permanentError{xerrors.Errorf("invalid address %s", address)}
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return permanentError{xerrors.Errorf("the webhook address %s is not public", host)}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+// Package webhook posts the events to the services of the recipients as
+// structured CloudEvents, signed with HMAC-SHA256.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// the headers of the posts, the signature is "sha256=" followed by the hex
+// HMAC-SHA256 of the timestamp, a dot and the body
+const (
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+
+	ContentType = "application/cloudevents+json; charset=utf-8"
+)
+
+const (
+	signaturePrefix = "sha256="
+	// MaxURLLen is the length of the longest webhook url.
+	MaxURLLen = 1024
+	// MinSecretLen and MaxSecretLen bound the length of the secrets.
+	MinSecretLen = 16
+	MaxSecretLen = 255
+)
+
+// Sign returns the signature of body posted at timestamp, in unix seconds.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the one of body posted at timestamp,
+// for the receivers to check the posts.
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature))
+}
+
+// ValidateURL checks that raw is an absolute http or https url without user
+// info. Where it may point to is checked when posting.
+func ValidateURL(raw string) error {
+	if len(raw) > MaxURLLen {
+		return xerrors.Errorf("the webhook is longer than %d", MaxURLLen)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return xerrors.Errorf("invalid webhook %s, err:%v", raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return xerrors.Errorf("the webhook %s is not http or https", raw)
+	}
+	if u.Hostname() == "" || u.User != nil || strings.ContainsAny(raw, " \r\n") {
+		return xerrors.Errorf("invalid webhook %s", raw)
+	}
+	return nil
+}
+
+// ValidateSecret checks the length of secret.
+func ValidateSecret(secret string) error {
+	if len(secret) < MinSecretLen || len(secret) > MaxSecretLen {
+		return xerrors.Errorf("the webhook secret must have %d to %d characters",
+			MinSecretLen, MaxSecretLen)
+	}
+	return nil
+}
+
+// permanentError is a post which fails the same when sent again.
+type permanentError struct {
+	error
+}
+
+func (e permanentError) Unwrap() error {
+	return e.error
+}
+
+// IsPermanent reports whether err is an invalid post, a forbidden address or
+// a rejection of the receiver, which sending again will not change.
+func IsPermanent(err error) bool {
+	var e permanentError
+	return errors.As(err, &e)
+}
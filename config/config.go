@@ -11,8 +11,11 @@ import (
 	"sigs.k8s.io/yaml"
 
 	"github.com/opensourceways/message-manager/common/cassandra"
+	"github.com/opensourceways/message-manager/common/community"
 	common "github.com/opensourceways/message-manager/common/config"
+	"github.com/opensourceways/message-manager/common/directory"
 	"github.com/opensourceways/message-manager/common/postgresql"
+	"github.com/opensourceways/message-manager/common/source"
 	"github.com/opensourceways/message-manager/common/user"
 )
 
@@ -20,6 +23,33 @@ type Config struct {
 	Postgresql postgresql.Config `yaml:"postgresql"`
 	Cassandra  cassandra.Config  `yaml:"cassandra"`
 	User       user.Config       `yaml:"user"`
+	Source     source.Config     `json:"source" yaml:"source"`
+	Community  community.Config  `json:"community" yaml:"community"`
+	Directory  directory.Config  `json:"directory" yaml:"directory"`
+
+	Membership directory.MembershipConfig `json:"membership" yaml:"membership"`
+
+	CloudEvent CloudEvent `json:"cloud_event" yaml:"cloud_event"`
+	Consumer   Consumer   `json:"consumer" yaml:"consumer"`
+	Mail       Mail       `json:"mail" yaml:"mail"`
+	Webhook    Webhook    `json:"webhook" yaml:"webhook"`
+	Chat       Chat       `json:"chat" yaml:"chat"`
+	Delivery   Delivery   `json:"delivery" yaml:"delivery"`
+
+	Verification Verification `json:"verification" yaml:"verification"`
+}
+
+// ConfigItems returns the items whose defaults are set and which are validated
+// when the config is loaded.
+func (cfg *Config) ConfigItems() []interface{} {
+	return []interface{}{
+		&cfg.Consumer,
+		&cfg.Membership,
+		&cfg.Mail,
+		&cfg.Webhook,
+		&cfg.Chat,
+		&cfg.Verification,
+	}
 }
 
 func LoadFromYaml(path string, cfg interface{}) error {
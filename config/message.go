@@ -0,0 +1,257 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package config
+
+import (
+	"slices"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/mail"
+	"github.com/opensourceways/message-manager/common/sms"
+	"github.com/opensourceways/message-manager/common/webhook"
+)
+
+const (
+	consumerDefaultDriver        = "kafka"
+	consumerDefaultGroup         = "message-manager"
+	consumerDefaultMaxAttempts   = 5
+	consumerDefaultRetryInterval = 5
+
+	mailDefaultInterval      = 10
+	mailDefaultBatchSize     = 100
+	mailDefaultMaxAttempts   = 5
+	mailDefaultRetryInterval = 60
+	mailDefaultLease         = 300
+
+	webhookDefaultInterval      = 10
+	webhookDefaultBatchSize     = 100
+	webhookDefaultMaxAttempts   = 8
+	webhookDefaultRetryInterval = 30
+	webhookDefaultLease         = 300
+	webhookDefaultDisableAfter  = 10
+
+	chatDefaultInterval      = 10
+	chatDefaultBatchSize     = 100
+	chatDefaultMaxAttempts   = 5
+	chatDefaultRetryInterval = 30
+	chatDefaultLease         = 300
+
+	verificationDefaultTTL         = 600
+	verificationDefaultCooldown    = 60
+	verificationDefaultMaxAttempts = 5
+)
+
+// consumerDrivers are the brokers message/infrastructure is able to read.
+var consumerDrivers = []string{"kafka", "memory"}
+
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
+
+// CloudEvent configures the ingestion, a publisher sends one of the tokens as
+// bearer token and publishes the events of its community only.
+type CloudEvent struct {
+	// Tokens are the tokens of the default community.
+	Tokens []string `json:"tokens"`
+	// CommunityTokens are the tokens of the communities by community id.
+	CommunityTokens map[string][]string `json:"community_tokens"`
+}
+
+// Consumer configures the consumer of the cloud events published by
+// message-collect, RetryInterval is in seconds.
+type Consumer struct {
+	Enable        bool     `json:"enable"`
+	Driver        string   `json:"driver"`
+	Brokers       []string `json:"brokers"`
+	Group         string   `json:"group"`
+	Topics        []string `json:"topics"`
+	MaxAttempts   int      `json:"max_attempts"`
+	RetryInterval int      `json:"retry_interval"`
+}
+
+func (cfg *Consumer) SetDefault() {
+	if cfg.Driver == "" {
+		cfg.Driver = consumerDefaultDriver
+	}
+	if cfg.Group == "" {
+		cfg.Group = consumerDefaultGroup
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = consumerDefaultMaxAttempts
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = consumerDefaultRetryInterval
+	}
+}
+
+// Validate checks the driver and the topics when the consumer is enabled.
+func (cfg *Consumer) Validate() error {
+	if !cfg.Enable {
+		return nil
+	}
+	if !slices.Contains(consumerDrivers, cfg.Driver) {
+		return xerrors.Errorf("unknown consumer driver %s", cfg.Driver)
+	}
+	if len(cfg.Topics) == 0 {
+		return xerrors.Errorf("consumer needs topics")
+	}
+	if cfg.Driver == consumerDefaultDriver && len(cfg.Brokers) == 0 {
+		return xerrors.Errorf("kafka consumer needs brokers")
+	}
+	return nil
+}
+
+// Mail configures the mails of the events, the durations are in seconds. A
+// failing mail is retried with a doubling RetryInterval MaxAttempts times.
+type Mail struct {
+	Enable        bool            `json:"enable"`
+	SMTP          mail.Config     `json:"smtp"`
+	Templates     []mail.Template `json:"templates"`
+	Interval      int             `json:"interval"`
+	BatchSize     int             `json:"batch_size"`
+	MaxAttempts   int             `json:"max_attempts"`
+	RetryInterval int             `json:"retry_interval"`
+	Lease         int             `json:"lease"`
+}
+
+func (cfg *Mail) SetDefault() {
+	cfg.SMTP.SetDefault()
+	if cfg.Interval <= 0 {
+		cfg.Interval = mailDefaultInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = mailDefaultBatchSize
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = mailDefaultMaxAttempts
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = mailDefaultRetryInterval
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = mailDefaultLease
+	}
+}
+
+// Validate checks the SMTP server when the mails are enabled and the templates
+// in any case, as the mails can be previewed.
+func (cfg *Mail) Validate() error {
+	if cfg.Enable {
+		if err := cfg.SMTP.Validate(); err != nil {
+			return err
+		}
+	}
+	_, err := mail.NewRenderer(cfg.Templates)
+	return err
+}
+
+func (cfg *Mail) IntervalDuration() time.Duration {
+	return seconds(cfg.Interval)
+}
+
+// Webhook configures the posts of the events, the durations are in seconds. A
+// webhook failing DisableAfter times in a row is disabled.
+type Webhook struct {
+	Enable        bool           `json:"enable"`
+	Client        webhook.Config `json:"client"`
+	Interval      int            `json:"interval"`
+	BatchSize     int            `json:"batch_size"`
+	MaxAttempts   int            `json:"max_attempts"`
+	RetryInterval int            `json:"retry_interval"`
+	Lease         int            `json:"lease"`
+	DisableAfter  int            `json:"disable_after"`
+}
+
+func (cfg *Webhook) SetDefault() {
+	cfg.Client.SetDefault()
+	if cfg.Interval <= 0 {
+		cfg.Interval = webhookDefaultInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = webhookDefaultBatchSize
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = webhookDefaultMaxAttempts
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = webhookDefaultRetryInterval
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = webhookDefaultLease
+	}
+	if cfg.DisableAfter <= 0 {
+		cfg.DisableAfter = webhookDefaultDisableAfter
+	}
+}
+
+func (cfg *Webhook) IntervalDuration() time.Duration {
+	return seconds(cfg.Interval)
+}
+
+// Chat configures the cards posted to the chat bots, the durations are in
+// seconds.
+type Chat struct {
+	Enable        bool           `json:"enable"`
+	Client        webhook.Config `json:"client"`
+	Interval      int            `json:"interval"`
+	BatchSize     int            `json:"batch_size"`
+	MaxAttempts   int            `json:"max_attempts"`
+	RetryInterval int            `json:"retry_interval"`
+	Lease         int            `json:"lease"`
+}
+
+func (cfg *Chat) SetDefault() {
+	cfg.Client.SetDefault()
+	if cfg.Interval <= 0 {
+		cfg.Interval = chatDefaultInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = chatDefaultBatchSize
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = chatDefaultMaxAttempts
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = chatDefaultRetryInterval
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = chatDefaultLease
+	}
+}
+
+func (cfg *Chat) IntervalDuration() time.Duration {
+	return seconds(cfg.Interval)
+}
+
+// Delivery configures the delivery history, the pushers report with one of
+// Tokens as bearer token and Admins inspect every attempt.
+type Delivery struct {
+	Tokens []string `json:"tokens"`
+	Admins []string `json:"admins"`
+}
+
+// Verification configures the codes verifying the contacts, the durations are
+// in seconds. The numbers are verified through SMS.
+type Verification struct {
+	TTL         int        `json:"ttl"`
+	Cooldown    int        `json:"cooldown"`
+	MaxAttempts int        `json:"max_attempts"`
+	SMS         sms.Config `json:"sms"`
+}
+
+func (cfg *Verification) SetDefault() {
+	cfg.SMS.SetDefault()
+	if cfg.TTL <= 0 {
+		cfg.TTL = verificationDefaultTTL
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = verificationDefaultCooldown
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = verificationDefaultMaxAttempts
+	}
+}
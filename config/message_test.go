@@ -0,0 +1,68 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	common "github.com/opensourceways/message-manager/common/config"
+	"github.com/opensourceways/message-manager/common/mail"
+)
+
+func TestConfigItemsSetDefault(t *testing.T) {
+	cfg := Config{
+		Consumer: Consumer{Enable: true, Topics: []string{"gitee"}},
+		Webhook:  Webhook{MaxAttempts: 3},
+		Chat:     Chat{RetryInterval: 5},
+		Verification: Verification{
+			Cooldown: 30,
+		},
+	}
+	common.SetDefault(&cfg)
+
+	assert.Equal(t, "kafka", cfg.Consumer.Driver)
+	assert.Equal(t, "message-manager", cfg.Consumer.Group)
+	assert.Equal(t, consumerDefaultMaxAttempts, cfg.Consumer.MaxAttempts)
+	assert.Equal(t, time.Hour, cfg.Membership.IntervalDuration())
+	assert.Equal(t, 30*time.Minute, cfg.Membership.TimeoutDuration())
+	assert.Equal(t, mailDefaultBatchSize, cfg.Mail.BatchSize)
+	assert.Equal(t, mail.TLSStartTLS, cfg.Mail.SMTP.TLS)
+	assert.Equal(t, 3, cfg.Webhook.MaxAttempts)
+	assert.Equal(t, webhookDefaultDisableAfter, cfg.Webhook.DisableAfter)
+	assert.Equal(t, 10, cfg.Webhook.Client.Timeout)
+	assert.False(t, cfg.Webhook.Client.AllowPrivateNetwork)
+	assert.Equal(t, 5, cfg.Chat.RetryInterval)
+	assert.Equal(t, chatDefaultMaxAttempts, cfg.Chat.MaxAttempts)
+	assert.Equal(t, 10*time.Second, cfg.Chat.IntervalDuration())
+	assert.Equal(t, verificationDefaultTTL, cfg.Verification.TTL)
+	assert.Equal(t, 30, cfg.Verification.Cooldown)
+	assert.Equal(t, 10, cfg.Verification.SMS.Timeout)
+}
+
+func TestMailValidate(t *testing.T) {
+	cfg := Mail{Enable: true, SMTP: mail.Config{Host: "smtp.example.com",
+		From: "noreply@example.com"}}
+	cfg.SetDefault()
+	assert.NoError(t, cfg.Validate())
+
+	// the server is only needed to send
+	assert.Error(t, (&Mail{Enable: true}).Validate())
+	assert.NoError(t, (&Mail{}).Validate())
+	assert.Error(t, (&Mail{Templates: []mail.Template{{Subject: "{{"}}}).Validate())
+}
+
+func TestConsumerValidate(t *testing.T) {
+	assert.NoError(t, (&Consumer{Driver: "unknown"}).Validate())
+	assert.NoError(t, (&Consumer{Enable: true, Driver: "kafka", Brokers: []string{"kafka:9092"},
+		Topics: []string{"gitee"}}).Validate())
+	assert.NoError(t, (&Consumer{Enable: true, Driver: "memory",
+		Topics: []string{"gitee"}}).Validate())
+
+	assert.Error(t, (&Consumer{Enable: true, Driver: "rabbitmq",
+		Topics: []string{"gitee"}}).Validate())
+	assert.Error(t, (&Consumer{Enable: true, Driver: "kafka", Brokers: []string{"kafka:9092"}}).
+		Validate())
+	assert.Error(t, (&Consumer{Enable: true, Driver: "kafka", Topics: []string{"gitee"}}).
+		Validate())
+}
@@ -11,9 +11,14 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/opensourceways/message-manager/common/community"
+	"github.com/opensourceways/message-manager/common/directory"
 	"github.com/opensourceways/message-manager/common/postgresql"
+	"github.com/opensourceways/message-manager/common/source"
 	"github.com/opensourceways/message-manager/common/user"
 	"github.com/opensourceways/message-manager/config"
+	messagectl "github.com/opensourceways/message-manager/message/controller"
+	"github.com/opensourceways/message-manager/message/infrastructure"
 	"github.com/opensourceways/message-manager/server"
 )
 
@@ -27,10 +32,14 @@ func gatherOptions(fs *flag.FlagSet, args ...string) (Options, error) {
 
 type Options struct {
 	Config string
+
+	ReconcileUnreadCounter bool
 }
 
 func (o *Options) AddFlags(fs *flag.FlagSet) {
 	fs.StringVar(&o.Config, "config-file", "", "Path to config file.")
+	fs.BoolVar(&o.ReconcileUnreadCounter, "reconcile-unread-counter", false,
+		"Rebuild the unread counters from the message tables and exit.")
 }
 
 // @title           Message Manager
@@ -55,12 +64,36 @@ func main() {
 		return
 	}
 
+	// init source registry
+	if err := source.Init(&cfg.Source); err != nil {
+		logrus.Errorf("init source failed, err:%s", err.Error())
+		return
+	}
+
+	// init communities, the one of the user config is the default
+	if err := community.Init(&cfg.Community, cfg.User.EulerCommunity); err != nil {
+		logrus.Errorf("init community failed, err:%s", err.Error())
+		return
+	}
+
+	// init the sig and repo admin directory
+	directory.Init(&cfg.Directory)
+
 	// init postgresql
 	if err := postgresql.Init(&cfg.Postgresql); err != nil {
 		fmt.Println("Postgresql数据库初始化失败, err:", err)
 		return
 	}
 
+	if o.ReconcileUnreadCounter {
+		if err := infrastructure.MessageCounterAdapter().Reconcile(); err != nil {
+			logrus.Errorf("reconcile unread counter failed, err:%s", err.Error())
+			return
+		}
+		logrus.Info("reconcile unread counter done")
+		return
+	}
+
 	//if err := cassandra.Init(&cfg.Cassandra); err != nil {
 	//	fmt.Println("Cassandra数据库初始化失败")
 	//}
@@ -68,5 +101,8 @@ func main() {
 	// init user
 	user.Init(&cfg.User)
 
-	server.StartWebServer()
+	messagectl.InitCloudEvent(&cfg.CloudEvent)
+	messagectl.InitDelivery(&cfg.Delivery)
+
+	server.StartWebServer(cfg)
 }
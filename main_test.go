@@ -35,6 +35,17 @@ func TestGatherOptions(t *testing.T) {
 	}
 }
 
+func TestGatherOptions_ReconcileUnreadCounter(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	options, err := gatherOptions(fs, "-reconcile-unread-counter")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !options.ReconcileUnreadCounter {
+		t.Errorf("expected reconcile unread counter to be set")
+	}
+}
+
 func TestLoadConfig(t *testing.T) {
 	// 创建一个临时配置文件
 	tempFile, err := os.CreateTemp("", "config.yaml")
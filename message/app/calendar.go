@@ -0,0 +1,189 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package app
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/community"
+	"github.com/opensourceways/message-manager/common/domain/allerror"
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+const (
+	calendarTokenBytes    = 32
+	calendarHistory       = 90 * 24 * time.Hour
+	calendarEventDuration = time.Hour
+	calendarLineOctets    = 75
+	calendarTimeLayout    = "20060102T150405Z"
+	calendarUidDomain     = "message-center.openeuler.org"
+
+	errorCodeCalendarNotFound = "calendar_not_found"
+)
+
+// meetingCanceledActions are the meeting actions which cancel a meeting.
+var meetingCanceledActions = map[string]bool{"delete": true, "cancel": true}
+
+type MessageCalendarAppService interface {
+	GetCalendarToken(userName string) (string, error)
+	ResetCalendarToken(userName string) (string, error)
+	GetCalendar(token string) ([]byte, error)
+}
+
+func NewMessageCalendarAppService(
+	messageCalendarAdapter domain.MessageCalendarAdapter,
+) MessageCalendarAppService {
+	return &messageCalendarAppService{
+		messageCalendarAdapter: messageCalendarAdapter,
+	}
+}
+
+type messageCalendarAppService struct {
+	messageCalendarAdapter domain.MessageCalendarAdapter
+}
+
+// GetCalendarToken returns the calendar token of userName and creates it on
+// first use.
+func (s *messageCalendarAppService) GetCalendarToken(userName string) (string, error) {
+	token, err := s.messageCalendarAdapter.GetCalendarToken(userName)
+	if err != nil {
+		return "", err
+	}
+	if token != "" {
+		return token, nil
+	}
+	return s.ResetCalendarToken(userName)
+}
+
+// ResetCalendarToken replaces the calendar token of userName, the feed URL
+// holding the previous one stops working.
+func (s *messageCalendarAppService) ResetCalendarToken(userName string) (string, error) {
+	b := make([]byte, calendarTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", xerrors.Errorf("generate calendar token failed, err:%v", err)
+	}
+	token := hex.EncodeToString(b)
+	if err := s.messageCalendarAdapter.SaveCalendarToken(userName, token); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// GetCalendar renders the meeting todos of the owner of token as iCalendar.
+func (s *messageCalendarAppService) GetCalendar(token string) ([]byte, error) {
+	userName, err := s.messageCalendarAdapter.GetUserNameByCalendarToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if userName == "" {
+		return nil, allerror.NewNotFound(errorCodeCalendarNotFound, "")
+	}
+
+	data, err := s.messageCalendarAdapter.GetMeetingEvent(userName, time.Now().Add(-calendarHistory))
+	if err != nil {
+		return nil, err
+	}
+	communityId, _ := community.SplitUserKey(userName)
+	c, ok := community.Get(communityId)
+	if !ok {
+		c = community.Default()
+	}
+	return renderCalendar(c, data, time.Now()), nil
+}
+
+// renderCalendar renders meetings as the iCalendar (RFC 5545) document of the
+// community c, keyed by business_id and versioned by the update time.
+func renderCalendar(c community.Community, meetings []MeetingEventDTO, now time.Time) []byte {
+	var b strings.Builder
+	line := func(name, value string) {
+		writeCalendarLine(&b, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	product, name := c.Calendar()
+	line("PRODID", product)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", escapeCalendarText(name))
+	for _, m := range meetings {
+		line("BEGIN", "VEVENT")
+		line("UID", m.BusinessId+"@"+calendarUidDomain)
+		line("DTSTAMP", now.UTC().Format(calendarTimeLayout))
+		line("LAST-MODIFIED", m.UpdatedAt.UTC().Format(calendarTimeLayout))
+		line("SEQUENCE", fmt.Sprint(calendarSequence(m.UpdatedAt)))
+		line("DTSTART", m.EventTime.UTC().Format(calendarTimeLayout))
+		line("DTEND", m.EventTime.Add(calendarEventDuration).UTC().Format(calendarTimeLayout))
+		if m.SourceGroup != "" {
+			line("SUMMARY", escapeCalendarText(fmt.Sprintf("[%s] %s", m.SourceGroup, m.Title)))
+			line("CATEGORIES", escapeCalendarText(m.SourceGroup))
+		} else {
+			line("SUMMARY", escapeCalendarText(m.Title))
+		}
+		description := m.Summary
+		if m.SourceUrl != "" {
+			description = strings.TrimSpace(description + "\n" + m.SourceUrl)
+			line("URL", m.SourceUrl)
+			line("LOCATION", escapeCalendarText(m.SourceUrl))
+		}
+		if description != "" {
+			line("DESCRIPTION", escapeCalendarText(description))
+		}
+		if meetingCanceledActions[strings.ToLower(m.Action)] {
+			line("STATUS", "CANCELLED")
+		} else {
+			line("STATUS", "CONFIRMED")
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+
+	return []byte(b.String())
+}
+
+// calendarSequence grows with every update of a meeting.
+func calendarSequence(updatedAt time.Time) int64 {
+	if seq := updatedAt.Unix(); seq > 0 {
+		return seq
+	}
+	return 0
+}
+
+func escapeCalendarText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", "",
+	).Replace(s)
+}
+
+// writeCalendarLine writes a content line folded at 75 octets without
+// splitting a UTF-8 character.
+func writeCalendarLine(b *strings.Builder, s string) {
+	limit := calendarLineOctets
+	for len(s) > limit {
+		n := limit
+		for n > 0 && !utf8.RuneStart(s[n]) {
+			n--
+		}
+		b.WriteString(s[:n])
+		b.WriteString("\r\n ")
+		s = s[n:]
+		// the leading space of a continuation line counts too
+		limit = calendarLineOctets - 1
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+}
@@ -0,0 +1,118 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/opensourceways/message-manager/common/community"
+	"github.com/opensourceways/message-manager/common/domain/allerror"
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+// MockMessageCalendarAdapter 是 MessageCalendarAdapter 的模拟实现
+type MockMessageCalendarAdapter struct {
+	mock.Mock
+}
+
+func (m *MockMessageCalendarAdapter) GetCalendarToken(userName string) (string, error) {
+	args := m.Called(userName)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockMessageCalendarAdapter) SaveCalendarToken(userName, token string) error {
+	return m.Called(userName, token).Error(0)
+}
+
+func (m *MockMessageCalendarAdapter) GetUserNameByCalendarToken(token string) (string, error) {
+	args := m.Called(token)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockMessageCalendarAdapter) GetMeetingEvent(userName string, since time.Time) (
+	[]domain.MeetingEventDO, error) {
+	args := m.Called(userName, since)
+	return args.Get(0).([]domain.MeetingEventDO), args.Error(1)
+}
+
+func TestGetCalendarToken(t *testing.T) {
+	mockAdapter := new(MockMessageCalendarAdapter)
+	service := NewMessageCalendarAppService(mockAdapter)
+
+	mockAdapter.On("GetCalendarToken", "oldUser").Return("token", nil)
+	mockAdapter.On("GetCalendarToken", "newUser").Return("", nil)
+	mockAdapter.On("SaveCalendarToken", "newUser", mock.AnythingOfType("string")).Return(nil)
+
+	token, err := service.GetCalendarToken("oldUser")
+	assert.NoError(t, err)
+	assert.Equal(t, "token", token)
+
+	token, err = service.GetCalendarToken("newUser")
+	assert.NoError(t, err)
+	assert.Len(t, token, 2*calendarTokenBytes)
+	mockAdapter.AssertCalled(t, "SaveCalendarToken", "newUser", token)
+}
+
+func TestGetCalendar(t *testing.T) {
+	mockAdapter := new(MockMessageCalendarAdapter)
+	service := NewMessageCalendarAppService(mockAdapter)
+
+	mockAdapter.On("GetUserNameByCalendarToken", "unknown").Return("", nil)
+	mockAdapter.On("GetUserNameByCalendarToken", "token").Return("testUser", nil)
+	mockAdapter.On("GetMeetingEvent", "testUser", mock.AnythingOfType("time.Time")).
+		Return([]domain.MeetingEventDO{{BusinessId: "meeting1", Title: "weekly"}}, nil)
+
+	_, err := service.GetCalendar("unknown")
+	assert.True(t, allerror.IsNotFound(err))
+
+	data, err := service.GetCalendar("token")
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "UID:meeting1@"+calendarUidDomain+"\r\n")
+}
+
+func TestRenderCalendar(t *testing.T) {
+	now := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+	start := time.Date(2024, 8, 2, 8, 0, 0, 0, time.FixedZone("CST", 8*3600))
+	c := community.Community{Id: "openeuler", DisplayName: "openEuler"}
+	data := string(renderCalendar(c, []MeetingEventDTO{
+		{
+			BusinessId:  "meeting1",
+			Title:       "weekly, meeting; 例会",
+			Summary:     "agenda",
+			SourceGroup: "sig-infra",
+			SourceUrl:   "https://meeting.example.com/j/1",
+			EventTime:   start,
+			UpdatedAt:   now,
+			Action:      "update",
+		},
+		{
+			BusinessId: "meeting2",
+			Title:      strings.Repeat("很长的会议标题", 10),
+			EventTime:  start,
+			UpdatedAt:  now,
+			Action:     "delete",
+		},
+	}, now))
+
+	assert.True(t, strings.HasPrefix(data, "BEGIN:VCALENDAR\r\n"))
+	assert.True(t, strings.HasSuffix(data, "END:VCALENDAR\r\n"))
+	assert.Contains(t, data, "PRODID:-//openEuler//Message Center//CN\r\n")
+	assert.Contains(t, data, "X-WR-CALNAME:openEuler meetings\r\n")
+	assert.Equal(t, 2, strings.Count(data, "BEGIN:VEVENT\r\n"))
+	assert.Contains(t, data, "DTSTART:20240802T000000Z\r\n")
+	assert.Contains(t, data, "DTEND:20240802T010000Z\r\n")
+	assert.Contains(t, data, "SEQUENCE:1722470400\r\n")
+	assert.Contains(t, data, `SUMMARY:[sig-infra] weekly\, meeting\; 例会`+"\r\n")
+	assert.Contains(t, data, `DESCRIPTION:agenda\nhttps://meeting.example.com/j/1`+"\r\n")
+	assert.Contains(t, data, "STATUS:CONFIRMED\r\n")
+	assert.Contains(t, data, "STATUS:CANCELLED\r\n")
+
+	for _, line := range strings.Split(strings.TrimSuffix(data, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), calendarLineOctets, line)
+	}
+	assert.Contains(t, strings.ReplaceAll(data, "\r\n ", ""),
+		"SUMMARY:"+strings.Repeat("很长的会议标题", 10)+"\r\n")
+}
@@ -0,0 +1,148 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package app
+
+import (
+	"encoding/json"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/chat"
+	"github.com/opensourceways/message-manager/common/community"
+	"github.com/opensourceways/message-manager/common/source"
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+type MessageChatAppService interface {
+	Notify(event CloudEventDTO) error
+	SendChat() (DeliveryResultDTO, error)
+	SendTestChat(event CloudEventDTO, bot chat.Bot) error
+}
+
+func NewMessageChatAppService(
+	messageChatAdapter domain.MessageChatAdapter,
+	messageDeliveryAdapter domain.MessageDeliveryAdapter,
+	sender domain.ChatSender,
+	rules []FanoutRule,
+	option DeliveryOption,
+) MessageChatAppService {
+	return &messageChatAppService{
+		messageChatAdapter:     messageChatAdapter,
+		messageDeliveryAdapter: messageDeliveryAdapter,
+		sender:                 sender,
+		rules:                  rules,
+		queue: newDeliveryQueue(messageDeliveryAdapter, domain.DeliveryChannelChat,
+			option),
+	}
+}
+
+type messageChatAppService struct {
+	messageChatAdapter     domain.MessageChatAdapter
+	messageDeliveryAdapter domain.MessageDeliveryAdapter
+	sender                 domain.ChatSender
+	rules                  []FanoutRule
+	queue                  *deliveryQueue
+}
+
+// newChatCard returns the card of event, titled as the default mail when the
+// event has no title.
+func newChatCard(event CloudEventDTO) chat.Card {
+	title := strings.TrimSpace(event.Title)
+	if title == "" {
+		sourceId := event.Source
+		if src, ok := source.GetByUrl(event.Source); ok {
+			sourceId = src.Id
+		}
+		title = "[" + sourceId + "] " + event.Type
+	}
+	return chat.Card{Title: title, Summary: event.Summary, SourceUrl: event.SourceUrl}
+}
+
+// Notify queues the card of event to the bots of the recipients whose
+// subscriptions match it, once however often it is queued.
+func (s *messageChatAppService) Notify(event CloudEventDTO) error {
+	if event.Community == "" {
+		event.Community = community.DefaultId()
+	}
+	if !readsSource(event.Community, event.Source) {
+		return nil
+	}
+	data, err := decodeEventData(event)
+	if err != nil {
+		return err
+	}
+
+	var filters map[string]string
+	if rule := findFanoutRule(s.rules, event); rule != nil {
+		filters = rule.Filters
+	}
+	sigs, repos := eventMembership(filters, data)
+	targets, err := s.messageChatAdapter.GetChatTarget(event.Community, event.Source, sigs,
+		repos)
+	if err != nil {
+		return err
+	}
+
+	var items []domain.DeliveryDO
+	seen := map[string]bool{}
+	for _, target := range matchSubscribeTarget(targets, event, data, filters) {
+		if target.Target == "" || seen[target.Target] {
+			continue
+		}
+		seen[target.Target] = true
+		items = append(items, domain.DeliveryDO{
+			Channel:     domain.DeliveryChannelChat,
+			EventId:     event.EventId,
+			RecipientId: target.RecipientId,
+			Target:      target.Target,
+		})
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	b, err := json.Marshal(newChatCard(event))
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		items[i].Content = b
+	}
+	_, err = s.messageDeliveryAdapter.EnqueueDelivery(items)
+	return err
+}
+
+// SendChat posts the queued cards which are due to the bots as configured
+// now. The cards to a bot which changed since are given up.
+func (s *messageChatAppService) SendChat() (DeliveryResultDTO, error) {
+	return s.queue.drain(s.deliver)
+}
+
+// SendTestChat posts the card of event to bot at once.
+func (s *messageChatAppService) SendTestChat(event CloudEventDTO, bot chat.Bot) error {
+	return s.sender.Send(bot, newChatCard(event))
+}
+
+func (s *messageChatAppService) deliver(item domain.DeliveryDO) (bool, error) {
+	var card chat.Card
+	if err := json.Unmarshal(item.Content, &card); err != nil {
+		return true, xerrors.Errorf("invalid chat card, err:%v", err)
+	}
+	bot, err := s.messageChatAdapter.GetChatBot(item.RecipientId)
+	if err != nil {
+		return false, err
+	}
+	if bot.ChatPlatform == "" || bot.ChatWebhook != item.Target {
+		return true, xerrors.Errorf("the chat bot of recipient %d is changed", item.RecipientId)
+	}
+
+	err = s.sender.Send(chat.Bot{
+		Platform: bot.ChatPlatform,
+		URL:      bot.ChatWebhook,
+		Secret:   bot.ChatSecret,
+	}, card)
+	return chat.IsPermanent(err), err
+}
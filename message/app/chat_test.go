@@ -0,0 +1,124 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/datatypes"
+
+	"github.com/opensourceways/message-manager/common/chat"
+	"github.com/opensourceways/message-manager/common/chat/chattest"
+	"github.com/opensourceways/message-manager/common/source"
+	"github.com/opensourceways/message-manager/common/webhook"
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+// MockMessageChatAdapter 是 MessageChatAdapter 的模拟实现
+type MockMessageChatAdapter struct {
+	mock.Mock
+}
+
+func (m *MockMessageChatAdapter) GetChatTarget(communityId, source string, sigs,
+	repos []string) ([]domain.SubscribeTargetDO, error) {
+	args := m.Called(communityId, source, sigs, repos)
+	return args.Get(0).([]domain.SubscribeTargetDO), args.Error(1)
+}
+
+func (m *MockMessageChatAdapter) GetChatBot(recipientId int64) (domain.ChatBotDO, error) {
+	args := m.Called(recipientId)
+	return args.Get(0).(domain.ChatBotDO), args.Error(1)
+}
+
+func TestChatNotify(t *testing.T) {
+	mockChat := new(MockMessageChatAdapter)
+	mockDelivery := new(MockMessageDeliveryAdapter)
+	service := NewMessageChatAppService(mockChat, mockDelivery, nil, FanoutRules(), mailOption)
+
+	mockChat.On("GetChatTarget", fanoutCommunity, source.DefaultGiteeUrl, noMembership,
+		noMembership).Return([]domain.SubscribeTargetDO{
+		{SubscribeId: 1, EventType: "pr", RecipientId: 9, Target: "https://open.feishu.cn/a",
+			ModeFilter: datatypes.JSON(`{"PullRequestEvent.PullRequest.State": "merged"}`)},
+		{SubscribeId: 2, EventType: "pr", RecipientId: 8, Target: "https://open.feishu.cn/b",
+			ModeFilter: datatypes.JSON(`{"PullRequestEvent.PullRequest.State": "open"}`)},
+		// a card is posted once to a bot
+		{SubscribeId: 3, EventType: "*", RecipientId: 7, Target: "https://open.feishu.cn/a"},
+	}, nil).Once()
+
+	var items []domain.DeliveryDO
+	mockDelivery.On("EnqueueDelivery", mock.Anything).Run(func(args mock.Arguments) {
+		items = args.Get(0).([]domain.DeliveryDO)
+	}).Return(nil).Once()
+
+	event := newFanoutEvent("pr-1", source.DefaultGiteeUrl, "pr", prFixture)
+	event.Summary = "alice merged PR #1"
+	event.SourceUrl = "https://gitee.com/openeuler/infrastructure/pulls/1"
+	assert.NoError(t, service.Notify(event))
+	mockChat.AssertExpectations(t)
+	mockDelivery.AssertExpectations(t)
+
+	if !assert.Len(t, items, 1) {
+		return
+	}
+	assert.Equal(t, domain.DeliveryChannelChat, items[0].Channel)
+	assert.Equal(t, int64(9), items[0].RecipientId)
+	var card chat.Card
+	assert.NoError(t, json.Unmarshal(items[0].Content, &card))
+	// the event has no title
+	assert.Equal(t, chat.Card{Title: "[gitee] pr", Summary: event.Summary,
+		SourceUrl: event.SourceUrl}, card)
+}
+
+func TestSendChat(t *testing.T) {
+	feishu := chattest.NewServer(chat.PlatformFeishu, "SECfeishu")
+	defer feishu.Close()
+	// the first card is rate limited, the second rejected for good
+	feishu.Fail(11232, 19024)
+	slack := chattest.NewServer(chat.PlatformSlack, "T000/B000/XXXX")
+	defer slack.Close()
+
+	mockChat := new(MockMessageChatAdapter)
+	mockDelivery := new(MockMessageDeliveryAdapter)
+	service := NewMessageChatAppService(mockChat, mockDelivery,
+		chat.NewClient(webhook.Config{AllowPrivateNetwork: true}), nil, mailOption)
+	now := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	service.(*messageChatAppService).queue.now = func() time.Time { return now }
+
+	botOf := func(bot chat.Bot) domain.ChatBotDO {
+		return domain.ChatBotDO{ChatPlatform: bot.Platform, ChatWebhook: bot.URL,
+			ChatSecret: bot.Secret}
+	}
+	mockChat.On("GetChatBot", int64(9)).Return(botOf(feishu.Bot()), nil)
+	mockChat.On("GetChatBot", int64(8)).Return(botOf(slack.Bot()), nil)
+	// the recipient removed its bot since
+	mockChat.On("GetChatBot", int64(7)).Return(domain.ChatBotDO{}, nil)
+
+	content := datatypes.JSON(`{"title": "PR #1 merged", "summary": "merged",
+		"source_url": "https://gitee.com/openeuler/infrastructure/pulls/1"}`)
+	mockDelivery.On("ClaimDelivery", domain.DeliveryChannelChat, 10, 5*time.Minute).Return(
+		[]domain.DeliveryDO{
+			{Id: 1, RecipientId: 9, Target: feishu.Bot().URL, Content: content, Attempts: 1},
+			{Id: 2, RecipientId: 9, Target: feishu.Bot().URL, Content: content, Attempts: 1},
+			{Id: 3, RecipientId: 9, Target: feishu.Bot().URL, Content: content, Attempts: 1},
+			{Id: 4, RecipientId: 8, Target: slack.Bot().URL, Content: content, Attempts: 1},
+			{Id: 5, RecipientId: 7, Target: slack.Bot().URL, Content: content, Attempts: 1},
+			{Id: 6, RecipientId: 8, Target: slack.Bot().URL, Content: datatypes.JSON(`[]`),
+				Attempts: 1},
+		}, nil).Once()
+	mockDelivery.On("RetryDelivery", int64(1), now.Add(time.Minute)).Return(nil).Once()
+	mockDelivery.On("FailDelivery", int64(2)).Return(nil).Once()
+	mockDelivery.On("CompleteDelivery", int64(3)).Return(nil).Once()
+	mockDelivery.On("CompleteDelivery", int64(4)).Return(nil).Once()
+	mockDelivery.On("FailDelivery", int64(5)).Return(nil).Once()
+	mockDelivery.On("FailDelivery", int64(6)).Return(nil).Once()
+
+	result, err := service.SendChat()
+	assert.NoError(t, err)
+	assert.Equal(t, DeliveryResultDTO{Sent: 2, Retried: 1, Failed: 3}, result)
+	mockChat.AssertExpectations(t)
+	mockDelivery.AssertExpectations(t)
+	assert.Len(t, feishu.Messages(), 1)
+	assert.Len(t, slack.Messages(), 1)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package app
+
+import (
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/community"
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+// CloudEventSpecVersion is the only CloudEvents version accepted.
+const CloudEventSpecVersion = "1.0"
+
+type MessageCloudEventAppService interface {
+	ValidateCloudEvent(events []CloudEventDTO) error
+	SaveCloudEvent(events []CloudEventDTO) (CloudEventResultDTO, error)
+}
+
+// EventNotifier notifies the recipients of an event outside the message
+// center, such as by mail. Notifying an event again must change nothing.
+type EventNotifier interface {
+	Notify(event CloudEventDTO) error
+}
+
+func NewMessageCloudEventAppService(
+	messageCloudEventAdapter domain.MessageCloudEventAdapter,
+	messageFanoutAppService MessageFanoutAppService,
+	notifiers ...EventNotifier,
+) MessageCloudEventAppService {
+	return &messageCloudEventAppService{
+		messageCloudEventAdapter: messageCloudEventAdapter,
+		messageFanoutAppService:  messageFanoutAppService,
+		notifiers:                notifiers,
+	}
+}
+
+type messageCloudEventAppService struct {
+	messageCloudEventAdapter domain.MessageCloudEventAdapter
+	messageFanoutAppService  MessageFanoutAppService
+	notifiers                []EventNotifier
+}
+
+// CloudEventResultDTO lists the stored events and the ones seen before.
+type CloudEventResultDTO struct {
+	Accepted  []string `json:"accepted"`
+	Duplicate []string `json:"duplicate"`
+}
+
+// ValidateCloudEvent checks the required attributes of every event.
+func (s *messageCloudEventAppService) ValidateCloudEvent(events []CloudEventDTO) error {
+	if len(events) == 0 {
+		return xerrors.Errorf("no cloud event")
+	}
+	for i := range events {
+		e := &events[i]
+		switch {
+		case e.EventId == "":
+			return xerrors.Errorf("the id of event %d is empty", i)
+		case e.Source == "":
+			return xerrors.Errorf("the source of event %s is empty", e.EventId)
+		case e.Type == "":
+			return xerrors.Errorf("the type of event %s is empty", e.EventId)
+		case e.SpecVersion != CloudEventSpecVersion:
+			return xerrors.Errorf("the specversion of event %s is unsupported, specversion:%s",
+				e.EventId, e.SpecVersion)
+		case e.Community != "" && !isCommunity(e.Community):
+			return xerrors.Errorf("the community of event %s is unknown, community:%s",
+				e.EventId, e.Community)
+		}
+	}
+	return nil
+}
+
+// SaveCloudEvent stores the new events and fans out every event as it is
+// stored, so a retry completes a failed fan-out.
+func (s *messageCloudEventAppService) SaveCloudEvent(events []CloudEventDTO) (
+	CloudEventResultDTO, error) {
+	result := CloudEventResultDTO{Accepted: []string{}, Duplicate: []string{}}
+	if err := s.ValidateCloudEvent(events); err != nil {
+		return result, err
+	}
+
+	for i := range events {
+		if events[i].EventTime.IsZero() {
+			events[i].EventTime = time.Now()
+		}
+		if events[i].Community == "" {
+			events[i].Community = community.DefaultId()
+		}
+		stored, saved, err := s.messageCloudEventAdapter.SaveCloudEvent(events[i])
+		if err != nil {
+			return result, err
+		}
+		if _, err = s.messageFanoutAppService.Fanout(stored); err != nil {
+			return result, err
+		}
+		for _, n := range s.notifiers {
+			if err = n.Notify(stored); err != nil {
+				return result, err
+			}
+		}
+		if saved {
+			result.Accepted = append(result.Accepted, events[i].EventId)
+		} else {
+			result.Duplicate = append(result.Duplicate, events[i].EventId)
+		}
+	}
+	return result, nil
+}
+
+func isCommunity(id string) bool {
+	_, ok := community.Get(id)
+	return ok
+}
@@ -0,0 +1,157 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+// MockMessageCloudEventAdapter 是 MessageCloudEventAdapter 的模拟实现
+type MockMessageCloudEventAdapter struct {
+	mock.Mock
+	// stored are the events stored before by id
+	stored map[string]domain.CloudEventDO
+}
+
+func (m *MockMessageCloudEventAdapter) SaveCloudEvent(event domain.CloudEventDO) (
+	domain.CloudEventDO, bool, error) {
+	args := m.Called(event.EventId)
+	if stored, ok := m.stored[event.EventId]; ok {
+		event = stored
+	}
+	return event, args.Bool(0), args.Error(1)
+}
+
+// MockMessageFanoutAppService 是 MessageFanoutAppService 的模拟实现
+type MockMessageFanoutAppService struct {
+	mock.Mock
+}
+
+func (m *MockMessageFanoutAppService) Fanout(event CloudEventDTO) (FanoutResultDTO, error) {
+	args := m.Called(event.EventId)
+	return FanoutResultDTO{}, args.Error(0)
+}
+
+// MockEventNotifier 是 EventNotifier 的模拟实现
+type MockEventNotifier struct {
+	mock.Mock
+}
+
+func (m *MockEventNotifier) Notify(event CloudEventDTO) error {
+	return m.Called(event.EventId).Error(0)
+}
+
+// eventNotifierFunc is an EventNotifier of a function.
+type eventNotifierFunc func(event CloudEventDTO) error
+
+func (f eventNotifierFunc) Notify(event CloudEventDTO) error {
+	return f(event)
+}
+
+func newCloudEvent(id string) CloudEventDTO {
+	return CloudEventDTO{EventId: id, Source: "https://gitee.com", Type: "pr",
+		SpecVersion: CloudEventSpecVersion}
+}
+
+func TestValidateCloudEvent(t *testing.T) {
+	service := NewMessageCloudEventAppService(new(MockMessageCloudEventAdapter),
+		new(MockMessageFanoutAppService))
+
+	assert.NoError(t, service.ValidateCloudEvent([]CloudEventDTO{newCloudEvent("1")}))
+	assert.Error(t, service.ValidateCloudEvent(nil))
+
+	for _, modify := range []func(e *CloudEventDTO){
+		func(e *CloudEventDTO) { e.EventId = "" },
+		func(e *CloudEventDTO) { e.Source = "" },
+		func(e *CloudEventDTO) { e.Type = "" },
+		func(e *CloudEventDTO) { e.SpecVersion = "0.3" },
+		func(e *CloudEventDTO) { e.Community = "unknown" },
+	} {
+		event := newCloudEvent("1")
+		modify(&event)
+		assert.Error(t, service.ValidateCloudEvent([]CloudEventDTO{newCloudEvent("0"), event}))
+	}
+}
+
+func TestSaveCloudEvent(t *testing.T) {
+	mockAdapter := new(MockMessageCloudEventAdapter)
+	mockFanout := new(MockMessageFanoutAppService)
+	service := NewMessageCloudEventAppService(mockAdapter, mockFanout)
+
+	mockAdapter.On("SaveCloudEvent", "new").Return(true, nil).Once()
+	mockAdapter.On("SaveCloudEvent", "old").Return(false, nil).Once()
+	mockFanout.On("Fanout", "new").Return(nil).Once()
+	mockFanout.On("Fanout", "old").Return(nil).Once()
+
+	result, err := service.SaveCloudEvent([]CloudEventDTO{newCloudEvent("new"), newCloudEvent("old")})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"new"}, result.Accepted)
+	assert.Equal(t, []string{"old"}, result.Duplicate)
+	mockAdapter.AssertExpectations(t)
+	mockFanout.AssertExpectations(t)
+
+	mockAdapter.On("SaveCloudEvent", "unfanned").Return(true, nil).Once()
+	mockFanout.On("Fanout", "unfanned").Return(xerrors.New("db error")).Once()
+	_, err = service.SaveCloudEvent([]CloudEventDTO{newCloudEvent("unfanned")})
+	assert.Error(t, err)
+
+	mockAdapter.On("SaveCloudEvent", "broken").Return(false, xerrors.New("db error")).Once()
+	_, err = service.SaveCloudEvent([]CloudEventDTO{newCloudEvent("broken")})
+	assert.Error(t, err)
+
+	_, err = service.SaveCloudEvent([]CloudEventDTO{{EventId: "invalid"}})
+	assert.Error(t, err)
+}
+
+func TestSaveCloudEventNotify(t *testing.T) {
+	mockAdapter := new(MockMessageCloudEventAdapter)
+	mockFanout := new(MockMessageFanoutAppService)
+	mockNotifier := new(MockEventNotifier)
+	service := NewMessageCloudEventAppService(mockAdapter, mockFanout, mockNotifier)
+
+	// the events seen before are notified again, which changes nothing
+	mockAdapter.On("SaveCloudEvent", "new").Return(true, nil).Once()
+	mockAdapter.On("SaveCloudEvent", "old").Return(false, nil).Once()
+	mockFanout.On("Fanout", mock.Anything).Return(nil)
+	mockNotifier.On("Notify", "new").Return(nil).Once()
+	mockNotifier.On("Notify", "old").Return(nil).Once()
+	_, err := service.SaveCloudEvent([]CloudEventDTO{newCloudEvent("new"),
+		newCloudEvent("old")})
+	assert.NoError(t, err)
+	mockNotifier.AssertExpectations(t)
+
+	mockAdapter.On("SaveCloudEvent", "unnotified").Return(true, nil).Once()
+	mockNotifier.On("Notify", "unnotified").Return(xerrors.New("db error")).Once()
+	_, err = service.SaveCloudEvent([]CloudEventDTO{newCloudEvent("unnotified")})
+	assert.Error(t, err)
+}
+
+func TestSaveCloudEventDuplicate(t *testing.T) {
+	stored := newCloudEvent("old")
+	stored.Title = "stored"
+	mockAdapter := &MockMessageCloudEventAdapter{
+		stored: map[string]domain.CloudEventDO{"old": stored},
+	}
+	mockFanout := new(MockMessageFanoutAppService)
+	var notified []string
+	service := NewMessageCloudEventAppService(mockAdapter, mockFanout,
+		eventNotifierFunc(func(event CloudEventDTO) error {
+			notified = append(notified, event.Title)
+			return nil
+		}))
+
+	// a resent event is fanned out and notified as it is stored
+	resent := newCloudEvent("old")
+	resent.Title = "resent"
+	mockAdapter.On("SaveCloudEvent", "old").Return(false, nil).Once()
+	mockFanout.On("Fanout", "old").Return(nil).Once()
+	result, err := service.SaveCloudEvent([]CloudEventDTO{resent})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"old"}, result.Duplicate)
+	assert.Equal(t, []string{"stored"}, notified)
+	mockFanout.AssertExpectations(t)
+}
@@ -0,0 +1,142 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+// ConsumerDecoder reads the events of a message, an error marks the message
+// as poison.
+type ConsumerDecoder func(msg ConsumerMessageDTO) ([]CloudEventDTO, error)
+
+// ConsumerOption tells how often the events of a message are saved before the
+// message goes to the dead letters.
+type ConsumerOption struct {
+	MaxAttempts   int
+	RetryInterval time.Duration
+}
+
+type MessageConsumerAppService interface {
+	Run(ctx context.Context)
+}
+
+func NewMessageConsumerAppService(
+	broker domain.MessageConsumerBroker,
+	deadLetterAdapter domain.MessageDeadLetterAdapter,
+	cloudEventAppService MessageCloudEventAppService,
+	decode ConsumerDecoder,
+	option ConsumerOption,
+) MessageConsumerAppService {
+	if option.MaxAttempts <= 0 {
+		option.MaxAttempts = 1
+	}
+	return &messageConsumerAppService{
+		broker:               broker,
+		deadLetterAdapter:    deadLetterAdapter,
+		cloudEventAppService: cloudEventAppService,
+		decode:               decode,
+		option:               option,
+	}
+}
+
+type messageConsumerAppService struct {
+	broker               domain.MessageConsumerBroker
+	deadLetterAdapter    domain.MessageDeadLetterAdapter
+	cloudEventAppService MessageCloudEventAppService
+	decode               ConsumerDecoder
+	option               ConsumerOption
+}
+
+// Run consumes messages until ctx is done, a message is committed once its
+// events or its dead letter are saved.
+func (s *messageConsumerAppService) Run(ctx context.Context) {
+	defer func() {
+		if err := s.broker.Close(); err != nil {
+			logrus.Errorf("close consumer broker failed, err:%v", err)
+		}
+	}()
+
+	for {
+		msg, err := s.broker.Fetch(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logrus.Errorf("fetch message failed, err:%v", err)
+			if !s.sleep(ctx) {
+				return
+			}
+			continue
+		}
+
+		if !s.consume(ctx, msg) {
+			return
+		}
+		if err := s.broker.Commit(ctx, msg); err != nil {
+			// the message is delivered again, saving it once more is harmless
+			logrus.Errorf("commit message %s/%d/%d failed, err:%v", msg.Topic, msg.Partition,
+				msg.Offset, err)
+		}
+	}
+}
+
+// consume saves the events of msg, or its dead letter when they cannot be
+// saved. It reports false if ctx is done first.
+func (s *messageConsumerAppService) consume(ctx context.Context, msg ConsumerMessageDTO) bool {
+	events, err := s.decode(msg)
+	if err == nil {
+		err = s.cloudEventAppService.ValidateCloudEvent(events)
+	}
+	if err != nil {
+		return s.saveDeadLetter(ctx, msg, 0, err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		if _, err = s.cloudEventAppService.SaveCloudEvent(events); err == nil {
+			return true
+		}
+		logrus.Errorf("save message %s/%d/%d failed, attempt:%d, err:%v", msg.Topic,
+			msg.Partition, msg.Offset, attempt, err)
+		if attempt >= s.option.MaxAttempts {
+			return s.saveDeadLetter(ctx, msg, attempt, err)
+		}
+		if !s.sleep(ctx) {
+			return false
+		}
+	}
+}
+
+// saveDeadLetter retries until the dead letter is saved, the message must not
+// be committed before.
+func (s *messageConsumerAppService) saveDeadLetter(ctx context.Context, msg ConsumerMessageDTO,
+	attempts int, cause error) bool {
+	letter := domain.DeadLetterDO{Message: msg, Error: cause.Error(), Attempts: attempts}
+	for {
+		err := s.deadLetterAdapter.SaveDeadLetter(letter)
+		if err == nil {
+			return true
+		}
+		logrus.Errorf("save dead letter %s/%d/%d failed, err:%v", msg.Topic, msg.Partition,
+			msg.Offset, err)
+		if !s.sleep(ctx) {
+			return false
+		}
+	}
+}
+
+func (s *messageConsumerAppService) sleep(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(s.option.RetryInterval):
+		return true
+	}
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/message/domain"
+	"github.com/opensourceways/message-manager/message/infrastructure"
+)
+
+// MockMessageDeadLetterAdapter 是 MessageDeadLetterAdapter 的模拟实现
+type MockMessageDeadLetterAdapter struct {
+	mock.Mock
+}
+
+func (m *MockMessageDeadLetterAdapter) SaveDeadLetter(letter domain.DeadLetterDO) error {
+	args := m.Called(string(letter.Message.Value), letter.Attempts)
+	return args.Error(0)
+}
+
+// decodeTestMessage reads a message holding the id of one event.
+func decodeTestMessage(msg ConsumerMessageDTO) ([]CloudEventDTO, error) {
+	var id string
+	if err := json.Unmarshal(msg.Value, &id); err != nil {
+		return nil, err
+	}
+	return []CloudEventDTO{newCloudEvent(id)}, nil
+}
+
+type consumerTest struct {
+	broker interface {
+		Publish(string, []byte, []byte, map[string]string)
+	}
+	committed  func() int64
+	adapter    *MockMessageCloudEventAdapter
+	fanout     *MockMessageFanoutAppService
+	deadLetter *MockMessageDeadLetterAdapter
+	service    MessageConsumerAppService
+}
+
+func newConsumerTest() *consumerTest {
+	broker := infrastructure.NewMemoryConsumerBroker([]string{"events"})
+	c := &consumerTest{
+		broker:     broker,
+		committed:  func() int64 { return broker.Committed("events") },
+		adapter:    new(MockMessageCloudEventAdapter),
+		fanout:     new(MockMessageFanoutAppService),
+		deadLetter: new(MockMessageDeadLetterAdapter),
+	}
+	c.service = NewMessageConsumerAppService(broker, c.deadLetter,
+		NewMessageCloudEventAppService(c.adapter, c.fanout), decodeTestMessage,
+		ConsumerOption{MaxAttempts: 3, RetryInterval: time.Millisecond})
+	return c
+}
+
+// runUntil runs the consumer until offset is committed.
+func (c *consumerTest) runUntil(t *testing.T, offset int64) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.service.Run(ctx)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return c.committed() >= offset }, time.Second,
+		time.Millisecond)
+	cancel()
+	<-done
+}
+
+func TestMessageConsumer(t *testing.T) {
+	c := newConsumerTest()
+
+	c.broker.Publish("events", nil, []byte(`"saved"`), nil)
+	c.adapter.On("SaveCloudEvent", "saved").Return(true, nil).Once()
+	c.fanout.On("Fanout", "saved").Return(nil).Once()
+
+	// poison messages go to the dead letters at once
+	c.broker.Publish("events", nil, []byte(`not json`), nil)
+	c.deadLetter.On("SaveDeadLetter", "not json", 0).Return(nil).Once()
+	c.broker.Publish("events", nil, []byte(`""`), nil)
+	c.deadLetter.On("SaveDeadLetter", `""`, 0).Return(nil).Once()
+
+	// a failure which goes away is retried
+	c.broker.Publish("events", nil, []byte(`"flaky"`), nil)
+	c.adapter.On("SaveCloudEvent", "flaky").Return(false, xerrors.New("db error")).Twice()
+	c.adapter.On("SaveCloudEvent", "flaky").Return(true, nil).Once()
+	c.fanout.On("Fanout", "flaky").Return(nil).Once()
+
+	// a failure which stays goes to the dead letters after the last attempt
+	c.broker.Publish("events", nil, []byte(`"broken"`), nil)
+	c.adapter.On("SaveCloudEvent", "broken").Return(false, xerrors.New("db error")).Times(3)
+	c.deadLetter.On("SaveDeadLetter", `"broken"`, 3).Return(nil).Once()
+
+	c.runUntil(t, 5)
+	c.adapter.AssertExpectations(t)
+	c.fanout.AssertExpectations(t)
+	c.deadLetter.AssertExpectations(t)
+}
+
+func TestMessageConsumerCommitAfterSave(t *testing.T) {
+	c := newConsumerTest()
+
+	// the message stays uncommitted while neither it nor its dead letter is
+	// saved
+	c.broker.Publish("events", nil, []byte(`"stuck"`), nil)
+	c.adapter.On("SaveCloudEvent", "stuck").Return(false, xerrors.New("db error"))
+	var failures atomic.Int32
+	c.deadLetter.On("SaveDeadLetter", `"stuck"`, 3).Return(xerrors.New("db error")).
+		Run(func(mock.Arguments) { failures.Add(1) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.service.Run(ctx)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return failures.Load() >= 2 }, time.Second,
+		time.Millisecond)
+	cancel()
+	<-done
+	assert.Equal(t, int64(0), c.committed())
+}
@@ -0,0 +1,107 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package app
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+// maxDeliveryRetryInterval bounds the wait between two attempts of a
+// notification.
+const maxDeliveryRetryInterval = 6 * time.Hour
+
+// DeliveryOption tells how the queued notifications of a channel are sent, a
+// failing one is retried with a doubling RetryInterval MaxAttempts times.
+type DeliveryOption struct {
+	BatchSize     int
+	MaxAttempts   int
+	RetryInterval time.Duration
+	Lease         time.Duration
+}
+
+// DeliveryResultDTO counts the notifications of a send.
+type DeliveryResultDTO struct {
+	Sent    int `json:"sent"`
+	Retried int `json:"retried"`
+	Failed  int `json:"failed"`
+}
+
+// deliverFunc sends item, permanent tells whether sending it again would fail
+// the same.
+type deliverFunc func(item domain.DeliveryDO) (permanent bool, err error)
+
+// deliveryQueue sends the queued notifications of channel.
+type deliveryQueue struct {
+	adapter domain.MessageDeliveryAdapter
+	channel string
+	option  DeliveryOption
+	now     func() time.Time
+}
+
+func newDeliveryQueue(adapter domain.MessageDeliveryAdapter, channel string,
+	option DeliveryOption) *deliveryQueue {
+	if option.BatchSize <= 0 {
+		option.BatchSize = 1
+	}
+	if option.MaxAttempts <= 0 {
+		option.MaxAttempts = 1
+	}
+	return &deliveryQueue{adapter: adapter, channel: channel, option: option, now: time.Now}
+}
+
+// drain sends the notifications which are due, batch after batch until none
+// is left.
+func (q *deliveryQueue) drain(deliver deliverFunc) (DeliveryResultDTO, error) {
+	var result DeliveryResultDTO
+	for {
+		items, err := q.adapter.ClaimDelivery(q.channel, q.option.BatchSize, q.option.Lease)
+		if err != nil {
+			return result, err
+		}
+		for i := range items {
+			if err := q.send(items[i], deliver, &result); err != nil {
+				return result, err
+			}
+		}
+		if len(items) < q.option.BatchSize {
+			return result, nil
+		}
+	}
+}
+
+// send sends item and records the outcome, it fails when the outcome cannot
+// be recorded.
+func (q *deliveryQueue) send(item domain.DeliveryDO, deliver deliverFunc,
+	result *DeliveryResultDTO) error {
+	permanent, err := deliver(item)
+	if err == nil {
+		result.Sent++
+		return q.adapter.CompleteDelivery(item.Id)
+	}
+
+	logrus.Errorf("send %s %d of event %s failed, attempt:%d, err:%v", q.channel, item.Id,
+		item.EventId, item.Attempts, err)
+	if permanent || item.Attempts >= q.option.MaxAttempts {
+		result.Failed++
+		return q.adapter.FailDelivery(item.Id, err.Error())
+	}
+	result.Retried++
+	return q.adapter.RetryDelivery(item.Id, err.Error(),
+		q.now().Add(retryInterval(q.option.RetryInterval, item.Attempts)))
+}
+
+// retryInterval returns the wait after the attempt, base doubled for every
+// attempt after the first one.
+func retryInterval(base time.Duration, attempt int) time.Duration {
+	d := base
+	for i := 1; i < attempt && d < maxDeliveryRetryInterval; i++ {
+		d *= 2
+	}
+	return min(d, maxDeliveryRetryInterval)
+}
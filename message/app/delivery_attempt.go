@@ -0,0 +1,141 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package app
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+const (
+	// MaxDeliveryReport bounds the attempts of a report.
+	MaxDeliveryReport = 100
+
+	maxDeliveryEventIdLen = 255
+	maxDeliveryTargetLen  = 1024
+	// the longer errors of the reports are cut
+	maxDeliveryErrorLen = 2048
+)
+
+var deliveryChannels = map[string]bool{
+	domain.DeliveryChannelMail:         true,
+	domain.DeliveryChannelMessage:      true,
+	domain.DeliveryChannelPhone:        true,
+	domain.DeliveryChannelInnerMessage: true,
+	domain.DeliveryChannelWebhook:      true,
+	domain.DeliveryChannelChat:         true,
+}
+
+var deliveryAttemptStatus = map[string]bool{
+	domain.DeliverySent:     true,
+	domain.DeliveryFailed:   true,
+	domain.DeliveryRetrying: true,
+}
+
+type MessageDeliveryAttemptAppService interface {
+	ValidateDeliveryAttempt(attempts []DeliveryAttemptDTO) error
+	ReportDeliveryAttempt(attempts []DeliveryAttemptDTO) (int64, error)
+	GetDeliveryAttempt(userName, eventId string) ([]DeliveryAttemptDTO, error)
+	GetAllDeliveryAttempt(cmd CmdToGetDeliveryAttempt) ([]DeliveryAttemptDTO, int64, error)
+}
+
+func NewMessageDeliveryAttemptAppService(
+	messageDeliveryAttemptAdapter domain.MessageDeliveryAttemptAdapter,
+) MessageDeliveryAttemptAppService {
+	return &messageDeliveryAttemptAppService{
+		messageDeliveryAttemptAdapter: messageDeliveryAttemptAdapter,
+		now:                           time.Now,
+	}
+}
+
+type messageDeliveryAttemptAppService struct {
+	messageDeliveryAttemptAdapter domain.MessageDeliveryAttemptAdapter
+	now                           func() time.Time
+}
+
+// ValidateDeliveryAttempt checks the attempts reported by a pusher.
+func (s *messageDeliveryAttemptAppService) ValidateDeliveryAttempt(
+	attempts []DeliveryAttemptDTO) error {
+	if len(attempts) == 0 || len(attempts) > MaxDeliveryReport {
+		return xerrors.Errorf("a report has 1 to %d attempts", MaxDeliveryReport)
+	}
+	for i, a := range attempts {
+		switch {
+		case a.EventId == "" || len(a.EventId) > maxDeliveryEventIdLen:
+			return xerrors.Errorf("the event id of attempt %d is empty or too long", i)
+		case a.RecipientId <= 0:
+			return xerrors.Errorf("the recipient id of attempt %d is invalid, recipient_id:%d",
+				i, a.RecipientId)
+		case !deliveryChannels[a.Channel]:
+			return xerrors.Errorf("the channel of attempt %d is invalid, channel:%s", i,
+				a.Channel)
+		case !deliveryAttemptStatus[a.Status]:
+			return xerrors.Errorf("the status of attempt %d is invalid, status:%s", i, a.Status)
+		case len(a.Target) > maxDeliveryTargetLen:
+			return xerrors.Errorf("the target of attempt %d is longer than %d", i,
+				maxDeliveryTargetLen)
+		}
+	}
+	return nil
+}
+
+// ReportDeliveryAttempt records the attempts reported by a pusher, it returns
+// how many are. The attempts to the recipients which do not exist are dropped.
+func (s *messageDeliveryAttemptAppService) ReportDeliveryAttempt(
+	attempts []DeliveryAttemptDTO) (int64, error) {
+	if err := s.ValidateDeliveryAttempt(attempts); err != nil {
+		return 0, err
+	}
+
+	now := s.now()
+	items := make([]domain.DeliveryAttemptDO, len(attempts))
+	for i, a := range attempts {
+		if a.AttemptedAt.IsZero() || a.AttemptedAt.After(now) {
+			a.AttemptedAt = now
+		}
+		if a.Attempt <= 0 {
+			a.Attempt = 1
+		}
+		a.Error = truncateUTF8(a.Error, maxDeliveryErrorLen)
+		items[i] = a
+	}
+	return s.messageDeliveryAttemptAdapter.SaveDeliveryAttempt(items)
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a UTF-8 character.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// GetDeliveryAttempt returns the attempts to deliver the event eventId to the
+// recipients of userName.
+func (s *messageDeliveryAttemptAppService) GetDeliveryAttempt(userName, eventId string) (
+	[]DeliveryAttemptDTO, error) {
+	data, err := s.messageDeliveryAttemptAdapter.GetDeliveryAttempt(userName, eventId)
+	if err != nil {
+		return []DeliveryAttemptDTO{}, err
+	}
+	return data, nil
+}
+
+// GetAllDeliveryAttempt returns the attempts to deliver to any recipient.
+func (s *messageDeliveryAttemptAppService) GetAllDeliveryAttempt(
+	cmd CmdToGetDeliveryAttempt) ([]DeliveryAttemptDTO, int64, error) {
+	data, count, err := s.messageDeliveryAttemptAdapter.GetAllDeliveryAttempt(cmd)
+	if err != nil {
+		return []DeliveryAttemptDTO{}, 0, err
+	}
+	return data, count, nil
+}
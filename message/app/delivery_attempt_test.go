@@ -0,0 +1,121 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+// MockMessageDeliveryAttemptAdapter 是 MessageDeliveryAttemptAdapter 的模拟实现
+type MockMessageDeliveryAttemptAdapter struct {
+	mock.Mock
+}
+
+func (m *MockMessageDeliveryAttemptAdapter) SaveDeliveryAttempt(
+	attempts []domain.DeliveryAttemptDO) (int64, error) {
+	args := m.Called(attempts)
+	return int64(args.Int(0)), args.Error(1)
+}
+
+func (m *MockMessageDeliveryAttemptAdapter) GetDeliveryAttempt(userName, eventId string) (
+	[]domain.DeliveryAttemptDO, error) {
+	args := m.Called(userName, eventId)
+	return args.Get(0).([]domain.DeliveryAttemptDO), args.Error(1)
+}
+
+func (m *MockMessageDeliveryAttemptAdapter) GetAllDeliveryAttempt(
+	cmd domain.CmdToGetDeliveryAttempt) ([]domain.DeliveryAttemptDO, int64, error) {
+	args := m.Called(cmd)
+	return args.Get(0).([]domain.DeliveryAttemptDO), int64(args.Int(1)), args.Error(2)
+}
+
+func TestValidateDeliveryAttempt(t *testing.T) {
+	service := NewMessageDeliveryAttemptAppService(new(MockMessageDeliveryAttemptAdapter))
+
+	valid := DeliveryAttemptDTO{EventId: "1", RecipientId: 1,
+		Channel: domain.DeliveryChannelMessage, Status: domain.DeliverySent}
+	assert.NoError(t, service.ValidateDeliveryAttempt([]DeliveryAttemptDTO{valid}))
+	assert.Error(t, service.ValidateDeliveryAttempt(nil))
+	assert.Error(t, service.ValidateDeliveryAttempt(
+		make([]DeliveryAttemptDTO, MaxDeliveryReport+1)))
+
+	for _, change := range []func(a *DeliveryAttemptDTO){
+		func(a *DeliveryAttemptDTO) { a.EventId = "" },
+		func(a *DeliveryAttemptDTO) { a.RecipientId = 0 },
+		func(a *DeliveryAttemptDTO) { a.Channel = "fax" },
+		func(a *DeliveryAttemptDTO) { a.Status = domain.DeliveryPending },
+		func(a *DeliveryAttemptDTO) { a.Target = strings.Repeat("a", maxDeliveryTargetLen+1) },
+	} {
+		a := valid
+		change(&a)
+		assert.Error(t, service.ValidateDeliveryAttempt([]DeliveryAttemptDTO{valid, a}))
+	}
+}
+
+func TestReportDeliveryAttempt(t *testing.T) {
+	mockAdapter := new(MockMessageDeliveryAttemptAdapter)
+	service := NewMessageDeliveryAttemptAppService(mockAdapter).(*messageDeliveryAttemptAppService)
+	now := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	earlier := now.Add(-time.Minute)
+	attempts := []DeliveryAttemptDTO{
+		{EventId: "1", RecipientId: 1, Channel: domain.DeliveryChannelMessage,
+			Status: domain.DeliverySent, Attempt: 2, AttemptedAt: earlier},
+		{EventId: "1", RecipientId: 2, Channel: domain.DeliveryChannelMail,
+			Status: domain.DeliveryFailed, Error: strings.Repeat("错", maxDeliveryErrorLen),
+			AttemptedAt: now.Add(time.Hour)},
+	}
+	saved := func(items []domain.DeliveryAttemptDO) bool {
+		return len(items) == 2 &&
+			items[0].Attempt == 2 && items[0].AttemptedAt.Equal(earlier) &&
+			items[1].Attempt == 1 && items[1].AttemptedAt.Equal(now) &&
+			len(items[1].Error) <= maxDeliveryErrorLen &&
+			strings.Trim(items[1].Error, "错") == ""
+	}
+	mockAdapter.On("SaveDeliveryAttempt", mock.MatchedBy(saved)).Return(1, nil).Once()
+
+	count, err := service.ReportDeliveryAttempt(attempts)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	_, err = service.ReportDeliveryAttempt([]DeliveryAttemptDTO{{EventId: "1"}})
+	assert.Error(t, err)
+
+	mockAdapter.On("SaveDeliveryAttempt", mock.Anything).
+		Return(0, xerrors.New("db error")).Once()
+	_, err = service.ReportDeliveryAttempt(attempts[:1])
+	assert.Error(t, err)
+	mockAdapter.AssertExpectations(t)
+}
+
+func TestGetDeliveryAttempt(t *testing.T) {
+	mockAdapter := new(MockMessageDeliveryAttemptAdapter)
+	service := NewMessageDeliveryAttemptAppService(mockAdapter)
+
+	data := []domain.DeliveryAttemptDO{{Id: 1, EventId: "1", Status: domain.DeliverySent}}
+	mockAdapter.On("GetDeliveryAttempt", "user", "1").Return(data, nil).Once()
+	result, err := service.GetDeliveryAttempt("user", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, data, result)
+
+	mockAdapter.On("GetDeliveryAttempt", "user", "2").
+		Return([]domain.DeliveryAttemptDO{}, xerrors.New("db error")).Once()
+	result, err = service.GetDeliveryAttempt("user", "2")
+	assert.Error(t, err)
+	assert.Empty(t, result)
+
+	cmd := CmdToGetDeliveryAttempt{RecipientId: 1, CountPerPage: 10, PageNum: 1}
+	mockAdapter.On("GetAllDeliveryAttempt", cmd).Return(data, 1, nil).Once()
+	result, count, err := service.GetAllDeliveryAttempt(cmd)
+	assert.NoError(t, err)
+	assert.Equal(t, data, result)
+	assert.Equal(t, int64(1), count)
+	mockAdapter.AssertExpectations(t)
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+func TestDeliveryQueueDrain(t *testing.T) {
+	mockDelivery := new(MockMessageDeliveryAdapter)
+	option := DeliveryOption{BatchSize: 2, MaxAttempts: 3, RetryInterval: time.Minute,
+		Lease: time.Minute}
+	queue := newDeliveryQueue(mockDelivery, domain.DeliveryChannelMail, option)
+	now := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	queue.now = func() time.Time { return now }
+
+	mockDelivery.On("ClaimDelivery", domain.DeliveryChannelMail, 2, time.Minute).
+		Return([]domain.DeliveryDO{{Id: 1, Attempts: 1}, {Id: 2, Attempts: 2}}, nil).Once()
+	mockDelivery.On("ClaimDelivery", domain.DeliveryChannelMail, 2, time.Minute).
+		Return([]domain.DeliveryDO{{Id: 3, Attempts: 1}}, nil).Once()
+	mockDelivery.On("CompleteDelivery", int64(1)).Return(nil)
+	mockDelivery.On("RetryDelivery", int64(2), now.Add(2*time.Minute)).Return(nil)
+	mockDelivery.On("FailDelivery", int64(3)).Return(nil)
+
+	result, err := queue.drain(func(item domain.DeliveryDO) (bool, error) {
+		switch item.Id {
+		case 1:
+			return false, nil
+		case 2:
+			return false, xerrors.New("timeout")
+		default:
+			return true, xerrors.New("invalid address")
+		}
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, DeliveryResultDTO{Sent: 1, Retried: 1, Failed: 1}, result)
+	mockDelivery.AssertExpectations(t)
+}
@@ -5,19 +5,31 @@ Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
 package app
 
 import (
+	"github.com/opensourceways/message-manager/common/mail"
 	"github.com/opensourceways/message-manager/message/domain"
 )
 
 type MessageListDTO = domain.MessageListDO
 type MessagePushDTO = domain.MessagePushDO
 type MessageRecipientDTO = domain.MessageRecipientDO
+type RecipientIdentityDTO = domain.RecipientIdentityDO
 type MessageSubscribeDTO = domain.MessageSubscribeDO
 type MessageSubscribeDTOWithPushConfig = domain.MessageSubscribeDOWithPushConfig
 type CountDTO = domain.CountDO
 type CountDataDTO = domain.CountDataDO
+type MessageNotifyDTO = domain.MessageNotifyDO
+type MeetingEventDTO = domain.MeetingEventDO
+type CloudEventDTO = domain.CloudEventDO
+type TodoFanoutDTO = domain.TodoFanoutDO
+type MembershipSyncDTO = domain.MembershipSyncDO
+type ConsumerMessageDTO = domain.ConsumerMessageDO
+type MailDTO = mail.Content
+type WebhookLogDTO = domain.WebhookLogDO
+type DeliveryAttemptDTO = domain.DeliveryAttemptDO
 
 type CmdToGetInnerMessageQuick = domain.CmdToGetInnerMessageQuick
 type CmdToGetInnerMessage = domain.CmdToGetInnerMessage
+type CmdToGetSourceMessage = domain.CmdToGetSourceMessage
 type CmdToSetIsRead = domain.CmdToSetIsRead
 type CmdToAddPushConfig = domain.CmdToAddPushConfig
 type CmdToUpdatePushConfig = domain.CmdToUpdatePushConfig
@@ -26,7 +38,9 @@ type CmdToAddRecipient = domain.CmdToAddRecipient
 type CmdToUpdateRecipient = domain.CmdToUpdateRecipient
 type CmdToDeleteRecipient = domain.CmdToDeleteRecipient
 type CmdToSyncUserInfo = domain.CmdToSyncUserInfo
+type CmdToLinkIdentity = domain.CmdToLinkIdentity
 type CmdToGetSubscribe = domain.CmdToGetSubscribe
 type CmdToAddSubscribe = domain.CmdToAddSubscribe
 type CmdToUpdateSubscribe = domain.CmdToUpdateSubscribe
 type CmdToDeleteSubscribe = domain.CmdToDeleteSubscribe
+type CmdToGetDeliveryAttempt = domain.CmdToGetDeliveryAttempt
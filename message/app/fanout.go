@@ -0,0 +1,357 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package app
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/community"
+	"github.com/opensourceways/message-manager/common/source"
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+// the filters the memberships of the recipients are matched by
+const (
+	sigFilter          = "sig"
+	repoFilter         = "repo_name"
+	mySigFilter        = "my_sig"
+	myManagementFilter = "my_management"
+)
+
+// FanoutRule is a rule of the source registry for the events of Source, their
+// logins are on Forge.
+type FanoutRule struct {
+	Source string
+	Forge  string
+	source.Rule
+}
+
+// FanoutRules returns the rules of the registered sources, the logins of a
+// source without forge are on Gitee as they always were.
+func FanoutRules() []FanoutRule {
+	var rules []FanoutRule
+	for _, s := range source.All() {
+		forge := s.Forge
+		if forge == "" {
+			forge = source.ForgeGitee
+		}
+		for _, rule := range s.Rules {
+			rules = append(rules, FanoutRule{Source: s.Url, Forge: forge, Rule: rule})
+		}
+	}
+	return rules
+}
+
+type MessageFanoutAppService interface {
+	Fanout(event CloudEventDTO) (FanoutResultDTO, error)
+}
+
+func NewMessageFanoutAppService(
+	messageFanoutAdapter domain.MessageFanoutAdapter,
+	rules []FanoutRule,
+) MessageFanoutAppService {
+	return &messageFanoutAppService{
+		messageFanoutAdapter: messageFanoutAdapter,
+		rules:                rules,
+	}
+}
+
+type messageFanoutAppService struct {
+	messageFanoutAdapter domain.MessageFanoutAdapter
+	rules                []FanoutRule
+}
+
+// FanoutResultDTO lists the recipients an event is delivered to.
+type FanoutResultDTO struct {
+	Follow  []int64         `json:"follow"`
+	Related []int64         `json:"related"`
+	Todo    []TodoFanoutDTO `json:"todo"`
+}
+
+// Fanout delivers event to the lists of its recipients in its community, once
+// however often it is delivered.
+func (s *messageFanoutAppService) Fanout(event CloudEventDTO) (FanoutResultDTO, error) {
+	var result FanoutResultDTO
+	if event.Community == "" {
+		event.Community = community.DefaultId()
+	}
+	if !readsSource(event.Community, event.Source) {
+		return result, nil
+	}
+	data, err := decodeEventData(event)
+	if err != nil {
+		return result, err
+	}
+
+	rule := findFanoutRule(s.rules, event)
+	follow, err := s.followRecipient(rule, event, data)
+	if err != nil {
+		return result, err
+	}
+	result.Follow = follow
+
+	cmd := domain.CmdToSaveFanout{
+		EventId:   event.EventId,
+		Community: event.Community,
+		Source:    event.Source,
+		EventTime: event.EventTime,
+	}
+	if rule != nil {
+		if result.Related, result.Todo, err = s.ruleRecipient(rule, event, data); err != nil {
+			return result, err
+		}
+		// the state of the item applies to the todos of everyone, not only
+		// to the recipients of this event
+		if businessId, isDone := businessState(rule, event, data); businessId != "" &&
+			isDone != nil {
+			cmd.BusinessId = businessId
+			cmd.IsDone = isDone
+		}
+	}
+
+	if len(result.Follow) == 0 && len(result.Related) == 0 && len(result.Todo) == 0 &&
+		cmd.IsDone == nil {
+		return result, nil
+	}
+	cmd.Follow = result.Follow
+	cmd.Related = result.Related
+	cmd.Todo = result.Todo
+	return result, s.messageFanoutAdapter.SaveFanout(cmd)
+}
+
+// followRecipient returns the recipients of the subscriptions matching event,
+// the filters of rule name the paths of its data.
+func (s *messageFanoutAppService) followRecipient(rule *FanoutRule, event CloudEventDTO,
+	data interface{}) ([]int64, error) {
+	var filters map[string]string
+	if rule != nil {
+		filters = rule.Filters
+	}
+
+	sigs, repos := eventMembership(filters, data)
+	targets, err := s.messageFanoutAdapter.GetSubscribeTarget(event.Community, event.Source,
+		sigs, repos)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int64
+	for _, target := range matchSubscribeTarget(targets, event, data, filters) {
+		ids = append(ids, target.RecipientId)
+	}
+	return uniqueId(ids), nil
+}
+
+// matchSubscribeTarget returns the targets whose subscriptions match event.
+func matchSubscribeTarget(targets []domain.SubscribeTargetDO, event CloudEventDTO,
+	data interface{}, filters map[string]string) []domain.SubscribeTargetDO {
+	var result []domain.SubscribeTargetDO
+	for _, target := range targets {
+		if !matchEventType(target.EventType, event.Type) {
+			continue
+		}
+		ok, err := matchModeFilter(target.ModeFilter, data, filters, map[string]bool{
+			mySigFilter:        target.MySig,
+			myManagementFilter: target.MyManagement,
+		})
+		if err != nil {
+			// a broken subscription must not hold back the others
+			logrus.Errorf("match subscription %d failed, err:%v", target.SubscribeId, err)
+			continue
+		}
+		if ok {
+			result = append(result, target)
+		}
+	}
+	return result
+}
+
+// eventMembership returns the sigs and the repositories of data at the sig and
+// repo_name filters.
+func eventMembership(filters map[string]string, data interface{}) ([]string, []string) {
+	var sigs, repos []string
+	if path, ok := filters[sigFilter]; ok {
+		sigs = uniqueLogin(lookupEventString(data, path))
+	}
+	if path, ok := filters[repoFilter]; ok {
+		repos = uniqueLogin(lookupEventString(data, path))
+	}
+	return sigs, repos
+}
+
+// decodeEventData returns the data of event decoded from JSON, nil when it has
+// none.
+func decodeEventData(event CloudEventDTO) (interface{}, error) {
+	var data interface{}
+	if len(event.DataJson) != 0 {
+		if err := json.Unmarshal(event.DataJson, &data); err != nil {
+			return nil, xerrors.Errorf("invalid data of event %s, err:%v", event.EventId, err)
+		}
+	}
+	return data, nil
+}
+
+// readsSource reports whether the community communityId reads the events of
+// the source url.
+func readsSource(communityId, url string) bool {
+	c, ok := community.Get(communityId)
+	if !ok {
+		return true
+	}
+	src, ok := source.GetByUrl(url)
+	return !ok || c.HasSource(src.Id)
+}
+
+// findFanoutRule returns the rule of rules for event, nil when there is none.
+func findFanoutRule(rules []FanoutRule, event CloudEventDTO) *FanoutRule {
+	for i := range rules {
+		if rules[i].Source == event.Source && rules[i].Type == event.Type {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// businessState returns the item event is about and, when event tells, whether
+// the item is done.
+func businessState(rule *FanoutRule, event CloudEventDTO, data interface{}) (string, *bool) {
+	businessId := event.SourceUrl
+	if rule.BusinessIdPath != "" {
+		if ids := lookupEventString(data, rule.BusinessIdPath); len(ids) != 0 {
+			businessId = ids[0]
+		}
+	}
+	if rule.DonePath == "" {
+		return businessId, nil
+	}
+	states := lookupEventString(data, rule.DonePath)
+	if len(states) == 0 {
+		return businessId, nil
+	}
+	isDone := false
+	for _, v := range states {
+		isDone = isDone || containsString(rule.DoneValues, strings.ToLower(v))
+	}
+	return businessId, &isDone
+}
+
+// ruleRecipient returns the related recipients and the todos of event.
+func (s *messageFanoutAppService) ruleRecipient(rule *FanoutRule, event CloudEventDTO,
+	data interface{}) ([]int64, []TodoFanoutDTO, error) {
+	senders := lookupEventString(data, rule.SenderPath)
+
+	var related []string
+	for _, path := range rule.RelatedPaths {
+		related = append(related, lookupEventString(data, path)...)
+	}
+	for _, path := range rule.MentionPaths {
+		for _, text := range lookupEventString(data, path) {
+			related = append(related, extractMentions(text)...)
+		}
+	}
+	related = excludeLogin(related, senders)
+
+	businessId, isDone := businessState(rule, event, data)
+	var todo []string
+	if businessId != "" {
+		for _, path := range rule.TodoPaths {
+			todo = append(todo, lookupEventString(data, path)...)
+		}
+	}
+
+	var sigMembers []int64
+	if businessId != "" && rule.TodoSigMembers {
+		if sigs, _ := eventMembership(rule.Filters, data); len(sigs) != 0 {
+			ids, err := s.messageFanoutAdapter.GetRecipientBySig(event.Community, sigs)
+			if err != nil {
+				return nil, nil, err
+			}
+			sigMembers = ids
+		}
+	}
+
+	logins := uniqueLogin(append(append([]string{}, related...), todo...))
+	var recipients []domain.RecipientLoginDO
+	if len(logins) != 0 {
+		var err error
+		recipients, err = s.messageFanoutAdapter.GetRecipientByLogin(event.Community,
+			rule.Forge, logins)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	byLogin := map[string][]int64{}
+	for _, r := range recipients {
+		login := strings.ToLower(r.Login)
+		byLogin[login] = append(byLogin[login], r.RecipientId)
+	}
+	idsOf := func(logins []string) []int64 {
+		var ids []int64
+		for _, login := range uniqueLogin(logins) {
+			ids = append(ids, byLogin[login]...)
+		}
+		return uniqueId(ids)
+	}
+
+	var todos []TodoFanoutDTO
+	for _, id := range uniqueId(append(idsOf(todo), sigMembers...)) {
+		todos = append(todos, TodoFanoutDTO{BusinessId: businessId, RecipientId: id,
+			IsDone: isDone != nil && *isDone})
+	}
+	return idsOf(related), todos, nil
+}
+
+// matchEventType reports whether a subscription to eventType receives events
+// of type t, an empty eventType or "*" subscribes to every type.
+func matchEventType(eventType, t string) bool {
+	return eventType == "" || eventType == "*" || eventType == t
+}
+
+func excludeLogin(logins, excluded []string) []string {
+	var result []string
+	for _, login := range logins {
+		skip := false
+		for _, e := range excluded {
+			skip = skip || strings.EqualFold(login, e)
+		}
+		if !skip {
+			result = append(result, login)
+		}
+	}
+	return result
+}
+
+// uniqueLogin returns the sorted, lower-cased logins without duplicates.
+func uniqueLogin(logins []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, login := range logins {
+		login = strings.ToLower(strings.TrimSpace(login))
+		if login != "" && !seen[login] {
+			seen[login] = true
+			result = append(result, login)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func uniqueId(ids []int64) []int64 {
+	seen := map[int64]bool{}
+	var result []int64
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
@@ -0,0 +1,294 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package app
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/repopattern"
+)
+
+// lookupEventData returns the values at the dotted path of data, walking into
+// the arrays on the way.
+func lookupEventData(data interface{}, path string) []interface{} {
+	values := []interface{}{data}
+	for _, key := range strings.Split(path, ".") {
+		var next []interface{}
+		for _, v := range values {
+			next = append(next, lookupEventKey(v, key)...)
+		}
+		values = next
+	}
+
+	var result []interface{}
+	for _, v := range values {
+		if list, ok := v.([]interface{}); ok {
+			result = append(result, list...)
+		} else if v != nil {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func lookupEventKey(v interface{}, key string) []interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if child, ok := t[key]; ok {
+			return []interface{}{child}
+		}
+	case []interface{}:
+		var result []interface{}
+		for _, item := range t {
+			result = append(result, lookupEventKey(item, key)...)
+		}
+		return result
+	}
+	return nil
+}
+
+// lookupEventString returns the non-empty strings at the dotted path of data.
+func lookupEventString(data interface{}, path string) []string {
+	var result []string
+	for _, v := range lookupEventData(data, path) {
+		if s := eventValueString(v); s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func eventValueString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(t)
+	default:
+		b, _ := json.Marshal(t)
+		return string(b)
+	}
+}
+
+var mentionRegexp = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9][\w.-]*)`)
+
+// extractMentions returns the logins mentioned as @login in text.
+func extractMentions(text string) []string {
+	var result []string
+	for _, m := range mentionRegexp.FindAllStringSubmatch(text, -1) {
+		if login := strings.TrimRight(m[1], ".-"); login != "" {
+			result = append(result, login)
+		}
+	}
+	return result
+}
+
+// matchModeFilter reports whether data satisfies every condition of a
+// mode_filter, written in the tag syntax of validator such as "eq=x|oneof=a b".
+func matchModeFilter(modeFilter []byte, data interface{}, filters map[string]string,
+	members map[string]bool) (bool, error) {
+	if len(modeFilter) == 0 || string(modeFilter) == "null" {
+		return true, nil
+	}
+	var filter map[string]interface{}
+	if err := json.Unmarshal(modeFilter, &filter); err != nil {
+		return false, xerrors.Errorf("invalid mode filter, err:%v", err)
+	}
+
+	for key, condition := range filter {
+		if want, ok := condition.(bool); ok {
+			if member, ok := members[key]; ok {
+				if member != want {
+					return false, nil
+				}
+				continue
+			}
+		}
+		path := key
+		if p, ok := filters[key]; ok {
+			path = p
+		}
+		values := lookupEventData(data, path)
+		match := matchCondition
+		if key == repoFilter {
+			match = matchRepoCondition
+		}
+		ok, err := match(condition, values)
+		if err != nil {
+			return false, xerrors.Errorf("invalid condition of %s, err:%v", key, err)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func matchCondition(condition interface{}, values []interface{}) (bool, error) {
+	switch c := condition.(type) {
+	case nil:
+		return true, nil
+	case []interface{}:
+		var options []string
+		for _, v := range c {
+			options = append(options, eventValueString(v))
+		}
+		return matchAny(values, func(v string) bool { return containsString(options, v) }), nil
+	case string:
+		if c == "" {
+			return true, nil
+		}
+		for _, tag := range strings.Split(c, ",") {
+			ok, err := matchOrTag(tag, values)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	default:
+		want := eventValueString(c)
+		return matchAny(values, func(v string) bool { return v == want }), nil
+	}
+}
+
+// matchRepoCondition matches the repositories of values against a list of
+// patterns or a string separating them by commas.
+func matchRepoCondition(condition interface{}, values []interface{}) (bool, error) {
+	var patterns []string
+	switch c := condition.(type) {
+	case string:
+		if isTagCondition(c) {
+			return matchCondition(c, values)
+		}
+		patterns = repopattern.Split(c)
+	case []interface{}:
+		for _, v := range c {
+			patterns = append(patterns, eventValueString(v))
+		}
+	default:
+		return matchCondition(condition, values)
+	}
+
+	set, err := repopattern.ParseSet(patterns)
+	if err != nil {
+		return false, err
+	}
+	if len(set) == 0 {
+		return true, nil
+	}
+	return matchAny(values, set.Match), nil
+}
+
+// isTagCondition reports whether c uses the tag syntax, the names of the
+// repositories never have "=".
+func isTagCondition(c string) bool {
+	return strings.Contains(c, "=") || strings.TrimSpace(c) == "required"
+}
+
+func matchOrTag(tag string, values []interface{}) (bool, error) {
+	for _, t := range strings.Split(tag, "|") {
+		ok, err := matchTag(strings.TrimSpace(t), values)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func matchTag(tag string, values []interface{}) (bool, error) {
+	op, arg, found := strings.Cut(tag, "=")
+	if !found {
+		if tag == "required" {
+			return matchAny(values, func(v string) bool { return v != "" }), nil
+		}
+		op, arg = "eq", tag
+	}
+
+	switch op {
+	case "eq":
+		return matchAny(values, func(v string) bool { return v == arg }), nil
+	case "ne":
+		return !matchAny(values, func(v string) bool { return v == arg }), nil
+	case "oneof":
+		options := strings.Fields(arg)
+		return matchAny(values, func(v string) bool { return containsString(options, v) }), nil
+	case "contains":
+		return matchAny(values, func(v string) bool { return strings.Contains(v, arg) }), nil
+	case "excludes":
+		return !matchAny(values, func(v string) bool { return strings.Contains(v, arg) }), nil
+	case "gt", "gte", "lt", "lte":
+		return matchAny(values, func(v string) bool { return compareOrdered(op, v, arg) }), nil
+	default:
+		return false, xerrors.Errorf("unknown operator:%s", op)
+	}
+}
+
+// compareOrdered compares v with arg as numbers, then as times and finally as
+// strings.
+func compareOrdered(op, v, arg string) bool {
+	if a, errA := strconv.ParseFloat(v, 64); errA == nil {
+		if b, errB := strconv.ParseFloat(arg, 64); errB == nil {
+			return orderedResult(op, compareFloat(a, b))
+		}
+	}
+	if a, errA := time.Parse(time.RFC3339Nano, v); errA == nil {
+		if b, errB := time.Parse(time.RFC3339Nano, arg); errB == nil {
+			return orderedResult(op, a.Compare(b))
+		}
+	}
+	return orderedResult(op, strings.Compare(v, arg))
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func orderedResult(op string, cmp int) bool {
+	switch op {
+	case "gt":
+		return cmp > 0
+	case "gte":
+		return cmp >= 0
+	case "lt":
+		return cmp < 0
+	default:
+		return cmp <= 0
+	}
+}
+
+func matchAny(values []interface{}, match func(v string) bool) bool {
+	for _, v := range values {
+		if match(eventValueString(v)) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
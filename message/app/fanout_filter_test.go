@@ -0,0 +1,99 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const filterFixture = `{
+	"NoteEvent": {
+		"Comment": {"Body": "cc @alice, @bob. mail a@b.com", "Id": 42},
+		"Issue": {"User": {"Login": "MaoMao19970922"}, "Labels": [{"Name": "bug"}, {"Name": "sig/infra"}]},
+		"Repository": {"FullName": "openeuler/infrastructure", "Private": false},
+		"UpdatedAt": "2024-05-01T08:00:00Z"
+	}
+}`
+
+func loadFixture(t *testing.T, s string) interface{} {
+	var data interface{}
+	assert.NoError(t, json.Unmarshal([]byte(s), &data))
+	return data
+}
+
+func TestLookupEventString(t *testing.T) {
+	data := loadFixture(t, filterFixture)
+	assert.Equal(t, []string{"bug", "sig/infra"}, lookupEventString(data, "NoteEvent.Issue.Labels.Name"))
+	assert.Equal(t, []string{"42"}, lookupEventString(data, "NoteEvent.Comment.Id"))
+	assert.Equal(t, []string{"false"}, lookupEventString(data, "NoteEvent.Repository.Private"))
+	assert.Nil(t, lookupEventString(data, "NoteEvent.PullRequest.User.Login"))
+	assert.Nil(t, lookupEventString(nil, "NoteEvent"))
+}
+
+func TestExtractMentions(t *testing.T) {
+	assert.Equal(t, []string{"alice", "bob"}, extractMentions("cc @alice, @bob. mail a@b.com"))
+	assert.Nil(t, extractMentions("no mention"))
+}
+
+func TestMatchModeFilter(t *testing.T) {
+	data := loadFixture(t, filterFixture)
+	cases := []struct {
+		filter string
+		want   bool
+	}{
+		{``, true},
+		{`null`, true},
+		{`{"NoteEvent.Issue.User.Login": "eq=MaoMao19970922"}`, true},
+		{`{"NoteEvent.Issue.User.Login": "MaoMao19970922"}`, true},
+		{`{"NoteEvent.Issue.User.Login": "ne=MaoMao19970922"}`, false},
+		{`{"NoteEvent.Issue.Labels.Name": "oneof=sig/infra sig/doc"}`, true},
+		{`{"NoteEvent.Issue.Labels.Name": ["sig/doc", "sig/kernel"]}`, false},
+		{`{"NoteEvent.Repository.FullName": "contains=infra,excludes=kernel"}`, true},
+		{`{"NoteEvent.Repository.FullName": "eq=openeuler/kernel|contains=infra"}`, true},
+		{`{"NoteEvent.Comment.Id": "gt=41,lte=42"}`, true},
+		{`{"NoteEvent.Comment.Id": 43}`, false},
+		{`{"NoteEvent.UpdatedAt": "gte=2024-05-01T00:00:00Z"}`, true},
+		{`{"NoteEvent.UpdatedAt": "lt=2024-05-01T00:00:00Z"}`, false},
+		{`{"NoteEvent.PullRequest.User.Login": "required"}`, false},
+		{`{"NoteEvent.Repository.Private": false}`, true},
+		{`{"NoteEvent.Issue.User.Login": "eq=MaoMao19970922", "NoteEvent.Comment.Id": 1}`, false},
+	}
+	for _, c := range cases {
+		ok, err := matchModeFilter([]byte(c.filter), data, nil, nil)
+		assert.NoError(t, err, c.filter)
+		assert.Equal(t, c.want, ok, c.filter)
+	}
+
+	_, err := matchModeFilter([]byte(`{"NoteEvent": "like=x"}`), data, nil, nil)
+	assert.Error(t, err)
+	_, err = matchModeFilter([]byte(`[`), data, nil, nil)
+	assert.Error(t, err)
+
+	// filter names stand for the paths of the data of the forge
+	filters := map[string]string{"repo_name": "NoteEvent.Repository.FullName"}
+	ok, err := matchModeFilter([]byte(`{"repo_name": "openeuler/infrastructure"}`), data,
+		filters, nil)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = matchModeFilter([]byte(`{"repo_name": "openeuler/infrastructure"}`), data, nil, nil)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = matchModeFilter([]byte(`{"repo_name": "openeuler/[a-"}`), data, filters, nil)
+	assert.Error(t, err)
+
+	// a true or false membership filter is matched against the synced membership
+	members := map[string]bool{"my_sig": true, "my_management": false}
+	for filter, want := range map[string]bool{
+		`{"my_sig": true}`:                         true,
+		`{"my_sig": false}`:                        false,
+		`{"my_management": true}`:                  false,
+		`{"my_sig": true, "my_management": false}`: true,
+		`{"my_sig": "required"}`:                   false,
+	} {
+		ok, err = matchModeFilter([]byte(filter), data, nil, members)
+		assert.NoError(t, err, filter)
+		assert.Equal(t, want, ok, filter)
+	}
+}
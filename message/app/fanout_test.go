@@ -0,0 +1,272 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/datatypes"
+
+	"github.com/opensourceways/message-manager/common/community"
+	"github.com/opensourceways/message-manager/common/source"
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+// MockMessageFanoutAdapter 是 MessageFanoutAdapter 的模拟实现
+type MockMessageFanoutAdapter struct {
+	mock.Mock
+}
+
+func (m *MockMessageFanoutAdapter) GetSubscribeTarget(communityId, source string, sigs,
+	repos []string) ([]domain.SubscribeTargetDO, error) {
+	args := m.Called(communityId, source, sigs, repos)
+	return args.Get(0).([]domain.SubscribeTargetDO), args.Error(1)
+}
+
+func (m *MockMessageFanoutAdapter) GetRecipientByLogin(communityId, forge string,
+	logins []string) ([]domain.RecipientLoginDO, error) {
+	args := m.Called(communityId, forge, logins)
+	return args.Get(0).([]domain.RecipientLoginDO), args.Error(1)
+}
+
+func (m *MockMessageFanoutAdapter) GetRecipientBySig(communityId string, sigs []string) (
+	[]int64, error) {
+	args := m.Called(communityId, sigs)
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (m *MockMessageFanoutAdapter) SaveFanout(cmd domain.CmdToSaveFanout) error {
+	args := m.Called(cmd)
+	return args.Error(0)
+}
+
+var fanoutEventTime = time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+const fanoutCommunity = "openeuler"
+
+// noMembership is the sigs or the repositories of an event without them
+var noMembership []string
+
+const prFixture = `{
+	"PullRequestEvent": {
+		"Sender": {"Login": "Alice"},
+		"PullRequest": {
+			"HtmlUrl": "https://gitee.com/openeuler/infrastructure/pulls/1",
+			"State": "merged",
+			"Body": "fixes a bug, cc @carol and @alice",
+			"User": {"Login": "alice"},
+			"Assignees": [{"Login": "Bob"}, {"Login": "dave"}]
+		}
+	}
+}`
+
+const meetingFixture = `{
+	"Action": "create",
+	"Msg": {"Id": 7, "GroupName": "Infra"},
+	"SigMaintainers": ["bob", "bob", "erin"]
+}`
+
+func newFanoutEvent(id, source, eventType, data string) CloudEventDTO {
+	return CloudEventDTO{EventId: id, Source: source, Type: eventType, SpecVersion: "1.0",
+		SourceUrl: "https://example.com/" + id, EventTime: fanoutEventTime,
+		Community: fanoutCommunity, DataJson: datatypes.JSON(data)}
+}
+
+var fanoutRecipients = []domain.RecipientLoginDO{
+	{RecipientId: 1, Login: "alice"},
+	{RecipientId: 2, Login: "bob"},
+	{RecipientId: 3, Login: "Carol"},
+	{RecipientId: 4, Login: "Erin"},
+	{RecipientId: 5, Login: "erin"},
+}
+
+func TestFanoutPullRequest(t *testing.T) {
+	mockAdapter := new(MockMessageFanoutAdapter)
+	service := NewMessageFanoutAppService(mockAdapter, FanoutRules())
+
+	mockAdapter.On("GetSubscribeTarget", fanoutCommunity, source.DefaultGiteeUrl, noMembership,
+		noMembership).Return(
+		[]domain.SubscribeTargetDO{
+			{SubscribeId: 1, EventType: "pr", RecipientId: 9,
+				ModeFilter: datatypes.JSON(`{"PullRequestEvent.PullRequest.State": "oneof=merged closed"}`)},
+			{SubscribeId: 2, EventType: "pr", RecipientId: 8,
+				ModeFilter: datatypes.JSON(`{"PullRequestEvent.PullRequest.State": "open"}`)},
+			{SubscribeId: 3, EventType: "issue", RecipientId: 7},
+			{SubscribeId: 4, EventType: "", RecipientId: 6},
+			{SubscribeId: 5, EventType: "*", RecipientId: 9},
+			{SubscribeId: 6, EventType: "pr", RecipientId: 5,
+				ModeFilter: datatypes.JSON(`{"PullRequestEvent": "unknown=1"}`)},
+		}, nil).Once()
+	// the sender alice is never related to the own pull request
+	mockAdapter.On("GetRecipientByLogin", fanoutCommunity, source.ForgeGitee,
+		[]string{"bob", "carol", "dave"}).Return(fanoutRecipients[1:3], nil).Once()
+
+	businessId := "https://gitee.com/openeuler/infrastructure/pulls/1"
+	want := FanoutResultDTO{
+		Follow:  []int64{6, 9},
+		Related: []int64{2, 3},
+		Todo:    []TodoFanoutDTO{{BusinessId: businessId, RecipientId: 2, IsDone: true}},
+	}
+	merged := true
+	mockAdapter.On("SaveFanout", domain.CmdToSaveFanout{
+		EventId: "pr-1", Community: fanoutCommunity, Source: source.DefaultGiteeUrl,
+		EventTime: fanoutEventTime,
+		Follow:    want.Follow, Related: want.Related, Todo: want.Todo,
+		BusinessId: businessId, IsDone: &merged,
+	}).Return(nil).Once()
+
+	result, err := service.Fanout(newFanoutEvent("pr-1", source.DefaultGiteeUrl, "pr", prFixture))
+	assert.NoError(t, err)
+	assert.Equal(t, want, result)
+	mockAdapter.AssertExpectations(t)
+}
+
+func TestFanoutMeeting(t *testing.T) {
+	mockAdapter := new(MockMessageFanoutAdapter)
+	service := NewMessageFanoutAppService(mockAdapter, FanoutRules())
+
+	mockAdapter.On("GetSubscribeTarget", fanoutCommunity, source.DefaultMeetingUrl,
+		[]string{"infra"}, noMembership).Return([]domain.SubscribeTargetDO{}, nil).Once()
+	mockAdapter.On("GetRecipientByLogin", fanoutCommunity, source.ForgeGitee,
+		[]string{"bob", "erin"}).Return(fanoutRecipients, nil).Once()
+	// the maintainers of the sig synced locally are invited too
+	mockAdapter.On("GetRecipientBySig", fanoutCommunity, []string{"infra"}).
+		Return([]int64{2, 6}, nil).Once()
+	mockAdapter.On("SaveFanout", mock.Anything).Return(nil).Once()
+
+	result, err := service.Fanout(newFanoutEvent("meeting-1", source.DefaultMeetingUrl, "meeting",
+		meetingFixture))
+	assert.NoError(t, err)
+	assert.Nil(t, result.Follow)
+	assert.Nil(t, result.Related)
+	assert.Equal(t, []TodoFanoutDTO{
+		{BusinessId: "7", RecipientId: 2},
+		{BusinessId: "7", RecipientId: 4},
+		{BusinessId: "7", RecipientId: 5},
+		{BusinessId: "7", RecipientId: 6},
+	}, result.Todo)
+	mockAdapter.AssertExpectations(t)
+}
+
+func TestFanoutBusinessState(t *testing.T) {
+	mockAdapter := new(MockMessageFanoutAdapter)
+	service := NewMessageFanoutAppService(mockAdapter, FanoutRules())
+
+	// closing an issue nobody of the center is assigned to still closes the
+	// todos of the issue
+	mockAdapter.On("GetSubscribeTarget", fanoutCommunity, source.DefaultGiteeUrl, noMembership,
+		noMembership).Return([]domain.SubscribeTargetDO{}, nil).Once()
+	closed := true
+	mockAdapter.On("SaveFanout", domain.CmdToSaveFanout{
+		EventId: "issue-1", Community: fanoutCommunity, Source: source.DefaultGiteeUrl,
+		EventTime:  fanoutEventTime,
+		BusinessId: "https://gitee.com/openeuler/infrastructure/issues/I1", IsDone: &closed,
+	}).Return(nil).Once()
+
+	_, err := service.Fanout(newFanoutEvent("issue-1", source.DefaultGiteeUrl, "issue", `{
+		"IssueEvent": {"Issue": {"HtmlUrl": "https://gitee.com/openeuler/infrastructure/issues/I1",
+			"State": "closed"}}
+	}`))
+	assert.NoError(t, err)
+	mockAdapter.AssertExpectations(t)
+}
+
+func TestFanoutNoRecipient(t *testing.T) {
+	mockAdapter := new(MockMessageFanoutAdapter)
+	service := NewMessageFanoutAppService(mockAdapter, FanoutRules())
+
+	// an event without a rule and without subscribers stores nothing
+	mockAdapter.On("GetSubscribeTarget", fanoutCommunity, source.DefaultEurUrl, noMembership,
+		noMembership).Return([]domain.SubscribeTargetDO{}, nil).Once()
+	result, err := service.Fanout(newFanoutEvent("eur-1", source.DefaultEurUrl, "build", `{}`))
+	assert.NoError(t, err)
+	assert.Equal(t, FanoutResultDTO{}, result)
+	mockAdapter.AssertExpectations(t)
+
+	_, err = service.Fanout(newFanoutEvent("broken", source.DefaultEurUrl, "build", `{`))
+	assert.Error(t, err)
+}
+
+const githubPrFixture = `{
+	"action": "closed",
+	"sender": {"login": "alice", "type": "User"},
+	"repository": {"full_name": "openeuler/infrastructure"},
+	"pull_request": {
+		"html_url": "https://github.com/openeuler/infrastructure/pull/1",
+		"state": "closed",
+		"body": "cc @carol",
+		"user": {"login": "alice"},
+		"requested_reviewers": [{"login": "bob"}]
+	}
+}`
+
+func TestFanoutGithubPullRequest(t *testing.T) {
+	mockAdapter := new(MockMessageFanoutAdapter)
+	service := NewMessageFanoutAppService(mockAdapter, FanoutRules())
+
+	// the filter names of a forge stand for the paths of its data, repo_name
+	// takes repository patterns and my_management is matched against the
+	// repositories synced locally
+	mockAdapter.On("GetSubscribeTarget", fanoutCommunity, source.DefaultGithubUrl, noMembership,
+		[]string{"openeuler/infrastructure"}).Return(
+		[]domain.SubscribeTargetDO{
+			{SubscribeId: 1, EventType: "pr", RecipientId: 9,
+				ModeFilter: datatypes.JSON(`{"pr_state": "closed", "repo_name": "openeuler/*"}`)},
+			{SubscribeId: 2, EventType: "pr", RecipientId: 8,
+				ModeFilter: datatypes.JSON(`{"pr_state": "closed",
+					"repo_name": "openeuler/infrastructure"}`)},
+			{SubscribeId: 3, EventType: "pr", RecipientId: 7, MyManagement: true,
+				ModeFilter: datatypes.JSON(`{"my_management": true}`)},
+			{SubscribeId: 4, EventType: "pr", RecipientId: 6, MySig: false,
+				ModeFilter: datatypes.JSON(`{"my_sig": true}`)},
+			{SubscribeId: 5, EventType: "pr", RecipientId: 5,
+				ModeFilter: datatypes.JSON(`{"repo_name": ["**", "!openeuler/infra*"]}`)},
+			{SubscribeId: 6, EventType: "pr", RecipientId: 4,
+				ModeFilter: datatypes.JSON(`{"repo_name": "ne=openeuler/infrastructure"}`)},
+		}, nil).Once()
+	mockAdapter.On("GetRecipientByLogin", fanoutCommunity, source.ForgeGithub,
+		[]string{"bob", "carol"}).
+		Return([]domain.RecipientLoginDO{{RecipientId: 2, Login: "Bob"}}, nil).Once()
+	mockAdapter.On("SaveFanout", mock.Anything).Return(nil).Once()
+
+	result, err := service.Fanout(newFanoutEvent("github-pr-1", source.DefaultGithubUrl, "pr",
+		githubPrFixture))
+	assert.NoError(t, err)
+	assert.Equal(t, FanoutResultDTO{
+		Follow:  []int64{7, 8, 9},
+		Related: []int64{2},
+		Todo: []TodoFanoutDTO{{BusinessId: "https://github.com/openeuler/infrastructure/pull/1",
+			RecipientId: 2, IsDone: true}},
+	}, result)
+	mockAdapter.AssertExpectations(t)
+}
+
+func TestFanoutCommunitySource(t *testing.T) {
+	assert.NoError(t, community.Init(&community.Config{Communities: []community.Community{
+		{Id: "opengauss", Sources: []string{source.IdGitee}}}}, fanoutCommunity))
+	defer func() { assert.NoError(t, community.Init(&community.Config{}, fanoutCommunity)) }()
+
+	mockAdapter := new(MockMessageFanoutAdapter)
+	service := NewMessageFanoutAppService(mockAdapter, FanoutRules())
+
+	// opengauss does not read eur, its events reach nobody there
+	event := newFanoutEvent("eur-2", source.DefaultEurUrl, "build", `{}`)
+	event.Community = "opengauss"
+	result, err := service.Fanout(event)
+	assert.NoError(t, err)
+	assert.Equal(t, FanoutResultDTO{}, result)
+
+	mockAdapter.On("GetSubscribeTarget", "opengauss", source.DefaultGiteeUrl, noMembership,
+		noMembership).
+		Return([]domain.SubscribeTargetDO{{SubscribeId: 1, RecipientId: 3}}, nil).Once()
+	mockAdapter.On("SaveFanout", mock.MatchedBy(func(cmd domain.CmdToSaveFanout) bool {
+		return cmd.Community == "opengauss"
+	})).Return(nil).Once()
+	event = newFanoutEvent("push-1", source.DefaultGiteeUrl, "push", `{}`)
+	event.Community = "opengauss"
+	result, err = service.Fanout(event)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3}, result.Follow)
+	mockAdapter.AssertExpectations(t)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package app
+
+import (
+	"regexp"
+
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/source"
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+const (
+	LoginRegexp = `^[A-Za-z0-9][A-Za-z0-9_.-]*$`
+	LoginMaxLen = 255
+)
+
+var loginRegex = regexp.MustCompile(LoginRegexp)
+
+type MessageIdentityAppService interface {
+	GetIdentity(userName string, recipientId int64) ([]RecipientIdentityDTO, error)
+	LinkIdentity(userName string, cmd *CmdToLinkIdentity) error
+	UnlinkIdentity(userName string, cmd *CmdToLinkIdentity) error
+}
+
+func NewMessageIdentityAppService(
+	messageIdentityAdapter domain.MessageIdentityAdapter,
+) MessageIdentityAppService {
+	return &messageIdentityAppService{
+		messageIdentityAdapter: messageIdentityAdapter,
+	}
+}
+
+type messageIdentityAppService struct {
+	messageIdentityAdapter domain.MessageIdentityAdapter
+}
+
+func validateIdentity(cmd *CmdToLinkIdentity) error {
+	if !source.IsForge(cmd.Provider) {
+		return xerrors.Errorf("the provider is invalid, provider:%s", cmd.Provider)
+	}
+	if !loginRegex.MatchString(cmd.Login) || len(cmd.Login) > LoginMaxLen {
+		return xerrors.Errorf("the login is invalid, login:%s", cmd.Login)
+	}
+	return nil
+}
+
+func (s *messageIdentityAppService) GetIdentity(userName string, recipientId int64) (
+	[]RecipientIdentityDTO, error) {
+	data, err := s.messageIdentityAdapter.GetIdentity(userName, recipientId)
+	if err != nil {
+		return []RecipientIdentityDTO{}, err
+	}
+	return data, nil
+}
+
+// LinkIdentity links a login to a recipient, it is unverified until the
+// account system syncs it.
+func (s *messageIdentityAppService) LinkIdentity(userName string, cmd *CmdToLinkIdentity) error {
+	if err := validateIdentity(cmd); err != nil {
+		return err
+	}
+	if err := s.messageIdentityAdapter.LinkIdentity(userName, *cmd); err != nil {
+		return xerrors.Errorf("link identity failed, err:%v", err)
+	}
+	return nil
+}
+
+func (s *messageIdentityAppService) UnlinkIdentity(userName string, cmd *CmdToLinkIdentity) error {
+	if err := validateIdentity(cmd); err != nil {
+		return err
+	}
+	if err := s.messageIdentityAdapter.UnlinkIdentity(userName, *cmd); err != nil {
+		return xerrors.Errorf("unlink identity failed, err:%v", err)
+	}
+	return nil
+}
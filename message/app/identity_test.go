@@ -0,0 +1,77 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+// MockMessageIdentityAdapter 是 MessageIdentityAdapter 的模拟实现
+type MockMessageIdentityAdapter struct {
+	mock.Mock
+}
+
+func (m *MockMessageIdentityAdapter) GetIdentity(userName string, recipientId int64) (
+	[]domain.RecipientIdentityDO, error) {
+	args := m.Called(userName, recipientId)
+	return args.Get(0).([]domain.RecipientIdentityDO), args.Error(1)
+}
+
+func (m *MockMessageIdentityAdapter) LinkIdentity(userName string,
+	cmd domain.CmdToLinkIdentity) error {
+	return m.Called(userName, cmd).Error(0)
+}
+
+func (m *MockMessageIdentityAdapter) UnlinkIdentity(userName string,
+	cmd domain.CmdToLinkIdentity) error {
+	return m.Called(userName, cmd).Error(0)
+}
+
+func TestLinkIdentity(t *testing.T) {
+	mockAdapter := new(MockMessageIdentityAdapter)
+	service := NewMessageIdentityAppService(mockAdapter)
+
+	valid := CmdToLinkIdentity{RecipientId: 1, Provider: "github", Login: "octo-cat"}
+	mockAdapter.On("LinkIdentity", "testUser", valid).Return(nil).Once()
+	mockAdapter.On("UnlinkIdentity", "testUser", valid).Return(xerrors.New("not linked")).Once()
+
+	assert.NoError(t, service.LinkIdentity("testUser", &valid))
+	assert.Error(t, service.UnlinkIdentity("testUser", &valid))
+
+	invalid := []CmdToLinkIdentity{
+		{RecipientId: 1, Provider: "gitlab", Login: "octo-cat"},
+		{RecipientId: 1, Provider: "github", Login: ""},
+		{RecipientId: 1, Provider: "github", Login: "-octo"},
+		{RecipientId: 1, Provider: "github", Login: "octo cat"},
+		{RecipientId: 1, Provider: "github", Login: strings.Repeat("a", LoginMaxLen+1)},
+	}
+	for i := range invalid {
+		assert.Error(t, service.LinkIdentity("testUser", &invalid[i]), invalid[i].Login)
+		assert.Error(t, service.UnlinkIdentity("testUser", &invalid[i]), invalid[i].Login)
+	}
+	mockAdapter.AssertExpectations(t)
+}
+
+func TestGetIdentity(t *testing.T) {
+	mockAdapter := new(MockMessageIdentityAdapter)
+	service := NewMessageIdentityAppService(mockAdapter)
+
+	mockAdapter.On("GetIdentity", "testUser", int64(1)).Return(
+		[]domain.RecipientIdentityDO{{RecipientId: 1, Provider: "gitee", Login: "user",
+			Verified: true}}, nil)
+	mockAdapter.On("GetIdentity", "testUser", int64(2)).Return(
+		[]domain.RecipientIdentityDO(nil), xerrors.New("db error"))
+
+	data, err := service.GetIdentity("testUser", 1)
+	assert.NoError(t, err)
+	assert.Len(t, data, 1)
+
+	data, err = service.GetIdentity("testUser", 2)
+	assert.Error(t, err)
+	assert.Empty(t, data)
+}
@@ -0,0 +1,169 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package app
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/community"
+	"github.com/opensourceways/message-manager/common/mail"
+	"github.com/opensourceways/message-manager/common/source"
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+type MessageMailAppService interface {
+	Notify(event CloudEventDTO) error
+	PreviewMail(event CloudEventDTO) (MailDTO, error)
+	SendMail() (DeliveryResultDTO, error)
+	SendTestMail(event CloudEventDTO, to string) error
+}
+
+func NewMessageMailAppService(
+	messageMailAdapter domain.MessageMailAdapter,
+	messageDeliveryAdapter domain.MessageDeliveryAdapter,
+	renderer domain.MailRenderer,
+	sender domain.MailSender,
+	rules []FanoutRule,
+	option DeliveryOption,
+) MessageMailAppService {
+	return &messageMailAppService{
+		messageMailAdapter:     messageMailAdapter,
+		messageDeliveryAdapter: messageDeliveryAdapter,
+		renderer:               renderer,
+		sender:                 sender,
+		rules:                  rules,
+		queue: newDeliveryQueue(messageDeliveryAdapter, domain.DeliveryChannelMail,
+			option),
+	}
+}
+
+type messageMailAppService struct {
+	messageMailAdapter     domain.MessageMailAdapter
+	messageDeliveryAdapter domain.MessageDeliveryAdapter
+	renderer               domain.MailRenderer
+	sender                 domain.MailSender
+	rules                  []FanoutRule
+	queue                  *deliveryQueue
+}
+
+// Notify queues the mail of event to the recipients whose subscriptions match
+// it, once however often it is queued.
+func (s *messageMailAppService) Notify(event CloudEventDTO) error {
+	if event.Community == "" {
+		event.Community = community.DefaultId()
+	}
+	if !readsSource(event.Community, event.Source) {
+		return nil
+	}
+	data, err := decodeEventData(event)
+	if err != nil {
+		return err
+	}
+
+	var filters map[string]string
+	if rule := findFanoutRule(s.rules, event); rule != nil {
+		filters = rule.Filters
+	}
+	sigs, repos := eventMembership(filters, data)
+	targets, err := s.messageMailAdapter.GetMailTarget(event.Community, event.Source, sigs,
+		repos)
+	if err != nil {
+		return err
+	}
+
+	var items []domain.DeliveryDO
+	seen := map[string]bool{}
+	for _, target := range matchSubscribeTarget(targets, event, data, filters) {
+		addr := strings.TrimSpace(target.Target)
+		key := strings.ToLower(addr)
+		if addr == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		items = append(items, domain.DeliveryDO{
+			Channel:     domain.DeliveryChannelMail,
+			EventId:     event.EventId,
+			RecipientId: target.RecipientId,
+			Target:      addr,
+		})
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	content, err := s.render(event, data)
+	if err != nil {
+		logrus.Errorf("render mail of event %s failed, err:%v", event.EventId, err)
+		return nil
+	}
+	b, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		items[i].Content = b
+	}
+	_, err = s.messageDeliveryAdapter.EnqueueDelivery(items)
+	return err
+}
+
+// PreviewMail returns the mail of event as it would be sent.
+func (s *messageMailAppService) PreviewMail(event CloudEventDTO) (MailDTO, error) {
+	data, err := decodeEventData(event)
+	if err != nil {
+		return MailDTO{}, err
+	}
+	return s.render(event, data)
+}
+
+func (s *messageMailAppService) render(event CloudEventDTO, data interface{}) (MailDTO,
+	error) {
+	sourceId := event.Source
+	if src, ok := source.GetByUrl(event.Source); ok {
+		sourceId = src.Id
+	}
+	return s.renderer.Render(mail.Event{
+		Id:          event.EventId,
+		Source:      event.Source,
+		SourceId:    sourceId,
+		Type:        event.Type,
+		Time:        event.EventTime,
+		User:        event.User,
+		Title:       event.Title,
+		Summary:     event.Summary,
+		SourceUrl:   event.SourceUrl,
+		SourceGroup: event.SourceGroup,
+		Community:   event.Community,
+		Data:        data,
+	})
+}
+
+// SendMail sends the queued mails which are due. A mail the server rejects for
+// good is given up at once.
+func (s *messageMailAppService) SendMail() (DeliveryResultDTO, error) {
+	return s.queue.drain(s.deliver)
+}
+
+// SendTestMail sends the mail of event to the address to at once.
+func (s *messageMailAppService) SendTestMail(event CloudEventDTO, to string) error {
+	content, err := s.PreviewMail(event)
+	if err != nil {
+		return err
+	}
+	return s.sender.Send(mail.Message{To: []string{to}, Content: content})
+}
+
+func (s *messageMailAppService) deliver(item domain.DeliveryDO) (bool, error) {
+	var content mail.Content
+	if err := json.Unmarshal(item.Content, &content); err != nil {
+		return true, xerrors.Errorf("invalid mail content, err:%v", err)
+	}
+	err := s.sender.Send(mail.Message{To: []string{item.Target}, Content: content})
+	return mail.IsPermanent(err), err
+}
@@ -0,0 +1,232 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/datatypes"
+
+	"github.com/opensourceways/message-manager/common/mail"
+	"github.com/opensourceways/message-manager/common/mail/mailtest"
+	"github.com/opensourceways/message-manager/common/source"
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+// MockMessageMailAdapter 是 MessageMailAdapter 的模拟实现
+type MockMessageMailAdapter struct {
+	mock.Mock
+}
+
+func (m *MockMessageMailAdapter) GetMailTarget(communityId, source string, sigs,
+	repos []string) ([]domain.SubscribeTargetDO, error) {
+	args := m.Called(communityId, source, sigs, repos)
+	return args.Get(0).([]domain.SubscribeTargetDO), args.Error(1)
+}
+
+// MockMessageDeliveryAdapter 是 MessageDeliveryAdapter 的模拟实现
+type MockMessageDeliveryAdapter struct {
+	mock.Mock
+}
+
+func (m *MockMessageDeliveryAdapter) EnqueueDelivery(items []domain.DeliveryDO) (int64, error) {
+	args := m.Called(items)
+	return int64(len(items)), args.Error(0)
+}
+
+func (m *MockMessageDeliveryAdapter) ClaimDelivery(channel string, limit int,
+	lease time.Duration) ([]domain.DeliveryDO, error) {
+	args := m.Called(channel, limit, lease)
+	return args.Get(0).([]domain.DeliveryDO), args.Error(1)
+}
+
+func (m *MockMessageDeliveryAdapter) CompleteDelivery(id int64) error {
+	return m.Called(id).Error(0)
+}
+
+func (m *MockMessageDeliveryAdapter) RetryDelivery(id int64, lastError string,
+	retryAt time.Time) error {
+	return m.Called(id, retryAt).Error(0)
+}
+
+func (m *MockMessageDeliveryAdapter) FailDelivery(id int64, lastError string) error {
+	return m.Called(id).Error(0)
+}
+
+var mailOption = DeliveryOption{BatchSize: 10, MaxAttempts: 3, RetryInterval: time.Minute,
+	Lease: 5 * time.Minute}
+
+func newMailRenderer(t *testing.T) *mail.Renderer {
+	r, err := mail.NewRenderer([]mail.Template{{
+		Source:    source.IdGitee,
+		EventType: "pr",
+		Subject:   "[{{.SourceId}}] {{.Data.PullRequestEvent.PullRequest.State}} {{.Title}}",
+		Text:      "{{.Summary}}",
+		HTML:      "<p>{{.Summary}}</p>",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestMailNotify(t *testing.T) {
+	mockMail := new(MockMessageMailAdapter)
+	mockDelivery := new(MockMessageDeliveryAdapter)
+	service := NewMessageMailAppService(mockMail, mockDelivery, newMailRenderer(t), nil,
+		FanoutRules(), mailOption)
+
+	mockMail.On("GetMailTarget", fanoutCommunity, source.DefaultGiteeUrl, noMembership,
+		noMembership).Return([]domain.SubscribeTargetDO{
+		{SubscribeId: 1, EventType: "pr", RecipientId: 9, Target: "alice@example.com",
+			ModeFilter: datatypes.JSON(`{"PullRequestEvent.PullRequest.State": "merged"}`)},
+		{SubscribeId: 2, EventType: "pr", RecipientId: 8, Target: "bob@example.com",
+			ModeFilter: datatypes.JSON(`{"PullRequestEvent.PullRequest.State": "open"}`)},
+		{SubscribeId: 3, EventType: "issue", RecipientId: 7, Target: "carol@example.com"},
+		// a mail is sent once to an address
+		{SubscribeId: 4, EventType: "*", RecipientId: 9, Target: "Alice@example.com "},
+		{SubscribeId: 5, EventType: "", RecipientId: 6, Target: "dave@example.com"},
+	}, nil).Once()
+
+	var items []domain.DeliveryDO
+	mockDelivery.On("EnqueueDelivery", mock.Anything).Run(func(args mock.Arguments) {
+		items = args.Get(0).([]domain.DeliveryDO)
+	}).Return(nil).Once()
+
+	event := newFanoutEvent("pr-1", source.DefaultGiteeUrl, "pr", prFixture)
+	event.Title, event.Summary = "PR #1", "alice merged <PR #1>"
+	assert.NoError(t, service.Notify(event))
+	mockMail.AssertExpectations(t)
+	mockDelivery.AssertExpectations(t)
+
+	if !assert.Len(t, items, 2) {
+		return
+	}
+	assert.Equal(t, "alice@example.com", items[0].Target)
+	assert.Equal(t, int64(9), items[0].RecipientId)
+	assert.Equal(t, "dave@example.com", items[1].Target)
+	for _, item := range items {
+		assert.Equal(t, domain.DeliveryChannelMail, item.Channel)
+		assert.Equal(t, "pr-1", item.EventId)
+	}
+
+	var content mail.Content
+	assert.NoError(t, json.Unmarshal(items[0].Content, &content))
+	assert.Equal(t, mail.Content{
+		Subject: "[gitee] merged PR #1",
+		Text:    "alice merged <PR #1>",
+		HTML:    "<p>alice merged &lt;PR #1&gt;</p>",
+	}, content)
+}
+
+func TestMailNotifyRenderFailed(t *testing.T) {
+	mockMail := new(MockMessageMailAdapter)
+	mockDelivery := new(MockMessageDeliveryAdapter)
+	service := NewMessageMailAppService(mockMail, mockDelivery, newMailRenderer(t), nil,
+		FanoutRules(), mailOption)
+
+	// the data misses the state the template prints, the event is skipped
+	// rather than retried for ever
+	mockMail.On("GetMailTarget", fanoutCommunity, source.DefaultGiteeUrl, noMembership,
+		noMembership).Return([]domain.SubscribeTargetDO{
+		{SubscribeId: 1, EventType: "pr", RecipientId: 9, Target: "alice@example.com"},
+	}, nil).Once()
+	assert.NoError(t, service.Notify(newFanoutEvent("pr-2", source.DefaultGiteeUrl, "pr",
+		`{"PullRequestEvent": {}}`)))
+	mockDelivery.AssertNotCalled(t, "EnqueueDelivery", mock.Anything)
+
+	assert.Error(t, service.Notify(newFanoutEvent("broken", source.DefaultGiteeUrl, "pr", `{`)))
+}
+
+func TestPreviewMail(t *testing.T) {
+	service := NewMessageMailAppService(nil, nil, newMailRenderer(t), nil, FanoutRules(),
+		mailOption)
+
+	event := newFanoutEvent("pr-1", source.DefaultGiteeUrl, "pr", prFixture)
+	event.Title = "PR #1"
+	content, err := service.PreviewMail(event)
+	assert.NoError(t, err)
+	assert.Equal(t, "[gitee] merged PR #1", content.Subject)
+
+	// the events of the other types get the default mail
+	event.Type, event.Summary = "issue", "opened"
+	content, err = service.PreviewMail(event)
+	assert.NoError(t, err)
+	assert.Equal(t, "PR #1", content.Subject)
+	assert.Contains(t, content.HTML, "opened")
+}
+
+func TestSendMail(t *testing.T) {
+	server := mailtest.NewServer(mail.TLSStartTLS)
+	defer server.Close()
+	// the first mail fails for now, the second for the last time and the
+	// third for good
+	server.Fail(451, 451, 550)
+
+	mockDelivery := new(MockMessageDeliveryAdapter)
+	service := NewMessageMailAppService(nil, mockDelivery, nil, mail.NewSender(server.Config()),
+		nil, mailOption)
+	now := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	service.(*messageMailAppService).queue.now = func() time.Time { return now }
+
+	content := datatypes.JSON(`{"subject": "PR merged", "text": "merged"}`)
+	mockDelivery.On("ClaimDelivery", domain.DeliveryChannelMail, 10, 5*time.Minute).Return(
+		[]domain.DeliveryDO{
+			{Id: 1, Target: "alice@example.com", Content: content, Attempts: 2},
+			{Id: 2, Target: "bob@example.com", Content: content, Attempts: 3},
+			{Id: 3, Target: "carol@example.com", Content: content, Attempts: 1},
+			{Id: 4, Target: "dave@example.com", Content: content, Attempts: 1},
+			{Id: 5, Target: "erin@example.com", Content: datatypes.JSON(`[]`), Attempts: 1},
+		}, nil).Once()
+	mockDelivery.On("RetryDelivery", int64(1), now.Add(2*time.Minute)).Return(nil).Once()
+	mockDelivery.On("FailDelivery", int64(2)).Return(nil).Once()
+	mockDelivery.On("FailDelivery", int64(3)).Return(nil).Once()
+	mockDelivery.On("CompleteDelivery", int64(4)).Return(nil).Once()
+	mockDelivery.On("FailDelivery", int64(5)).Return(nil).Once()
+
+	result, err := service.SendMail()
+	assert.NoError(t, err)
+	assert.Equal(t, DeliveryResultDTO{Sent: 1, Retried: 1, Failed: 3}, result)
+	mockDelivery.AssertExpectations(t)
+
+	messages := server.Messages()
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, []string{"dave@example.com"}, messages[0].To)
+		assert.True(t, messages[0].TLS)
+	}
+}
+
+func TestSendMailBatches(t *testing.T) {
+	server := mailtest.NewServer(mail.TLSNone)
+	defer server.Close()
+
+	mockDelivery := new(MockMessageDeliveryAdapter)
+	option := mailOption
+	option.BatchSize = 1
+	service := NewMessageMailAppService(nil, mockDelivery, nil, mail.NewSender(server.Config()),
+		nil, option)
+
+	content := datatypes.JSON(`{"subject": "s", "text": "t"}`)
+	mockDelivery.On("ClaimDelivery", domain.DeliveryChannelMail, 1, option.Lease).Return(
+		[]domain.DeliveryDO{{Id: 1, Target: "alice@example.com", Content: content}}, nil).Once()
+	mockDelivery.On("ClaimDelivery", domain.DeliveryChannelMail, 1, option.Lease).Return(
+		[]domain.DeliveryDO{{Id: 2, Target: "bob@example.com", Content: content}}, nil).Once()
+	mockDelivery.On("ClaimDelivery", domain.DeliveryChannelMail, 1, option.Lease).Return(
+		[]domain.DeliveryDO{}, nil).Once()
+	mockDelivery.On("CompleteDelivery", mock.Anything).Return(nil).Twice()
+
+	result, err := service.SendMail()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Sent)
+	assert.Len(t, server.Messages(), 2)
+	mockDelivery.AssertExpectations(t)
+}
+
+func TestRetryInterval(t *testing.T) {
+	assert.Equal(t, time.Minute, retryInterval(time.Minute, 1))
+	assert.Equal(t, 2*time.Minute, retryInterval(time.Minute, 2))
+	assert.Equal(t, 8*time.Minute, retryInterval(time.Minute, 4))
+	assert.Equal(t, maxDeliveryRetryInterval, retryInterval(time.Minute, 30))
+}
@@ -0,0 +1,158 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+// MembershipSyncNever is the status before the first sync.
+const MembershipSyncNever = "never"
+
+type MessageMembershipAppService interface {
+	Sync() (MembershipSyncDTO, error)
+	GetSyncStatus() (MembershipSyncDTO, error)
+}
+
+// NewMessageMembershipAppService returns the service syncing the memberships
+// from directory, a sync running longer than timeout is taken as abandoned.
+func NewMessageMembershipAppService(
+	messageMembershipAdapter domain.MessageMembershipAdapter,
+	directory domain.MembershipDirectory,
+	timeout time.Duration,
+) MessageMembershipAppService {
+	return &messageMembershipAppService{
+		messageMembershipAdapter: messageMembershipAdapter,
+		directory:                directory,
+		timeout:                  timeout,
+	}
+}
+
+type messageMembershipAppService struct {
+	messageMembershipAdapter domain.MessageMembershipAdapter
+	directory                domain.MembershipDirectory
+	timeout                  time.Duration
+}
+
+// membershipRecipient is a recipient to sync, with its verified Gitee logins.
+type membershipRecipient struct {
+	id        int64
+	community string
+	logins    []string
+}
+
+// Sync copies the memberships of the recipients from the directory, unless
+// another replica syncs them.
+func (s *messageMembershipAppService) Sync() (MembershipSyncDTO, error) {
+	id, started, err := s.messageMembershipAdapter.StartMembershipSync(s.timeout)
+	if err != nil {
+		return MembershipSyncDTO{}, err
+	}
+	if !started {
+		logrus.Info("membership sync is running elsewhere, skipped")
+		return s.GetSyncStatus()
+	}
+
+	result := MembershipSyncDTO{Id: id, Status: domain.MembershipSyncFailed}
+	targets, err := s.messageMembershipAdapter.GetMembershipTarget()
+	if err != nil {
+		result.Error = err.Error()
+		return result, s.messageMembershipAdapter.FinishMembershipSync(result)
+	}
+
+	for _, r := range groupMembershipTarget(targets) {
+		result.Recipients++
+		cmd, err := s.lookup(r)
+		if err == nil {
+			err = s.messageMembershipAdapter.SaveMembership(cmd)
+		}
+		if err != nil {
+			logrus.Errorf("sync membership of recipient %d failed, err:%v", r.id, err)
+			result.Failed++
+			result.Error = fmt.Sprintf("recipient %d: %v", r.id, err)
+			continue
+		}
+		result.Sigs += len(cmd.Sigs)
+		result.Repos += len(cmd.Repos)
+	}
+	if err := s.messageMembershipAdapter.PruneMembership(); err != nil {
+		result.Error = err.Error()
+		return result, s.messageMembershipAdapter.FinishMembershipSync(result)
+	}
+
+	switch {
+	case result.Failed == 0:
+		result.Status = domain.MembershipSyncSucceeded
+	case result.Failed < result.Recipients:
+		result.Status = domain.MembershipSyncPartial
+	}
+	return result, s.messageMembershipAdapter.FinishMembershipSync(result)
+}
+
+// lookup returns the memberships of all the logins of r.
+func (s *messageMembershipAppService) lookup(r membershipRecipient) (
+	domain.CmdToSaveMembership, error) {
+	cmd := domain.CmdToSaveMembership{RecipientId: r.id}
+	for _, login := range r.logins {
+		sigs, err := s.directory.GetUserSig(r.community, login)
+		if err != nil {
+			return cmd, err
+		}
+		repos, err := s.directory.GetUserAdminRepos(login)
+		if err != nil {
+			return cmd, err
+		}
+		cmd.Sigs = append(cmd.Sigs, sigs...)
+		cmd.Repos = append(cmd.Repos, repos...)
+	}
+	cmd.Sigs = uniqueString(cmd.Sigs)
+	cmd.Repos = uniqueString(cmd.Repos)
+	return cmd, nil
+}
+
+// GetSyncStatus returns the latest sync.
+func (s *messageMembershipAppService) GetSyncStatus() (MembershipSyncDTO, error) {
+	status, err := s.messageMembershipAdapter.GetMembershipSync()
+	if err != nil {
+		return MembershipSyncDTO{}, err
+	}
+	if status.Id == 0 {
+		status.Status = MembershipSyncNever
+	}
+	return status, nil
+}
+
+// groupMembershipTarget returns the recipients of targets in their order, the
+// targets of a recipient are next to each other.
+func groupMembershipTarget(targets []domain.MembershipTargetDO) []membershipRecipient {
+	var result []membershipRecipient
+	for _, t := range targets {
+		if n := len(result); n != 0 && result[n-1].id == t.RecipientId {
+			result[n-1].logins = append(result[n-1].logins, t.Login)
+			continue
+		}
+		result = append(result, membershipRecipient{id: t.RecipientId, community: t.Community,
+			logins: []string{t.Login}})
+	}
+	return result
+}
+
+// uniqueString returns values without empty ones and duplicates, in order.
+func uniqueString(values []string) []string {
+	seen := map[string]bool{}
+	result := []string{}
+	for _, v := range values {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
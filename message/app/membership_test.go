@@ -0,0 +1,152 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+// MockMessageMembershipAdapter 是 MessageMembershipAdapter 的模拟实现
+type MockMessageMembershipAdapter struct {
+	mock.Mock
+}
+
+func (m *MockMessageMembershipAdapter) StartMembershipSync(timeout time.Duration) (int64, bool,
+	error) {
+	args := m.Called(timeout)
+	return args.Get(0).(int64), args.Bool(1), args.Error(2)
+}
+
+func (m *MockMessageMembershipAdapter) FinishMembershipSync(cmd domain.MembershipSyncDO) error {
+	return m.Called(cmd).Error(0)
+}
+
+func (m *MockMessageMembershipAdapter) GetMembershipSync() (domain.MembershipSyncDO, error) {
+	args := m.Called()
+	return args.Get(0).(domain.MembershipSyncDO), args.Error(1)
+}
+
+func (m *MockMessageMembershipAdapter) GetMembershipTarget() ([]domain.MembershipTargetDO,
+	error) {
+	args := m.Called()
+	return args.Get(0).([]domain.MembershipTargetDO), args.Error(1)
+}
+
+func (m *MockMessageMembershipAdapter) SaveMembership(cmd domain.CmdToSaveMembership) error {
+	return m.Called(cmd).Error(0)
+}
+
+func (m *MockMessageMembershipAdapter) PruneMembership() error {
+	return m.Called().Error(0)
+}
+
+// fakeDirectory answers the lookups from maps, a login missing from sigs fails.
+type fakeDirectory struct {
+	sigs  map[string][]string
+	repos map[string][]string
+}
+
+func (d fakeDirectory) GetUserSig(communityId, userName string) ([]string, error) {
+	sigs, ok := d.sigs[communityId+"/"+userName]
+	if !ok {
+		return nil, xerrors.New("directory is unavailable")
+	}
+	return sigs, nil
+}
+
+func (d fakeDirectory) GetUserAdminRepos(login string) ([]string, error) {
+	return d.repos[login], nil
+}
+
+const membershipTimeout = 30 * time.Minute
+
+func TestMembershipSync(t *testing.T) {
+	mockAdapter := new(MockMessageMembershipAdapter)
+	service := NewMessageMembershipAppService(mockAdapter, fakeDirectory{
+		sigs: map[string][]string{
+			"openeuler/alice":  {"Infra", "sig-ops"},
+			"openeuler/alice2": {"Infra"},
+			"opengauss/bob":    {},
+		},
+		repos: map[string][]string{"alice": {"openeuler/infrastructure"}},
+	}, membershipTimeout)
+
+	mockAdapter.On("StartMembershipSync", membershipTimeout).Return(int64(3), true, nil).Once()
+	mockAdapter.On("GetMembershipTarget").Return([]domain.MembershipTargetDO{
+		{RecipientId: 1, Community: "openeuler", Login: "alice"},
+		{RecipientId: 1, Community: "openeuler", Login: "alice2"},
+		{RecipientId: 2, Community: "opengauss", Login: "bob"},
+		{RecipientId: 4, Community: "openeuler", Login: "carol"},
+	}, nil).Once()
+	mockAdapter.On("SaveMembership", domain.CmdToSaveMembership{RecipientId: 1,
+		Sigs: []string{"Infra", "sig-ops"}, Repos: []string{"openeuler/infrastructure"}}).
+		Return(nil).Once()
+	mockAdapter.On("SaveMembership", domain.CmdToSaveMembership{RecipientId: 2,
+		Sigs: []string{}, Repos: []string{}}).Return(nil).Once()
+	mockAdapter.On("PruneMembership").Return(nil).Once()
+
+	// the lookup of carol fails, the memberships stored for carol are kept
+	want := domain.MembershipSyncDO{Id: 3, Status: domain.MembershipSyncPartial, Recipients: 3,
+		Failed: 1, Sigs: 2, Repos: 1,
+		Error: "recipient 4: directory is unavailable"}
+	mockAdapter.On("FinishMembershipSync", want).Return(nil).Once()
+
+	result, err := service.Sync()
+	assert.NoError(t, err)
+	assert.Equal(t, want, result)
+	mockAdapter.AssertExpectations(t)
+}
+
+func TestMembershipSyncRunningElsewhere(t *testing.T) {
+	mockAdapter := new(MockMessageMembershipAdapter)
+	service := NewMessageMembershipAppService(mockAdapter, fakeDirectory{}, membershipTimeout)
+
+	running := domain.MembershipSyncDO{Id: 2, Status: domain.MembershipSyncRunning}
+	mockAdapter.On("StartMembershipSync", membershipTimeout).Return(int64(0), false, nil).Once()
+	mockAdapter.On("GetMembershipSync").Return(running, nil).Once()
+
+	result, err := service.Sync()
+	assert.NoError(t, err)
+	assert.Equal(t, running, result)
+	mockAdapter.AssertExpectations(t)
+}
+
+func TestMembershipSyncFailed(t *testing.T) {
+	mockAdapter := new(MockMessageMembershipAdapter)
+	service := NewMessageMembershipAppService(mockAdapter, fakeDirectory{}, membershipTimeout)
+
+	mockAdapter.On("StartMembershipSync", membershipTimeout).Return(int64(5), true, nil).Once()
+	mockAdapter.On("GetMembershipTarget").Return([]domain.MembershipTargetDO{
+		{RecipientId: 1, Community: "openeuler", Login: "alice"},
+	}, nil).Once()
+	mockAdapter.On("PruneMembership").Return(nil).Once()
+	mockAdapter.On("FinishMembershipSync", mock.MatchedBy(func(cmd domain.MembershipSyncDO) bool {
+		return cmd.Id == 5 && cmd.Status == domain.MembershipSyncFailed && cmd.Failed == 1
+	})).Return(nil).Once()
+
+	result, err := service.Sync()
+	assert.NoError(t, err)
+	assert.Equal(t, domain.MembershipSyncFailed, result.Status)
+
+	mockAdapter.On("StartMembershipSync", membershipTimeout).Return(int64(0), false,
+		xerrors.New("db error")).Once()
+	_, err = service.Sync()
+	assert.Error(t, err)
+	mockAdapter.AssertExpectations(t)
+}
+
+func TestMembershipGetSyncStatus(t *testing.T) {
+	mockAdapter := new(MockMessageMembershipAdapter)
+	service := NewMessageMembershipAppService(mockAdapter, fakeDirectory{}, membershipTimeout)
+
+	mockAdapter.On("GetMembershipSync").Return(domain.MembershipSyncDO{}, nil).Once()
+	result, err := service.GetSyncStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, MembershipSyncNever, result.Status)
+	mockAdapter.AssertExpectations(t)
+}
@@ -5,48 +5,67 @@ Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
 package app
 
 import (
+	"fmt"
+
 	"golang.org/x/xerrors"
 
+	"github.com/opensourceways/message-manager/common/community"
+	"github.com/opensourceways/message-manager/common/domain/allerror"
+	"github.com/opensourceways/message-manager/common/source"
 	"github.com/opensourceways/message-manager/message/domain"
 )
 
+const errorCodeSourceNotFound = "source_not_found"
+
 type MessageListAppService interface {
 	CountAllUnReadMessage(userName string) ([]CountDTO, error)
 	SetMessageIsRead(userName string, eventId string) error
 	RemoveMessage(userName string, eventId string) error
 
-	GetAllToDoMessage(userName string, giteeUsername string, isDone *bool,
-		pageNum, countPerPage int, startTime string, isRead *bool) ([]MessageListDTO, int64, error)
-	GetAllAboutMessage(userName string, giteeUsername string, isBot *bool,
-		pageNum, countPerPage int, startTime string, isRead *bool) ([]MessageListDTO, int64, error)
-	GetAllWatchMessage(userName string, giteeUsername string,
-		pageNum, countPerPage int, startTime string, isRead *bool) ([]MessageListDTO, int64, error)
+	GetAllToDoMessage(userName string, isDone *bool,
+		pageNum, countPerPage int, startTime string, isRead *bool, cursor string) ([]MessageListDTO,
+		int64, error)
+	GetAllAboutMessage(userName string, isBot *bool,
+		pageNum, countPerPage int, startTime string, isRead *bool, cursor string) ([]MessageListDTO,
+		int64, error)
+	GetAllWatchMessage(userName string,
+		pageNum, countPerPage int, startTime string, isRead *bool, cursor string) ([]MessageListDTO,
+		int64, error)
 
-	CountAllMessage(userName string, giteeUsername string) (CountDataDTO, error)
+	CountAllMessage(userName string) (CountDataDTO, error)
 
 	GetForumSystemMessage(userName string, pageNum, countPerPage int,
-		startTime string, isRead *bool) ([]MessageListDTO, int64, error)
+		startTime string, isRead *bool, cursor string) ([]MessageListDTO, int64, error)
 	GetForumAboutMessage(userName string, isBot *bool, pageNum,
-		countPerPage int, startTime string, isRead *bool) ([]MessageListDTO, int64, error)
+		countPerPage int, startTime string, isRead *bool, cursor string) (
+		[]MessageListDTO, int64, error)
 	GetMeetingToDoMessage(userName string, filter int, pageNum, countPerPage int,
-		startTime string, isRead *bool) ([]MessageListDTO, int64, error)
-	GetCVEToDoMessage(userName string, giteeUsername string, isDone *bool,
-		pageNum, countPerPage int, startTime string, isRead *bool) ([]MessageListDTO, int64, error)
-	GetCVEMessage(userName string, giteeUsername string,
-		pageNum, countPerPage int, startTime string, isRead *bool) ([]MessageListDTO, int64, error)
-	GetIssueToDoMessage(userName string, giteeUsername string, isDone *bool,
-		pageNum, countPerPage int, startTime string, isRead *bool) ([]MessageListDTO, int64, error)
-	GetPullRequestToDoMessage(userName string, giteeUsername string, isDone *bool,
-		pageNum, countPerPage int, startTime string, isRead *bool) ([]MessageListDTO, int64, error)
-	GetGiteeAboutMessage(userName string, giteeUsername string, isBot *bool,
-		pageNum, countPerPage int, startTime string, isRead *bool) ([]MessageListDTO, int64, error)
-	GetGiteeMessage(userName string, giteeUsername string, pageNum,
-		countPerPage int, startTime string, isRead *bool) ([]MessageListDTO, int64, error)
+		startTime string, isRead *bool, cursor string) ([]MessageListDTO, int64, error)
+	GetCVEToDoMessage(userName string, isDone *bool,
+		pageNum, countPerPage int, startTime string, isRead *bool, cursor string) (
+		[]MessageListDTO, int64, error)
+	GetCVEMessage(userName string,
+		pageNum, countPerPage int, startTime string, isRead *bool, cursor string) (
+		[]MessageListDTO, int64, error)
+	GetIssueToDoMessage(userName string, isDone *bool,
+		pageNum, countPerPage int, startTime string, isRead *bool, cursor string) (
+		[]MessageListDTO, int64, error)
+	GetPullRequestToDoMessage(userName string, isDone *bool,
+		pageNum, countPerPage int, startTime string, isRead *bool, cursor string) (
+		[]MessageListDTO, int64, error)
+	GetGiteeAboutMessage(userName string, isBot *bool,
+		pageNum, countPerPage int, startTime string, isRead *bool, cursor string) (
+		[]MessageListDTO, int64, error)
+	GetGiteeMessage(userName string, pageNum,
+		countPerPage int, startTime string, isRead *bool, cursor string) (
+		[]MessageListDTO, int64, error)
 	GetEurMessage(userName string, pageNum, countPerPage int,
-		startTime string, isRead *bool) ([]MessageListDTO, int64, error)
+		startTime string, isRead *bool, cursor string) ([]MessageListDTO, int64, error)
+	GetSourceMessage(sourceId string, cmd CmdToGetSourceMessage) ([]MessageListDTO, int64,
+		error)
 
-	GetAllMessage(userName string, pageNum, countPerPage int, isRead *bool) ([]MessageListDTO,
-		int64, error)
+	GetAllMessage(userName string, pageNum, countPerPage int, isRead *bool,
+		cursor string) ([]MessageListDTO, int64, error)
 }
 
 func NewMessageListAppService(
@@ -83,31 +102,33 @@ func (s *messageListAppService) RemoveMessage(userName string, eventId string) e
 	return nil
 }
 
-func (s *messageListAppService) GetAllToDoMessage(userName string, giteeUsername string,
-	isDone *bool, pageNum, countPerPage int, startTime string, isRead *bool) (
+func (s *messageListAppService) GetAllToDoMessage(userName string,
+	isDone *bool, pageNum, countPerPage int, startTime string, isRead *bool, cursor string) (
 	[]MessageListDTO, int64, error) {
-	response, count, err := s.messageListAdapter.GetAllToDoMessage(userName, giteeUsername,
-		isDone, pageNum, countPerPage, startTime, isRead)
+	response, count, err := s.messageListAdapter.GetAllToDoMessage(userName,
+		isDone, pageNum, countPerPage, startTime, isRead, cursor)
 	if err != nil {
 		return []MessageListDTO{}, 0, err
 	}
 	return response, count, nil
 }
 
-func (s *messageListAppService) GetAllAboutMessage(userName string, giteeUsername string,
-	isBot *bool, pageNum, countPerPage int, startTime string, isRead *bool) ([]MessageListDTO, int64, error) {
-	response, count, err := s.messageListAdapter.GetAllAboutMessage(userName, giteeUsername,
-		isBot, pageNum, countPerPage, startTime, isRead)
+func (s *messageListAppService) GetAllAboutMessage(userName string,
+	isBot *bool, pageNum, countPerPage int, startTime string, isRead *bool, cursor string) (
+	[]MessageListDTO, int64, error) {
+	response, count, err := s.messageListAdapter.GetAllAboutMessage(userName,
+		isBot, pageNum, countPerPage, startTime, isRead, cursor)
 	if err != nil {
 		return []MessageListDTO{}, 0, err
 	}
 	return response, count, nil
 }
 
-func (s *messageListAppService) GetAllWatchMessage(userName string, giteeUsername string,
-	pageNum, countPerPage int, startTime string, isRead *bool) ([]MessageListDTO, int64, error) {
-	response, count, err := s.messageListAdapter.GetAllWatchMessage(userName, giteeUsername,
-		pageNum, countPerPage, startTime, isRead)
+func (s *messageListAppService) GetAllWatchMessage(userName string,
+	pageNum, countPerPage int, startTime string, isRead *bool, cursor string) ([]MessageListDTO,
+	int64, error) {
+	response, count, err := s.messageListAdapter.GetAllWatchMessage(userName,
+		pageNum, countPerPage, startTime, isRead, cursor)
 	if err != nil {
 		return []MessageListDTO{}, 0, err
 	}
@@ -115,9 +136,9 @@ func (s *messageListAppService) GetAllWatchMessage(userName string, giteeUsernam
 }
 
 func (s *messageListAppService) GetForumSystemMessage(userName string, pageNum, countPerPage int,
-	startTime string, isRead *bool) ([]MessageListDTO, int64, error) {
+	startTime string, isRead *bool, cursor string) ([]MessageListDTO, int64, error) {
 	response, count, err := s.messageListAdapter.GetForumSystemMessage(userName, pageNum,
-		countPerPage, startTime, isRead)
+		countPerPage, startTime, isRead, cursor)
 	if err != nil {
 		return []MessageListDTO{}, 0, err
 	}
@@ -125,9 +146,10 @@ func (s *messageListAppService) GetForumSystemMessage(userName string, pageNum,
 }
 
 func (s *messageListAppService) GetForumAboutMessage(userName string, isBot *bool, pageNum,
-	countPerPage int, startTime string, isRead *bool) ([]MessageListDTO, int64, error) {
+	countPerPage int, startTime string, isRead *bool, cursor string) (
+	[]MessageListDTO, int64, error) {
 	response, count, err := s.messageListAdapter.GetForumAboutMessage(userName, isBot, pageNum,
-		countPerPage, startTime, isRead)
+		countPerPage, startTime, isRead, cursor)
 	if err != nil {
 		return []MessageListDTO{}, 0, err
 	}
@@ -135,70 +157,77 @@ func (s *messageListAppService) GetForumAboutMessage(userName string, isBot *boo
 }
 
 func (s *messageListAppService) GetMeetingToDoMessage(userName string, filter int, pageNum,
-	countPerPage int, startTime string, isRead *bool) ([]MessageListDTO, int64, error) {
+	countPerPage int, startTime string, isRead *bool, cursor string) (
+	[]MessageListDTO, int64, error) {
 	response, count, err := s.messageListAdapter.GetMeetingToDoMessage(userName, filter,
-		pageNum, countPerPage, startTime, isRead)
+		pageNum, countPerPage, startTime, isRead, cursor)
 	if err != nil {
 		return []MessageListDTO{}, 0, err
 	}
 	return response, count, nil
 }
 
-func (s *messageListAppService) GetCVEToDoMessage(userName string, giteeUsername string,
-	isDone *bool, pageNum, countPerPage int, startTime string, isRead *bool) ([]MessageListDTO, int64, error) {
-	response, count, err := s.messageListAdapter.GetCVEToDoMessage(userName, giteeUsername,
-		isDone, pageNum, countPerPage, startTime, isRead)
+func (s *messageListAppService) GetCVEToDoMessage(userName string,
+	isDone *bool, pageNum, countPerPage int, startTime string, isRead *bool, cursor string) (
+	[]MessageListDTO, int64, error) {
+	response, count, err := s.messageListAdapter.GetCVEToDoMessage(userName,
+		isDone, pageNum, countPerPage, startTime, isRead, cursor)
 	if err != nil {
 		return []MessageListDTO{}, 0, err
 	}
 	return response, count, nil
 }
 
-func (s *messageListAppService) GetCVEMessage(userName string, giteeUsername string, pageNum,
-	countPerPage int, startTime string, isRead *bool) ([]MessageListDTO, int64, error) {
-	response, count, err := s.messageListAdapter.GetCVEMessage(userName, giteeUsername, pageNum,
-		countPerPage, startTime, isRead)
+func (s *messageListAppService) GetCVEMessage(userName string, pageNum,
+	countPerPage int, startTime string, isRead *bool, cursor string) (
+	[]MessageListDTO, int64, error) {
+	response, count, err := s.messageListAdapter.GetCVEMessage(userName, pageNum,
+		countPerPage, startTime, isRead, cursor)
 	if err != nil {
 		return []MessageListDTO{}, 0, err
 	}
 	return response, count, nil
 }
 
-func (s *messageListAppService) GetIssueToDoMessage(userName string, giteeUsername string,
-	isDone *bool, pageNum, countPerPage int, startTime string, isRead *bool) ([]MessageListDTO, int64, error) {
-	response, count, err := s.messageListAdapter.GetIssueToDoMessage(userName, giteeUsername,
-		isDone, pageNum, countPerPage, startTime, isRead)
+func (s *messageListAppService) GetIssueToDoMessage(userName string,
+	isDone *bool, pageNum, countPerPage int, startTime string, isRead *bool, cursor string) (
+	[]MessageListDTO, int64, error) {
+	response, count, err := s.messageListAdapter.GetIssueToDoMessage(userName,
+		isDone, pageNum, countPerPage, startTime, isRead, cursor)
 	if err != nil {
 		return []MessageListDTO{}, 0, err
 	}
 	return response, count, nil
 }
 
-func (s *messageListAppService) GetPullRequestToDoMessage(userName string, giteeUsername string,
-	isDone *bool, pageNum, countPerPage int, startTime string, isRead *bool) ([]MessageListDTO,
+func (s *messageListAppService) GetPullRequestToDoMessage(userName string,
+	isDone *bool, pageNum, countPerPage int, startTime string, isRead *bool, cursor string) (
+	[]MessageListDTO,
 	int64, error) {
-	response, count, err := s.messageListAdapter.GetPullRequestToDoMessage(userName,
-		giteeUsername, isDone, pageNum, countPerPage, startTime, isRead)
+	response, count, err := s.messageListAdapter.GetPullRequestToDoMessage(userName, isDone,
+		pageNum, countPerPage, startTime, isRead, cursor)
 	if err != nil {
 		return []MessageListDTO{}, 0, err
 	}
 	return response, count, nil
 }
 
-func (s *messageListAppService) GetGiteeAboutMessage(userName string, giteeUsername string,
-	isBot *bool, pageNum, countPerPage int, startTime string, isRead *bool) ([]MessageListDTO, int64, error) {
-	response, count, err := s.messageListAdapter.GetGiteeAboutMessage(userName, giteeUsername,
-		isBot, pageNum, countPerPage, startTime, isRead)
+func (s *messageListAppService) GetGiteeAboutMessage(userName string,
+	isBot *bool, pageNum, countPerPage int, startTime string, isRead *bool, cursor string) (
+	[]MessageListDTO, int64, error) {
+	response, count, err := s.messageListAdapter.GetGiteeAboutMessage(userName,
+		isBot, pageNum, countPerPage, startTime, isRead, cursor)
 	if err != nil {
 		return []MessageListDTO{}, 0, err
 	}
 	return response, count, nil
 }
 
-func (s *messageListAppService) GetGiteeMessage(userName string, giteeUsername string, pageNum,
-	countPerPage int, startTime string, isRead *bool) ([]MessageListDTO, int64, error) {
-	response, count, err := s.messageListAdapter.GetGiteeMessage(userName, giteeUsername,
-		pageNum, countPerPage, startTime, isRead)
+func (s *messageListAppService) GetGiteeMessage(userName string, pageNum,
+	countPerPage int, startTime string, isRead *bool, cursor string) (
+	[]MessageListDTO, int64, error) {
+	response, count, err := s.messageListAdapter.GetGiteeMessage(userName,
+		pageNum, countPerPage, startTime, isRead, cursor)
 	if err != nil {
 		return []MessageListDTO{}, 0, err
 	}
@@ -206,17 +235,39 @@ func (s *messageListAppService) GetGiteeMessage(userName string, giteeUsername s
 }
 
 func (s *messageListAppService) GetEurMessage(userName string, pageNum, countPerPage int,
-	startTime string, isRead *bool) ([]MessageListDTO, int64, error) {
+	startTime string, isRead *bool, cursor string) ([]MessageListDTO, int64, error) {
 	response, count, err := s.messageListAdapter.GetEurMessage(userName, pageNum, countPerPage,
-		startTime, isRead)
+		startTime, isRead, cursor)
+	if err != nil {
+		return []MessageListDTO{}, 0, err
+	}
+	return response, count, nil
+}
+
+// GetSourceMessage lists a category of the registered source sourceId, it is
+// not found if the source does not feed the category or send the event type.
+func (s *messageListAppService) GetSourceMessage(sourceId string, cmd CmdToGetSourceMessage) (
+	[]MessageListDTO, int64, error) {
+	src, ok := source.Get(sourceId)
+	communityId, _ := community.SplitUserKey(cmd.UserName)
+	if !ok || !readsSource(communityId, src.Url) || !src.HasCategory(cmd.Category) ||
+		(cmd.EventType != "" && !src.HasEventType(cmd.EventType)) {
+		return []MessageListDTO{}, 0, allerror.NewNotFound(errorCodeSourceNotFound,
+			fmt.Sprintf("source %s has no %s messages of type %s", sourceId, cmd.Category,
+				cmd.EventType))
+	}
+	cmd.Source = src.Url
+	cmd.Schedule = src.Schedule
+
+	response, count, err := s.messageListAdapter.GetSourceMessage(cmd)
 	if err != nil {
 		return []MessageListDTO{}, 0, err
 	}
 	return response, count, nil
 }
 
-func (s *messageListAppService) CountAllMessage(userName string, giteeUsername string) (CountDataDTO, error) {
-	data, err := s.messageListAdapter.CountAllMessage(userName, giteeUsername)
+func (s *messageListAppService) CountAllMessage(userName string) (CountDataDTO, error) {
+	data, err := s.messageListAdapter.CountAllMessage(userName)
 	if err != nil {
 		return CountDataDTO{}, err
 	}
@@ -224,8 +275,9 @@ func (s *messageListAppService) CountAllMessage(userName string, giteeUsername s
 }
 
 func (s *messageListAppService) GetAllMessage(userName string, pageNum, countPerPage int,
-	isRead *bool) ([]MessageListDTO, int64, error) {
-	response, count, err := s.messageListAdapter.GetAllMessage(userName, pageNum, countPerPage, isRead)
+	isRead *bool, cursor string) ([]MessageListDTO, int64, error) {
+	response, count, err := s.messageListAdapter.GetAllMessage(userName, pageNum, countPerPage,
+		isRead, cursor)
 	if err != nil {
 		return []MessageListDTO{}, 0, err
 	}
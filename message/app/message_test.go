@@ -3,6 +3,9 @@ package app
 import (
 	"testing"
 
+	"github.com/opensourceways/message-manager/common/community"
+	"github.com/opensourceways/message-manager/common/domain/allerror"
+	"github.com/opensourceways/message-manager/common/source"
 	"github.com/opensourceways/message-manager/message/domain"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -14,79 +17,101 @@ type MockMessageListAdapter struct {
 	mock.Mock
 }
 
-func (m *MockMessageListAdapter) GetAllToDoMessage(userName, giteeUsername string, isDone *bool, pageNum, countPerPage int, startTime string, isRead *bool) ([]domain.MessageListDO, int64, error) {
+func (m *MockMessageListAdapter) GetAllToDoMessage(userName string, isDone *bool, pageNum, countPerPage int, startTime string, isRead *bool, cursor string) ([]domain.MessageListDO, int64, error) {
 	//TODO implement me
 	panic("implement me")
 }
 
-func (m *MockMessageListAdapter) GetAllAboutMessage(userName, giteeUsername string, isBot *bool, pageNum, countPerPage int, startTime string, isRead *bool) ([]domain.MessageListDO, int64, error) {
+func (m *MockMessageListAdapter) GetAllAboutMessage(userName string, isBot *bool, pageNum, countPerPage int, startTime string, isRead *bool, cursor string) ([]domain.MessageListDO, int64, error) {
 	//TODO implement me
 	panic("implement me")
 }
 
-func (m *MockMessageListAdapter) GetAllWatchMessage(userName, giteeUsername string, pageNum, countPerPage int, startTime string, isRead *bool) ([]domain.MessageListDO, int64, error) {
+func (m *MockMessageListAdapter) GetAllWatchMessage(userName string, pageNum, countPerPage int, startTime string, isRead *bool, cursor string) ([]domain.MessageListDO, int64, error) {
 	//TODO implement me
 	panic("implement me")
 }
 
-func (m *MockMessageListAdapter) GetForumSystemMessage(userName string, pageNum, countPerPage int, startTime string, isRead *bool) ([]domain.MessageListDO, int64, error) {
+func (m *MockMessageListAdapter) GetForumSystemMessage(userName string, pageNum, countPerPage int, startTime string, isRead *bool, cursor string) ([]domain.MessageListDO, int64, error) {
 	//TODO implement me
 	panic("implement me")
 }
 
-func (m *MockMessageListAdapter) GetForumAboutMessage(userName string, isBot *bool, pageNum, countPerPage int, startTime string, isRead *bool) ([]domain.MessageListDO, int64, error) {
+func (m *MockMessageListAdapter) GetForumAboutMessage(userName string, isBot *bool, pageNum, countPerPage int, startTime string, isRead *bool, cursor string) ([]domain.MessageListDO, int64, error) {
 	//TODO implement me
 	panic("implement me")
 }
 
-func (m *MockMessageListAdapter) GetMeetingToDoMessage(userName string, filter int, pageNum, countPerPage int, startTime string, isRead *bool) ([]domain.MessageListDO, int64, error) {
+func (m *MockMessageListAdapter) GetMeetingToDoMessage(userName string, filter int, pageNum, countPerPage int, startTime string, isRead *bool, cursor string) ([]domain.MessageListDO, int64, error) {
 	//TODO implement me
 	panic("implement me")
 }
 
-func (m *MockMessageListAdapter) GetCVEToDoMessage(userName, giteeUsername string, isDone *bool, pageNum, countPerPage int, startTime string, isRead *bool) ([]domain.MessageListDO, int64, error) {
+func (m *MockMessageListAdapter) GetCVEToDoMessage(userName string, isDone *bool, pageNum, countPerPage int, startTime string, isRead *bool, cursor string) ([]domain.MessageListDO, int64, error) {
 	//TODO implement me
 	panic("implement me")
 }
 
-func (m *MockMessageListAdapter) GetCVEMessage(userName, giteeUsername string, pageNum, countPerPage int, startTime string, isRead *bool) ([]domain.MessageListDO, int64, error) {
+func (m *MockMessageListAdapter) GetCVEMessage(userName string, pageNum, countPerPage int, startTime string, isRead *bool, cursor string) ([]domain.MessageListDO, int64, error) {
 	//TODO implement me
 	panic("implement me")
 }
 
-func (m *MockMessageListAdapter) GetIssueToDoMessage(userName, giteeUsername string, isDone *bool, pageNum, countPerPage int, startTime string, isRead *bool) ([]domain.MessageListDO, int64, error) {
+func (m *MockMessageListAdapter) GetIssueToDoMessage(userName string, isDone *bool, pageNum, countPerPage int, startTime string, isRead *bool, cursor string) ([]domain.MessageListDO, int64, error) {
 	//TODO implement me
 	panic("implement me")
 }
 
-func (m *MockMessageListAdapter) GetPullRequestToDoMessage(userName, giteeUsername string, isDone *bool, pageNum, countPerPage int, startTime string, isRead *bool) ([]domain.MessageListDO, int64, error) {
+func (m *MockMessageListAdapter) GetPullRequestToDoMessage(userName string, isDone *bool, pageNum, countPerPage int, startTime string, isRead *bool, cursor string) ([]domain.MessageListDO, int64, error) {
 	//TODO implement me
 	panic("implement me")
 }
 
-func (m *MockMessageListAdapter) GetGiteeAboutMessage(userName, giteeUsername string, isBot *bool, pageNum, countPerPage int, startTime string, isRead *bool) ([]domain.MessageListDO, int64, error) {
+func (m *MockMessageListAdapter) GetGiteeAboutMessage(userName string, isBot *bool, pageNum, countPerPage int, startTime string, isRead *bool, cursor string) ([]domain.MessageListDO, int64, error) {
 	//TODO implement me
 	panic("implement me")
 }
 
-func (m *MockMessageListAdapter) GetGiteeMessage(userName, giteeUsername string, pageNum, countPerPage int, startTime string, isRead *bool) ([]domain.MessageListDO, int64, error) {
+func (m *MockMessageListAdapter) GetGiteeMessage(userName string, pageNum, countPerPage int, startTime string, isRead *bool, cursor string) ([]domain.MessageListDO, int64, error) {
 	//TODO implement me
 	panic("implement me")
 }
 
-func (m *MockMessageListAdapter) GetEurMessage(userName string, pageNum, countPerPage int, startTime string, isRead *bool) ([]domain.MessageListDO, int64, error) {
+func (m *MockMessageListAdapter) GetEurMessage(userName string, pageNum, countPerPage int, startTime string, isRead *bool, cursor string) ([]domain.MessageListDO, int64, error) {
 	//TODO implement me
 	panic("implement me")
 }
 
-func (m *MockMessageListAdapter) CountAllMessage(username, giteeUsername string) (domain.CountDataDO, error) {
-	//TODO implement me
-	panic("implement me")
+func (m *MockMessageListAdapter) GetSourceMessage(cmd domain.CmdToGetSourceMessage) ([]domain.MessageListDO, int64, error) {
+	args := m.Called(cmd)
+	return args.Get(0).([]domain.MessageListDO), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockMessageListAdapter) GetAllMessage(username string, pageNum, countPerPage int, isRead *bool) ([]domain.MessageListDO, int64, error) {
-	//TODO implement me
-	panic("implement me")
+func (m *MockMessageListAdapter) CountAllMessage(username string) (domain.CountDataDO, error) {
+	args := m.Called(username)
+	return args.Get(0).(domain.CountDataDO), args.Error(1)
+}
+
+func (m *MockMessageListAdapter) GetUnreadCounter(username string) (
+	[]domain.UnreadCounterDO, error) {
+	args := m.Called(username)
+	return args.Get(0).([]domain.UnreadCounterDO), args.Error(1)
+}
+
+func (m *MockMessageListAdapter) GetAllMessage(username string, pageNum, countPerPage int, isRead *bool, cursor string) ([]domain.MessageListDO, int64, error) {
+	args := m.Called(username, pageNum, countPerPage, isRead, cursor)
+	return args.Get(0).([]domain.MessageListDO), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockMessageListAdapter) GetLatestStreamSeq(username string) (int64, error) {
+	args := m.Called(username)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockMessageListAdapter) GetAllMessageAfter(username string, after int64, limit int) (
+	[]domain.MessageListDO, error) {
+	args := m.Called(username, after, limit)
+	return args.Get(0).([]domain.MessageListDO), args.Error(1)
 }
 
 func (m *MockMessageListAdapter) CountAllUnReadMessage(userName string) ([]CountDTO, error) {
@@ -132,6 +157,25 @@ func TestCountAllUnReadMessage(t *testing.T) {
 
 }
 
+func TestCountAllMessage(t *testing.T) {
+	mockAdapter := new(MockMessageListAdapter)
+	service := NewMessageListAppService(mockAdapter)
+
+	mockData := CountDataDTO{TodoCount: 3, MeetingCount: 1, AboutCount: 2, WatchCount: 5}
+	mockAdapter.On("CountAllMessage", "testUser").Return(mockData, nil)
+	mockAdapter.On("CountAllMessage", "errUser").Return(CountDataDTO{},
+		xerrors.Errorf("查询失败"))
+
+	data, err := service.CountAllMessage("testUser")
+	assert.NoError(t, err)
+	assert.Equal(t, mockData, data)
+
+	data, err = service.CountAllMessage("errUser")
+	assert.Error(t, err)
+	assert.Equal(t, CountDataDTO{}, data)
+	mockAdapter.AssertExpectations(t)
+}
+
 func TestSetMessageIsRead(t *testing.T) {
 	mockAdapter := new(MockMessageListAdapter)
 	service := NewMessageListAppService(mockAdapter)
@@ -186,3 +230,41 @@ func TestRemoveMessage_Error(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "set message is_read failed")
 }
+
+func TestGetSourceMessage(t *testing.T) {
+	mockAdapter := new(MockMessageListAdapter)
+	service := NewMessageListAppService(mockAdapter)
+
+	data := []domain.MessageListDO{{EventId: "event1"}}
+	mockAdapter.On("GetSourceMessage", CmdToGetSourceMessage{UserName: "testUser",
+		Source: source.DefaultMeetingUrl, Category: source.CategoryTodo, Schedule: true,
+		PageNum: 1, CountPerPage: 10}).Return(data, int64(1), nil).Once()
+
+	result, count, err := service.GetSourceMessage(source.IdMeeting, CmdToGetSourceMessage{
+		UserName: "testUser", Category: source.CategoryTodo, PageNum: 1, CountPerPage: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, data, result)
+	assert.Equal(t, int64(1), count)
+	mockAdapter.AssertExpectations(t)
+
+	for _, cmd := range []struct {
+		source string
+		cmd    CmdToGetSourceMessage
+	}{
+		{"unknown", CmdToGetSourceMessage{Category: source.CategoryFollow}},
+		{source.IdMeeting, CmdToGetSourceMessage{Category: source.CategoryFollow}},
+		{source.IdGitee, CmdToGetSourceMessage{Category: "unknown"}},
+		{source.IdGitee, CmdToGetSourceMessage{Category: source.CategoryTodo,
+			EventType: "meeting"}},
+	} {
+		_, _, err = service.GetSourceMessage(cmd.source, cmd.cmd)
+		assert.True(t, allerror.IsNotFound(err))
+	}
+	// a community only lists the sources it reads
+	assert.NoError(t, community.Init(&community.Config{Communities: []community.Community{
+		{Id: "opengauss", Sources: []string{source.IdGitee}}}}, "openeuler"))
+	defer func() { assert.NoError(t, community.Init(&community.Config{}, "openeuler")) }()
+	_, _, err = service.GetSourceMessage(source.IdMeeting, CmdToGetSourceMessage{
+		UserName: "opengauss/testUser", Category: source.CategoryTodo})
+	assert.True(t, allerror.IsNotFound(err))
+}
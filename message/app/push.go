@@ -13,8 +13,8 @@ import (
 type MessagePushAppService interface {
 	GetPushConfig(countPerPage, pageNum int, userName string,
 		subsIds []string) ([]MessagePushDTO, error)
-	AddPushConfig(cmd *CmdToAddPushConfig) error
-	UpdatePushConfig(cmd *CmdToUpdatePushConfig) error
+	AddPushConfig(cmd *CmdToAddPushConfig, userName string) error
+	UpdatePushConfig(cmd *CmdToUpdatePushConfig, userName string) error
 	RemovePushConfig(cmd *CmdToDeletePushConfig) error
 }
 
@@ -40,15 +40,16 @@ func (s *messagePushAppService) GetPushConfig(countPerPage, pageNum int, userNam
 	return data, nil
 }
 
-func (s *messagePushAppService) AddPushConfig(cmd *CmdToAddPushConfig) error {
-	if err := s.messagePushAdapter.AddPushConfig(*cmd); err != nil {
+func (s *messagePushAppService) AddPushConfig(cmd *CmdToAddPushConfig, userName string) error {
+	if err := s.messagePushAdapter.AddPushConfig(*cmd, userName); err != nil {
 		return xerrors.Errorf("add message push config failed, err:%v", err.Error())
 	}
 	return nil
 }
 
-func (s *messagePushAppService) UpdatePushConfig(cmd *CmdToUpdatePushConfig) error {
-	if err := s.messagePushAdapter.UpdatePushConfig(*cmd); err != nil {
+func (s *messagePushAppService) UpdatePushConfig(cmd *CmdToUpdatePushConfig,
+	userName string) error {
+	if err := s.messagePushAdapter.UpdatePushConfig(*cmd, userName); err != nil {
 		return xerrors.Errorf("update message push config failed, err:%v", err.Error())
 	}
 	return nil
@@ -21,13 +21,14 @@ func (m *MockMessagePushAdapter) GetPushConfig(subsIds []string, countPerPage, p
 	return args.Get(0).([]MessagePushDTO), args.Error(1)
 }
 
-func (m *MockMessagePushAdapter) AddPushConfig(cmd CmdToAddPushConfig) error {
-	args := m.Called(cmd)
+func (m *MockMessagePushAdapter) AddPushConfig(cmd CmdToAddPushConfig, userName string) error {
+	args := m.Called(cmd, userName)
 	return args.Error(0)
 }
 
-func (m *MockMessagePushAdapter) UpdatePushConfig(cmd CmdToUpdatePushConfig) error {
-	args := m.Called(cmd)
+func (m *MockMessagePushAdapter) UpdatePushConfig(cmd CmdToUpdatePushConfig,
+	userName string) error {
+	args := m.Called(cmd, userName)
 	return args.Error(0)
 }
 
@@ -88,9 +89,9 @@ func TestAddPushConfig(t *testing.T) {
 		NeedMail:         true,
 		NeedInnerMessage: false,
 	}
-	mockAdapter.On("AddPushConfig", cmd).Return(nil)
+	mockAdapter.On("AddPushConfig", cmd, "testUser").Return(nil)
 
-	err := service.AddPushConfig(&cmd)
+	err := service.AddPushConfig(&cmd, "testUser")
 
 	assert.NoError(t, err)
 	mockAdapter.AssertExpectations(t)
@@ -108,9 +109,9 @@ func TestAddPushConfig_Error(t *testing.T) {
 		NeedMail:         true,
 		NeedInnerMessage: false,
 	}
-	mockAdapter.On("AddPushConfig", cmd).Return(xerrors.New("error"))
+	mockAdapter.On("AddPushConfig", cmd, "testUser").Return(xerrors.New("error"))
 
-	err := service.AddPushConfig(&cmd)
+	err := service.AddPushConfig(&cmd, "testUser")
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "add message push config failed")
@@ -128,9 +129,9 @@ func TestUpdatePushConfig(t *testing.T) {
 		NeedMail:         true,
 		NeedInnerMessage: false,
 	}
-	mockAdapter.On("UpdatePushConfig", cmd).Return(nil)
+	mockAdapter.On("UpdatePushConfig", cmd, "testUser").Return(nil)
 
-	err := service.UpdatePushConfig(&cmd)
+	err := service.UpdatePushConfig(&cmd, "testUser")
 
 	assert.NoError(t, err)
 	mockAdapter.AssertExpectations(t)
@@ -148,9 +149,9 @@ func TestUpdatePushConfig_Error(t *testing.T) {
 		NeedMail:         true,
 		NeedInnerMessage: false,
 	}
-	mockAdapter.On("UpdatePushConfig", cmd).Return(xerrors.New("error"))
+	mockAdapter.On("UpdatePushConfig", cmd, "testUser").Return(xerrors.New("error"))
 
-	err := service.UpdatePushConfig(&cmd)
+	err := service.UpdatePushConfig(&cmd, "testUser")
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "update message push config failed")
@@ -7,8 +7,12 @@ package app
 import (
 	"regexp"
 
+	"github.com/sirupsen/logrus"
 	"golang.org/x/xerrors"
 
+	"github.com/opensourceways/message-manager/common/chat"
+	"github.com/opensourceways/message-manager/common/phone"
+	"github.com/opensourceways/message-manager/common/webhook"
 	"github.com/opensourceways/message-manager/message/domain"
 )
 
@@ -36,8 +40,6 @@ type messageRecipientAppService struct {
 const (
 	EmailRegexp = `^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`
 	EmailMaxLen = 254
-	PhoneRegexp = `^\+861[3-9]\d{9}$`
-	PhoneLen    = 14
 )
 
 func isValidEmail(email string) bool {
@@ -47,9 +49,18 @@ func isValidEmail(email string) bool {
 }
 
 func isValidPhoneNumber(phoneNumber string) bool {
-	// 中国大陆手机号码的简单正则表达式，可能根据情况调整
-	phoneRegex := regexp.MustCompile(PhoneRegexp)
-	return phoneRegex.MatchString(phoneNumber) && len(phoneNumber) == PhoneLen
+	// 国际格式的号码，按所属国家或地区的规则校验
+	_, err := phone.Parse(phoneNumber)
+	return err == nil
+}
+
+// normalizePhone returns the phone number in E.164 as stored, the empty one
+// stays empty.
+func normalizePhone(phoneNumber string) (string, error) {
+	if phoneNumber == "" {
+		return "", nil
+	}
+	return phone.Normalize(phoneNumber)
 }
 
 func validateData(email string, phoneNumber string) error {
@@ -63,6 +74,75 @@ func validateData(email string, phoneNumber string) error {
 	return nil
 }
 
+// recipientContact is how a recipient is reached.
+type recipientContact struct {
+	mail, phone            string
+	webhook, webhookSecret string
+	chatPlatform           string
+	chatWebhook            string
+	chatSecret             string
+}
+
+// validate checks the contacts which are set. A recipient reached by a webhook
+// or a chat bot, such as a team, needs no mail nor phone.
+func (c recipientContact) validate() error {
+	if c.webhook == "" && c.webhookSecret == "" && c.chatPlatform == "" &&
+		c.chatWebhook == "" && c.chatSecret == "" {
+		return validateData(c.mail, c.phone)
+	}
+	if c.webhook != "" {
+		if err := webhook.ValidateURL(c.webhook); err != nil {
+			return err
+		}
+	}
+	if c.webhookSecret != "" {
+		if err := webhook.ValidateSecret(c.webhookSecret); err != nil {
+			return err
+		}
+	}
+	if (c.chatPlatform == "") != (c.chatWebhook == "") {
+		return xerrors.Errorf("the chat platform and its webhook go together")
+	}
+	if c.chatPlatform != "" {
+		if err := chat.ValidateBot(c.chatPlatform, c.chatWebhook, c.chatSecret); err != nil {
+			return err
+		}
+	} else if len(c.chatSecret) > webhook.MaxSecretLen {
+		return xerrors.Errorf("the chat secret is longer than %d", webhook.MaxSecretLen)
+	}
+	if c.mail != "" && !isValidEmail(c.mail) {
+		return xerrors.Errorf("the email is invalid, email:%s", c.mail)
+	}
+	if c.phone != "" && !isValidPhoneNumber(c.phone) {
+		return xerrors.Errorf("the phone number is invalid, phone:%s", c.phone)
+	}
+	return nil
+}
+
+func contactOfAdd(cmd *CmdToAddRecipient) recipientContact {
+	return recipientContact{
+		mail:          cmd.Mail,
+		phone:         cmd.Phone,
+		webhook:       cmd.Webhook,
+		webhookSecret: cmd.WebhookSecret,
+		chatPlatform:  cmd.ChatPlatform,
+		chatWebhook:   cmd.ChatWebhook,
+		chatSecret:    cmd.ChatSecret,
+	}
+}
+
+func contactOfUpdate(cmd *CmdToUpdateRecipient) recipientContact {
+	return recipientContact{
+		mail:          cmd.Mail,
+		phone:         cmd.Phone,
+		webhook:       cmd.Webhook,
+		webhookSecret: cmd.WebhookSecret,
+		chatPlatform:  cmd.ChatPlatform,
+		chatWebhook:   cmd.ChatWebhook,
+		chatSecret:    cmd.ChatSecret,
+	}
+}
+
 func (s *messageRecipientAppService) GetRecipientConfig(countPerPage, pageNum int, userName string) (
 	[]MessageRecipientDTO, int64, error) {
 
@@ -71,6 +151,9 @@ func (s *messageRecipientAppService) GetRecipientConfig(countPerPage, pageNum in
 	if err != nil {
 		return []MessageRecipientDTO{}, 0, err
 	}
+	for i := range data {
+		data[i].PhoneDisplay = phone.Format(data[i].Phone)
+	}
 	return data, count, nil
 }
 
@@ -80,10 +163,21 @@ func (s *messageRecipientAppService) AddRecipientConfig(userName string,
 		return xerrors.Errorf("the recipient is null")
 	}
 
-	if err := validateData(cmd.Mail, cmd.Phone); err != nil {
+	if (cmd.Webhook == "") != (cmd.WebhookSecret == "") {
+		return xerrors.Errorf("data is invalid, err:the webhook and its secret go together")
+	}
+	if err := contactOfAdd(cmd).validate(); err != nil {
+		return xerrors.Errorf("data is invalid, err:%v", err.Error())
+	}
+	var err error
+	if cmd.Phone, err = normalizePhone(cmd.Phone); err != nil {
+		return xerrors.Errorf("data is invalid, err:%v", err.Error())
+	}
+	// the number the SMS are sent to is stored like the phone
+	if cmd.Message, err = normalizePhone(cmd.Message); err != nil {
 		return xerrors.Errorf("data is invalid, err:%v", err.Error())
 	}
-	err := s.messageRecipientAdapter.AddRecipientConfig(*cmd, userName)
+	err = s.messageRecipientAdapter.AddRecipientConfig(*cmd, userName)
 	if err != nil {
 		return err
 	}
@@ -92,11 +186,19 @@ func (s *messageRecipientAppService) AddRecipientConfig(userName string,
 
 func (s *messageRecipientAppService) UpdateRecipientConfig(userName string,
 	cmd *CmdToUpdateRecipient) error {
-	if err := validateData(cmd.Mail, cmd.Phone); err != nil {
+	if err := contactOfUpdate(cmd).validate(); err != nil {
+		return xerrors.Errorf("data is invalid, err:%v", err.Error())
+	}
+	var err error
+	if cmd.Phone, err = normalizePhone(cmd.Phone); err != nil {
+		return xerrors.Errorf("data is invalid, err:%v", err.Error())
+	}
+	// the number the SMS are sent to is stored like the phone
+	if cmd.Message, err = normalizePhone(cmd.Message); err != nil {
 		return xerrors.Errorf("data is invalid, err:%v", err.Error())
 	}
 
-	err := s.messageRecipientAdapter.UpdateRecipientConfig(*cmd, userName)
+	err = s.messageRecipientAdapter.UpdateRecipientConfig(*cmd, userName)
 	if err != nil {
 		return err
 	}
@@ -112,7 +214,18 @@ func (s *messageRecipientAppService) RemoveRecipientConfig(userName string,
 	return nil
 }
 
+// SyncUserInfo saves the account of a user, an invalid phone number is not
+// saved but the rest of the account is.
 func (s *messageRecipientAppService) SyncUserInfo(cmd *CmdToSyncUserInfo) (uint, error) {
+	if cmd.Phone != "" {
+		if n, err := phone.ParseWithCountryCode(cmd.CountryCode, cmd.Phone); err != nil {
+			logrus.Warnf("drop the phone of %s, err:%v", cmd.UserName, err)
+			cmd.Phone = ""
+			cmd.KeepPhone = true
+		} else {
+			cmd.Phone = n.E164()
+		}
+	}
 	data, err := s.messageRecipientAdapter.SyncUserInfo(*cmd)
 	if err != nil {
 		return 0, xerrors.Errorf("sync user info failed, err:%v", err)
@@ -0,0 +1,105 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package app
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/opensourceways/message-manager/common/source"
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+// StreamBatchSize bounds every query for new messages of a stream.
+const StreamBatchSize = 100
+
+type MessageStreamAppService interface {
+	Subscribe(userName string) (<-chan MessageNotifyDTO, func())
+	GetLatestStreamSeq(userName string) (int64, error)
+	GetMessageAfter(userName string, after int64) ([]MessageListDTO, error)
+	GetCount(userName string) ([]CountDTO, CountDataDTO, error)
+}
+
+func NewMessageStreamAppService(
+	messageListAdapter domain.MessageListAdapter,
+	messageNotifyAdapter domain.MessageNotifyAdapter,
+) MessageStreamAppService {
+	return &messageStreamAppService{
+		messageListAdapter:   messageListAdapter,
+		messageNotifyAdapter: messageNotifyAdapter,
+	}
+}
+
+type messageStreamAppService struct {
+	messageListAdapter   domain.MessageListAdapter
+	messageNotifyAdapter domain.MessageNotifyAdapter
+}
+
+func (s *messageStreamAppService) Subscribe(userName string) (<-chan MessageNotifyDTO, func()) {
+	return s.messageNotifyAdapter.Subscribe(userName)
+}
+
+// GetLatestStreamSeq returns the stream sequence of the newest message of
+// userName, or 0 if the user has no message yet.
+func (s *messageStreamAppService) GetLatestStreamSeq(userName string) (int64, error) {
+	return s.messageListAdapter.GetLatestStreamSeq(userName)
+}
+
+// GetMessageAfter returns at most StreamBatchSize messages delivered after the
+// stream sequence after, oldest first.
+func (s *messageStreamAppService) GetMessageAfter(userName string, after int64) (
+	[]MessageListDTO, error) {
+	data, err := s.messageListAdapter.GetAllMessageAfter(userName, after, StreamBatchSize)
+	if err != nil {
+		return []MessageListDTO{}, err
+	}
+	return data, nil
+}
+
+// GetCount returns the unread counts of userName by source and by list. Both
+// are read from the counters, the meetings count by their undone todos.
+func (s *messageStreamAppService) GetCount(userName string) ([]CountDTO, CountDataDTO, error) {
+	counters, err := s.messageListAdapter.GetUnreadCounter(userName)
+	if err != nil {
+		return []CountDTO{}, CountDataDTO{}, err
+	}
+
+	schedule, other := true, false
+	follow := source.Urls(source.CategoryFollow, nil)
+	related := source.Urls(source.CategoryRelated, nil)
+	meeting := source.Urls(source.CategoryTodo, &schedule)
+	todo := source.Urls(source.CategoryTodo, &other)
+
+	bySource := map[string]int{}
+	countNew := CountDataDTO{}
+	for _, c := range counters {
+		bySource[c.Source] += int(c.UnreadCount)
+		switch c.Category {
+		case source.CategoryFollow:
+			if slices.Contains(follow, c.Source) {
+				countNew.WatchCount += c.UnreadCount
+			}
+		case source.CategoryRelated:
+			if slices.Contains(related, c.Source) {
+				countNew.AboutCount += c.UnreadCount
+			}
+		case source.CategoryTodo:
+			if slices.Contains(meeting, c.Source) {
+				countNew.MeetingCount += c.UndoneCount
+			} else if slices.Contains(todo, c.Source) {
+				countNew.TodoCount += c.UndoneCount
+			}
+		}
+	}
+
+	count := []CountDTO{}
+	for k, v := range bySource {
+		if v > 0 {
+			count = append(count, CountDTO{Source: k, Count: v})
+		}
+	}
+	sort.Slice(count, func(i, j int) bool { return count[i].Source < count[j].Source })
+	return count, countNew, nil
+}
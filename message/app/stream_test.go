@@ -0,0 +1,102 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/source"
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+// MockMessageNotifyAdapter 是 MessageNotifyAdapter 的模拟实现
+type MockMessageNotifyAdapter struct {
+	mock.Mock
+}
+
+func (m *MockMessageNotifyAdapter) Publish(notify domain.MessageNotifyDO) {
+	m.Called(notify)
+}
+
+func (m *MockMessageNotifyAdapter) Subscribe(userName string) (<-chan domain.MessageNotifyDO, func()) {
+	args := m.Called(userName)
+	return args.Get(0).(chan domain.MessageNotifyDO), args.Get(1).(func())
+}
+
+func TestStreamSubscribe(t *testing.T) {
+	mockNotify := new(MockMessageNotifyAdapter)
+	service := NewMessageStreamAppService(new(MockMessageListAdapter), mockNotify)
+
+	ch := make(chan domain.MessageNotifyDO, 1)
+	mockNotify.On("Subscribe", "testUser").Return(ch, func() {})
+
+	notify, cancel := service.Subscribe("testUser")
+	ch <- domain.MessageNotifyDO{UserName: "testUser", Op: "insert"}
+	assert.Equal(t, "insert", (<-notify).Op)
+	cancel()
+	mockNotify.AssertExpectations(t)
+}
+
+func TestGetLatestStreamSeq(t *testing.T) {
+	mockAdapter := new(MockMessageListAdapter)
+	service := NewMessageStreamAppService(mockAdapter, new(MockMessageNotifyAdapter))
+
+	mockAdapter.On("GetLatestStreamSeq", "testUser").Return(int64(42), nil)
+	mockAdapter.On("GetLatestStreamSeq", "errUser").Return(int64(0), xerrors.New("db error"))
+
+	seq, err := service.GetLatestStreamSeq("testUser")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), seq)
+
+	_, err = service.GetLatestStreamSeq("errUser")
+	assert.Error(t, err)
+}
+
+func TestGetMessageAfter(t *testing.T) {
+	mockAdapter := new(MockMessageListAdapter)
+	service := NewMessageStreamAppService(mockAdapter, new(MockMessageNotifyAdapter))
+
+	mockData := []domain.MessageListDO{{EventId: "event2", StreamSeq: 8}}
+	mockAdapter.On("GetAllMessageAfter", "testUser", int64(7), StreamBatchSize).
+		Return(mockData, nil)
+	mockAdapter.On("GetAllMessageAfter", "errUser", int64(7), StreamBatchSize).
+		Return([]domain.MessageListDO{}, xerrors.New("db error"))
+
+	data, err := service.GetMessageAfter("testUser", 7)
+	assert.NoError(t, err)
+	assert.Equal(t, mockData, data)
+
+	data, err = service.GetMessageAfter("errUser", 7)
+	assert.Error(t, err)
+	assert.Equal(t, []MessageListDTO{}, data)
+}
+
+func TestStreamGetCount(t *testing.T) {
+	mockAdapter := new(MockMessageListAdapter)
+	service := NewMessageStreamAppService(mockAdapter, new(MockMessageNotifyAdapter))
+
+	mockAdapter.On("GetUnreadCounter", "testUser").Return([]domain.UnreadCounterDO{
+		{Source: source.DefaultGiteeUrl, Category: source.CategoryFollow, UnreadCount: 2},
+		{Source: source.DefaultGiteeUrl, Category: source.CategoryRelated, UnreadCount: 1},
+		{Source: source.DefaultGiteeUrl, Category: source.CategoryTodo, UnreadCount: 1,
+			UndoneCount: 3},
+		{Source: source.DefaultMeetingUrl, Category: source.CategoryTodo, UndoneCount: 4},
+		{Source: source.DefaultEurUrl, Category: source.CategoryFollow, UnreadCount: 5},
+	}, nil)
+	mockAdapter.On("GetUnreadCounter", "errUser").
+		Return([]domain.UnreadCounterDO{}, xerrors.New("db error"))
+
+	count, countNew, err := service.GetCount("testUser")
+	assert.NoError(t, err)
+	assert.Equal(t, []CountDTO{
+		{Source: source.DefaultEurUrl, Count: 5},
+		{Source: source.DefaultGiteeUrl, Count: 4},
+	}, count)
+	assert.Equal(t, CountDataDTO{WatchCount: 7, AboutCount: 1, TodoCount: 3, MeetingCount: 4},
+		countNew)
+
+	_, _, err = service.GetCount("errUser")
+	assert.Error(t, err)
+}
@@ -5,8 +5,13 @@ Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
 package app
 
 import (
+	"encoding/json"
+	"strings"
+
 	"golang.org/x/xerrors"
+	"gorm.io/datatypes"
 
+	"github.com/opensourceways/message-manager/common/repopattern"
 	"github.com/opensourceways/message-manager/message/domain"
 )
 
@@ -54,7 +59,14 @@ func (s *messageSubscribeAppService) AddSubsConfig(userName string,
 		return []uint{}, xerrors.Errorf("必填项不能为空")
 	}
 
-	data, err := s.messageSubscribeAdapter.AddSubsConfig(*cmd, userName)
+	subscribe := *cmd
+	modeFilter, err := normalizeRepoFilter(cmd.ModeFilter)
+	if err != nil {
+		return []uint{}, xerrors.Errorf("仓库筛选无效, err:%v", err)
+	}
+	subscribe.ModeFilter = modeFilter
+
+	data, err := s.messageSubscribeAdapter.AddSubsConfig(subscribe, userName)
 	if err != nil {
 		return []uint{}, xerrors.Errorf("add subs failed, err:%v", err)
 	} else {
@@ -81,3 +93,55 @@ func (s *messageSubscribeAppService) RemoveSubsConfig(userName string,
 		return nil
 	}
 }
+
+// normalizeRepoFilter merges the repository patterns of the repo_name condition
+// of modeFilter, keeping it a list or a string.
+func normalizeRepoFilter(modeFilter datatypes.JSON) (datatypes.JSON, error) {
+	var filter map[string]json.RawMessage
+	if err := json.Unmarshal(modeFilter, &filter); err != nil {
+		return modeFilter, nil
+	}
+	raw, ok := filter[repoFilter]
+	if !ok {
+		return modeFilter, nil
+	}
+	var condition interface{}
+	if err := json.Unmarshal(raw, &condition); err != nil {
+		return modeFilter, nil
+	}
+
+	var value interface{}
+	switch c := condition.(type) {
+	case string:
+		if isTagCondition(c) {
+			return modeFilter, nil
+		}
+		merged, err := repopattern.Merge(repopattern.Split(c))
+		if err != nil {
+			return nil, err
+		}
+		value = strings.Join(merged, ",")
+	case []interface{}:
+		var patterns []string
+		for _, v := range c {
+			patterns = append(patterns, eventValueString(v))
+		}
+		merged, err := repopattern.Merge(patterns)
+		if err != nil {
+			return nil, err
+		}
+		value = merged
+	default:
+		return modeFilter, nil
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	filter[repoFilter] = b
+	if b, err = json.Marshal(filter); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -108,6 +108,43 @@ func TestAddSubsConfig(t *testing.T) {
 	mockAdapter.AssertExpectations(t)
 }
 
+func TestAddSubsConfig_RepoPatterns(t *testing.T) {
+	// the repository patterns are stored merged, in the form they were sent
+	cases := []struct {
+		input string
+		want  string
+	}{
+		{`{"repo_name": " OpenEuler/*, openeuler/docs,!openeuler/docs", "pr_state": "open"}`,
+			`{"pr_state": "open", "repo_name": "openeuler/*,!openeuler/docs"}`},
+		{`{"repo_name": ["src-openeuler/python-*", "src-openeuler/python-requests"]}`,
+			`{"repo_name": ["src-openeuler/python-*"]}`},
+		{`{"repo_name": "eq=openeuler/docs"}`, `{"repo_name": "eq=openeuler/docs"}`},
+	}
+	for _, c := range cases {
+		input, want := c.input, c.want
+		mockAdapter := new(MockMessageSubscribeAdapter)
+		service := NewMessageSubscribeAppService(mockAdapter)
+
+		var stored CmdToAddSubscribe
+		mockAdapter.On("AddSubsConfig", mock.Anything, "testUser").Run(func(args mock.Arguments) {
+			stored = args.Get(0).(CmdToAddSubscribe)
+		}).Return([]uint{1}, nil).Once()
+
+		cmd := CmdToAddSubscribe{Source: "https://gitee.com", ModeName: "repos",
+			ModeFilter: datatypes.JSON(input)}
+		_, err := service.AddSubsConfig("testUser", &cmd)
+		assert.NoError(t, err, input)
+		assert.JSONEq(t, want, string(stored.ModeFilter), input)
+		assert.Equal(t, input, string(cmd.ModeFilter))
+	}
+
+	service := NewMessageSubscribeAppService(new(MockMessageSubscribeAdapter))
+	cmd := CmdToAddSubscribe{Source: "https://gitee.com", ModeName: "repos",
+		ModeFilter: datatypes.JSON(`{"repo_name": "openeuler/[a-"}`)}
+	_, err := service.AddSubsConfig("testUser", &cmd)
+	assert.ErrorContains(t, err, "仓库筛选无效")
+}
+
 func TestAddSubsConfig_Error(t *testing.T) {
 	mockAdapter := new(MockMessageSubscribeAdapter)
 	service := NewMessageSubscribeAppService(mockAdapter)
@@ -0,0 +1,264 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package app
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/chat"
+	"github.com/opensourceways/message-manager/common/community"
+	"github.com/opensourceways/message-manager/common/domain/allerror"
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+const (
+	TestSendSent    = "sent"
+	TestSendFailed  = "failed"
+	TestSendSkipped = "skipped"
+
+	errorCodeTestSendNotFound = "test_send_not_found"
+
+	testSendEventIdBytes = 8
+	testSendTitlePrefix  = "[测试] "
+)
+
+// TestSendResultDTO tells how the test message went through a channel. Status
+// is sent, failed or skipped, the phone calls are always skipped.
+type TestSendResultDTO struct {
+	Channel string `json:"channel"`
+	Target  string `json:"target"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+// TestSenders are the services sending the test messages, the ones of the
+// channels disabled are nil. SMS sends the text messages.
+type TestSenders struct {
+	Mail    MessageMailAppService
+	Webhook MessageWebhookAppService
+	Chat    MessageChatAppService
+	SMS     domain.SMSGateway
+}
+
+type MessageTestSendAppService interface {
+	TestRecipient(userName string, recipientId int64, subscribeId int) ([]TestSendResultDTO,
+		error)
+	TestPush(userName string, recipientId int64, subscribeId int) ([]TestSendResultDTO, error)
+}
+
+func NewMessageTestSendAppService(
+	messageTestSendAdapter domain.MessageTestSendAdapter,
+	senders TestSenders,
+) MessageTestSendAppService {
+	return &messageTestSendAppService{
+		messageTestSendAdapter: messageTestSendAdapter,
+		senders:                senders,
+	}
+}
+
+type messageTestSendAppService struct {
+	messageTestSendAdapter domain.MessageTestSendAdapter
+	senders                TestSenders
+}
+
+// TestRecipient sends a sample message of the subscription subscribeId to
+// every contact of the recipient recipientId of userName.
+func (s *messageTestSendAppService) TestRecipient(userName string, recipientId int64,
+	subscribeId int) ([]TestSendResultDTO, error) {
+	target, event, err := s.prepare(userName, recipientId, subscribeId)
+	if err != nil {
+		return []TestSendResultDTO{}, err
+	}
+
+	var channels []string
+	for channel, contact := range map[string]string{
+		domain.DeliveryChannelMessage: target.Message,
+		domain.DeliveryChannelPhone:   target.Phone,
+		domain.DeliveryChannelMail:    target.Mail,
+		domain.DeliveryChannelWebhook: target.Webhook,
+		domain.DeliveryChannelChat:    target.ChatPlatform,
+	} {
+		if contact != "" {
+			channels = append(channels, channel)
+		}
+	}
+	return s.send(target, event, channels), nil
+}
+
+// TestPush sends a sample message of the subscription subscribeId through the
+// channels of its push config for the recipient recipientId of userName.
+func (s *messageTestSendAppService) TestPush(userName string, recipientId int64,
+	subscribeId int) ([]TestSendResultDTO, error) {
+	target, event, err := s.prepare(userName, recipientId, subscribeId)
+	if err != nil {
+		return []TestSendResultDTO{}, err
+	}
+	if target.NeedMail == nil {
+		return []TestSendResultDTO{}, allerror.NewNotFound(errorCodeTestSendNotFound,
+			"the push config is not found")
+	}
+
+	var channels []string
+	for channel, need := range map[string]*bool{
+		domain.DeliveryChannelMessage:      target.NeedMessage,
+		domain.DeliveryChannelPhone:        target.NeedPhone,
+		domain.DeliveryChannelMail:         target.NeedMail,
+		domain.DeliveryChannelInnerMessage: target.NeedInnerMessage,
+		domain.DeliveryChannelWebhook:      target.NeedWebhook,
+		domain.DeliveryChannelChat:         target.NeedChat,
+	} {
+		if need != nil && *need {
+			channels = append(channels, channel)
+		}
+	}
+	return s.send(target, event, channels), nil
+}
+
+// prepare returns the recipient with its push config and the sample message
+// of the subscription, the latest event it receives or a made up one.
+func (s *messageTestSendAppService) prepare(userName string, recipientId int64,
+	subscribeId int) (domain.TestSendTargetDO, CloudEventDTO, error) {
+	target, err := s.messageTestSendAdapter.GetTestSendTarget(userName, recipientId,
+		subscribeId)
+	if err != nil {
+		return target, CloudEventDTO{}, err
+	}
+	if target.RecipientId == 0 {
+		return target, CloudEventDTO{}, allerror.NewNotFound(errorCodeTestSendNotFound,
+			"the recipient or the subscription is not found")
+	}
+	if target.Community == "" {
+		target.Community = community.DefaultId()
+	}
+
+	// a real event is only used when the recipient received it already
+	event, err := s.messageTestSendAdapter.GetTestSendEvent(target.RecipientId,
+		target.Community, target.Source, target.EventType)
+	if err != nil {
+		return target, CloudEventDTO{}, err
+	}
+	if event.EventId == "" {
+		event = CloudEventDTO{
+			Source:    target.Source,
+			Type:      target.EventType,
+			User:      userName,
+			Title:     target.ModeName,
+			Summary:   testSendSummary(target),
+			Community: target.Community,
+		}
+		if event.Type == "" || event.Type == "*" {
+			event.Type = "test"
+		}
+	}
+
+	b := make([]byte, testSendEventIdBytes)
+	if _, err := rand.Read(b); err != nil {
+		return target, CloudEventDTO{}, xerrors.Errorf("generate event id failed, err:%v", err)
+	}
+	event.EventId = "test-" + hex.EncodeToString(b)
+	event.EventTime = time.Now()
+	event.Title = testSendTitlePrefix + event.Title
+	return target, event, nil
+}
+
+func testSendSummary(target domain.TestSendTargetDO) string {
+	return "这是一条测试消息，用于验证订阅「" + target.ModeName + "」的推送配置。"
+}
+
+// send sends event through the channels one by one, sorted by name.
+func (s *messageTestSendAppService) send(target domain.TestSendTargetDO, event CloudEventDTO,
+	channels []string) []TestSendResultDTO {
+	sort.Strings(channels)
+	results := make([]TestSendResultDTO, 0, len(channels))
+	for _, channel := range channels {
+		results = append(results, s.sendTo(channel, target, event))
+	}
+	return results
+}
+
+func (s *messageTestSendAppService) sendTo(channel string, target domain.TestSendTargetDO,
+	event CloudEventDTO) TestSendResultDTO {
+	result := TestSendResultDTO{Channel: channel}
+	verified := true
+	var send func() error
+	switch channel {
+	case domain.DeliveryChannelMail:
+		result.Target = target.Mail
+		verified = target.MailVerified
+		if s.senders.Mail != nil {
+			send = func() error { return s.senders.Mail.SendTestMail(event, target.Mail) }
+		}
+	case domain.DeliveryChannelWebhook:
+		result.Target = target.Webhook
+		if target.WebhookDisabledAt != nil {
+			result.Status = TestSendFailed
+			result.Error = "the webhook is disabled after failing too many times, " +
+				"configure it again to enable it"
+			return result
+		}
+		if s.senders.Webhook != nil {
+			send = func() error {
+				return s.senders.Webhook.SendTestWebhook(event, target.Webhook,
+					target.WebhookSecret)
+			}
+		}
+	case domain.DeliveryChannelChat:
+		result.Target = target.ChatWebhook
+		if s.senders.Chat != nil {
+			send = func() error {
+				return s.senders.Chat.SendTestChat(event, chat.Bot{
+					Platform: target.ChatPlatform,
+					URL:      target.ChatWebhook,
+					Secret:   target.ChatSecret,
+				})
+			}
+		}
+	case domain.DeliveryChannelMessage:
+		result.Target = target.Message
+		verified = target.MessageVerified
+		if s.senders.SMS != nil {
+			send = func() error {
+				return s.senders.SMS.Send(target.Message, "【消息中心】"+testSendSummary(target))
+			}
+		}
+	case domain.DeliveryChannelPhone:
+		result.Target = target.Phone
+		verified = target.PhoneVerified
+	case domain.DeliveryChannelInnerMessage:
+		result.Status = TestSendSkipped
+		result.Error = "the inner messages are not sent out"
+		return result
+	}
+
+	switch {
+	case strings.TrimSpace(result.Target) == "":
+		result.Status = TestSendFailed
+		result.Error = "the recipient has no contact of the channel"
+	case !verified:
+		result.Status = TestSendFailed
+		result.Error = "the contact is not verified"
+	case channel == domain.DeliveryChannelPhone:
+		// the gateway only sends texts, the calls are made by the pushers
+		result.Status = TestSendSkipped
+		result.Error = "the phone calls can not be tested here"
+	case send == nil:
+		result.Status = TestSendSkipped
+		result.Error = "the channel is disabled"
+	default:
+		if err := send(); err != nil {
+			result.Status = TestSendFailed
+			result.Error = err.Error()
+		} else {
+			result.Status = TestSendSent
+		}
+	}
+	return result
+}
@@ -0,0 +1,151 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/opensourceways/message-manager/common/chat"
+	"github.com/opensourceways/message-manager/common/chat/chattest"
+	"github.com/opensourceways/message-manager/common/domain/allerror"
+	"github.com/opensourceways/message-manager/common/mail"
+	"github.com/opensourceways/message-manager/common/mail/mailtest"
+	"github.com/opensourceways/message-manager/common/source"
+	"github.com/opensourceways/message-manager/common/webhook"
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+// MockMessageTestSendAdapter 是 MessageTestSendAdapter 的模拟实现
+type MockMessageTestSendAdapter struct {
+	mock.Mock
+}
+
+func (m *MockMessageTestSendAdapter) GetTestSendTarget(userName string, recipientId int64,
+	subscribeId int) (domain.TestSendTargetDO, error) {
+	args := m.Called(userName, recipientId, subscribeId)
+	return args.Get(0).(domain.TestSendTargetDO), args.Error(1)
+}
+
+func (m *MockMessageTestSendAdapter) GetTestSendEvent(recipientId int64, communityId, source,
+	eventType string) (domain.CloudEventDO, error) {
+	args := m.Called(recipientId, communityId, source, eventType)
+	return args.Get(0).(domain.CloudEventDO), args.Error(1)
+}
+
+func TestTestRecipient(t *testing.T) {
+	mailServer := mailtest.NewServer(mail.TLSStartTLS)
+	defer mailServer.Close()
+	bot := chattest.NewServer(chat.PlatformFeishu, "SECfeishu")
+	defer bot.Close()
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer hook.Close()
+
+	client := webhook.Config{AllowPrivateNetwork: true}
+	senders := TestSenders{
+		Mail: NewMessageMailAppService(nil, nil, newMailRenderer(t),
+			mail.NewSender(mailServer.Config()), nil, mailOption),
+		Webhook: NewMessageWebhookAppService(nil, nil, webhook.NewClient(client), nil,
+			mailOption, 3),
+		Chat: NewMessageChatAppService(nil, nil, chat.NewClient(client), nil, mailOption),
+		SMS:  new(MockSMSGateway),
+	}
+	senders.SMS.(*MockSMSGateway).On("Send", "+8613800000000",
+		"【消息中心】这是一条测试消息，用于验证订阅「my prs」的推送配置。").Return(nil).Once()
+	mockAdapter := new(MockMessageTestSendAdapter)
+	service := NewMessageTestSendAppService(mockAdapter, senders)
+
+	mockAdapter.On("GetTestSendTarget", "alice", int64(9), 3).Return(domain.TestSendTargetDO{
+		RecipientId: 9, Mail: "alice@example.com", Message: "+8613800000000",
+		MailVerified: true, MessageVerified: true, Phone: "+8613800000001",
+		Webhook: hook.URL, ChatPlatform: chat.PlatformFeishu, ChatWebhook: bot.Bot().URL,
+		ChatSecret: bot.Bot().Secret, SubscribeId: 3, Source: source.DefaultGiteeUrl,
+		EventType: "pr", ModeName: "my prs", Community: fanoutCommunity}, nil).Once()
+	mockAdapter.On("GetTestSendEvent", int64(9), fanoutCommunity, source.DefaultGiteeUrl,
+		"pr").Return(newFanoutEvent("pr-1", source.DefaultGiteeUrl, "pr", prFixture), nil).Once()
+
+	results, err := service.TestRecipient("alice", 9, 3)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 5) {
+		assert.Equal(t, TestSendResultDTO{Channel: domain.DeliveryChannelChat,
+			Target: bot.Bot().URL, Status: TestSendSent}, results[0])
+		assert.Equal(t, TestSendResultDTO{Channel: domain.DeliveryChannelMail,
+			Target: "alice@example.com", Status: TestSendSent}, results[1])
+		assert.Equal(t, TestSendResultDTO{Channel: domain.DeliveryChannelMessage,
+			Target: "+8613800000000", Status: TestSendSent}, results[2])
+		assert.Equal(t, TestSendResultDTO{Channel: domain.DeliveryChannelPhone,
+			Target: "+8613800000001", Status: TestSendFailed,
+			Error: "the contact is not verified"}, results[3])
+		assert.Equal(t, domain.DeliveryChannelWebhook, results[4].Channel)
+		assert.Equal(t, TestSendFailed, results[4].Status)
+		assert.Contains(t, results[4].Error, "404")
+	}
+	mockAdapter.AssertExpectations(t)
+	senders.SMS.(*MockSMSGateway).AssertExpectations(t)
+
+	if messages := mailServer.Messages(); assert.Len(t, messages, 1) {
+		assert.Equal(t, []string{"alice@example.com"}, messages[0].To)
+		// the subject is "[gitee] merged [测试]" encoded
+		assert.Contains(t, messages[0].Data, "[=E6=B5=8B=E8=AF=95]")
+	}
+	assert.Len(t, bot.Messages(), 1)
+
+	mockAdapter.On("GetTestSendTarget", "alice", int64(8), 3).
+		Return(domain.TestSendTargetDO{}, nil).Once()
+	_, err = service.TestRecipient("alice", 8, 3)
+	assert.True(t, allerror.IsNotFound(err))
+}
+
+func TestTestPush(t *testing.T) {
+	bot := chattest.NewServer(chat.PlatformSlack, "T000/B000/XXXX")
+	defer bot.Close()
+
+	client := webhook.Config{AllowPrivateNetwork: true}
+	mockAdapter := new(MockMessageTestSendAdapter)
+	service := NewMessageTestSendAppService(mockAdapter, TestSenders{
+		Chat: NewMessageChatAppService(nil, nil, chat.NewClient(client), nil, mailOption),
+	})
+
+	yes, no := true, false
+	target := domain.TestSendTargetDO{
+		RecipientId: 9, Mail: "alice@example.com", MailVerified: true,
+		Phone: "+8613800000001", PhoneVerified: true, ChatPlatform: chat.PlatformSlack,
+		ChatWebhook: bot.Bot().URL, SubscribeId: 3, Source: source.DefaultGiteeUrl,
+		EventType: "*", ModeName: "everything", Community: fanoutCommunity,
+		NeedMail: &yes, NeedChat: &yes, NeedInnerMessage: &yes, NeedWebhook: &yes,
+		NeedMessage: &no, NeedPhone: &yes,
+	}
+	mockAdapter.On("GetTestSendTarget", "alice", int64(9), 3).Return(target, nil).Once()
+	// the source has no event yet
+	mockAdapter.On("GetTestSendEvent", int64(9), fanoutCommunity, source.DefaultGiteeUrl, "*").
+		Return(domain.CloudEventDO{}, nil)
+
+	results, err := service.TestPush("alice", 9, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []TestSendResultDTO{
+		{Channel: domain.DeliveryChannelChat, Target: bot.Bot().URL, Status: TestSendSent},
+		{Channel: domain.DeliveryChannelInnerMessage, Status: TestSendSkipped,
+			Error: "the inner messages are not sent out"},
+		{Channel: domain.DeliveryChannelMail, Target: "alice@example.com",
+			Status: TestSendSkipped, Error: "the channel is disabled"},
+		{Channel: domain.DeliveryChannelPhone, Target: "+8613800000001",
+			Status: TestSendSkipped, Error: "the phone calls can not be tested here"},
+		{Channel: domain.DeliveryChannelWebhook, Status: TestSendFailed,
+			Error: "the recipient has no contact of the channel"},
+	}, results)
+	if messages := bot.Messages(); assert.Len(t, messages, 1) {
+		assert.True(t, strings.Contains(messages[0].Body["text"].(string), "[测试] everything"))
+	}
+
+	// the recipient is not pushed by the subscription
+	target.NeedMail, target.NeedChat = nil, nil
+	mockAdapter.On("GetTestSendTarget", "alice", int64(9), 3).Return(target, nil).Once()
+	_, err = service.TestPush("alice", 9, 3)
+	assert.True(t, allerror.IsNotFound(err))
+	mockAdapter.AssertExpectations(t)
+}
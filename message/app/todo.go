@@ -0,0 +1,64 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package app
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+type MessageTodoAppService interface {
+	SetTodoIsDone(userName string, eventIds []string, isDone bool) error
+	CompleteExpiredTodo(now time.Time) error
+}
+
+func NewMessageTodoAppService(
+	messageTodoAdapter domain.MessageTodoAdapter,
+	rules []FanoutRule,
+) MessageTodoAppService {
+	return &messageTodoAppService{
+		messageTodoAdapter: messageTodoAdapter,
+		rules:              rules,
+	}
+}
+
+type messageTodoAppService struct {
+	messageTodoAdapter domain.MessageTodoAdapter
+	rules              []FanoutRule
+}
+
+// SetTodoIsDone marks the todos of userName done or undone by hand, a todo
+// done by hand is left done by the later events of the item.
+func (s *messageTodoAppService) SetTodoIsDone(userName string, eventIds []string,
+	isDone bool) error {
+	for _, eventId := range eventIds {
+		if err := s.messageTodoAdapter.SetTodoIsDone(userName, eventId, isDone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CompleteExpiredTodo marks the todos done whose rule lets them expire, such
+// as the meetings which have passed at now.
+func (s *messageTodoAppService) CompleteExpiredTodo(now time.Time) error {
+	for _, rule := range s.rules {
+		if rule.ExpireMinutes <= 0 {
+			continue
+		}
+		before := now.Add(-time.Duration(rule.ExpireMinutes) * time.Minute)
+		count, err := s.messageTodoAdapter.CompleteExpiredTodo(rule.Source, rule.Type, before)
+		if err != nil {
+			return err
+		}
+		if count != 0 {
+			logrus.Infof("complete %d expired %s todos", count, rule.Type)
+		}
+	}
+	return nil
+}
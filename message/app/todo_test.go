@@ -0,0 +1,57 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/source"
+)
+
+// MockMessageTodoAdapter 是 MessageTodoAdapter 的模拟实现
+type MockMessageTodoAdapter struct {
+	mock.Mock
+}
+
+func (m *MockMessageTodoAdapter) SetTodoIsDone(userName string, eventId string, isDone bool) error {
+	args := m.Called(userName, eventId, isDone)
+	return args.Error(0)
+}
+
+func (m *MockMessageTodoAdapter) CompleteExpiredTodo(source, eventType string, before time.Time) (
+	int64, error) {
+	args := m.Called(source, eventType, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestSetTodoIsDone(t *testing.T) {
+	mockAdapter := new(MockMessageTodoAdapter)
+	service := NewMessageTodoAppService(mockAdapter, FanoutRules())
+
+	mockAdapter.On("SetTodoIsDone", "user", "1", true).Return(nil).Once()
+	mockAdapter.On("SetTodoIsDone", "user", "2", true).Return(nil).Once()
+	assert.NoError(t, service.SetTodoIsDone("user", []string{"1", "2"}, true))
+
+	mockAdapter.On("SetTodoIsDone", "user", "3", false).Return(xerrors.New("db error")).Once()
+	assert.Error(t, service.SetTodoIsDone("user", []string{"3", "4"}, false))
+	mockAdapter.AssertExpectations(t)
+}
+
+func TestCompleteExpiredTodo(t *testing.T) {
+	mockAdapter := new(MockMessageTodoAdapter)
+	service := NewMessageTodoAppService(mockAdapter, FanoutRules())
+
+	// only the meetings expire, an hour after they start
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	mockAdapter.On("CompleteExpiredTodo", source.DefaultMeetingUrl, "meeting", now.Add(-time.Hour)).
+		Return(int64(2), nil).Once()
+	assert.NoError(t, service.CompleteExpiredTodo(now))
+
+	mockAdapter.On("CompleteExpiredTodo", source.DefaultMeetingUrl, "meeting", now.Add(-time.Hour)).
+		Return(int64(0), xerrors.New("db error")).Once()
+	assert.Error(t, service.CompleteExpiredTodo(now))
+	mockAdapter.AssertExpectations(t)
+}
@@ -0,0 +1,183 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package app
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/domain/allerror"
+	"github.com/opensourceways/message-manager/common/mail"
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+const (
+	VerificationCodeLen = 6
+
+	errorCodeRecipientNotFound    = "recipient_not_found"
+	errorCodeVerificationTooOften = "verification_too_often"
+)
+
+// VerificationOption tells how the contacts are verified. A code is valid for
+// TTL and MaxAttempts confirmations, the next one is sent after Cooldown.
+type VerificationOption struct {
+	TTL         time.Duration
+	Cooldown    time.Duration
+	MaxAttempts int
+}
+
+type MessageVerificationAppService interface {
+	SendVerification(userName string, recipientId int64, contactType string) error
+	ConfirmVerification(userName string, recipientId int64, contactType, code string) error
+}
+
+// NewMessageVerificationAppService returns the service verifying the mails by
+// sender and the numbers by gateway, either is nil when it is not available.
+func NewMessageVerificationAppService(
+	messageVerificationAdapter domain.MessageVerificationAdapter,
+	sender domain.MailSender,
+	gateway domain.SMSGateway,
+	option VerificationOption,
+) MessageVerificationAppService {
+	return &messageVerificationAppService{
+		messageVerificationAdapter: messageVerificationAdapter,
+		sender:                     sender,
+		gateway:                    gateway,
+		option:                     option,
+		now:                        time.Now,
+	}
+}
+
+type messageVerificationAppService struct {
+	messageVerificationAdapter domain.MessageVerificationAdapter
+	sender                     domain.MailSender
+	gateway                    domain.SMSGateway
+	option                     VerificationOption
+	now                        func() time.Time
+}
+
+// contactOf returns the contact of contactType of the recipient recipientId of
+// userName and whether it is verified.
+func (s *messageVerificationAppService) contactOf(userName string, recipientId int64,
+	contactType string) (string, bool, error) {
+	c, err := s.messageVerificationAdapter.GetRecipientContact(userName, recipientId)
+	if err != nil {
+		return "", false, err
+	}
+	if c.RecipientId == 0 {
+		return "", false, allerror.NewNotFound(errorCodeRecipientNotFound,
+			"the recipient is not found")
+	}
+
+	var contact string
+	var verified bool
+	switch contactType {
+	case domain.ContactMail:
+		contact, verified = c.Mail, c.MailVerified
+	case domain.ContactMessage:
+		contact, verified = c.Message, c.MessageVerified
+	case domain.ContactPhone:
+		contact, verified = c.Phone, c.PhoneVerified
+	default:
+		return "", false, allerror.NewInvalidParam("the contact type is invalid, type:" +
+			contactType)
+	}
+	if contact == "" {
+		return "", false, allerror.NewInvalidParam("the recipient has no " + contactType)
+	}
+	return contact, verified, nil
+}
+
+// SendVerification sends a code to the contact of contactType of the recipient
+// recipientId of userName, the previous code stops working.
+func (s *messageVerificationAppService) SendVerification(userName string, recipientId int64,
+	contactType string) error {
+	contact, verified, err := s.contactOf(userName, recipientId, contactType)
+	if err != nil {
+		return err
+	}
+	if verified {
+		return allerror.NewInvalidParam("the " + contactType + " is verified already")
+	}
+	if contactType == domain.ContactMail && s.sender == nil ||
+		contactType != domain.ContactMail && s.gateway == nil {
+		return xerrors.Errorf("the %s can not be verified for now", contactType)
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return xerrors.Errorf("generate verification code failed, err:%v", err)
+	}
+	code := fmt.Sprintf("%0*d", VerificationCodeLen, n.Int64())
+	saved, err := s.messageVerificationAdapter.SaveVerification(domain.VerificationDO{
+		RecipientId: recipientId,
+		ContactType: contactType,
+		Contact:     contact,
+		CodeHash:    hashVerificationCode(recipientId, contact, code),
+		ExpiresAt:   s.now().Add(s.option.TTL),
+	}, s.option.Cooldown)
+	if err != nil {
+		return err
+	}
+	if !saved {
+		return allerror.NewOverLimit(errorCodeVerificationTooOften, fmt.Sprintf(
+			"a code was sent within %d seconds, try again later",
+			int(s.option.Cooldown.Seconds())))
+	}
+
+	minutes := strconv.Itoa(int(s.option.TTL.Minutes()))
+	if contactType == domain.ContactMail {
+		return s.sender.Send(mail.Message{To: []string{contact}, Content: mail.Content{
+			Subject: "消息中心邮箱验证码",
+			Text: "您的验证码是 " + code + "，" + minutes + " 分钟内有效。" +
+				"如非本人操作，请忽略本邮件。",
+		}})
+	}
+	return s.gateway.Send(contact, "【消息中心】您的验证码是 "+code+"，"+minutes+
+		" 分钟内有效。如非本人操作，请忽略本短信。")
+}
+
+// ConfirmVerification verifies the contact of contactType of the recipient
+// recipientId of userName if code is the one sent to it.
+func (s *messageVerificationAppService) ConfirmVerification(userName string, recipientId int64,
+	contactType, code string) error {
+	contact, _, err := s.contactOf(userName, recipientId, contactType)
+	if err != nil {
+		return err
+	}
+	v, err := s.messageVerificationAdapter.ClaimVerification(recipientId, contactType,
+		s.option.MaxAttempts)
+	if err != nil {
+		return err
+	}
+	if v.RecipientId == 0 {
+		return allerror.NewInvalidParam("the code expired or was tried too many times, " +
+			"request a new one")
+	}
+	if v.Contact != contact {
+		return allerror.NewInvalidParam("the " + contactType + " changed since the code " +
+			"was sent, request a new one")
+	}
+	if subtle.ConstantTimeCompare([]byte(v.CodeHash),
+		[]byte(hashVerificationCode(recipientId, contact, code))) != 1 {
+		return allerror.NewInvalidParam("the code is wrong")
+	}
+	return s.messageVerificationAdapter.CompleteVerification(recipientId, contactType, contact)
+}
+
+// hashVerificationCode binds code to the contact, only its hash is stored.
+func hashVerificationCode(recipientId int64, contact, code string) string {
+	sum := sha256.Sum256([]byte(strconv.FormatInt(recipientId, 10) + "\n" + contact + "\n" +
+		code))
+	return hex.EncodeToString(sum[:])
+}
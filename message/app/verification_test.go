@@ -0,0 +1,185 @@
+package app
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/opensourceways/message-manager/common/domain/allerror"
+	"github.com/opensourceways/message-manager/common/mail"
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+// MockMessageVerificationAdapter 是 MessageVerificationAdapter 的模拟实现
+type MockMessageVerificationAdapter struct {
+	mock.Mock
+}
+
+func (m *MockMessageVerificationAdapter) GetRecipientContact(userName string,
+	recipientId int64) (domain.RecipientContactDO, error) {
+	args := m.Called(userName, recipientId)
+	return args.Get(0).(domain.RecipientContactDO), args.Error(1)
+}
+
+func (m *MockMessageVerificationAdapter) SaveVerification(v domain.VerificationDO,
+	cooldown time.Duration) (bool, error) {
+	args := m.Called(v, cooldown)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMessageVerificationAdapter) ClaimVerification(recipientId int64,
+	contactType string, maxAttempts int) (domain.VerificationDO, error) {
+	args := m.Called(recipientId, contactType, maxAttempts)
+	return args.Get(0).(domain.VerificationDO), args.Error(1)
+}
+
+func (m *MockMessageVerificationAdapter) CompleteVerification(recipientId int64,
+	contactType, contact string) error {
+	args := m.Called(recipientId, contactType, contact)
+	return args.Error(0)
+}
+
+// MockMailSender 是 MailSender 的模拟实现
+type MockMailSender struct {
+	mock.Mock
+}
+
+func (m *MockMailSender) Send(msg mail.Message) error {
+	args := m.Called(msg)
+	return args.Error(0)
+}
+
+// MockSMSGateway 是 SMSGateway 的模拟实现
+type MockSMSGateway struct {
+	mock.Mock
+}
+
+func (m *MockSMSGateway) Send(phone, text string) error {
+	args := m.Called(phone, text)
+	return args.Error(0)
+}
+
+var verificationOption = VerificationOption{
+	TTL: 10 * time.Minute, Cooldown: time.Minute, MaxAttempts: 5,
+}
+
+var verificationCode = regexp.MustCompile(`\d{6}`)
+
+func TestSendVerification(t *testing.T) {
+	mockAdapter := new(MockMessageVerificationAdapter)
+	mockSender := new(MockMailSender)
+	mockGateway := new(MockSMSGateway)
+	service := NewMessageVerificationAppService(mockAdapter, mockSender, mockGateway,
+		verificationOption)
+	now := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	service.(*messageVerificationAppService).now = func() time.Time { return now }
+
+	mockAdapter.On("GetRecipientContact", "alice", int64(1)).Return(domain.RecipientContactDO{
+		RecipientId: 1, Mail: "alice@example.com", Phone: "+8613800000000", PhoneVerified: true,
+	}, nil)
+	mockAdapter.On("GetRecipientContact", "alice", int64(2)).Return(
+		domain.RecipientContactDO{}, nil)
+
+	var code string
+	saved := func(v domain.VerificationDO) bool {
+		return v.RecipientId == 1 && v.ContactType == domain.ContactMail &&
+			v.Contact == "alice@example.com" && v.ExpiresAt.Equal(now.Add(10*time.Minute)) &&
+			len(v.CodeHash) == 64
+	}
+	mockAdapter.On("SaveVerification", mock.MatchedBy(saved), time.Minute).Return(true,
+		nil).Once()
+	mockSender.On("Send", mock.MatchedBy(func(msg mail.Message) bool {
+		code = verificationCode.FindString(msg.Content.Text)
+		return strings.Join(msg.To, ",") == "alice@example.com" &&
+			strings.Contains(msg.Content.Text, "10 分钟")
+	})).Return(nil).Once()
+
+	assert.NoError(t, service.SendVerification("alice", 1, domain.ContactMail))
+	if assert.Len(t, code, VerificationCodeLen) {
+		v := mockAdapter.Calls[1].Arguments.Get(0).(domain.VerificationDO)
+		assert.Equal(t, hashVerificationCode(1, "alice@example.com", code), v.CodeHash)
+	}
+
+	// the next code is not sent within the cooldown
+	mockAdapter.On("SaveVerification", mock.MatchedBy(saved), time.Minute).Return(false,
+		nil).Once()
+	err := service.SendVerification("alice", 1, domain.ContactMail)
+	assert.True(t, allerror.IsOverLimit(err))
+
+	err = service.SendVerification("alice", 1, domain.ContactPhone)
+	assert.True(t, allerror.IsInvalidParam(err), "the verified phone")
+	err = service.SendVerification("alice", 1, domain.ContactMessage)
+	assert.True(t, allerror.IsInvalidParam(err), "no number")
+	err = service.SendVerification("alice", 1, "fax")
+	assert.True(t, allerror.IsInvalidParam(err))
+	err = service.SendVerification("alice", 2, domain.ContactMail)
+	assert.True(t, allerror.IsNotFound(err))
+
+	mockAdapter.AssertExpectations(t)
+	mockSender.AssertExpectations(t)
+	mockGateway.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+}
+
+func TestSendVerificationBySMS(t *testing.T) {
+	mockAdapter := new(MockMessageVerificationAdapter)
+	mockGateway := new(MockSMSGateway)
+
+	mockAdapter.On("GetRecipientContact", "alice", int64(1)).Return(domain.RecipientContactDO{
+		RecipientId: 1, Mail: "alice@example.com", Phone: "+8613800000000",
+	}, nil)
+	mockAdapter.On("SaveVerification", mock.Anything, time.Minute).Return(true, nil).Once()
+	mockGateway.On("Send", "+8613800000000", mock.MatchedBy(func(text string) bool {
+		return verificationCode.MatchString(text)
+	})).Return(nil).Once()
+
+	// the mails can not be verified without the sender
+	service := NewMessageVerificationAppService(mockAdapter, nil, mockGateway,
+		verificationOption)
+	assert.Error(t, service.SendVerification("alice", 1, domain.ContactMail))
+	assert.NoError(t, service.SendVerification("alice", 1, domain.ContactPhone))
+
+	mockAdapter.AssertExpectations(t)
+	mockGateway.AssertExpectations(t)
+}
+
+func TestConfirmVerification(t *testing.T) {
+	mockAdapter := new(MockMessageVerificationAdapter)
+	service := NewMessageVerificationAppService(mockAdapter, nil, nil, verificationOption)
+
+	mockAdapter.On("GetRecipientContact", "alice", int64(1)).Return(domain.RecipientContactDO{
+		RecipientId: 1, Mail: "alice@example.com", Message: "+8613800000000",
+		Phone: "+8613800000000",
+	}, nil)
+	mockAdapter.On("ClaimVerification", int64(1), domain.ContactMail, 5).Return(
+		domain.VerificationDO{
+			RecipientId: 1, ContactType: domain.ContactMail, Contact: "alice@example.com",
+			CodeHash: hashVerificationCode(1, "alice@example.com", "123456"),
+		}, nil)
+	mockAdapter.On("ClaimVerification", int64(1), domain.ContactPhone, 5).Return(
+		domain.VerificationDO{
+			RecipientId: 1, ContactType: domain.ContactPhone, Contact: "+8613900000000",
+			CodeHash: hashVerificationCode(1, "+8613900000000", "123456"),
+		}, nil)
+	mockAdapter.On("ClaimVerification", int64(1), domain.ContactMessage, 5).Return(
+		domain.VerificationDO{}, nil)
+	mockAdapter.On("CompleteVerification", int64(1), domain.ContactMail,
+		"alice@example.com").Return(nil).Once()
+
+	err := service.ConfirmVerification("alice", 1, domain.ContactMail, "654321")
+	assert.True(t, allerror.IsInvalidParam(err), "the wrong code")
+	assert.NoError(t, service.ConfirmVerification("alice", 1, domain.ContactMail, "123456"))
+
+	// the phone changed since the code was sent
+	err = service.ConfirmVerification("alice", 1, domain.ContactPhone, "123456")
+	assert.True(t, allerror.IsInvalidParam(err))
+
+	// the code of the message number expired
+	err = service.ConfirmVerification("alice", 1, domain.ContactMessage, "123456")
+	assert.True(t, allerror.IsInvalidParam(err))
+
+	mockAdapter.AssertExpectations(t)
+}
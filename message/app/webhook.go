@@ -0,0 +1,226 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package app
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/community"
+	"github.com/opensourceways/message-manager/common/webhook"
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+// the CloudEvents spec version of the posts
+const webhookSpecVersion = "1.0"
+
+type MessageWebhookAppService interface {
+	Notify(event CloudEventDTO) error
+	SendWebhook() (DeliveryResultDTO, error)
+	SendTestWebhook(event CloudEventDTO, url, secret string) error
+	GetWebhookLog(userName string, recipientId int64, countPerPage, pageNum int) (
+		[]WebhookLogDTO, int64, error)
+}
+
+// NewMessageWebhookAppService returns the service posting the events to the
+// webhooks, a webhook failing disableAfter times in a row is disabled.
+func NewMessageWebhookAppService(
+	messageWebhookAdapter domain.MessageWebhookAdapter,
+	messageDeliveryAdapter domain.MessageDeliveryAdapter,
+	sender domain.WebhookSender,
+	rules []FanoutRule,
+	option DeliveryOption,
+	disableAfter int,
+) MessageWebhookAppService {
+	return &messageWebhookAppService{
+		messageWebhookAdapter:  messageWebhookAdapter,
+		messageDeliveryAdapter: messageDeliveryAdapter,
+		sender:                 sender,
+		rules:                  rules,
+		disableAfter:           max(disableAfter, 1),
+		queue: newDeliveryQueue(messageDeliveryAdapter, domain.DeliveryChannelWebhook,
+			option),
+	}
+}
+
+type messageWebhookAppService struct {
+	messageWebhookAdapter  domain.MessageWebhookAdapter
+	messageDeliveryAdapter domain.MessageDeliveryAdapter
+	sender                 domain.WebhookSender
+	rules                  []FanoutRule
+	disableAfter           int
+	queue                  *deliveryQueue
+}
+
+// webhookEvent is an event in the structured content mode of the CloudEvents
+// HTTP binding, the optional attributes being empty are left out.
+type webhookEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	Id              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Time            string          `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	DataSchema      string          `json:"dataschema,omitempty"`
+	User            string          `json:"user,omitempty"`
+	SourceUrl       string          `json:"sourceurl,omitempty"`
+	Title           string          `json:"title,omitempty"`
+	Summary         string          `json:"summary,omitempty"`
+	SourceGroup     string          `json:"sourcegroup,omitempty"`
+	Community       string          `json:"community,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+}
+
+func newWebhookEvent(event CloudEventDTO) webhookEvent {
+	e := webhookEvent{
+		SpecVersion: webhookSpecVersion,
+		Id:          event.EventId,
+		Source:      event.Source,
+		Type:        event.Type,
+		DataSchema:  event.DataSchema,
+		User:        event.User,
+		SourceUrl:   event.SourceUrl,
+		Title:       event.Title,
+		Summary:     event.Summary,
+		SourceGroup: event.SourceGroup,
+		Community:   event.Community,
+	}
+	if !event.EventTime.IsZero() {
+		e.Time = event.EventTime.UTC().Format(time.RFC3339Nano)
+	}
+	if len(event.DataJson) != 0 {
+		e.DataContentType = "application/json"
+		e.Data = json.RawMessage(event.DataJson)
+	}
+	return e
+}
+
+// Notify queues the post of event to the webhooks of the recipients whose
+// subscriptions match it, once however often it is queued.
+func (s *messageWebhookAppService) Notify(event CloudEventDTO) error {
+	if event.Community == "" {
+		event.Community = community.DefaultId()
+	}
+	if !readsSource(event.Community, event.Source) {
+		return nil
+	}
+	data, err := decodeEventData(event)
+	if err != nil {
+		return err
+	}
+
+	var filters map[string]string
+	if rule := findFanoutRule(s.rules, event); rule != nil {
+		filters = rule.Filters
+	}
+	sigs, repos := eventMembership(filters, data)
+	targets, err := s.messageWebhookAdapter.GetWebhookTarget(event.Community, event.Source,
+		sigs, repos)
+	if err != nil {
+		return err
+	}
+
+	var items []domain.DeliveryDO
+	seen := map[string]bool{}
+	for _, target := range matchSubscribeTarget(targets, event, data, filters) {
+		if target.Target == "" || seen[target.Target] {
+			continue
+		}
+		seen[target.Target] = true
+		items = append(items, domain.DeliveryDO{
+			Channel:     domain.DeliveryChannelWebhook,
+			EventId:     event.EventId,
+			RecipientId: target.RecipientId,
+			Target:      target.Target,
+		})
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	b, err := json.Marshal(newWebhookEvent(event))
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		items[i].Content = b
+	}
+	_, err = s.messageDeliveryAdapter.EnqueueDelivery(items)
+	return err
+}
+
+// SendWebhook posts the due events with the current secrets, the posts to a
+// webhook changed or disabled since are given up.
+func (s *messageWebhookAppService) SendWebhook() (DeliveryResultDTO, error) {
+	return s.queue.drain(s.deliver)
+}
+
+// SendTestWebhook posts event to the webhook url at once, the post is neither
+// logged nor counted as a failure of the webhook.
+func (s *messageWebhookAppService) SendTestWebhook(event CloudEventDTO, url,
+	secret string) error {
+	b, err := json.Marshal(newWebhookEvent(event))
+	if err != nil {
+		return err
+	}
+	_, err = s.sender.Send(url, secret, b)
+	return err
+}
+
+func (s *messageWebhookAppService) deliver(item domain.DeliveryDO) (bool, error) {
+	hook, err := s.messageWebhookAdapter.GetWebhook(item.RecipientId)
+	if err != nil {
+		return false, err
+	}
+	if hook.Webhook != item.Target || hook.WebhookDisabledAt != nil {
+		return true, xerrors.Errorf("the webhook of recipient %d is changed or disabled",
+			item.RecipientId)
+	}
+
+	result, err := s.sender.Send(item.Target, hook.WebhookSecret, item.Content)
+	log := domain.WebhookLogDO{
+		DeliveryId:  item.Id,
+		RecipientId: item.RecipientId,
+		EventId:     item.EventId,
+		Url:         item.Target,
+		Attempt:     item.Attempts,
+		StatusCode:  result.StatusCode,
+		DurationMs:  result.Duration.Milliseconds(),
+	}
+	if err != nil {
+		log.Error = err.Error()
+	}
+	if err := s.messageWebhookAdapter.SaveWebhookLog(log); err != nil {
+		logrus.Errorf("save webhook log of delivery %d failed, err:%v", item.Id, err)
+	}
+
+	disabled, recordErr := s.messageWebhookAdapter.RecordWebhookResult(item.RecipientId,
+		err == nil, s.disableAfter)
+	if recordErr != nil {
+		logrus.Errorf("record webhook result of recipient %d failed, err:%v",
+			item.RecipientId, recordErr)
+	}
+	if disabled {
+		logrus.Warnf("the webhook of recipient %d is disabled after %d failures",
+			item.RecipientId, s.disableAfter)
+		return true, err
+	}
+	return webhook.IsPermanent(err), err
+}
+
+// GetWebhookLog returns the posts to the webhook of the recipient recipientId
+// of userName, the latest first.
+func (s *messageWebhookAppService) GetWebhookLog(userName string, recipientId int64,
+	countPerPage, pageNum int) ([]WebhookLogDTO, int64, error) {
+	data, count, err := s.messageWebhookAdapter.GetWebhookLog(userName, recipientId,
+		countPerPage, pageNum)
+	if err != nil {
+		return []WebhookLogDTO{}, 0, err
+	}
+	return data, count, nil
+}
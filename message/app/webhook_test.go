@@ -0,0 +1,178 @@
+package app
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/datatypes"
+
+	"github.com/opensourceways/message-manager/common/source"
+	"github.com/opensourceways/message-manager/common/webhook"
+	"github.com/opensourceways/message-manager/message/domain"
+)
+
+// MockMessageWebhookAdapter 是 MessageWebhookAdapter 的模拟实现
+type MockMessageWebhookAdapter struct {
+	mock.Mock
+}
+
+func (m *MockMessageWebhookAdapter) GetWebhookTarget(communityId, source string, sigs,
+	repos []string) ([]domain.SubscribeTargetDO, error) {
+	args := m.Called(communityId, source, sigs, repos)
+	return args.Get(0).([]domain.SubscribeTargetDO), args.Error(1)
+}
+
+func (m *MockMessageWebhookAdapter) GetWebhook(recipientId int64) (domain.WebhookDO, error) {
+	args := m.Called(recipientId)
+	return args.Get(0).(domain.WebhookDO), args.Error(1)
+}
+
+func (m *MockMessageWebhookAdapter) RecordWebhookResult(recipientId int64, ok bool,
+	disableAfter int) (bool, error) {
+	args := m.Called(recipientId, ok, disableAfter)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMessageWebhookAdapter) SaveWebhookLog(log domain.WebhookLogDO) error {
+	return m.Called(log.DeliveryId, log.StatusCode, log.Error != "").Error(0)
+}
+
+func (m *MockMessageWebhookAdapter) GetWebhookLog(userName string, recipientId int64,
+	countPerPage, pageNum int) ([]domain.WebhookLogDO, int64, error) {
+	args := m.Called(userName, recipientId, countPerPage, pageNum)
+	return args.Get(0).([]domain.WebhookLogDO), int64(args.Int(1)), args.Error(2)
+}
+
+const testWebhookSecret = "0123456789abcdef"
+
+func TestWebhookNotify(t *testing.T) {
+	mockWebhook := new(MockMessageWebhookAdapter)
+	mockDelivery := new(MockMessageDeliveryAdapter)
+	service := NewMessageWebhookAppService(mockWebhook, mockDelivery, nil, FanoutRules(),
+		mailOption, 3)
+
+	mockWebhook.On("GetWebhookTarget", fanoutCommunity, source.DefaultGiteeUrl, noMembership,
+		noMembership).Return([]domain.SubscribeTargetDO{
+		{SubscribeId: 1, EventType: "pr", RecipientId: 9, Target: "https://ci.example.com/a",
+			ModeFilter: datatypes.JSON(`{"PullRequestEvent.PullRequest.State": "merged"}`)},
+		{SubscribeId: 2, EventType: "pr", RecipientId: 8, Target: "https://ci.example.com/b",
+			ModeFilter: datatypes.JSON(`{"PullRequestEvent.PullRequest.State": "open"}`)},
+		// an event is posted once to a webhook
+		{SubscribeId: 3, EventType: "*", RecipientId: 9, Target: "https://ci.example.com/a"},
+	}, nil).Once()
+
+	var items []domain.DeliveryDO
+	mockDelivery.On("EnqueueDelivery", mock.Anything).Run(func(args mock.Arguments) {
+		items = args.Get(0).([]domain.DeliveryDO)
+	}).Return(nil).Once()
+
+	event := newFanoutEvent("pr-1", source.DefaultGiteeUrl, "pr", prFixture)
+	event.Title = "PR #1"
+	event.EventTime = time.Date(2024, 5, 1, 8, 0, 0, 0, time.FixedZone("CST", 8*3600))
+	assert.NoError(t, service.Notify(event))
+	mockWebhook.AssertExpectations(t)
+	mockDelivery.AssertExpectations(t)
+
+	if !assert.Len(t, items, 1) {
+		return
+	}
+	assert.Equal(t, domain.DeliveryChannelWebhook, items[0].Channel)
+	assert.Equal(t, "https://ci.example.com/a", items[0].Target)
+
+	var payload map[string]interface{}
+	assert.NoError(t, json.Unmarshal(items[0].Content, &payload))
+	assert.Equal(t, "1.0", payload["specversion"])
+	assert.Equal(t, "pr-1", payload["id"])
+	assert.Equal(t, "2024-05-01T00:00:00Z", payload["time"])
+	assert.Equal(t, "application/json", payload["datacontenttype"])
+	assert.Equal(t, "PR #1", payload["title"])
+	assert.NotContains(t, payload, "dataschema")
+	assert.NotContains(t, payload, "summary")
+	assert.Contains(t, payload["data"], "PullRequestEvent")
+}
+
+func TestSendWebhook(t *testing.T) {
+	var bodies [][]byte
+	statuses := []int{http.StatusOK, http.StatusServiceUnavailable, http.StatusBadRequest,
+		http.StatusServiceUnavailable}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !webhook.Verify(testWebhookSecret, r.Header.Get(webhook.HeaderTimestamp), body,
+			r.Header.Get(webhook.HeaderSignature)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		bodies = append(bodies, body)
+		w.WriteHeader(statuses[0])
+		statuses = statuses[1:]
+	}))
+	defer server.Close()
+
+	mockWebhook := new(MockMessageWebhookAdapter)
+	mockDelivery := new(MockMessageDeliveryAdapter)
+	service := NewMessageWebhookAppService(mockWebhook, mockDelivery,
+		webhook.NewClient(webhook.Config{AllowPrivateNetwork: true}), nil, mailOption, 3)
+	now := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	service.(*messageWebhookAppService).queue.now = func() time.Time { return now }
+
+	content := datatypes.JSON(`{"specversion": "1.0", "id": "pr-1"}`)
+	mockDelivery.On("ClaimDelivery", domain.DeliveryChannelWebhook, 10, 5*time.Minute).Return(
+		[]domain.DeliveryDO{
+			{Id: 1, RecipientId: 9, Target: server.URL, Content: content, Attempts: 1},
+			{Id: 2, RecipientId: 9, Target: server.URL, Content: content, Attempts: 2},
+			{Id: 3, RecipientId: 8, Target: server.URL, Content: content, Attempts: 1},
+			{Id: 4, RecipientId: 7, Target: server.URL, Content: content, Attempts: 1},
+			// the webhook changed since the event was queued
+			{Id: 5, RecipientId: 6, Target: server.URL, Content: content, Attempts: 1},
+		}, nil).Once()
+	hook := domain.WebhookDO{Webhook: server.URL, WebhookSecret: testWebhookSecret}
+	for _, id := range []int64{9, 8, 7} {
+		mockWebhook.On("GetWebhook", id).Return(hook, nil)
+	}
+	mockWebhook.On("GetWebhook", int64(6)).Return(domain.WebhookDO{
+		Webhook: "https://ci.example.com/new", WebhookSecret: testWebhookSecret}, nil).Once()
+
+	mockWebhook.On("SaveWebhookLog", int64(1), http.StatusOK, false).Return(nil).Once()
+	mockWebhook.On("SaveWebhookLog", int64(2), http.StatusServiceUnavailable, true).
+		Return(nil).Once()
+	mockWebhook.On("SaveWebhookLog", int64(3), http.StatusBadRequest, true).Return(nil).Once()
+	mockWebhook.On("SaveWebhookLog", int64(4), http.StatusServiceUnavailable, true).
+		Return(nil).Once()
+	mockWebhook.On("RecordWebhookResult", int64(9), true, 3).Return(false, nil).Once()
+	mockWebhook.On("RecordWebhookResult", int64(9), false, 3).Return(false, nil).Once()
+	mockWebhook.On("RecordWebhookResult", int64(8), false, 3).Return(false, nil).Once()
+	// the third failure in a row disables the webhook
+	mockWebhook.On("RecordWebhookResult", int64(7), false, 3).Return(true, nil).Once()
+
+	mockDelivery.On("CompleteDelivery", int64(1)).Return(nil).Once()
+	mockDelivery.On("RetryDelivery", int64(2), now.Add(2*time.Minute)).Return(nil).Once()
+	mockDelivery.On("FailDelivery", int64(3)).Return(nil).Once()
+	mockDelivery.On("FailDelivery", int64(4)).Return(nil).Once()
+	mockDelivery.On("FailDelivery", int64(5)).Return(nil).Once()
+
+	result, err := service.SendWebhook()
+	assert.NoError(t, err)
+	assert.Equal(t, DeliveryResultDTO{Sent: 1, Retried: 1, Failed: 3}, result)
+	mockWebhook.AssertExpectations(t)
+	mockDelivery.AssertExpectations(t)
+	assert.Len(t, bodies, 4)
+	assert.Equal(t, []byte(content), bodies[0])
+}
+
+func TestGetWebhookLog(t *testing.T) {
+	mockWebhook := new(MockMessageWebhookAdapter)
+	service := NewMessageWebhookAppService(mockWebhook, nil, nil, nil, mailOption, 3)
+
+	logs := []domain.WebhookLogDO{{Id: 1, RecipientId: 9, StatusCode: http.StatusOK}}
+	mockWebhook.On("GetWebhookLog", "alice", int64(9), 10, 1).Return(logs, 1, nil).Once()
+	data, count, err := service.GetWebhookLog("alice", 9, 10, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	assert.Equal(t, logs, data)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package controller
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opensourceways/message-manager/common/user"
+	"golang.org/x/xerrors"
+
+	commonctl "github.com/opensourceways/message-manager/common/controller"
+	"github.com/opensourceways/message-manager/common/domain/allerror"
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+const (
+	calendarPath   = "/message_center/calendar/"
+	calendarSuffix = ".ics"
+)
+
+func AddRouterForMessageCalendarController(
+	r *gin.Engine,
+	s app.MessageCalendarAppService,
+) {
+	ctl := messageCalendarController{
+		appService: s,
+	}
+
+	r.GET(calendarPath+":token", ctl.GetCalendar)
+
+	v1 := r.Group("/message_center/config")
+	v1.GET("/calendar", ctl.GetCalendarToken)
+	v1.POST("/calendar/reset", ctl.ResetCalendarToken)
+}
+
+type messageCalendarController struct {
+	appService app.MessageCalendarAppService
+}
+
+// GetCalendar
+// @Summary			GetCalendar
+// @Description		get the meeting todos as an iCalendar feed 会议日历订阅
+// @Tags			calendar
+// @Param			token path string true "calendar token followed by .ics"
+// @Produce			text/calendar
+// @Success			200	string ok 日历
+// @Failure			404	string not_found 日历不存在
+// @Failure			500	string system_error  查询失败
+// @Router			/message_center/calendar/{token} [get]
+// @Id		getCalendar
+func (ctl *messageCalendarController) GetCalendar(ctx *gin.Context) {
+	token, ok := strings.CutSuffix(ctx.Param("token"), calendarSuffix)
+	if !ok || token == "" {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "日历不存在"})
+		return
+	}
+
+	data, err := ctl.appService.GetCalendar(token)
+	if err != nil {
+		if allerror.IsNotFound(err) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "日历不存在"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v",
+				err)})
+		}
+		return
+	}
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Data(http.StatusOK, "text/calendar; charset=utf-8", data)
+}
+
+// GetCalendarToken
+// @Summary			GetCalendarToken
+// @Description		get the calendar feed url of the user 获取日历订阅地址
+// @Tags			calendar
+// @Success			200	string ok 日历订阅地址
+// @Failure			401	string unauthorized 用户未授权
+// @Failure			500	string system_error  查询失败
+// @Router			/message_center/config/calendar [get]
+// @Id		getCalendarToken
+func (ctl *messageCalendarController) GetCalendarToken(ctx *gin.Context) {
+	userName, err := user.GetSystemUserName(ctx)
+	if err != nil {
+		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
+		return
+	}
+	if token, err := ctl.appService.GetCalendarToken(userName); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v",
+			err)})
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"token": token, "url": calendarPath + token + calendarSuffix})
+	}
+}
+
+// ResetCalendarToken
+// @Summary			ResetCalendarToken
+// @Description		replace the calendar feed url of the user, the old one stops working 重置日历订阅地址
+// @Tags			calendar
+// @Success			202	string accepted 重置成功
+// @Failure			401	string unauthorized 用户未授权
+// @Failure			500	string system_error  重置失败
+// @Router			/message_center/config/calendar/reset [post]
+// @Id		resetCalendarToken
+func (ctl *messageCalendarController) ResetCalendarToken(ctx *gin.Context) {
+	userName, err := user.GetSystemUserName(ctx)
+	if err != nil {
+		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
+		return
+	}
+	if token, err := ctl.appService.ResetCalendarToken(userName); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("重置失败，err:%v",
+			err)})
+	} else {
+		ctx.JSON(http.StatusAccepted, gin.H{"token": token,
+			"url": calendarPath + token + calendarSuffix})
+	}
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/domain/allerror"
+)
+
+// Mock for the MessageCalendarAppService
+type MockMessageCalendarAppService struct {
+	mock.Mock
+}
+
+func (m *MockMessageCalendarAppService) GetCalendarToken(userName string) (string, error) {
+	args := m.Called(userName)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockMessageCalendarAppService) ResetCalendarToken(userName string) (string, error) {
+	args := m.Called(userName)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockMessageCalendarAppService) GetCalendar(token string) ([]byte, error) {
+	args := m.Called(token)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func TestGetCalendar(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	mockAppService := new(MockMessageCalendarAppService)
+
+	AddRouterForMessageCalendarController(router, mockAppService)
+
+	mockAppService.On("GetCalendar", "token").Return([]byte("BEGIN:VCALENDAR\r\n"), nil)
+	mockAppService.On("GetCalendar", "unknown").
+		Return([]byte(nil), allerror.NewNotFound("calendar_not_found", ""))
+	mockAppService.On("GetCalendar", "broken").Return([]byte(nil), xerrors.New("db error"))
+
+	tests := []struct {
+		path string
+		code int
+	}{
+		{"/message_center/calendar/token.ics", http.StatusOK},
+		{"/message_center/calendar/token", http.StatusNotFound},
+		{"/message_center/calendar/.ics", http.StatusNotFound},
+		{"/message_center/calendar/unknown.ics", http.StatusNotFound},
+		{"/message_center/calendar/broken.ics", http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		req, err := http.NewRequest(http.MethodGet, test.path, nil)
+		if err != nil {
+			t.Fatal("Failed to create request:", err)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, test.code, recorder.Code, test.path)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "/message_center/calendar/token.ics", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, "text/calendar; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "BEGIN:VCALENDAR\r\n", recorder.Body.String())
+}
+
+func TestGetCalendarToken_Unauthorized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	AddRouterForMessageCalendarController(router, new(MockMessageCalendarAppService))
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		path := "/message_center/config/calendar"
+		if method == http.MethodPost {
+			path += "/reset"
+		}
+		req, err := http.NewRequest(method, path, nil)
+		if err != nil {
+			t.Fatal("Failed to create request:", err)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package controller
+
+import (
+	"crypto/subtle"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/community"
+	commonctl "github.com/opensourceways/message-manager/common/controller"
+	"github.com/opensourceways/message-manager/config"
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+const cloudEventMaxBodyBytes = 4 << 20
+
+var cloudEventConfig config.CloudEvent
+
+func InitCloudEvent(cfg *config.CloudEvent) {
+	cloudEventConfig = config.CloudEvent{
+		Tokens:          cfg.Tokens,
+		CommunityTokens: cfg.CommunityTokens,
+	}
+	if len(cfg.Tokens) == 0 && len(cfg.CommunityTokens) == 0 {
+		logrus.Warn("no cloud event token is configured, every cloud event is refused")
+	}
+	for id := range cfg.CommunityTokens {
+		if _, ok := community.Get(id); !ok {
+			logrus.Warnf("the cloud event tokens of unknown community %s are refused", id)
+		}
+	}
+}
+
+func AddRouterForMessageCloudEventController(
+	r *gin.Engine,
+	s app.MessageCloudEventAppService,
+) {
+	ctl := messageCloudEventController{
+		appService: s,
+	}
+
+	v1 := r.Group("/message_center")
+	v1.POST("/cloudevents", ctl.ReceiveCloudEvent)
+}
+
+type messageCloudEventController struct {
+	appService app.MessageCloudEventAppService
+}
+
+// ReceiveCloudEvent
+// @Summary			ReceiveCloudEvent
+// @Description		receive CloudEvents 1.0 in structured, batch or binary content mode 接收消息事件
+// @Tags			cloud_event
+// @Accept			json
+// @Success			202	string accepted 接收成功
+// @Failure			400	string bad_request  无效的事件
+// @Failure			401	string unauthorized 发布者未授权
+// @Failure			403	string forbidden 发布者无权发布该社区的事件
+// @Failure			500	string system_error  保存失败
+// @Router			/message_center/cloudevents [post]
+// @Id		receiveCloudEvent
+func (ctl *messageCloudEventController) ReceiveCloudEvent(ctx *gin.Context) {
+	communityId, ok := cloudEventCommunity(ctx.GetHeader("Authorization"))
+	if !ok {
+		commonctl.SendUnauthorized(ctx, xerrors.Errorf("invalid publisher token"))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, cloudEventMaxBodyBytes))
+	if err != nil {
+		commonctl.SendBadRequestParam(ctx, xerrors.Errorf("read body failed, err:%v", err))
+		return
+	}
+	events, err := parseCloudEvent(ctx.Request.Header, body)
+	if err != nil {
+		commonctl.SendBadRequestParam(ctx, err)
+		return
+	}
+	// the publisher may only publish the events of the community of its token
+	for i := range events {
+		if events[i].Community == "" {
+			events[i].Community = communityId
+		} else if events[i].Community != communityId {
+			commonctl.SendForbidden(ctx, xerrors.Errorf(
+				"the publisher can not publish the event %s of community %s",
+				events[i].EventId, events[i].Community))
+			return
+		}
+	}
+	if err = ctl.appService.ValidateCloudEvent(events); err != nil {
+		commonctl.SendBadRequestParam(ctx, err)
+		return
+	}
+
+	if data, err := ctl.appService.SaveCloudEvent(events); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("保存失败，err:%v",
+			err), "result": data})
+	} else {
+		ctx.JSON(http.StatusAccepted, gin.H{"result": data})
+	}
+}
+
+// cloudEventCommunity returns the community of the publisher token in
+// authorization.
+func cloudEventCommunity(authorization string) (string, bool) {
+	for id, tokens := range cloudEventConfig.CommunityTokens {
+		if _, ok := community.Get(id); ok && checkBearerToken(tokens, authorization) {
+			return id, true
+		}
+	}
+	if checkBearerToken(cloudEventConfig.Tokens, authorization) {
+		return community.DefaultId(), true
+	}
+	return "", false
+}
+
+// checkBearerToken tells whether authorization has one of tokens as bearer
+// token, none is accepted when tokens is empty.
+func checkBearerToken(tokens []string, authorization string) bool {
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok || token == "" {
+		return false
+	}
+	for _, v := range tokens {
+		if v != "" && subtle.ConstantTimeCompare([]byte(token), []byte(v)) == 1 {
+			return true
+		}
+	}
+	return false
+}
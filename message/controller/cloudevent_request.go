@@ -0,0 +1,147 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package controller
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+const (
+	cloudEventContentType      = "application/cloudevents+json"
+	cloudEventBatchContentType = "application/cloudevents-batch+json"
+	cloudEventHeaderPrefix     = "Ce-"
+)
+
+// cloudEventRequest is a CloudEvents 1.0 event in the json format, the
+// extensions carry the columns of the message.
+type cloudEventRequest struct {
+	Id              string          `json:"id"`
+	Source          string          `json:"source"`
+	SpecVersion     string          `json:"specversion"`
+	Type            string          `json:"type"`
+	DataContentType string          `json:"datacontenttype"`
+	DataSchema      string          `json:"dataschema"`
+	Time            string          `json:"time"`
+	User            string          `json:"user"`
+	SourceUrl       string          `json:"sourceurl"`
+	Title           string          `json:"title"`
+	Summary         string          `json:"summary"`
+	SourceGroup     string          `json:"sourcegroup"`
+	Community       string          `json:"community"`
+	Data            json.RawMessage `json:"data"`
+	DataBase64      string          `json:"data_base64"`
+}
+
+func (req *cloudEventRequest) toCmd() (cmd app.CloudEventDTO, err error) {
+	cmd.EventId = req.Id
+	cmd.Source = req.Source
+	cmd.SpecVersion = req.SpecVersion
+	cmd.Type = req.Type
+	cmd.DataContentType = req.DataContentType
+	cmd.DataSchema = req.DataSchema
+	cmd.User = req.User
+	cmd.SourceUrl = req.SourceUrl
+	cmd.Title = req.Title
+	cmd.Summary = req.Summary
+	cmd.SourceGroup = req.SourceGroup
+	cmd.Community = req.Community
+
+	if req.Time != "" {
+		if cmd.EventTime, err = time.Parse(time.RFC3339Nano, req.Time); err != nil {
+			return cmd, xerrors.Errorf("invalid time of event %s, err:%v", req.Id, err)
+		}
+	}
+
+	data := []byte(req.Data)
+	if req.DataBase64 != "" {
+		if data, err = base64.StdEncoding.DecodeString(req.DataBase64); err != nil {
+			return cmd, xerrors.Errorf("invalid data_base64 of event %s, err:%v", req.Id, err)
+		}
+	}
+	// only json data can be queried later, other data is dropped
+	if len(bytes.TrimSpace(data)) != 0 && !bytes.Equal(bytes.TrimSpace(data), []byte("null")) &&
+		json.Valid(data) {
+		cmd.DataJson = data
+	}
+	return cmd, nil
+}
+
+// parseCloudEvent reads the events of a request in the structured, batch or
+// binary content mode.
+func parseCloudEvent(header http.Header, body []byte) ([]app.CloudEventDTO, error) {
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+
+	var reqs []cloudEventRequest
+	switch mediaType {
+	case cloudEventContentType, cloudEventBatchContentType:
+		trimmed := bytes.TrimSpace(body)
+		if len(trimmed) != 0 && trimmed[0] == '[' {
+			if err := json.Unmarshal(trimmed, &reqs); err != nil {
+				return nil, xerrors.Errorf("invalid cloud event batch, err:%v", err)
+			}
+		} else {
+			var req cloudEventRequest
+			if err := json.Unmarshal(trimmed, &req); err != nil {
+				return nil, xerrors.Errorf("invalid cloud event, err:%v", err)
+			}
+			reqs = append(reqs, req)
+		}
+	default:
+		reqs = append(reqs, binaryCloudEvent(header, mediaType, body))
+	}
+
+	events := make([]app.CloudEventDTO, 0, len(reqs))
+	for i := range reqs {
+		cmd, err := reqs[i].toCmd()
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, cmd)
+	}
+	return events, nil
+}
+
+// binaryCloudEvent reads the attributes from the ce- headers and the data
+// from the body.
+func binaryCloudEvent(header http.Header, mediaType string, body []byte) cloudEventRequest {
+	attr := func(name string) string {
+		v := header.Get(cloudEventHeaderPrefix + name)
+		if decoded, err := url.PathUnescape(v); err == nil {
+			return decoded
+		}
+		return v
+	}
+
+	req := cloudEventRequest{
+		Id:              attr("Id"),
+		Source:          attr("Source"),
+		SpecVersion:     attr("Specversion"),
+		Type:            attr("Type"),
+		DataContentType: header.Get("Content-Type"),
+		DataSchema:      attr("Dataschema"),
+		Time:            attr("Time"),
+		User:            attr("User"),
+		SourceUrl:       attr("Sourceurl"),
+		Title:           attr("Title"),
+		Summary:         attr("Summary"),
+		SourceGroup:     attr("Sourcegroup"),
+		Community:       attr("Community"),
+	}
+	if mediaType == "" || mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+		req.Data = body
+	}
+	return req
+}
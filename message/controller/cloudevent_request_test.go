@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCloudEventStructured(t *testing.T) {
+	header := http.Header{}
+	header.Set("Content-Type", "application/cloudevents+json; charset=utf-8")
+	body := `{"specversion":"1.0","id":"1","source":"https://gitee.com","type":"pr",
+		"time":"2024-08-01T10:00:00Z","sourceurl":"https://gitee.com/pr/1","title":"t",
+		"data":{"Action":"open"}}`
+
+	events, err := parseCloudEvent(header, []byte(body))
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "1", events[0].EventId)
+	assert.Equal(t, "https://gitee.com/pr/1", events[0].SourceUrl)
+	assert.True(t, time.Date(2024, 8, 1, 10, 0, 0, 0, time.UTC).Equal(events[0].EventTime))
+	assert.JSONEq(t, `{"Action":"open"}`, string(events[0].DataJson))
+}
+
+func TestParseCloudEventBatch(t *testing.T) {
+	header := http.Header{}
+	header.Set("Content-Type", "application/cloudevents-batch+json")
+	body := `[{"specversion":"1.0","id":"1","source":"s","type":"t","data_base64":"eyJhIjoxfQ=="},
+		{"specversion":"1.0","id":"2","source":"s","type":"t","data":"plain"}]`
+
+	events, err := parseCloudEvent(header, []byte(body))
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+	assert.JSONEq(t, `{"a":1}`, string(events[0].DataJson))
+	assert.JSONEq(t, `"plain"`, string(events[1].DataJson))
+
+	_, err = parseCloudEvent(header, []byte(`[{"id":"1","time":"yesterday"}]`))
+	assert.Error(t, err)
+	_, err = parseCloudEvent(header, []byte(`[{]`))
+	assert.Error(t, err)
+}
+
+func TestParseCloudEventBinary(t *testing.T) {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("ce-specversion", "1.0")
+	header.Set("ce-id", "1")
+	header.Set("ce-source", "https://gitee.com")
+	header.Set("ce-type", "issue")
+	header.Set("ce-title", "%E6%A0%87%E9%A2%98")
+	header.Set("ce-community", "opengauss")
+
+	events, err := parseCloudEvent(header, []byte(`{"a":1}`))
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "issue", events[0].Type)
+	assert.Equal(t, "标题", events[0].Title)
+	assert.Equal(t, "opengauss", events[0].Community)
+	assert.Equal(t, "application/json", events[0].DataContentType)
+	assert.JSONEq(t, `{"a":1}`, string(events[0].DataJson))
+
+	header.Set("Content-Type", "text/plain")
+	events, err = parseCloudEvent(header, []byte(`{"a":1}`))
+	assert.NoError(t, err)
+	assert.Nil(t, events[0].DataJson)
+}
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/community"
+	"github.com/opensourceways/message-manager/config"
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+// Mock for the MessageCloudEventAppService
+type MockMessageCloudEventAppService struct {
+	mock.Mock
+}
+
+func (m *MockMessageCloudEventAppService) ValidateCloudEvent(events []app.CloudEventDTO) error {
+	return m.Called(len(events)).Error(0)
+}
+
+func (m *MockMessageCloudEventAppService) SaveCloudEvent(events []app.CloudEventDTO) (
+	app.CloudEventResultDTO, error) {
+	args := m.Called(len(events))
+	return args.Get(0).(app.CloudEventResultDTO), args.Error(1)
+}
+
+func TestReceiveCloudEvent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	mockAppService := new(MockMessageCloudEventAppService)
+	AddRouterForMessageCloudEventController(router, mockAppService)
+
+	defer InitCloudEvent(&config.CloudEvent{})
+
+	body := `{"specversion":"1.0","id":"1","source":"s","type":"t"}`
+	send := func(token, body string) int {
+		req, err := http.NewRequest(http.MethodPost, "/message_center/cloudevents",
+			strings.NewReader(body))
+		if err != nil {
+			t.Fatal("Failed to create request:", err)
+		}
+		req.Header.Set("Content-Type", "application/cloudevents+json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	InitCloudEvent(&config.CloudEvent{})
+	assert.Equal(t, http.StatusUnauthorized, send("secret", body))
+
+	InitCloudEvent(&config.CloudEvent{Tokens: []string{"secret"}})
+	assert.Equal(t, http.StatusUnauthorized, send("", body))
+	assert.Equal(t, http.StatusUnauthorized, send("wrong", body))
+	assert.Equal(t, http.StatusBadRequest, send("secret", "{"))
+
+	mockAppService.On("ValidateCloudEvent", 1).Return(nil).Once()
+	mockAppService.On("SaveCloudEvent", 1).
+		Return(app.CloudEventResultDTO{Accepted: []string{"1"}}, nil).Once()
+	assert.Equal(t, http.StatusAccepted, send("secret", body))
+
+	mockAppService.On("ValidateCloudEvent", 1).Return(xerrors.New("invalid")).Once()
+	assert.Equal(t, http.StatusBadRequest, send("secret", body))
+
+	mockAppService.On("ValidateCloudEvent", 1).Return(nil).Once()
+	mockAppService.On("SaveCloudEvent", 1).
+		Return(app.CloudEventResultDTO{}, xerrors.New("db error")).Once()
+	assert.Equal(t, http.StatusInternalServerError, send("secret", body))
+
+	// the token of a community may only publish the events of it
+	assert.NoError(t, community.Init(&community.Config{}, "openeuler"))
+	InitCloudEvent(&config.CloudEvent{
+		CommunityTokens: map[string][]string{"openeuler": {"euler"}, "unknown": {"other"}},
+	})
+	assert.Equal(t, http.StatusUnauthorized, send("other", body))
+	assert.Equal(t, http.StatusForbidden, send("euler",
+		`{"specversion":"1.0","id":"1","source":"s","type":"t","community":"mindspore"}`))
+	mockAppService.On("ValidateCloudEvent", 1).Return(nil).Once()
+	mockAppService.On("SaveCloudEvent", 1).
+		Return(app.CloudEventResultDTO{Accepted: []string{"1"}}, nil).Once()
+	assert.Equal(t, http.StatusAccepted, send("euler",
+		`{"specversion":"1.0","id":"1","source":"s","type":"t","community":"openeuler"}`))
+	mockAppService.AssertExpectations(t)
+}
+
+func TestCheckBearerToken(t *testing.T) {
+	assert.False(t, checkBearerToken(nil, "Bearer secret"))
+	assert.False(t, checkBearerToken([]string{""}, "Bearer "))
+	assert.False(t, checkBearerToken([]string{"secret"}, "secret"))
+	assert.False(t, checkBearerToken([]string{"secret"}, "Bearer wrong"))
+	assert.True(t, checkBearerToken([]string{"other", "secret"}, "Bearer secret"))
+}
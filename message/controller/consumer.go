@@ -0,0 +1,36 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package controller
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+const (
+	kafkaCloudEventHeaderPrefix = "ce_"
+	kafkaContentTypeHeader      = "content-type"
+)
+
+// DecodeConsumerMessage reads the events of a message in the structured or
+// binary mode of the kafka binding of CloudEvents.
+func DecodeConsumerMessage(msg app.ConsumerMessageDTO) ([]app.CloudEventDTO, error) {
+	header := http.Header{}
+	for k, v := range msg.Headers {
+		name := strings.ToLower(k)
+		switch {
+		case name == kafkaContentTypeHeader:
+			header.Set("Content-Type", v)
+		case strings.HasPrefix(name, kafkaCloudEventHeaderPrefix):
+			header.Set(cloudEventHeaderPrefix+name[len(kafkaCloudEventHeaderPrefix):], v)
+		}
+	}
+	if header.Get("Content-Type") == "" && header.Get(cloudEventHeaderPrefix+"Id") == "" {
+		header.Set("Content-Type", cloudEventContentType)
+	}
+	return parseCloudEvent(header, msg.Value)
+}
@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+func TestDecodeConsumerMessage(t *testing.T) {
+	// structured mode without headers, as message-collect publishes
+	events, err := DecodeConsumerMessage(app.ConsumerMessageDTO{
+		Value: []byte(`{"id":"1","source":"https://gitee.com","specversion":"1.0","type":"pr",` +
+			`"data":{"a":1}}`),
+	})
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "1", events[0].EventId)
+	assert.JSONEq(t, `{"a":1}`, string(events[0].DataJson))
+
+	events, err = DecodeConsumerMessage(app.ConsumerMessageDTO{
+		Headers: map[string]string{"content-type": "application/cloudevents-batch+json"},
+		Value:   []byte(`[{"id":"1"},{"id":"2"}]`),
+	})
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	// binary mode
+	events, err = DecodeConsumerMessage(app.ConsumerMessageDTO{
+		Headers: map[string]string{"ce_id": "3", "ce_source": "cve", "ce_specversion": "1.0",
+			"ce_type": "cve", "ce_title": "CVE%20fixed", "content-type": "application/json"},
+		Value: []byte(`{"b":2}`),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "3", events[0].EventId)
+	assert.Equal(t, "cve", events[0].Source)
+	assert.Equal(t, "CVE fixed", events[0].Title)
+	assert.JSONEq(t, `{"b":2}`, string(events[0].DataJson))
+
+	_, err = DecodeConsumerMessage(app.ConsumerMessageDTO{Value: []byte(`not json`)})
+	assert.Error(t, err)
+}
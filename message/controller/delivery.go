@@ -0,0 +1,178 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package controller
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/community"
+	commonctl "github.com/opensourceways/message-manager/common/controller"
+	"github.com/opensourceways/message-manager/common/user"
+	"github.com/opensourceways/message-manager/config"
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+var deliveryConfig config.Delivery
+
+func InitDelivery(cfg *config.Delivery) {
+	deliveryConfig = config.Delivery{
+		Tokens: cfg.Tokens,
+		Admins: cfg.Admins,
+	}
+}
+
+func AddRouterForMessageDeliveryController(
+	r *gin.Engine,
+	s app.MessageDeliveryAttemptAppService,
+) {
+	ctl := messageDeliveryController{
+		appService: s,
+	}
+
+	v1 := r.Group("/message_center")
+	v1.POST("/delivery/report", ctl.ReportDeliveryAttempt)
+	v1.GET("/delivery", ctl.GetDeliveryAttempt)
+	v1.GET("/admin/delivery", ctl.GetAllDeliveryAttempt)
+}
+
+type messageDeliveryController struct {
+	appService app.MessageDeliveryAttemptAppService
+}
+
+// ReportDeliveryAttempt
+// @Summary			ReportDeliveryAttempt
+// @Description		report the attempts of a pusher to deliver the messages 上报消息投递结果
+// @Tags			delivery
+// @Param			body body deliveryReportDTO true "deliveryReportDTO"
+// @Accept			json
+// @Success			202	string accepted 上报成功
+// @Failure			400	string bad_request  无效的参数
+// @Failure			401	string unauthorized 推送方未授权
+// @Failure			500	string system_error  上报失败
+// @Router			/message_center/delivery/report [post]
+// @Id		reportDeliveryAttempt
+func (ctl *messageDeliveryController) ReportDeliveryAttempt(ctx *gin.Context) {
+	if !checkBearerToken(deliveryConfig.Tokens, ctx.GetHeader("Authorization")) {
+		commonctl.SendUnauthorized(ctx, xerrors.Errorf("invalid pusher token"))
+		return
+	}
+	var req deliveryReportDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		commonctl.SendBadRequestParam(ctx, xerrors.Errorf("无法解析请求正文，err:%v", err))
+		return
+	}
+
+	attempts := req.toDTO()
+	if err := ctl.appService.ValidateDeliveryAttempt(attempts); err != nil {
+		commonctl.SendBadRequestParam(ctx, err)
+		return
+	}
+
+	if count, err := ctl.appService.ReportDeliveryAttempt(attempts); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("上报失败，err:%v",
+			err)})
+	} else {
+		ctx.JSON(http.StatusAccepted, gin.H{"saved": count})
+	}
+}
+
+// GetDeliveryAttempt
+// @Summary			GetDeliveryAttempt
+// @Description		get the attempts to deliver a message to the recipients of the user 查询消息投递记录
+// @Tags			delivery
+// @Param			event_id	query	string	true	"event id"
+// @Accept			json
+// @Success			202	{object}  app.DeliveryAttemptDTO
+// @Failure			400	string bad_request  无效的参数
+// @Failure			401	string unauthorized 用户未授权
+// @Failure			500	string system_error  查询失败
+// @Router			/message_center/delivery [get]
+// @Id		getDeliveryAttempt
+func (ctl *messageDeliveryController) GetDeliveryAttempt(ctx *gin.Context) {
+	userName, err := user.GetSystemUserName(ctx)
+	if err != nil {
+		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
+		return
+	}
+	eventId := ctx.Query("event_id")
+	if eventId == "" {
+		commonctl.SendBadRequestParam(ctx, xerrors.Errorf("event_id is required"))
+		return
+	}
+
+	if data, err := ctl.appService.GetDeliveryAttempt(userName, eventId); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v",
+			err)})
+	} else {
+		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": len(data)})
+	}
+}
+
+// GetAllDeliveryAttempt
+// @Summary			GetAllDeliveryAttempt
+// @Description		get the attempts to deliver the messages in the community of the admin 管理员查询消息投递记录
+// @Tags			delivery
+// @Param			event_id		query	string	false	"event id"
+// @Param			recipient_id	query	int		false	"recipient id"
+// @Param			channel			query	string	false	"channel"
+// @Param			status			query	string	false	"status"
+// @Param			count_per_page	query	int		true	"count per page"
+// @Param			page			query	int		true	"page"
+// @Accept			json
+// @Success			202	{object}  app.DeliveryAttemptDTO
+// @Failure			400	string bad_request  无效的参数
+// @Failure			401	string unauthorized 用户未授权
+// @Failure			403	string forbidden 不是管理员
+// @Failure			500	string system_error  查询失败
+// @Router			/message_center/admin/delivery [get]
+// @Id		getAllDeliveryAttempt
+func (ctl *messageDeliveryController) GetAllDeliveryAttempt(ctx *gin.Context) {
+	userName, err := user.GetSystemUserName(ctx)
+	if err != nil {
+		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
+		return
+	}
+	if !slices.Contains(deliveryConfig.Admins, userName) {
+		commonctl.SendForbidden(ctx, xerrors.Errorf("%s is not an admin", userName))
+		return
+	}
+
+	// the admins only see the recipients of their own community
+	communityId, _ := community.SplitUserKey(userName)
+	cmd := app.CmdToGetDeliveryAttempt{
+		Community: communityId,
+		EventId:   ctx.Query("event_id"),
+		Channel:   ctx.Query("channel"),
+		Status:    ctx.Query("status"),
+	}
+	if v := ctx.Query("recipient_id"); v != "" {
+		if cmd.RecipientId, err = strconv.ParseInt(v, 10, 64); err != nil {
+			commonctl.SendBadRequestParam(ctx, xerrors.Errorf("invalid recipient_id, err:%v",
+				err))
+			return
+		}
+	}
+	if cmd.CountPerPage, err = strconv.Atoi(ctx.Query("count_per_page")); err != nil ||
+		cmd.CountPerPage <= 0 {
+		commonctl.SendBadRequestParam(ctx, xerrors.Errorf("invalid count_per_page"))
+		return
+	}
+	if cmd.PageNum, err = strconv.Atoi(ctx.Query("page")); err != nil || cmd.PageNum <= 0 {
+		commonctl.SendBadRequestParam(ctx, xerrors.Errorf("invalid page"))
+		return
+	}
+
+	if data, count, err := ctl.appService.GetAllDeliveryAttempt(cmd); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v",
+			err)})
+	} else {
+		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count})
+	}
+}
@@ -0,0 +1,44 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package controller
+
+import (
+	"strings"
+	"time"
+
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+type deliveryAttemptDTO struct {
+	EventId     string    `json:"event_id"`
+	RecipientId int64     `json:"recipient_id"`
+	Channel     string    `json:"channel"`
+	Target      string    `json:"target"`
+	Status      string    `json:"status"`
+	Error       string    `json:"error"`
+	Attempt     int       `json:"attempt"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+type deliveryReportDTO struct {
+	Attempts []deliveryAttemptDTO `json:"attempts"`
+}
+
+func (req *deliveryReportDTO) toDTO() []app.DeliveryAttemptDTO {
+	attempts := make([]app.DeliveryAttemptDTO, len(req.Attempts))
+	for i, a := range req.Attempts {
+		attempts[i] = app.DeliveryAttemptDTO{
+			EventId:     strings.TrimSpace(a.EventId),
+			RecipientId: a.RecipientId,
+			Channel:     strings.ToLower(strings.TrimSpace(a.Channel)),
+			Target:      strings.TrimSpace(a.Target),
+			Status:      strings.ToLower(strings.TrimSpace(a.Status)),
+			Error:       a.Error,
+			Attempt:     a.Attempt,
+			AttemptedAt: a.AttemptedAt,
+		}
+	}
+	return attempts
+}
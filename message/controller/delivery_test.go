@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/community"
+	"github.com/opensourceways/message-manager/common/user"
+	"github.com/opensourceways/message-manager/config"
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+// Mock for the MessageDeliveryAttemptAppService
+type MockMessageDeliveryAttemptAppService struct {
+	mock.Mock
+}
+
+func (m *MockMessageDeliveryAttemptAppService) ValidateDeliveryAttempt(
+	attempts []app.DeliveryAttemptDTO) error {
+	return m.Called(len(attempts)).Error(0)
+}
+
+func (m *MockMessageDeliveryAttemptAppService) ReportDeliveryAttempt(
+	attempts []app.DeliveryAttemptDTO) (int64, error) {
+	args := m.Called(attempts)
+	return int64(args.Int(0)), args.Error(1)
+}
+
+func (m *MockMessageDeliveryAttemptAppService) GetDeliveryAttempt(userName, eventId string) (
+	[]app.DeliveryAttemptDTO, error) {
+	args := m.Called(userName, eventId)
+	return args.Get(0).([]app.DeliveryAttemptDTO), args.Error(1)
+}
+
+func (m *MockMessageDeliveryAttemptAppService) GetAllDeliveryAttempt(
+	cmd app.CmdToGetDeliveryAttempt) ([]app.DeliveryAttemptDTO, int64, error) {
+	args := m.Called(cmd)
+	return args.Get(0).([]app.DeliveryAttemptDTO), int64(args.Int(1)), args.Error(2)
+}
+
+func TestReportDeliveryAttempt(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	mockAppService := new(MockMessageDeliveryAttemptAppService)
+	AddRouterForMessageDeliveryController(router, mockAppService)
+
+	body := `{"attempts":[{"event_id":" 1 ","recipient_id":2,"channel":"Message ",` +
+		`"status":"Sent"}]}`
+	send := func(token, body string) int {
+		req, err := http.NewRequest(http.MethodPost, "/message_center/delivery/report",
+			strings.NewReader(body))
+		if err != nil {
+			t.Fatal("Failed to create request:", err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// every report is refused without tokens
+	assert.Equal(t, http.StatusUnauthorized, send("", body))
+	assert.Equal(t, http.StatusUnauthorized, send("secret", body))
+
+	InitDelivery(&config.Delivery{Tokens: []string{"secret"}})
+	defer InitDelivery(&config.Delivery{})
+
+	assert.Equal(t, http.StatusUnauthorized, send("", body))
+	assert.Equal(t, http.StatusUnauthorized, send("wrong", body))
+	assert.Equal(t, http.StatusBadRequest, send("secret", "{"))
+
+	expected := []app.DeliveryAttemptDTO{{EventId: "1", RecipientId: 2, Channel: "message",
+		Status: "sent"}}
+	mockAppService.On("ValidateDeliveryAttempt", 1).Return(nil).Twice()
+	mockAppService.On("ReportDeliveryAttempt", expected).Return(1, nil).Once()
+	assert.Equal(t, http.StatusAccepted, send("secret", body))
+
+	mockAppService.On("ReportDeliveryAttempt", expected).Return(0, xerrors.New("db error")).Once()
+	assert.Equal(t, http.StatusInternalServerError, send("secret", body))
+
+	mockAppService.On("ValidateDeliveryAttempt", 1).Return(xerrors.New("invalid")).Once()
+	assert.Equal(t, http.StatusBadRequest, send("secret", body))
+	mockAppService.AssertExpectations(t)
+}
+
+func TestGetDeliveryAttempt(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	patches := gomonkey.ApplyFuncReturn(user.GetSystemUserName, "testUser", nil)
+	defer patches.Reset()
+
+	router := gin.Default()
+	mockAppService := new(MockMessageDeliveryAttemptAppService)
+	AddRouterForMessageDeliveryController(router, mockAppService)
+
+	mockAppService.On("GetDeliveryAttempt", "testUser", "1").
+		Return([]app.DeliveryAttemptDTO{{Id: 1, EventId: "1"}}, nil).Once()
+	mockAppService.On("GetDeliveryAttempt", "testUser", "2").
+		Return([]app.DeliveryAttemptDTO{}, xerrors.New("db error")).Once()
+
+	for query, code := range map[string]int{
+		"event_id=1": http.StatusAccepted,
+		"event_id=2": http.StatusInternalServerError,
+		"":           http.StatusBadRequest,
+	} {
+		req, err := http.NewRequest(http.MethodGet, "/message_center/delivery?"+query, nil)
+		if err != nil {
+			t.Fatal("Failed to create request:", err)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, code, recorder.Code, query)
+	}
+	mockAppService.AssertExpectations(t)
+}
+
+func TestGetAllDeliveryAttempt(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	patches := gomonkey.ApplyFuncReturn(user.GetSystemUserName, "testUser", nil)
+	defer patches.Reset()
+
+	router := gin.Default()
+	mockAppService := new(MockMessageDeliveryAttemptAppService)
+	AddRouterForMessageDeliveryController(router, mockAppService)
+
+	get := func(query string) int {
+		req, err := http.NewRequest(http.MethodGet, "/message_center/admin/delivery?"+query,
+			nil)
+		if err != nil {
+			t.Fatal("Failed to create request:", err)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, get("count_per_page=10&page=1"))
+
+	InitDelivery(&config.Delivery{Admins: []string{"testUser"}})
+	defer InitDelivery(&config.Delivery{})
+
+	mockAppService.On("GetAllDeliveryAttempt", app.CmdToGetDeliveryAttempt{
+		Community: community.DefaultId(), RecipientId: 9, Status: "failed", CountPerPage: 10,
+		PageNum: 1}).
+		Return([]app.DeliveryAttemptDTO{{Id: 1, RecipientId: 9}}, 1, nil).Once()
+	mockAppService.On("GetAllDeliveryAttempt", app.CmdToGetDeliveryAttempt{
+		Community: community.DefaultId(), EventId: "1", CountPerPage: 10, PageNum: 1}).
+		Return([]app.DeliveryAttemptDTO{}, 0, xerrors.New("db error")).Once()
+
+	for query, code := range map[string]int{
+		"recipient_id=9&status=failed&count_per_page=10&page=1": http.StatusAccepted,
+		"event_id=1&count_per_page=10&page=1":                   http.StatusInternalServerError,
+		"recipient_id=x&count_per_page=10&page=1":               http.StatusBadRequest,
+		"count_per_page=0&page=1":                               http.StatusBadRequest,
+		"count_per_page=10":                                     http.StatusBadRequest,
+	} {
+		assert.Equal(t, code, get(query), query)
+	}
+	mockAppService.AssertExpectations(t)
+}
@@ -0,0 +1,394 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package controller
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opensourceways/message-manager/common/user"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+
+	commonctl "github.com/opensourceways/message-manager/common/controller"
+	"github.com/opensourceways/message-manager/common/domain/allerror"
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+const (
+	exportBatchSize = 200
+
+	exportFormatCSV    = "csv"
+	exportFormatJSON   = "json"
+	exportFormatNDJSON = "ndjson"
+
+	exportListTodo        = "todo"
+	exportListAbout       = "about"
+	exportListWatch       = "watch"
+	exportListAll         = "all"
+	exportListForumSystem = "forum_system"
+	exportListForumAbout  = "forum_about"
+	exportListMeetingTodo = "meeting_todo"
+	exportListCVETodo     = "cve_todo"
+	exportListCVE         = "cve"
+	exportListIssueTodo   = "issue_todo"
+	exportListPRTodo      = "pr_todo"
+	exportListGiteeAbout  = "gitee_about"
+	exportListGitee       = "gitee"
+	exportListEur         = "eur"
+)
+
+// exportSourceLists are the lists of a registered source, the same as the
+// categories of GetSourceMessage.
+var exportSourceLists = map[string]bool{"follow": true, "related": true, "todo": true}
+
+var exportContentTypes = map[string]string{
+	exportFormatCSV:    "text/csv; charset=utf-8",
+	exportFormatJSON:   "application/json; charset=utf-8",
+	exportFormatNDJSON: "application/x-ndjson; charset=utf-8",
+}
+
+// exportDefaultColumns are exported when no column is selected.
+var exportDefaultColumns = []string{
+	"event_id", "title", "summary", "source", "type", "source_group", "user", "source_url",
+	"time", "is_read",
+}
+
+// exportColumns maps every exportable json name of MessageListDTO to its field.
+var exportColumns = func() map[string]int {
+	columns := map[string]int{}
+	t := reflect.TypeOf(app.MessageListDTO{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" || name == "total_count" {
+			continue
+		}
+		columns[name] = i
+	}
+	return columns
+}()
+
+type exportParams struct {
+	SourceQueryParams
+	List    string `form:"list"`
+	Source  string `form:"source"`
+	Format  string `form:"format"`
+	Columns string `form:"columns"`
+}
+
+func (req *exportParams) validate() ([]string, error) {
+	if req.List == "" {
+		req.List = exportListAll
+	}
+	if req.Source != "" {
+		if !exportSourceLists[req.List] {
+			return nil, xerrors.Errorf("invalid list of source:%s", req.List)
+		}
+	} else {
+		switch req.List {
+		case exportListTodo, exportListAbout, exportListWatch, exportListAll,
+			exportListForumSystem, exportListForumAbout, exportListMeetingTodo,
+			exportListCVETodo, exportListCVE, exportListIssueTodo, exportListPRTodo,
+			exportListGiteeAbout, exportListGitee, exportListEur:
+		default:
+			return nil, xerrors.Errorf("invalid list:%s", req.List)
+		}
+	}
+
+	if req.Format == "" {
+		req.Format = exportFormatCSV
+	}
+	if _, ok := exportContentTypes[req.Format]; !ok {
+		return nil, xerrors.Errorf("invalid format:%s", req.Format)
+	}
+
+	if req.Columns == "" {
+		return exportDefaultColumns, nil
+	}
+	var columns []string
+	for _, column := range strings.Split(req.Columns, ",") {
+		column = strings.TrimSpace(column)
+		if _, ok := exportColumns[column]; !ok {
+			return nil, xerrors.Errorf("invalid column:%s", column)
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// ExportMessage
+// @Summary			ExportMessage
+// @Description		export the messages of a list as csv, json or ndjson 导出消息
+// @Tags			message_center_openeuler_summit
+// @Param			list query string false "all, todo, about, watch or a category such as cve_todo"
+// @Param			source query string false "id of a registered source, list is then follow, related or todo"
+// @Param			format query string false "csv, json or ndjson, default csv"
+// @Param			columns query string false "comma separated columns of the message"
+// @Param			params query SourceQueryParams false "SourceQueryParams"
+// @Produce			text/csv
+// @Produce			json
+// @Success			200	string ok 导出成功
+// @Failure         400 string bad_request 无效的参数
+// @Failure			401	string unauthorized 用户未授权
+// @Failure			500	string system_error  查询失败
+// @Router			/message_center/inner/export [get]
+// @Id	    exportMessage
+func (ctl *messageListController) ExportMessage(ctx *gin.Context) {
+	var params exportParams
+	if err := ctx.ShouldBindQuery(&params); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	columns, err := params.validate()
+	if err != nil {
+		commonctl.SendBadRequestParam(ctx, err)
+		return
+	}
+	userName, err := user.GetSystemUserName(ctx)
+	if err != nil {
+		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
+		return
+	}
+
+	fetch := func(cursor string) ([]app.MessageListDTO, error) {
+		return ctl.fetchExport(userName, &params, cursor)
+	}
+	next := nextCursor
+	if (params.List == exportListMeetingTodo || params.List == exportListForumAbout) &&
+		params.Source == "" {
+		next = nextTimeCursor
+	}
+
+	// the first batch is fetched before the response starts so that a failure
+	// can still be reported by the status code
+	data, err := fetch("")
+	if err != nil {
+		if allerror.IsNotFound(err) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v", err)})
+		return
+	}
+
+	ctx.Header("Content-Type", exportContentTypes[params.Format])
+	name := params.List
+	if params.Source != "" {
+		name = params.Source + "-" + name
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="messages-%s.%s"`,
+		name, params.Format))
+	ctx.Status(http.StatusOK)
+
+	w := newExportWriter(ctx.Writer, params.Format, columns)
+	for {
+		if err = w.write(data); err != nil {
+			return
+		}
+		ctx.Writer.Flush()
+
+		cursor := next(data, exportBatchSize)
+		if cursor == "" {
+			break
+		}
+		if data, err = fetch(cursor); err != nil {
+			// the response is cut short, which the client notices by the
+			// missing end of the document
+			logrus.Errorf("export message failed, err:%v", err)
+			return
+		}
+	}
+	_ = w.close()
+}
+
+// fetchExport queries one batch of the list to export with the same filters as
+// the list API of it.
+func (ctl *messageListController) fetchExport(userName string, params *exportParams,
+	cursor string) (data []app.MessageListDTO, err error) {
+	if params.Source != "" {
+		cmd := params.toCmd(userName, params.List)
+		cmd.PageNum, cmd.CountPerPage, cmd.Cursor = 1, exportBatchSize, cursor
+		data, _, err = ctl.appService.GetSourceMessage(params.Source, cmd)
+		return
+	}
+
+	s, p := ctl.appService, params
+	switch params.List {
+	case exportListTodo:
+		data, _, err = s.GetAllToDoMessage(userName, p.IsDone, 1,
+			exportBatchSize, p.StartTime, p.IsRead, cursor)
+	case exportListAbout:
+		data, _, err = s.GetAllAboutMessage(userName, p.IsBot, 1,
+			exportBatchSize, p.StartTime, p.IsRead, cursor)
+	case exportListWatch:
+		data, _, err = s.GetAllWatchMessage(userName, 1, exportBatchSize,
+			p.StartTime, p.IsRead, cursor)
+	case exportListForumSystem:
+		data, _, err = s.GetForumSystemMessage(userName, 1, exportBatchSize, p.StartTime,
+			p.IsRead, cursor)
+	case exportListForumAbout:
+		data, _, err = s.GetForumAboutMessage(userName, p.IsBot, 1, exportBatchSize,
+			p.StartTime, p.IsRead, cursor)
+	case exportListMeetingTodo:
+		data, _, err = s.GetMeetingToDoMessage(userName, p.Filter, 1, exportBatchSize,
+			p.StartTime, p.IsRead, cursor)
+	case exportListCVETodo:
+		data, _, err = s.GetCVEToDoMessage(userName, p.IsDone, 1,
+			exportBatchSize, p.StartTime, p.IsRead, cursor)
+	case exportListCVE:
+		data, _, err = s.GetCVEMessage(userName, 1, exportBatchSize,
+			p.StartTime, p.IsRead, cursor)
+	case exportListIssueTodo:
+		data, _, err = s.GetIssueToDoMessage(userName, p.IsDone, 1,
+			exportBatchSize, p.StartTime, p.IsRead, cursor)
+	case exportListPRTodo:
+		data, _, err = s.GetPullRequestToDoMessage(userName, p.IsDone, 1,
+			exportBatchSize, p.StartTime, p.IsRead, cursor)
+	case exportListGiteeAbout:
+		data, _, err = s.GetGiteeAboutMessage(userName, p.IsBot, 1,
+			exportBatchSize, p.StartTime, p.IsRead, cursor)
+	case exportListGitee:
+		data, _, err = s.GetGiteeMessage(userName, 1, exportBatchSize,
+			p.StartTime, p.IsRead, cursor)
+	case exportListEur:
+		data, _, err = s.GetEurMessage(userName, 1, exportBatchSize, p.StartTime, p.IsRead,
+			cursor)
+	default:
+		data, _, err = s.GetAllMessage(userName, 1, exportBatchSize, p.IsRead, cursor)
+	}
+	return
+}
+
+// exportWriter writes messages in one of the export formats.
+type exportWriter struct {
+	w       gin.ResponseWriter
+	format  string
+	columns []string
+	csv     *csv.Writer
+	count   int
+}
+
+func newExportWriter(w gin.ResponseWriter, format string, columns []string) *exportWriter {
+	return &exportWriter{w: w, format: format, columns: columns}
+}
+
+func (e *exportWriter) write(data []app.MessageListDTO) error {
+	switch e.format {
+	case exportFormatCSV:
+		return e.writeCSV(data)
+	case exportFormatJSON:
+		for i := range data {
+			sep := ",\n"
+			if e.count == 0 {
+				sep = "[\n"
+			}
+			if err := e.writeJSON(sep, data[i]); err != nil {
+				return err
+			}
+		}
+	default:
+		for i := range data {
+			if err := e.writeJSON("", data[i]); err != nil {
+				return err
+			}
+			if _, err := e.w.WriteString("\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// close ends the document, the csv header is already written by the first
+// write.
+func (e *exportWriter) close() error {
+	if e.format != exportFormatJSON {
+		return nil
+	}
+	if e.count == 0 {
+		_, err := e.w.WriteString("[]\n")
+		return err
+	}
+	_, err := e.w.WriteString("\n]\n")
+	return err
+}
+
+func (e *exportWriter) writeCSV(data []app.MessageListDTO) error {
+	if e.csv == nil {
+		e.csv = csv.NewWriter(e.w)
+		if err := e.csv.Write(e.columns); err != nil {
+			return err
+		}
+	}
+	record := make([]string, len(e.columns))
+	for i := range data {
+		v := reflect.ValueOf(data[i])
+		for j, column := range e.columns {
+			record[j] = escapeCSVCell(formatExportValue(v.Field(exportColumns[column]).Interface()))
+		}
+		if err := e.csv.Write(record); err != nil {
+			return err
+		}
+		e.count++
+	}
+	e.csv.Flush()
+	return e.csv.Error()
+}
+
+// writeJSON writes one message as an object keeping the order of the columns.
+func (e *exportWriter) writeJSON(prefix string, data app.MessageListDTO) error {
+	var b strings.Builder
+	b.WriteString(prefix)
+	b.WriteString("{")
+	v := reflect.ValueOf(data)
+	for i, column := range e.columns {
+		value, err := json.Marshal(v.Field(exportColumns[column]).Interface())
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(strconv.Quote(column))
+		b.WriteString(":")
+		b.Write(value)
+	}
+	b.WriteString("}")
+	e.count++
+
+	_, err := e.w.WriteString(b.String())
+	return err
+}
+
+func formatExportValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// escapeCSVCell keeps spreadsheets from evaluating a cell as a formula.
+func escapeCSVCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
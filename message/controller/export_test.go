@@ -0,0 +1,215 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/opensourceways/message-manager/common/user"
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+// fakeExportListService pages over data by cursor like the list adapters.
+type fakeExportListService struct {
+	app.MessageListAppService
+	data []app.MessageListDTO
+
+	isDone    *bool
+	sourceCmd app.CmdToGetSourceMessage
+}
+
+func (s *fakeExportListService) GetCVEToDoMessage(userName string,
+	isDone *bool, pageNum, countPerPage int, startTime string, isRead *bool, cursor string) (
+	[]app.MessageListDTO, int64, error) {
+	s.isDone = isDone
+	return s.GetAllMessage(userName, pageNum, countPerPage, isRead, cursor)
+}
+
+func (s *fakeExportListService) GetSourceMessage(source string, cmd app.CmdToGetSourceMessage) (
+	[]app.MessageListDTO, int64, error) {
+	s.sourceCmd = cmd
+	return s.GetAllMessage(cmd.UserName, cmd.PageNum, cmd.CountPerPage, cmd.IsRead, cmd.Cursor)
+}
+
+func (s *fakeExportListService) GetAllMessage(userName string, pageNum, countPerPage int,
+	isRead *bool, cursor string) ([]app.MessageListDTO, int64, error) {
+	start := 0
+	for cursor != "" && start < len(s.data) {
+		start++
+		if nextCursor(s.data[start-1:start], 1) == cursor {
+			break
+		}
+	}
+	end := start + countPerPage
+	if end > len(s.data) {
+		end = len(s.data)
+	}
+	return s.data[start:end], int64(len(s.data)), nil
+}
+
+func exportFixture(n int) []app.MessageListDTO {
+	updatedAt := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+	data := make([]app.MessageListDTO, n)
+	for i := range data {
+		data[i] = app.MessageListDTO{
+			EventId:   fmt.Sprintf("event%d", i),
+			Title:     fmt.Sprintf("title, %d", i),
+			UpdatedAt: updatedAt.Add(-time.Duration(i) * time.Minute),
+		}
+	}
+	return data
+}
+
+func TestExportWriter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	data := exportFixture(2)
+	data[1].Title = "=cmd()"
+	data[1].IsRead = true
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{exportFormatCSV, "event_id,title,is_read\nevent0,\"title, 0\",false\nevent1,'=cmd(),true\n"},
+		{exportFormatJSON, "[\n{\"event_id\":\"event0\",\"title\":\"title, 0\",\"is_read\":false},\n" +
+			"{\"event_id\":\"event1\",\"title\":\"=cmd()\",\"is_read\":true}\n]\n"},
+		{exportFormatNDJSON, "{\"event_id\":\"event0\",\"title\":\"title, 0\",\"is_read\":false}\n" +
+			"{\"event_id\":\"event1\",\"title\":\"=cmd()\",\"is_read\":true}\n"},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		e := newExportWriter(c.Writer, test.format, []string{"event_id", "title", "is_read"})
+		assert.NoError(t, e.write(data[:1]))
+		assert.NoError(t, e.write(data[1:]))
+		assert.NoError(t, e.close())
+		assert.Equal(t, test.want, w.Body.String(), test.format)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	e := newExportWriter(c.Writer, exportFormatJSON, []string{"event_id"})
+	assert.NoError(t, e.write(nil))
+	assert.NoError(t, e.close())
+	assert.Equal(t, "[]\n", w.Body.String())
+}
+
+func TestExportParamsValidate(t *testing.T) {
+	params := exportParams{}
+	columns, err := params.validate()
+	assert.NoError(t, err)
+	assert.Equal(t, exportDefaultColumns, columns)
+	assert.Equal(t, exportListAll, params.List)
+	assert.Equal(t, exportFormatCSV, params.Format)
+
+	params = exportParams{Columns: "title, updated_at"}
+	columns, err = params.validate()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"title", "updated_at"}, columns)
+
+	for _, params := range []exportParams{
+		{List: exportListCVETodo}, {List: "todo", Source: "forum"},
+	} {
+		_, err = params.validate()
+		assert.NoError(t, err, params)
+	}
+
+	for _, params := range []exportParams{
+		{List: "unknown"}, {Format: "xml"}, {Columns: "total_count"}, {Columns: "title,"},
+		{List: exportListCVE, Source: "forum"}, {List: "follow"},
+	} {
+		_, err = params.validate()
+		assert.Error(t, err, params)
+	}
+}
+
+func TestExportMessage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	AddRouterForMessageListController(router, &fakeExportListService{})
+
+	req, err := http.NewRequest(http.MethodGet, "/message_center/inner/export?format=xml", nil)
+	if err != nil {
+		t.Fatal("Failed to create request:", err)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req, err = http.NewRequest(http.MethodGet, "/message_center/inner/export", nil)
+	if err != nil {
+		t.Fatal("Failed to create request:", err)
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestExportMessagePaging(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	patches := gomonkey.ApplyFuncReturn(user.GetSystemUserName, "testUser", nil)
+	defer patches.Reset()
+
+	data := exportFixture(exportBatchSize + 1)
+	router := gin.Default()
+	AddRouterForMessageListController(router, &fakeExportListService{data: data})
+
+	req, err := http.NewRequest(http.MethodGet,
+		"/message_center/inner/export?format=ndjson&columns=event_id", nil)
+	if err != nil {
+		t.Fatal("Failed to create request:", err)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `attachment; filename="messages-all.ndjson"`, w.Header().Get("Content-Disposition"))
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Len(t, lines, len(data))
+	var last map[string]string
+	assert.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &last))
+	assert.Equal(t, data[len(data)-1].EventId, last["event_id"])
+}
+
+func TestExportMessageFilters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	patches := gomonkey.ApplyFuncReturn(user.GetSystemUserName, "testUser", nil)
+	defer patches.Reset()
+
+	service := &fakeExportListService{data: exportFixture(1)}
+	router := gin.Default()
+	AddRouterForMessageListController(router, service)
+
+	req, err := http.NewRequest(http.MethodGet,
+		"/message_center/inner/export?list=cve_todo&is_done=true", nil)
+	if err != nil {
+		t.Fatal("Failed to create request:", err)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	if assert.NotNil(t, service.isDone) {
+		assert.True(t, *service.isDone)
+	}
+
+	req, err = http.NewRequest(http.MethodGet,
+		"/message_center/inner/export?source=forum&list=related&event_type=reply", nil)
+	if err != nil {
+		t.Fatal("Failed to create request:", err)
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `attachment; filename="messages-forum-related.csv"`,
+		w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "related", service.sourceCmd.Category)
+	assert.Equal(t, "reply", service.sourceCmd.EventType)
+}
@@ -0,0 +1,136 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/xerrors"
+
+	commonctl "github.com/opensourceways/message-manager/common/controller"
+	"github.com/opensourceways/message-manager/common/user"
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+func AddRouterForMessageIdentityController(
+	r *gin.Engine,
+	s app.MessageIdentityAppService,
+) {
+	ctl := messageIdentityController{
+		appService: s,
+	}
+
+	v1 := r.Group("/message_center/config")
+	v1.GET("/recipient/identity", ctl.GetIdentity)
+	v1.POST("/recipient/identity", ctl.LinkIdentity)
+	v1.DELETE("/recipient/identity", ctl.UnlinkIdentity)
+}
+
+type messageIdentityController struct {
+	appService app.MessageIdentityAppService
+}
+
+// GetIdentity
+// @Summary			GetIdentity
+// @Description		get the linked identities of a recipient 获取接收人关联的账号
+// @Tags			recipient
+// @Param			recipient_id query int true "recipient id"
+// @Accept			json
+// @Success			202	{object}  []app.RecipientIdentityDTO
+// @Failure			400	string bad_request  无法解析请求参数
+// @Failure			401	string unauthorized 用户未授权
+// @Failure			500	string system_error  查询失败
+// @Router			/message_center/config/recipient/identity [get]
+// @Id		getIdentity
+func (ctl *messageIdentityController) GetIdentity(ctx *gin.Context) {
+	recipientId, err := strconv.ParseInt(ctx.Query("recipient_id"), 10, 64)
+	if err != nil {
+		commonctl.SendBadRequestParam(ctx, xerrors.Errorf("无法解析请求参数"))
+		return
+	}
+	userName, err := user.GetSystemUserName(ctx)
+	if err != nil {
+		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
+		return
+	}
+	if data, err := ctl.appService.GetIdentity(userName, recipientId); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v",
+			err)})
+	} else {
+		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data})
+	}
+}
+
+// LinkIdentity
+// @Summary			LinkIdentity
+// @Description		link a forge login to a recipient 关联账号
+// @Tags			recipient
+// @Param			body body identityDTO true "identityDTO"
+// @Accept			json
+// @Success			202	string accepted 关联账号成功
+// @Failure			400	string bad_request  无法解析请求正文
+// @Failure			401	string unauthorized 用户未授权
+// @Failure			500	string server_error  关联账号失败
+// @Router			/message_center/config/recipient/identity [post]
+// @Id		linkIdentity
+func (ctl *messageIdentityController) LinkIdentity(ctx *gin.Context) {
+	cmd, userName, ok := ctl.bind(ctx)
+	if !ok {
+		return
+	}
+	if err := ctl.appService.LinkIdentity(userName, &cmd); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("关联账号失败，err:%v",
+			err)})
+	} else {
+		ctx.JSON(http.StatusAccepted, gin.H{"message": "关联账号成功"})
+	}
+}
+
+// UnlinkIdentity
+// @Summary			UnlinkIdentity
+// @Description		unlink a forge login from a recipient 取消关联账号
+// @Tags			recipient
+// @Param			body body identityDTO true "identityDTO"
+// @Accept			json
+// @Success			202	string accepted 取消关联账号成功
+// @Failure			400	string bad_request  无法解析请求正文
+// @Failure			401	string unauthorized 用户未授权
+// @Failure			500	string server_error  取消关联账号失败
+// @Router			/message_center/config/recipient/identity [delete]
+// @Id		unlinkIdentity
+func (ctl *messageIdentityController) UnlinkIdentity(ctx *gin.Context) {
+	cmd, userName, ok := ctl.bind(ctx)
+	if !ok {
+		return
+	}
+	if err := ctl.appService.UnlinkIdentity(userName, &cmd); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf(
+			"取消关联账号失败，err:%v", err)})
+	} else {
+		ctx.JSON(http.StatusAccepted, gin.H{"message": "取消关联账号成功"})
+	}
+}
+
+func (ctl *messageIdentityController) bind(ctx *gin.Context) (app.CmdToLinkIdentity, string,
+	bool) {
+	var req identityDTO
+	if err := ctx.BindJSON(&req); err != nil {
+		commonctl.SendBadRequestParam(ctx, xerrors.Errorf("failed to bind params, %w", err))
+		return app.CmdToLinkIdentity{}, "", false
+	}
+	cmd, err := req.toCmd()
+	if err != nil {
+		commonctl.SendBadRequestParam(ctx, xerrors.Errorf("failed to convert req to cmd, %w", err))
+		return app.CmdToLinkIdentity{}, "", false
+	}
+	userName, err := user.GetSystemUserName(ctx)
+	if err != nil {
+		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
+		return app.CmdToLinkIdentity{}, "", false
+	}
+	return cmd, userName, true
+}
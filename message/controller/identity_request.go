@@ -0,0 +1,24 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package controller
+
+import (
+	"strings"
+
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+type identityDTO struct {
+	RecipientId int64  `json:"recipient_id"`
+	Provider    string `json:"provider"`
+	Login       string `json:"login"`
+}
+
+func (req *identityDTO) toCmd() (cmd app.CmdToLinkIdentity, err error) {
+	cmd.RecipientId = req.RecipientId
+	cmd.Provider = strings.ToLower(strings.TrimSpace(req.Provider))
+	cmd.Login = strings.TrimSpace(req.Login)
+	return
+}
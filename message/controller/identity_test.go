@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/user"
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+// Mock for the MessageIdentityAppService
+type MockMessageIdentityAppService struct {
+	mock.Mock
+}
+
+func (m *MockMessageIdentityAppService) GetIdentity(userName string, recipientId int64) (
+	[]app.RecipientIdentityDTO, error) {
+	args := m.Called(userName, recipientId)
+	return args.Get(0).([]app.RecipientIdentityDTO), args.Error(1)
+}
+
+func (m *MockMessageIdentityAppService) LinkIdentity(userName string,
+	cmd *app.CmdToLinkIdentity) error {
+	return m.Called(userName, *cmd).Error(0)
+}
+
+func (m *MockMessageIdentityAppService) UnlinkIdentity(userName string,
+	cmd *app.CmdToLinkIdentity) error {
+	return m.Called(userName, *cmd).Error(0)
+}
+
+func TestIdentity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	patches := gomonkey.ApplyFuncReturn(user.GetSystemUserName, "testUser", nil)
+	defer patches.Reset()
+
+	router := gin.Default()
+	mockAppService := new(MockMessageIdentityAppService)
+	AddRouterForMessageIdentityController(router, mockAppService)
+
+	cmd := app.CmdToLinkIdentity{RecipientId: 1, Provider: "github", Login: "octo"}
+	mockAppService.On("GetIdentity", "testUser", int64(1)).
+		Return([]app.RecipientIdentityDTO{{RecipientId: 1, Provider: "github", Login: "octo"}}, nil)
+	mockAppService.On("LinkIdentity", "testUser", cmd).Return(nil).Once()
+	mockAppService.On("UnlinkIdentity", "testUser", cmd).Return(xerrors.New("not linked")).Once()
+
+	body := `{"recipient_id":1,"provider":" GitHub ","login":" octo "}`
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		{http.MethodGet, "/message_center/config/recipient/identity?recipient_id=1", "",
+			http.StatusAccepted},
+		{http.MethodGet, "/message_center/config/recipient/identity?recipient_id=a", "",
+			http.StatusBadRequest},
+		{http.MethodPost, "/message_center/config/recipient/identity", body, http.StatusAccepted},
+		{http.MethodPost, "/message_center/config/recipient/identity", "{", http.StatusBadRequest},
+		{http.MethodDelete, "/message_center/config/recipient/identity", body,
+			http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		req, err := http.NewRequest(test.method, test.path, bytes.NewBufferString(test.body))
+		if err != nil {
+			t.Fatal("Failed to create request:", err)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, test.code, recorder.Code, test.method+" "+test.path)
+	}
+	mockAppService.AssertExpectations(t)
+}
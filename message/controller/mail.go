@@ -0,0 +1,83 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package controller
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/community"
+	commonctl "github.com/opensourceways/message-manager/common/controller"
+	"github.com/opensourceways/message-manager/common/user"
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+func AddRouterForMessageMailController(
+	r *gin.Engine,
+	s app.MessageMailAppService,
+) {
+	ctl := messageMailController{
+		appService: s,
+	}
+
+	v1 := r.Group("/message_center")
+	v1.POST("/mail/preview", ctl.PreviewMail)
+}
+
+type messageMailController struct {
+	appService app.MessageMailAppService
+}
+
+// PreviewMail
+// @Summary			PreviewMail
+// @Description		render the mail of a CloudEvent in structured or binary content mode 预览邮件
+// @Tags			mail
+// @Accept			json
+// @Success			202	{object}  app.MailDTO
+// @Failure			400	string bad_request  无效的事件
+// @Failure			401	string unauthorized 用户未授权
+// @Failure			500	string system_error  预览失败
+// @Router			/message_center/mail/preview [post]
+// @Id		previewMail
+func (ctl *messageMailController) PreviewMail(ctx *gin.Context) {
+	if _, err := user.GetSystemUserName(ctx); err != nil {
+		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body,
+		cloudEventMaxBodyBytes))
+	if err != nil {
+		commonctl.SendBadRequestParam(ctx, xerrors.Errorf("read body failed, err:%v", err))
+		return
+	}
+	events, err := parseCloudEvent(ctx.Request.Header, body)
+	if err == nil && len(events) != 1 {
+		err = xerrors.Errorf("only one cloud event can be previewed")
+	}
+	if err != nil {
+		commonctl.SendBadRequestParam(ctx, err)
+		return
+	}
+	event := events[0]
+	if event.Community == "" {
+		c, err := community.FromRequest(ctx.Request)
+		if err != nil {
+			commonctl.SendBadRequestParam(ctx, err)
+			return
+		}
+		event.Community = c.Id
+	}
+
+	if data, err := ctl.appService.PreviewMail(event); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("预览失败，err:%v",
+			err)})
+	} else {
+		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data})
+	}
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/community"
+	"github.com/opensourceways/message-manager/common/user"
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+// Mock for the MessageMailAppService
+type MockMessageMailAppService struct {
+	mock.Mock
+}
+
+func (m *MockMessageMailAppService) Notify(event app.CloudEventDTO) error {
+	return m.Called(event).Error(0)
+}
+
+func (m *MockMessageMailAppService) PreviewMail(event app.CloudEventDTO) (app.MailDTO, error) {
+	args := m.Called(event.EventId, event.Community)
+	return args.Get(0).(app.MailDTO), args.Error(1)
+}
+
+func (m *MockMessageMailAppService) SendMail() (app.DeliveryResultDTO, error) {
+	args := m.Called()
+	return args.Get(0).(app.DeliveryResultDTO), args.Error(1)
+}
+
+func (m *MockMessageMailAppService) SendTestMail(event app.CloudEventDTO, to string) error {
+	return m.Called(event, to).Error(0)
+}
+
+func TestPreviewMail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	patches := gomonkey.ApplyFuncReturn(user.GetSystemUserName, "testUser", nil)
+	defer patches.Reset()
+
+	router := gin.Default()
+	mockAppService := new(MockMessageMailAppService)
+	AddRouterForMessageMailController(router, mockAppService)
+
+	mockAppService.On("PreviewMail", "1", community.DefaultId()).
+		Return(app.MailDTO{Subject: "PR merged", Text: "merged"}, nil).Once()
+	mockAppService.On("PreviewMail", "2", community.DefaultId()).
+		Return(app.MailDTO{}, xerrors.New("render error")).Once()
+
+	event := `{"specversion": "1.0", "id": "%s", "source": "https://gitee.com", "type": "pr"}`
+	cases := []struct {
+		body string
+		code int
+	}{
+		{strings.Replace(event, "%s", "1", 1), http.StatusAccepted},
+		{strings.Replace(event, "%s", "2", 1), http.StatusInternalServerError},
+		{"[" + strings.Replace(event, "%s", "3", 1) + "," +
+			strings.Replace(event, "%s", "4", 1) + "]", http.StatusBadRequest},
+		{"{", http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		req, err := http.NewRequest(http.MethodPost, "/message_center/mail/preview",
+			strings.NewReader(c.body))
+		if err != nil {
+			t.Fatal("Failed to create request:", err)
+		}
+		req.Header.Set("Content-Type", cloudEventContentType)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, c.code, recorder.Code, c.body)
+	}
+	mockAppService.AssertExpectations(t)
+}
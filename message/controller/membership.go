@@ -0,0 +1,55 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/xerrors"
+
+	commonctl "github.com/opensourceways/message-manager/common/controller"
+	"github.com/opensourceways/message-manager/common/user"
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+func AddRouterForMessageMembershipController(
+	r *gin.Engine,
+	s app.MessageMembershipAppService,
+) {
+	ctl := messageMembershipController{
+		appService: s,
+	}
+
+	v1 := r.Group("/message_center")
+	v1.GET("/membership/sync", ctl.GetSyncStatus)
+}
+
+type messageMembershipController struct {
+	appService app.MessageMembershipAppService
+}
+
+// GetSyncStatus
+// @Summary			GetSyncStatus
+// @Description		get the latest sync of the sigs and the managed repositories 查询成员关系同步状态
+// @Tags			membership
+// @Accept			json
+// @Success			202	{object}  app.MembershipSyncDTO
+// @Failure			401	string unauthorized 用户未授权
+// @Failure			500	string system_error  查询失败
+// @Router			/message_center/membership/sync [get]
+// @Id		getMembershipSyncStatus
+func (ctl *messageMembershipController) GetSyncStatus(ctx *gin.Context) {
+	if _, err := user.GetSystemUserName(ctx); err != nil {
+		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
+		return
+	}
+	if data, err := ctl.appService.GetSyncStatus(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v",
+			err)})
+	} else {
+		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data})
+	}
+}
@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/user"
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+// Mock for the MessageMembershipAppService
+type MockMessageMembershipAppService struct {
+	mock.Mock
+}
+
+func (m *MockMessageMembershipAppService) Sync() (app.MembershipSyncDTO, error) {
+	args := m.Called()
+	return args.Get(0).(app.MembershipSyncDTO), args.Error(1)
+}
+
+func (m *MockMessageMembershipAppService) GetSyncStatus() (app.MembershipSyncDTO, error) {
+	args := m.Called()
+	return args.Get(0).(app.MembershipSyncDTO), args.Error(1)
+}
+
+func TestMembershipSyncStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	patches := gomonkey.ApplyFuncReturn(user.GetSystemUserName, "testUser", nil)
+	defer patches.Reset()
+
+	router := gin.Default()
+	mockAppService := new(MockMessageMembershipAppService)
+	AddRouterForMessageMembershipController(router, mockAppService)
+
+	mockAppService.On("GetSyncStatus").
+		Return(app.MembershipSyncDTO{Id: 1, Status: "succeeded"}, nil).Once()
+	mockAppService.On("GetSyncStatus").
+		Return(app.MembershipSyncDTO{}, xerrors.New("db error")).Once()
+
+	for _, code := range []int{http.StatusAccepted, http.StatusInternalServerError} {
+		req, err := http.NewRequest(http.MethodGet, "/message_center/membership/sync", nil)
+		if err != nil {
+			t.Fatal("Failed to create request:", err)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, code, recorder.Code)
+	}
+	mockAppService.AssertExpectations(t)
+}
@@ -12,6 +12,7 @@ import (
 	"golang.org/x/xerrors"
 
 	commonctl "github.com/opensourceways/message-manager/common/controller"
+	"github.com/opensourceways/message-manager/common/domain/allerror"
 	"github.com/opensourceways/message-manager/message/app"
 )
 
@@ -33,6 +34,7 @@ func AddRouterForMessageListController(
 	v1.GET("/inner/todo", ctl.GetAllTodoMessage)
 	v1.GET("/inner/about", ctl.GetAllAboutMessage)
 	v1.GET("/inner/watch", ctl.GetAllWatchMessage)
+	v1.GET("/inner/export", ctl.ExportMessage)
 
 	v1.GET("/inner/count_new", ctl.CountAllMessage)
 	v1.GET("/inner/forum/system", ctl.GetForumSystemMessage)
@@ -45,6 +47,7 @@ func AddRouterForMessageListController(
 	v1.GET("/inner/gitee/about", ctl.GetGiteeAboutMessage)
 	v1.GET("/inner/gitee", ctl.GetGiteeMessage)
 	v1.GET("/inner/eur", ctl.GetEurMessage)
+	v1.GET("/inner/sources/:source/:category", ctl.GetSourceMessage)
 
 	// ubmc
 	v1.GET("/all", ctl.GetAllMessage)
@@ -165,11 +168,16 @@ func (ctl *messageListController) GetForumSystemMessage(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := params.validateCursor(); err != nil {
+		commonctl.SendBadRequestParam(ctx, err)
+		return
+	}
 	if data, count, err := ctl.appService.GetForumSystemMessage(userName, params.PageNum,
-		params.CountPerPage, params.StartTime, params.IsRead); err != nil {
+		params.CountPerPage, params.StartTime, params.IsRead, params.Cursor); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v", err)})
 	} else {
-		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count})
+		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count,
+			"next_cursor": nextCursor(data, params.CountPerPage)})
 	}
 }
 
@@ -195,11 +203,17 @@ func (ctl *messageListController) GetForumAboutMessage(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := params.validateCursor(); err != nil {
+		commonctl.SendBadRequestParam(ctx, err)
+		return
+	}
 	if data, count, err := ctl.appService.GetForumAboutMessage(userName, params.IsBot,
-		params.PageNum, params.CountPerPage, params.StartTime, params.IsRead); err != nil {
+		params.PageNum, params.CountPerPage, params.StartTime, params.IsRead,
+		params.Cursor); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v", err)})
 	} else {
-		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count})
+		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count,
+			"next_cursor": nextTimeCursor(data, params.CountPerPage)})
 	}
 }
 
@@ -225,11 +239,17 @@ func (ctl *messageListController) GetMeetingToDoMessage(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := params.validateCursor(); err != nil {
+		commonctl.SendBadRequestParam(ctx, err)
+		return
+	}
 	if data, count, err := ctl.appService.GetMeetingToDoMessage(userName, params.Filter,
-		params.PageNum, params.CountPerPage, params.StartTime, params.IsRead); err != nil {
+		params.PageNum, params.CountPerPage, params.StartTime, params.IsRead,
+		params.Cursor); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v", err)})
 	} else {
-		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count})
+		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count,
+			"next_cursor": nextTimeCursor(data, params.CountPerPage)})
 	}
 }
 
@@ -255,12 +275,18 @@ func (ctl *messageListController) GetCVEToDoMessage(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := params.validateCursor(); err != nil {
+		commonctl.SendBadRequestParam(ctx, err)
+		return
+	}
 
-	if data, count, err := ctl.appService.GetCVEToDoMessage(userName, params.GiteeUserName,
-		params.IsDone, params.PageNum, params.CountPerPage, params.StartTime, params.IsRead); err != nil {
+	if data, count, err := ctl.appService.GetCVEToDoMessage(userName,
+		params.IsDone, params.PageNum, params.CountPerPage, params.StartTime, params.IsRead,
+		params.Cursor); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v", err)})
 	} else {
-		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count})
+		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count,
+			"next_cursor": nextCursor(data, params.CountPerPage)})
 	}
 }
 
@@ -286,12 +312,18 @@ func (ctl *messageListController) GetCVEMessage(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := params.validateCursor(); err != nil {
+		commonctl.SendBadRequestParam(ctx, err)
+		return
+	}
 
-	if data, count, err := ctl.appService.GetCVEMessage(userName, params.GiteeUserName,
-		params.PageNum, params.CountPerPage, params.StartTime, params.IsRead); err != nil {
+	if data, count, err := ctl.appService.GetCVEMessage(userName,
+		params.PageNum, params.CountPerPage, params.StartTime, params.IsRead,
+		params.Cursor); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v", err)})
 	} else {
-		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count})
+		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count,
+			"next_cursor": nextCursor(data, params.CountPerPage)})
 	}
 }
 
@@ -317,12 +349,18 @@ func (ctl *messageListController) GetIssueToDoMessage(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := params.validateCursor(); err != nil {
+		commonctl.SendBadRequestParam(ctx, err)
+		return
+	}
 
-	if data, count, err := ctl.appService.GetIssueToDoMessage(userName, params.GiteeUserName,
-		params.IsDone, params.PageNum, params.CountPerPage, params.StartTime, params.IsRead); err != nil {
+	if data, count, err := ctl.appService.GetIssueToDoMessage(userName,
+		params.IsDone, params.PageNum, params.CountPerPage, params.StartTime, params.IsRead,
+		params.Cursor); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v", err)})
 	} else {
-		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count})
+		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count,
+			"next_cursor": nextCursor(data, params.CountPerPage)})
 	}
 }
 
@@ -348,13 +386,18 @@ func (ctl *messageListController) GetPullRequestToDoMessage(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := params.validateCursor(); err != nil {
+		commonctl.SendBadRequestParam(ctx, err)
+		return
+	}
 
 	if data, count, err := ctl.appService.GetPullRequestToDoMessage(userName,
-		params.GiteeUserName, params.IsDone, params.PageNum, params.CountPerPage,
-		params.StartTime, params.IsRead); err != nil {
+		params.IsDone, params.PageNum, params.CountPerPage, params.StartTime, params.IsRead,
+		params.Cursor); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v", err)})
 	} else {
-		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count})
+		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count,
+			"next_cursor": nextCursor(data, params.CountPerPage)})
 	}
 }
 
@@ -380,11 +423,17 @@ func (ctl *messageListController) GetGiteeAboutMessage(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if data, count, err := ctl.appService.GetGiteeAboutMessage(userName, params.GiteeUserName,
-		params.IsBot, params.PageNum, params.CountPerPage, params.StartTime, params.IsRead); err != nil {
+	if err := params.validateCursor(); err != nil {
+		commonctl.SendBadRequestParam(ctx, err)
+		return
+	}
+	if data, count, err := ctl.appService.GetGiteeAboutMessage(userName,
+		params.IsBot, params.PageNum, params.CountPerPage, params.StartTime, params.IsRead,
+		params.Cursor); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v", err)})
 	} else {
-		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count})
+		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count,
+			"next_cursor": nextCursor(data, params.CountPerPage)})
 	}
 }
 
@@ -410,12 +459,18 @@ func (ctl *messageListController) GetGiteeMessage(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := params.validateCursor(); err != nil {
+		commonctl.SendBadRequestParam(ctx, err)
+		return
+	}
 
-	if data, count, err := ctl.appService.GetGiteeMessage(userName, params.GiteeUserName,
-		params.PageNum, params.CountPerPage, params.StartTime, params.IsRead); err != nil {
+	if data, count, err := ctl.appService.GetGiteeMessage(userName,
+		params.PageNum, params.CountPerPage, params.StartTime, params.IsRead,
+		params.Cursor); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v", err)})
 	} else {
-		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count})
+		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count,
+			"next_cursor": nextCursor(data, params.CountPerPage)})
 	}
 }
 
@@ -441,14 +496,64 @@ func (ctl *messageListController) GetEurMessage(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := params.validateCursor(); err != nil {
+		commonctl.SendBadRequestParam(ctx, err)
+		return
+	}
 	if data, count, err := ctl.appService.GetEurMessage(userName, params.PageNum,
-		params.CountPerPage, params.StartTime, params.IsRead); err != nil {
+		params.CountPerPage, params.StartTime, params.IsRead, params.Cursor); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v", err)})
 	} else {
-		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count})
+		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count,
+			"next_cursor": nextCursor(data, params.CountPerPage)})
 	}
 }
 
+// GetSourceMessage get the messages of a source
+// @Summary			GetSourceMessage
+// @Description		get the follow, related or todo messages of a registered source 获取消息源的消息
+// @Tags			message_center
+// @Param			source path string true "source id"
+// @Param			category path string true "follow, related or todo"
+// @Param			body body SourceQueryParams true "SourceQueryParams"
+// @Accept			json
+// @Success			202	string accepted 查询成功
+// @Failure         400 string bad_request 无法解析请求正文
+// @Failure			401	string unauthorized 用户未授权
+// @Failure			404	string not_found 消息源不存在
+// @Failure			500	string system_error  查询失败
+// @Router			/message_center/inner/sources/{source}/{category} [get]
+// @Id	    getSourceMessage
+func (ctl *messageListController) GetSourceMessage(ctx *gin.Context) {
+	var params SourceQueryParams
+	if err := ctx.ShouldBindQuery(&params); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := params.validateCursor(); err != nil {
+		commonctl.SendBadRequestParam(ctx, err)
+		return
+	}
+	userName, err := user.GetSystemUserName(ctx)
+	if err != nil {
+		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
+		return
+	}
+
+	data, count, err := ctl.appService.GetSourceMessage(ctx.Param("source"),
+		params.toCmd(userName, ctx.Param("category")))
+	if err != nil {
+		if allerror.IsNotFound(err) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v", err)})
+		return
+	}
+	ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count,
+		"next_cursor": nextCursor(data, params.CountPerPage)})
+}
+
 // GetAllTodoMessage get alltodo message
 // @Summary			GetAllTodoMessage
 // @Description		get all todo message 获取所有待办消息
@@ -466,17 +571,22 @@ func (ctl *messageListController) GetAllTodoMessage(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := params.validateCursor(); err != nil {
+		commonctl.SendBadRequestParam(ctx, err)
+		return
+	}
 	userName, err := user.GetSystemUserName(ctx)
 	if err != nil {
 		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
 		return
 	}
-	if data, count, err := ctl.appService.GetAllToDoMessage(userName, params.GiteeUserName,
+	if data, count, err := ctl.appService.GetAllToDoMessage(userName,
 		params.IsDone, params.PageNum, params.CountPerPage, params.StartTime,
-		params.IsRead); err != nil {
+		params.IsRead, params.Cursor); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v", err)})
 	} else {
-		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count})
+		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count,
+			"next_cursor": nextCursor(data, params.CountPerPage)})
 	}
 }
 
@@ -497,16 +607,22 @@ func (ctl *messageListController) GetAllAboutMessage(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := params.validateCursor(); err != nil {
+		commonctl.SendBadRequestParam(ctx, err)
+		return
+	}
 	userName, err := user.GetSystemUserName(ctx)
 	if err != nil {
 		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
 		return
 	}
-	if data, count, err := ctl.appService.GetAllAboutMessage(userName, params.GiteeUserName,
-		params.IsBot, params.PageNum, params.CountPerPage, params.StartTime, params.IsRead); err != nil {
+	if data, count, err := ctl.appService.GetAllAboutMessage(userName,
+		params.IsBot, params.PageNum, params.CountPerPage, params.StartTime, params.IsRead,
+		params.Cursor); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v", err)})
 	} else {
-		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count})
+		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count,
+			"next_cursor": nextCursor(data, params.CountPerPage)})
 	}
 }
 
@@ -527,16 +643,22 @@ func (ctl *messageListController) GetAllWatchMessage(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := params.validateCursor(); err != nil {
+		commonctl.SendBadRequestParam(ctx, err)
+		return
+	}
 	userName, err := user.GetSystemUserName(ctx)
 	if err != nil {
 		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
 		return
 	}
 	if data, count, err := ctl.appService.GetAllWatchMessage(userName,
-		params.GiteeUserName, params.PageNum, params.CountPerPage, params.StartTime, params.IsRead); err != nil {
+		params.PageNum, params.CountPerPage, params.StartTime, params.IsRead,
+		params.Cursor); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v", err)})
 	} else {
-		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count})
+		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count,
+			"next_cursor": nextCursor(data, params.CountPerPage)})
 	}
 }
 
@@ -562,7 +684,7 @@ func (ctl *messageListController) CountAllMessage(ctx *gin.Context) {
 		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
 		return
 	}
-	if data, err := ctl.appService.CountAllMessage(userName, params.GiteeUserName); err != nil {
+	if data, err := ctl.appService.CountAllMessage(userName); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v", err)})
 	} else {
 		ctx.JSON(http.StatusAccepted, gin.H{"count": data})
@@ -586,14 +708,20 @@ func (ctl *messageListController) GetAllMessage(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := params.validateCursor(); err != nil {
+		commonctl.SendBadRequestParam(ctx, err)
+		return
+	}
 	userName, err := user.GetSystemUserName(ctx)
 	if err != nil {
 		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
 		return
 	}
-	if data, count, err := ctl.appService.GetAllMessage(userName, params.PageNum, params.CountPerPage, params.IsRead); err != nil {
+	if data, count, err := ctl.appService.GetAllMessage(userName, params.PageNum,
+		params.CountPerPage, params.IsRead, params.Cursor); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v", err)})
 	} else {
-		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count})
+		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count,
+			"next_cursor": nextCursor(data, params.CountPerPage)})
 	}
 }
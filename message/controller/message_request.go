@@ -4,7 +4,10 @@ Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
 
 package controller
 
-import "github.com/opensourceways/message-manager/message/app"
+import (
+	"github.com/opensourceways/message-manager/message/app"
+	"github.com/opensourceways/message-manager/utils"
+)
 
 type queryInnerParams struct {
 	Source           string `json:"source"`     // 消息源
@@ -103,12 +106,57 @@ func (req *messageStatus) toCmd() (cmd app.CmdToSetIsRead, err error) {
 }
 
 type QueryParams struct {
-	GiteeUserName string `form:"gitee_user_name"`
-	IsBot         *bool  `form:"is_bot"`
-	Filter        int    `form:"filter"`
-	IsDone        *bool  `form:"is_done"`
-	PageNum       int    `form:"page_num"`
-	CountPerPage  int    `form:"count_per_page"`
-	StartTime     string `form:"start_time"`
-	IsRead        *bool  `form:"is_read"`
+	IsBot        *bool  `form:"is_bot"`
+	Filter       int    `form:"filter"`
+	IsDone       *bool  `form:"is_done"`
+	PageNum      int    `form:"page_num"`
+	CountPerPage int    `form:"count_per_page"`
+	StartTime    string `form:"start_time"`
+	IsRead       *bool  `form:"is_read"`
+	Cursor       string `form:"cursor"`
+}
+
+type SourceQueryParams struct {
+	QueryParams
+	EventType string `form:"event_type"`
+}
+
+func (req *SourceQueryParams) toCmd(userName, category string) app.CmdToGetSourceMessage {
+	return app.CmdToGetSourceMessage{
+		UserName:     userName,
+		Category:     category,
+		EventType:    req.EventType,
+		IsDone:       req.IsDone,
+		IsRead:       req.IsRead,
+		StartTime:    req.StartTime,
+		PageNum:      req.PageNum,
+		CountPerPage: req.CountPerPage,
+		Cursor:       req.Cursor,
+	}
+}
+
+func (req *QueryParams) validateCursor() error {
+	if req.Cursor == "" {
+		return nil
+	}
+	_, _, err := utils.DecodeCursor(req.Cursor)
+	return err
+}
+
+// nextCursor returns the cursor of the page after data, or "" on the last page.
+func nextCursor(data []app.MessageListDTO, countPerPage int) string {
+	if countPerPage <= 0 || len(data) < countPerPage {
+		return ""
+	}
+	last := data[len(data)-1]
+	return utils.EncodeCursor(last.UpdatedAt, last.EventId)
+}
+
+// nextTimeCursor is nextCursor of the lists ordered by the time of the events.
+func nextTimeCursor(data []app.MessageListDTO, countPerPage int) string {
+	if countPerPage <= 0 || len(data) < countPerPage {
+		return ""
+	}
+	last := data[len(data)-1]
+	return utils.EncodeCursor(last.EventTime, last.EventId)
 }
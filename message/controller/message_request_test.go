@@ -2,8 +2,12 @@ package controller
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/opensourceways/message-manager/message/app"
+	"github.com/opensourceways/message-manager/utils"
 )
 
 func TestQueryInnerParamsToCmd(t *testing.T) {
@@ -75,3 +79,37 @@ func TestMessageStatusToCmd(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "event_123", cmd.EventId)
 }
+
+func TestQueryParamsValidateCursor(t *testing.T) {
+	req := &QueryParams{}
+	assert.NoError(t, req.validateCursor())
+
+	req.Cursor = utils.EncodeCursor(time.Now(), "event_123")
+	assert.NoError(t, req.validateCursor())
+
+	req.Cursor = "invalid"
+	assert.Error(t, req.validateCursor())
+}
+
+func TestNextCursor(t *testing.T) {
+	updatedAt := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+	data := []app.MessageListDTO{
+		{EventId: "event_1", UpdatedAt: updatedAt.Add(time.Minute)},
+		{EventId: "event_2", UpdatedAt: updatedAt},
+	}
+
+	assert.Equal(t, "", nextCursor(data, 10))
+	assert.Equal(t, utils.EncodeCursor(updatedAt, "event_2"), nextCursor(data, 2))
+}
+
+func TestNextTimeCursor(t *testing.T) {
+	start := time.Date(2024, 8, 1, 9, 0, 0, 0, time.UTC)
+	data := []app.MessageListDTO{
+		{EventId: "meeting_1", EventTime: start, UpdatedAt: start.Add(-time.Hour)},
+		{EventId: "meeting_2", EventTime: start.Add(time.Hour), UpdatedAt: start},
+	}
+
+	assert.Equal(t, "", nextTimeCursor(data, 10))
+	assert.Equal(t, utils.EncodeCursor(start.Add(time.Hour), "meeting_2"),
+		nextTimeCursor(data, 2))
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/domain/allerror"
+	"github.com/opensourceways/message-manager/common/user"
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+// MockSourceListService mocks the source listing of MessageListAppService
+type MockSourceListService struct {
+	app.MessageListAppService
+	mock.Mock
+}
+
+func (m *MockSourceListService) GetSourceMessage(sourceId string,
+	cmd app.CmdToGetSourceMessage) ([]app.MessageListDTO, int64, error) {
+	args := m.Called(sourceId, cmd)
+	return args.Get(0).([]app.MessageListDTO), args.Get(1).(int64), args.Error(2)
+}
+
+func TestGetSourceMessage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	patches := gomonkey.ApplyFuncReturn(user.GetSystemUserName, "testUser", nil)
+	defer patches.Reset()
+
+	mockService := new(MockSourceListService)
+	router := gin.Default()
+	AddRouterForMessageListController(router, mockService)
+
+	isDone := false
+	mockService.On("GetSourceMessage", "gitee", app.CmdToGetSourceMessage{
+		UserName: "testUser", Category: "todo", EventType: "pr",
+		IsDone: &isDone, PageNum: 1, CountPerPage: 1,
+	}).Return([]app.MessageListDTO{{EventId: "event1"}}, int64(2), nil).Once()
+	mockService.On("GetSourceMessage", "unknown", mock.Anything).
+		Return([]app.MessageListDTO{}, int64(0), allerror.NewNotFound("source_not_found", "")).
+		Once()
+	mockService.On("GetSourceMessage", "forum", mock.Anything).
+		Return([]app.MessageListDTO{}, int64(0), xerrors.New("db error")).Once()
+
+	for _, c := range []struct {
+		url  string
+		code int
+	}{
+		{"/message_center/inner/sources/gitee/todo?gitee_user_name=alice&event_type=pr" +
+			"&is_done=false&page_num=1&count_per_page=1", http.StatusAccepted},
+		{"/message_center/inner/sources/unknown/follow", http.StatusNotFound},
+		{"/message_center/inner/sources/forum/follow", http.StatusInternalServerError},
+		{"/message_center/inner/sources/forum/follow?cursor=broken", http.StatusBadRequest},
+	} {
+		req, _ := http.NewRequest(http.MethodGet, c.url, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, c.code, w.Code, c.url)
+		if c.code == http.StatusAccepted {
+			assert.Contains(t, w.Body.String(), `"next_cursor":"`)
+		}
+	}
+	mockService.AssertExpectations(t)
+}
@@ -95,7 +95,13 @@ func (ctl *messagePushController) AddPushConfig(ctx *gin.Context) {
 		return
 	}
 
-	if err := ctl.appService.AddPushConfig(&cmd); err != nil {
+	userName, err := user.GetSystemUserName(ctx)
+	if err != nil {
+		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
+		return
+	}
+
+	if err := ctl.appService.AddPushConfig(&cmd, userName); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("新增配置失败，err:%v",
 			err)})
 	} else {
@@ -126,7 +132,12 @@ func (ctl *messagePushController) UpdatePushConfig(ctx *gin.Context) {
 		commonctl.SendBadRequestParam(ctx, xerrors.Errorf("failed to convert req to cmd, %w", err))
 		return
 	}
-	if err := ctl.appService.UpdatePushConfig(&cmd); err != nil {
+	userName, err := user.GetSystemUserName(ctx)
+	if err != nil {
+		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
+		return
+	}
+	if err := ctl.appService.UpdatePushConfig(&cmd, userName); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("更新配置失败,err:%v",
 			err)})
 	} else {
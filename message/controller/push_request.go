@@ -24,6 +24,8 @@ type newPushConfigDTO struct {
 	NeedPhone        bool  `json:"need_phone"`
 	NeedMail         bool  `json:"need_mail"`
 	NeedInnerMessage bool  `json:"need_inner_message"`
+	NeedWebhook      bool  `json:"need_webhook"`
+	NeedChat         bool  `json:"need_chat"`
 }
 
 func (req *newPushConfigDTO) toCmd() (cmd app.CmdToAddPushConfig, err error) {
@@ -33,6 +35,8 @@ func (req *newPushConfigDTO) toCmd() (cmd app.CmdToAddPushConfig, err error) {
 	cmd.NeedPhone = req.NeedPhone
 	cmd.NeedMail = req.NeedMail
 	cmd.NeedInnerMessage = req.NeedInnerMessage
+	cmd.NeedWebhook = req.NeedWebhook
+	cmd.NeedChat = req.NeedChat
 	return cmd, nil
 }
 
@@ -43,6 +47,8 @@ type updatePushConfigDTO struct {
 	NeedPhone        bool     `json:"need_phone"`
 	NeedMail         bool     `json:"need_mail"`
 	NeedInnerMessage bool     `json:"need_inner_message"`
+	NeedWebhook      bool     `json:"need_webhook"`
+	NeedChat         bool     `json:"need_chat"`
 }
 
 func (req *updatePushConfigDTO) toCmd() (cmd app.CmdToUpdatePushConfig, err error) {
@@ -52,5 +58,7 @@ func (req *updatePushConfigDTO) toCmd() (cmd app.CmdToUpdatePushConfig, err erro
 	cmd.NeedPhone = req.NeedPhone
 	cmd.NeedMail = req.NeedMail
 	cmd.NeedInnerMessage = req.NeedInnerMessage
+	cmd.NeedWebhook = req.NeedWebhook
+	cmd.NeedChat = req.NeedChat
 	return cmd, nil
 }
@@ -26,6 +26,8 @@ func TestNewPushConfigDTOToCmd(t *testing.T) {
 		NeedPhone:        false,
 		NeedMail:         true,
 		NeedInnerMessage: false,
+		NeedWebhook:      true,
+		NeedChat:         true,
 	}
 
 	cmd, err := req.toCmd()
@@ -36,6 +38,8 @@ func TestNewPushConfigDTOToCmd(t *testing.T) {
 	assert.False(t, cmd.NeedPhone)
 	assert.True(t, cmd.NeedMail)
 	assert.False(t, cmd.NeedInnerMessage)
+	assert.True(t, cmd.NeedWebhook)
+	assert.True(t, cmd.NeedChat)
 }
 
 func TestUpdatePushConfigDTOToCmd(t *testing.T) {
@@ -46,6 +50,8 @@ func TestUpdatePushConfigDTOToCmd(t *testing.T) {
 		NeedPhone:        true,
 		NeedMail:         false,
 		NeedInnerMessage: true,
+		NeedWebhook:      true,
+		NeedChat:         true,
 	}
 
 	cmd, err := req.toCmd()
@@ -56,4 +62,6 @@ func TestUpdatePushConfigDTOToCmd(t *testing.T) {
 	assert.True(t, cmd.NeedPhone)
 	assert.False(t, cmd.NeedMail)
 	assert.True(t, cmd.NeedInnerMessage)
+	assert.True(t, cmd.NeedWebhook)
+	assert.True(t, cmd.NeedChat)
 }
@@ -7,11 +7,13 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/agiledragon/gomonkey/v2"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"golang.org/x/xerrors"
 
+	"github.com/opensourceways/message-manager/common/user"
 	"github.com/opensourceways/message-manager/message/app"
 )
 
@@ -26,13 +28,15 @@ func (m *MockMessagePushAppService) GetPushConfig(countPerPage, pageNum int,
 	return args.Get(0).([]app.MessagePushDTO), args.Error(1)
 }
 
-func (m *MockMessagePushAppService) AddPushConfig(cmd *app.CmdToAddPushConfig) error {
-	args := m.Called(cmd)
+func (m *MockMessagePushAppService) AddPushConfig(cmd *app.CmdToAddPushConfig,
+	userName string) error {
+	args := m.Called(cmd, userName)
 	return args.Error(0)
 }
 
-func (m *MockMessagePushAppService) UpdatePushConfig(cmd *app.CmdToUpdatePushConfig) error {
-	args := m.Called(cmd)
+func (m *MockMessagePushAppService) UpdatePushConfig(cmd *app.CmdToUpdatePushConfig,
+	userName string) error {
+	args := m.Called(cmd, userName)
 	return args.Error(0)
 }
 
@@ -117,7 +121,9 @@ func TestAddPushConfig_Success(t *testing.T) {
 	if err != nil {
 		t.Fatal("Failed to marshal messages:", err)
 	}
-	mockService.On("AddPushConfig", mock.Anything).Return(nil)
+	patches := gomonkey.ApplyFuncReturn(user.GetSystemUserName, "testUser", nil)
+	defer patches.Reset()
+	mockService.On("AddPushConfig", mock.Anything, "testUser").Return(nil)
 
 	req, err := http.NewRequest("POST", "/message_center/config/push", bytes.NewBuffer(body))
 	if err != nil {
@@ -164,7 +170,10 @@ func TestAddPushConfig_ServiceError(t *testing.T) {
 	if err != nil {
 		t.Fatal("Failed to marshal messages:", err)
 	}
-	mockService.On("AddPushConfig", mock.Anything).Return(xerrors.New("service error"))
+	patches := gomonkey.ApplyFuncReturn(user.GetSystemUserName, "testUser", nil)
+	defer patches.Reset()
+	mockService.On("AddPushConfig", mock.Anything, "testUser").
+		Return(xerrors.New("service error"))
 
 	req, err := http.NewRequest("POST", "/message_center/config/push", bytes.NewBuffer(body))
 	if err != nil {
@@ -194,7 +203,9 @@ func TestUpdatePushConfig_Success(t *testing.T) {
 	if err != nil {
 		t.Fatal("Failed to marshal messages:", err)
 	}
-	mockService.On("UpdatePushConfig", mock.Anything).Return(nil)
+	patches := gomonkey.ApplyFuncReturn(user.GetSystemUserName, "testUser", nil)
+	defer patches.Reset()
+	mockService.On("UpdatePushConfig", mock.Anything, "testUser").Return(nil)
 
 	req, err := http.NewRequest("PUT", "/message_center/config/push", bytes.NewBuffer(body))
 	if err != nil {
@@ -241,7 +252,10 @@ func TestUpdatePushConfig_ServiceError(t *testing.T) {
 	if err != nil {
 		t.Fatal("Failed to marshal messages:", err)
 	}
-	mockService.On("UpdatePushConfig", mock.Anything).Return(xerrors.New("service error"))
+	patches := gomonkey.ApplyFuncReturn(user.GetSystemUserName, "testUser", nil)
+	defer patches.Reset()
+	mockService.On("UpdatePushConfig", mock.Anything, "testUser").
+		Return(xerrors.New("service error"))
 
 	req, err := http.NewRequest("PUT", "/message_center/config/push", bytes.NewBuffer(body))
 	if err != nil {
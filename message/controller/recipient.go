@@ -12,6 +12,7 @@ import (
 	"github.com/opensourceways/message-manager/common/user"
 	"golang.org/x/xerrors"
 
+	"github.com/opensourceways/message-manager/common/community"
 	commonctl "github.com/opensourceways/message-manager/common/controller"
 	"github.com/opensourceways/message-manager/message/app"
 )
@@ -197,6 +198,13 @@ func (ctl *messageRecipientController) SyncUserInfo(ctx *gin.Context) {
 		commonctl.SendBadRequestParam(ctx, xerrors.Errorf("failed to convert req to cmd, %w", err))
 		return
 	}
+	// the user is synced in the community of the request
+	c, err := community.FromRequest(ctx.Request)
+	if err != nil {
+		commonctl.SendBadRequestParam(ctx, err)
+		return
+	}
+	cmd.UserName = community.UserKey(c.Id, cmd.UserName)
 	if data, err := ctl.appService.SyncUserInfo(&cmd); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("同步用户信息失败，"+
 			"err:%v", err)})
@@ -7,11 +7,13 @@ package controller
 import "github.com/opensourceways/message-manager/message/app"
 
 type syncUserInfoDTO struct {
-	Mail          string `json:"mail"`
-	Phone         string `json:"phone"`
-	CountryCode   string `json:"country_code"`
-	UserName      string `json:"user_name"`
-	GiteeUserName string `json:"gitee_user_name"`
+	Mail            string `json:"mail"`
+	Phone           string `json:"phone"`
+	CountryCode     string `json:"country_code"`
+	UserName        string `json:"user_name"`
+	GiteeUserName   string `json:"gitee_user_name"`
+	GithubUserName  string `json:"github_user_name"`
+	GitcodeUserName string `json:"gitcode_user_name"`
 }
 
 func (req *syncUserInfoDTO) toCmd() (cmd app.CmdToSyncUserInfo, err error) {
@@ -20,15 +22,22 @@ func (req *syncUserInfoDTO) toCmd() (cmd app.CmdToSyncUserInfo, err error) {
 	cmd.CountryCode = req.CountryCode
 	cmd.UserName = req.UserName
 	cmd.GiteeUserName = req.GiteeUserName
+	cmd.GithubUserName = req.GithubUserName
+	cmd.GitcodeUserName = req.GitcodeUserName
 	return
 }
 
 type newRecipientDTO struct {
-	Name    string `gorm:"column:recipient_name" json:"recipient_id"`
-	Mail    string `gorm:"column:mail" json:"mail"`
-	Message string `gorm:"column:message" json:"message"`
-	Phone   string `gorm:"column:phone" json:"phone"`
-	Remark  string `gorm:"column:remark" json:"remark"`
+	Name          string `gorm:"column:recipient_name" json:"recipient_id"`
+	Mail          string `gorm:"column:mail" json:"mail"`
+	Message       string `gorm:"column:message" json:"message"`
+	Phone         string `gorm:"column:phone" json:"phone"`
+	Remark        string `gorm:"column:remark" json:"remark"`
+	Webhook       string `gorm:"column:webhook" json:"webhook"`
+	WebhookSecret string `gorm:"column:webhook_secret" json:"webhook_secret"`
+	ChatPlatform  string `gorm:"column:chat_platform" json:"chat_platform"`
+	ChatWebhook   string `gorm:"column:chat_webhook" json:"chat_webhook"`
+	ChatSecret    string `gorm:"column:chat_secret" json:"chat_secret"`
 }
 
 func (req *newRecipientDTO) toCmd() (cmd app.CmdToAddRecipient, err error) {
@@ -37,16 +46,26 @@ func (req *newRecipientDTO) toCmd() (cmd app.CmdToAddRecipient, err error) {
 	cmd.Message = req.Message
 	cmd.Phone = req.Phone
 	cmd.Remark = req.Remark
+	cmd.Webhook = req.Webhook
+	cmd.WebhookSecret = req.WebhookSecret
+	cmd.ChatPlatform = req.ChatPlatform
+	cmd.ChatWebhook = req.ChatWebhook
+	cmd.ChatSecret = req.ChatSecret
 	return
 }
 
 type updateRecipientDTO struct {
-	Id      string `gorm:"column:id"   json:"id"`
-	Name    string `gorm:"column:recipient_name" json:"recipient_id"`
-	Mail    string `gorm:"column:mail" json:"mail"`
-	Message string `gorm:"column:message" json:"message"`
-	Phone   string `gorm:"column:phone" json:"phone"`
-	Remark  string `gorm:"column:remark" json:"remark"`
+	Id            string `gorm:"column:id"   json:"id"`
+	Name          string `gorm:"column:recipient_name" json:"recipient_id"`
+	Mail          string `gorm:"column:mail" json:"mail"`
+	Message       string `gorm:"column:message" json:"message"`
+	Phone         string `gorm:"column:phone" json:"phone"`
+	Remark        string `gorm:"column:remark" json:"remark"`
+	Webhook       string `gorm:"column:webhook" json:"webhook"`
+	WebhookSecret string `gorm:"column:webhook_secret" json:"webhook_secret"`
+	ChatPlatform  string `gorm:"column:chat_platform" json:"chat_platform"`
+	ChatWebhook   string `gorm:"column:chat_webhook" json:"chat_webhook"`
+	ChatSecret    string `gorm:"column:chat_secret" json:"chat_secret"`
 }
 
 func (req *updateRecipientDTO) toCmd() (cmd app.CmdToUpdateRecipient, err error) {
@@ -56,6 +75,11 @@ func (req *updateRecipientDTO) toCmd() (cmd app.CmdToUpdateRecipient, err error)
 	cmd.Message = req.Message
 	cmd.Phone = req.Phone
 	cmd.Remark = req.Remark
+	cmd.Webhook = req.Webhook
+	cmd.WebhookSecret = req.WebhookSecret
+	cmd.ChatPlatform = req.ChatPlatform
+	cmd.ChatWebhook = req.ChatWebhook
+	cmd.ChatSecret = req.ChatSecret
 	return
 }
 
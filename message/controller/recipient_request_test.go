@@ -26,11 +26,16 @@ func TestSyncUserInfoDTOToCmd(t *testing.T) {
 
 func TestNewRecipientDTOToCmd(t *testing.T) {
 	req := &newRecipientDTO{
-		Name:    "Recipient Name",
-		Mail:    "recipient@example.com",
-		Message: "Welcome!",
-		Phone:   "0987654321",
-		Remark:  "Important recipient",
+		Name:          "Recipient Name",
+		Mail:          "recipient@example.com",
+		Message:       "Welcome!",
+		Phone:         "0987654321",
+		Remark:        "Important recipient",
+		Webhook:       "https://ci.example.com/hooks",
+		WebhookSecret: "0123456789abcdef",
+		ChatPlatform:  "feishu",
+		ChatWebhook:   "https://open.feishu.cn/open-apis/bot/v2/hook/token",
+		ChatSecret:    "SECa1b2c3",
 	}
 
 	cmd, err := req.toCmd()
@@ -40,16 +45,23 @@ func TestNewRecipientDTOToCmd(t *testing.T) {
 	assert.Equal(t, "Welcome!", cmd.Message)
 	assert.Equal(t, "0987654321", cmd.Phone)
 	assert.Equal(t, "Important recipient", cmd.Remark)
+	assert.Equal(t, "https://ci.example.com/hooks", cmd.Webhook)
+	assert.Equal(t, "0123456789abcdef", cmd.WebhookSecret)
+	assert.Equal(t, "feishu", cmd.ChatPlatform)
+	assert.Equal(t, "https://open.feishu.cn/open-apis/bot/v2/hook/token", cmd.ChatWebhook)
+	assert.Equal(t, "SECa1b2c3", cmd.ChatSecret)
 }
 
 func TestUpdateRecipientDTOToCmd(t *testing.T) {
 	req := &updateRecipientDTO{
-		Id:      "recipient-id-123",
-		Name:    "Updated Recipient Name",
-		Mail:    "updated@example.com",
-		Message: "Updated message",
-		Phone:   "1122334455",
-		Remark:  "Updated remark",
+		Id:            "recipient-id-123",
+		Name:          "Updated Recipient Name",
+		Mail:          "updated@example.com",
+		Message:       "Updated message",
+		Phone:         "1122334455",
+		Remark:        "Updated remark",
+		Webhook:       "https://ci.example.com/new",
+		WebhookSecret: "fedcba9876543210",
 	}
 
 	cmd, err := req.toCmd()
@@ -60,6 +72,8 @@ func TestUpdateRecipientDTOToCmd(t *testing.T) {
 	assert.Equal(t, "Updated message", cmd.Message)
 	assert.Equal(t, "1122334455", cmd.Phone)
 	assert.Equal(t, "Updated remark", cmd.Remark)
+	assert.Equal(t, "https://ci.example.com/new", cmd.Webhook)
+	assert.Equal(t, "fedcba9876543210", cmd.WebhookSecret)
 }
 
 func TestDeleteRecipientDTOToCmd(t *testing.T) {
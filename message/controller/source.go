@@ -0,0 +1,67 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/opensourceways/message-manager/common/community"
+	commonctl "github.com/opensourceways/message-manager/common/controller"
+	"github.com/opensourceways/message-manager/common/source"
+)
+
+func AddRouterForMessageSourceController(r *gin.Engine) {
+	ctl := messageSourceController{}
+
+	v1 := r.Group("/message_center")
+	v1.GET("/sources", ctl.GetSources)
+}
+
+type messageSourceController struct{}
+
+type sourceResponse struct {
+	Id          string   `json:"id"`
+	Url         string   `json:"url"`
+	DisplayName string   `json:"display_name"`
+	Categories  []string `json:"categories"`
+	EventTypes  []string `json:"event_types"`
+	Schedule    bool     `json:"schedule"`
+}
+
+// GetSources
+// @Summary			GetSources
+// @Description		get the sources of the community of the request 获取消息源
+// @Tags			message_center
+// @Accept			json
+// @Success			202	{object}  []sourceResponse
+// @Failure			400	string bad_request  未知的社区
+// @Router			/message_center/sources [get]
+// @Id		getSources
+func (ctl *messageSourceController) GetSources(ctx *gin.Context) {
+	c, err := community.FromRequest(ctx.Request)
+	if err != nil {
+		commonctl.SendBadRequestParam(ctx, err)
+		return
+	}
+	sources := source.All()
+	data := make([]sourceResponse, 0, len(sources))
+	for i := range sources {
+		s := &sources[i]
+		if !c.HasSource(s.Id) {
+			continue
+		}
+		data = append(data, sourceResponse{
+			Id:          s.Id,
+			Url:         s.Url,
+			DisplayName: s.DisplayName,
+			Categories:  s.Categories,
+			EventTypes:  s.EventTypes,
+			Schedule:    s.Schedule,
+		})
+	}
+	ctx.JSON(http.StatusAccepted, gin.H{"query_info": data})
+}
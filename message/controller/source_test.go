@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/opensourceways/message-manager/common/community"
+	"github.com/opensourceways/message-manager/common/source"
+)
+
+func TestGetSources(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	AddRouterForMessageSourceController(router)
+
+	req, _ := http.NewRequest(http.MethodGet, "/message_center/sources", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	var body struct {
+		QueryInfo []map[string]interface{} `json:"query_info"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Len(t, body.QueryInfo, len(source.All()))
+	assert.Equal(t, source.IdForum, body.QueryInfo[0]["id"])
+	assert.NotContains(t, body.QueryInfo[0], "rules")
+}
+
+func TestGetSources_Community(t *testing.T) {
+	assert.NoError(t, community.Init(&community.Config{Communities: []community.Community{
+		{Id: "opengauss", Sources: []string{source.IdGitee, source.IdMeeting}}}}, "openeuler"))
+	defer func() { assert.NoError(t, community.Init(&community.Config{}, "openeuler")) }()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	AddRouterForMessageSourceController(router)
+
+	get := func(name string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/message_center/sources", nil)
+		req.Header.Set(community.DefaultHeader, name)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("opengauss")
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	var body struct {
+		QueryInfo []map[string]interface{} `json:"query_info"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Len(t, body.QueryInfo, 2)
+	assert.Equal(t, source.IdGitee, body.QueryInfo[0]["id"])
+
+	assert.Equal(t, http.StatusBadRequest, get("unknown").Code)
+}
@@ -0,0 +1,222 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opensourceways/message-manager/common/user"
+	"golang.org/x/xerrors"
+
+	commonctl "github.com/opensourceways/message-manager/common/controller"
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+const (
+	streamHeartbeatInterval = 30 * time.Second
+	streamRetryMillisecond  = 3000
+	// streamDebounceInterval merges the notifications of a burst of messages,
+	// so the counts of a connection are queried once per burst.
+	streamDebounceInterval = time.Second
+
+	streamEventMessage  = "message"
+	streamEventCount    = "count"
+	streamEventCountNew = "count_new"
+	streamEventError    = "error"
+)
+
+func AddRouterForMessageStreamController(
+	r *gin.Engine,
+	streamService app.MessageStreamAppService,
+) {
+	ctl := messageStreamController{
+		streamService: streamService,
+	}
+
+	v1 := r.Group("/message_center")
+	v1.GET("/inner/stream", ctl.StreamMessage)
+}
+
+type messageStreamController struct {
+	streamService app.MessageStreamAppService
+}
+
+// StreamMessage
+// @Summary			StreamMessage
+// @Description		stream new messages and unread counts by server-sent events 实时推送新消息和未读数量
+// @Tags			message_center
+// @Param			Last-Event-ID header string false "id of the last received message event"
+// @Produce			text/event-stream
+// @Success			200	string ok 事件流
+// @Failure         400 string bad_request 无效的Last-Event-ID
+// @Failure			401	string unauthorized 用户未授权
+// @Failure			500	string system_error  查询失败
+// @Router			/message_center/inner/stream [get]
+// @Id	    streamMessage
+func (ctl *messageStreamController) StreamMessage(ctx *gin.Context) {
+	var params QueryParams
+	if err := ctx.ShouldBindQuery(&params); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	lastId := ctx.GetHeader("Last-Event-ID")
+	lastSeq, err := parseStreamId(lastId)
+	if err != nil {
+		commonctl.SendBadRequestParam(ctx, err)
+		return
+	}
+	userName, err := user.GetSystemUserName(ctx)
+	if err != nil {
+		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
+		return
+	}
+
+	notify, cancel := ctl.streamService.Subscribe(userName)
+	defer cancel()
+
+	if lastId == "" {
+		if lastSeq, err = ctl.streamService.GetLatestStreamSeq(userName); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v",
+				err)})
+			return
+		}
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	if _, err = fmt.Fprintf(ctx.Writer, "retry: %d\n\n", streamRetryMillisecond); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	write := func(id, event string, data interface{}) error {
+		return writeStreamEvent(ctx, id, event, data)
+	}
+	for {
+		if lastSeq, err = sendNewMessage(ctl.streamService, write, userName, lastSeq); err != nil {
+			return
+		}
+		if err = sendCount(ctl.streamService, write, userName); err != nil {
+			return
+		}
+		ctx.Writer.Flush()
+
+		if !waitStreamNotify(ctx, notify, heartbeat.C) {
+			return
+		}
+	}
+}
+
+// waitStreamNotify blocks until a notification arrives and sends heartbeats in
+// the meantime. It returns false once the client is gone.
+func waitStreamNotify(ctx *gin.Context, notify <-chan app.MessageNotifyDTO,
+	heartbeat <-chan time.Time) bool {
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return false
+		case <-notify:
+			return debounceNotify(ctx.Request.Context().Done(), notify)
+		case <-heartbeat:
+			if _, err := fmt.Fprint(ctx.Writer, ": heartbeat\n\n"); err != nil {
+				return false
+			}
+			ctx.Writer.Flush()
+		}
+	}
+}
+
+// debounceNotify drops the notifications arriving within streamDebounceInterval
+// after the first one. It returns false once done is closed.
+func debounceNotify(done <-chan struct{}, notify <-chan app.MessageNotifyDTO) bool {
+	timer := time.NewTimer(streamDebounceInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-done:
+			return false
+		case <-notify:
+		case <-timer.C:
+			return true
+		}
+	}
+}
+
+// streamWriter writes one event to a client, an empty id keeps the last event
+// id of the client unchanged.
+type streamWriter func(id, event string, data interface{}) error
+
+// parseStreamId parses the id of a stream event, which is the stream sequence
+// of its message. An empty id is 0.
+func parseStreamId(id string) (int64, error) {
+	if id == "" {
+		return 0, nil
+	}
+	seq, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, xerrors.Errorf("invalid event id %q", id)
+	}
+	return seq, nil
+}
+
+// sendNewMessage writes every message after lastSeq and returns the stream
+// sequence of the last one written.
+func sendNewMessage(streamService app.MessageStreamAppService, write streamWriter,
+	userName string, lastSeq int64) (int64, error) {
+	for {
+		data, err := streamService.GetMessageAfter(userName, lastSeq)
+		if err != nil {
+			return lastSeq, write("", streamEventError, gin.H{"error": err.Error()})
+		}
+		for i := range data {
+			id := strconv.FormatInt(data[i].StreamSeq, 10)
+			if err = write(id, streamEventMessage, data[i]); err != nil {
+				return lastSeq, err
+			}
+			lastSeq = data[i].StreamSeq
+		}
+		if len(data) < app.StreamBatchSize {
+			return lastSeq, nil
+		}
+	}
+}
+
+func sendCount(streamService app.MessageStreamAppService, write streamWriter,
+	userName string) error {
+	count, countNew, err := streamService.GetCount(userName)
+	if err != nil {
+		return write("", streamEventError, gin.H{"error": err.Error()})
+	}
+	if err = write("", streamEventCount, gin.H{"count": count}); err != nil {
+		return err
+	}
+	return write("", streamEventCountNew, gin.H{"count": countNew})
+}
+
+// writeStreamEvent writes one server-sent event.
+func writeStreamEvent(ctx *gin.Context, id, event string, data interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err = fmt.Fprintf(ctx.Writer, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(ctx.Writer, "event: %s\ndata: %s\n\n", event, b)
+	return err
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+func TestStreamMessage_Unauthorized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	AddRouterForMessageStreamController(r, nil)
+
+	req, err := http.NewRequest(http.MethodGet, "/message_center/inner/stream", nil)
+	if err != nil {
+		t.Fatal("Failed to create request:", err)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestStreamMessage_InvalidLastEventId(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	AddRouterForMessageStreamController(r, nil)
+
+	req, err := http.NewRequest(http.MethodGet, "/message_center/inner/stream", nil)
+	if err != nil {
+		t.Fatal("Failed to create request:", err)
+	}
+	req.Header.Set("Last-Event-ID", "invalid")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestWriteStreamEvent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	assert.NoError(t, writeStreamEvent(c, "id1", streamEventMessage, gin.H{"title": "t"}))
+	assert.NoError(t, writeStreamEvent(c, "", streamEventCount, gin.H{"count": 1}))
+
+	assert.Equal(t, "id: id1\nevent: message\ndata: {\"title\":\"t\"}\n\n"+
+		"event: count\ndata: {\"count\":1}\n\n", w.Body.String())
+}
+
+func TestDebounceNotify(t *testing.T) {
+	notify := make(chan app.MessageNotifyDTO, 3)
+	for i := 0; i < 3; i++ {
+		notify <- app.MessageNotifyDTO{}
+	}
+	assert.True(t, debounceNotify(make(chan struct{}), notify))
+	assert.Empty(t, notify)
+
+	done := make(chan struct{})
+	close(done)
+	assert.False(t, debounceNotify(done, notify))
+}
+
+func TestParseStreamId(t *testing.T) {
+	seq, err := parseStreamId("")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), seq)
+
+	seq, err = parseStreamId("42")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), seq)
+
+	for _, id := range []string{"invalid", "-1"} {
+		_, err = parseStreamId(id)
+		assert.Error(t, err)
+	}
+}
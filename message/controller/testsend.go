@@ -0,0 +1,122 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/xerrors"
+
+	commonctl "github.com/opensourceways/message-manager/common/controller"
+	"github.com/opensourceways/message-manager/common/domain/allerror"
+	"github.com/opensourceways/message-manager/common/user"
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+func AddRouterForMessageTestSendController(
+	r *gin.Engine,
+	s app.MessageTestSendAppService,
+) {
+	ctl := messageTestSendController{
+		appService: s,
+	}
+
+	v1 := r.Group("/message_center/config")
+	v1.POST("/recipient/:id/test", ctl.TestRecipient)
+	v1.POST("/push/test", ctl.TestPush)
+}
+
+type messageTestSendController struct {
+	appService app.MessageTestSendAppService
+}
+
+type testRecipientDTO struct {
+	SubscribeId int `json:"subscribe_id"`
+}
+
+type testPushDTO struct {
+	SubscribeId int   `json:"subscribe_id"`
+	RecipientId int64 `json:"recipient_id"`
+}
+
+// TestRecipient
+// @Summary			TestRecipient
+// @Description		send a sample message of a subscription to every contact of a recipient 测试接收人配置
+// @Tags			recipient
+// @Param			id		path	int					true	"recipient id"
+// @Param			body	body	testRecipientDTO	true	"testRecipientDTO"
+// @Accept			json
+// @Success			202	{object}  app.TestSendResultDTO
+// @Failure			400	string bad_request  无效的参数
+// @Failure			401	string unauthorized 用户未授权
+// @Failure			404	string not_found  接收人或订阅不存在
+// @Failure			500	string system_error  测试失败
+// @Router			/message_center/config/recipient/{id}/test [post]
+// @Id		testRecipient
+func (ctl *messageTestSendController) TestRecipient(ctx *gin.Context) {
+	userName, err := user.GetSystemUserName(ctx)
+	if err != nil {
+		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
+		return
+	}
+	recipientId, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || recipientId <= 0 {
+		commonctl.SendBadRequestParam(ctx, xerrors.Errorf("invalid recipient id"))
+		return
+	}
+	var req testRecipientDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.SubscribeId <= 0 {
+		commonctl.SendBadRequestParam(ctx, xerrors.Errorf("invalid subscribe_id"))
+		return
+	}
+
+	data, err := ctl.appService.TestRecipient(userName, recipientId, req.SubscribeId)
+	sendTestSendResult(ctx, data, err)
+}
+
+// TestPush
+// @Summary			TestPush
+// @Description		send a sample message of a subscription through every channel of a push config 测试推送配置
+// @Tags			message_push
+// @Param			body	body	testPushDTO	true	"testPushDTO"
+// @Accept			json
+// @Success			202	{object}  app.TestSendResultDTO
+// @Failure			400	string bad_request  无效的参数
+// @Failure			401	string unauthorized 用户未授权
+// @Failure			404	string not_found  推送配置不存在
+// @Failure			500	string system_error  测试失败
+// @Router			/message_center/config/push/test [post]
+// @Id		testPush
+func (ctl *messageTestSendController) TestPush(ctx *gin.Context) {
+	userName, err := user.GetSystemUserName(ctx)
+	if err != nil {
+		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
+		return
+	}
+	var req testPushDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.SubscribeId <= 0 ||
+		req.RecipientId <= 0 {
+		commonctl.SendBadRequestParam(ctx, xerrors.Errorf("invalid subscribe_id or recipient_id"))
+		return
+	}
+
+	data, err := ctl.appService.TestPush(userName, req.RecipientId, req.SubscribeId)
+	sendTestSendResult(ctx, data, err)
+}
+
+// sendTestSendResult replies the results of the channels, which failed or not.
+func sendTestSendResult(ctx *gin.Context, data []app.TestSendResultDTO, err error) {
+	switch {
+	case allerror.IsNotFound(err):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("测试失败，err:%v",
+			err)})
+	default:
+		ctx.JSON(http.StatusAccepted, gin.H{"result": data})
+	}
+}
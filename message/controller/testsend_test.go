@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/domain/allerror"
+	"github.com/opensourceways/message-manager/common/user"
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+// Mock for the MessageTestSendAppService
+type MockMessageTestSendAppService struct {
+	mock.Mock
+}
+
+func (m *MockMessageTestSendAppService) TestRecipient(userName string, recipientId int64,
+	subscribeId int) ([]app.TestSendResultDTO, error) {
+	args := m.Called(userName, recipientId, subscribeId)
+	return args.Get(0).([]app.TestSendResultDTO), args.Error(1)
+}
+
+func (m *MockMessageTestSendAppService) TestPush(userName string, recipientId int64,
+	subscribeId int) ([]app.TestSendResultDTO, error) {
+	args := m.Called(userName, recipientId, subscribeId)
+	return args.Get(0).([]app.TestSendResultDTO), args.Error(1)
+}
+
+func TestTestSend(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	patches := gomonkey.ApplyFuncReturn(user.GetSystemUserName, "testUser", nil)
+	defer patches.Reset()
+
+	router := gin.Default()
+	mockAppService := new(MockMessageTestSendAppService)
+	// the test route lives beside the sync one of the recipients
+	AddRouterForMessageRecipientController(router, new(MockMessageRecipientAppService))
+	AddRouterForMessageTestSendController(router, mockAppService)
+
+	results := []app.TestSendResultDTO{{Channel: "mail", Target: "a@example.com",
+		Status: app.TestSendFailed, Error: "550"}}
+	mockAppService.On("TestRecipient", "testUser", int64(9), 3).Return(results, nil).Once()
+	mockAppService.On("TestRecipient", "testUser", int64(8), 3).Return(
+		[]app.TestSendResultDTO{}, allerror.NewNotFound("test_send_not_found", "")).Once()
+	mockAppService.On("TestPush", "testUser", int64(9), 3).Return(results, nil).Once()
+	mockAppService.On("TestPush", "testUser", int64(7), 3).Return(
+		[]app.TestSendResultDTO{}, xerrors.New("db error")).Once()
+
+	for _, c := range []struct {
+		url  string
+		body string
+		code int
+	}{
+		{"/message_center/config/recipient/9/test", `{"subscribe_id":3}`, http.StatusAccepted},
+		{"/message_center/config/recipient/8/test", `{"subscribe_id":3}`, http.StatusNotFound},
+		{"/message_center/config/recipient/x/test", `{"subscribe_id":3}`, http.StatusBadRequest},
+		{"/message_center/config/recipient/9/test", `{}`, http.StatusBadRequest},
+		{"/message_center/config/push/test", `{"subscribe_id":3,"recipient_id":9}`,
+			http.StatusAccepted},
+		{"/message_center/config/push/test", `{"subscribe_id":3,"recipient_id":7}`,
+			http.StatusInternalServerError},
+		{"/message_center/config/push/test", `{"subscribe_id":3}`, http.StatusBadRequest},
+	} {
+		req, err := http.NewRequest(http.MethodPost, c.url, strings.NewReader(c.body))
+		if err != nil {
+			t.Fatal("Failed to create request:", err)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, c.code, recorder.Code, c.url+" "+c.body)
+	}
+	mockAppService.AssertExpectations(t)
+}
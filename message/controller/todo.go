@@ -0,0 +1,84 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opensourceways/message-manager/common/user"
+	"golang.org/x/xerrors"
+
+	commonctl "github.com/opensourceways/message-manager/common/controller"
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+func AddRouterForMessageTodoController(
+	r *gin.Engine,
+	s app.MessageTodoAppService,
+) {
+	ctl := messageTodoController{
+		appService: s,
+	}
+
+	v1 := r.Group("/message_center")
+	v1.PUT("/inner/todo/done", ctl.SetTodoIsDone)
+	v1.PUT("/inner/todo/undone", ctl.SetTodoIsUndone)
+}
+
+type messageTodoController struct {
+	appService app.MessageTodoAppService
+}
+
+// SetTodoIsDone
+// @Summary			SetTodoIsDone
+// @Description		mark todos done 设置待办已完成
+// @Tags			message_center
+// @Param			eventId body []string true "eventId"
+// @Accept			json
+// @Success			202	string accepted 设置成功
+// @Failure         400 string bad_request 无法解析请求正文
+// @Failure			401	string unauthorized 用户未授权
+// @Failure			500	string system_error  设置失败
+// @Router			/message_center/inner/todo/done [put]
+// @Id		setTodoIsDone
+func (ctl *messageTodoController) SetTodoIsDone(ctx *gin.Context) {
+	ctl.setTodoIsDone(ctx, true)
+}
+
+// SetTodoIsUndone
+// @Summary			SetTodoIsUndone
+// @Description		mark todos undone 设置待办未完成
+// @Tags			message_center
+// @Param			eventId body []string true "eventId"
+// @Accept			json
+// @Success			202	string accepted 设置成功
+// @Failure         400 string bad_request 无法解析请求正文
+// @Failure			401	string unauthorized 用户未授权
+// @Failure			500	string system_error  设置失败
+// @Router			/message_center/inner/todo/undone [put]
+// @Id		setTodoIsUndone
+func (ctl *messageTodoController) SetTodoIsUndone(ctx *gin.Context) {
+	ctl.setTodoIsDone(ctx, false)
+}
+
+func (ctl *messageTodoController) setTodoIsDone(ctx *gin.Context, isDone bool) {
+	var messages []string
+	if err := ctx.BindJSON(&messages); err != nil {
+		commonctl.SendBadRequestParam(ctx, xerrors.Errorf("无法解析请求正文"))
+		return
+	}
+	userName, err := user.GetSystemUserName(ctx)
+	if err != nil {
+		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
+		return
+	}
+	if err := ctl.appService.SetTodoIsDone(userName, messages, isDone); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("设置失败，err:%v",
+			err)})
+		return
+	}
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "设置成功"})
+}
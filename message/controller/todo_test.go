@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/user"
+)
+
+// Mock for the MessageTodoAppService
+type MockMessageTodoAppService struct {
+	mock.Mock
+}
+
+func (m *MockMessageTodoAppService) SetTodoIsDone(userName string, eventIds []string,
+	isDone bool) error {
+	args := m.Called(userName, eventIds, isDone)
+	return args.Error(0)
+}
+
+func (m *MockMessageTodoAppService) CompleteExpiredTodo(now time.Time) error {
+	args := m.Called(now)
+	return args.Error(0)
+}
+
+func TestSetTodoIsDone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	patches := gomonkey.ApplyFuncReturn(user.GetSystemUserName, "testUser", nil)
+	defer patches.Reset()
+
+	router := gin.Default()
+	mockAppService := new(MockMessageTodoAppService)
+	AddRouterForMessageTodoController(router, mockAppService)
+
+	mockAppService.On("SetTodoIsDone", "testUser", []string{"1", "2"}, true).Return(nil).Once()
+	mockAppService.On("SetTodoIsDone", "testUser", []string{"1"}, false).Return(nil).Once()
+	mockAppService.On("SetTodoIsDone", "testUser", []string{"broken"}, true).
+		Return(xerrors.New("db error")).Once()
+
+	tests := []struct {
+		path string
+		body string
+		code int
+	}{
+		{"/message_center/inner/todo/done", `["1","2"]`, http.StatusAccepted},
+		{"/message_center/inner/todo/undone", `["1"]`, http.StatusAccepted},
+		{"/message_center/inner/todo/done", `["broken"]`, http.StatusInternalServerError},
+		{"/message_center/inner/todo/done", `{`, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		req, err := http.NewRequest(http.MethodPut, test.path, bytes.NewBufferString(test.body))
+		if err != nil {
+			t.Fatal("Failed to create request:", err)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, test.code, recorder.Code, test.path+" "+test.body)
+	}
+	mockAppService.AssertExpectations(t)
+}
+
+func TestSetTodoIsDone_Unauthorized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	AddRouterForMessageTodoController(router, new(MockMessageTodoAppService))
+
+	req, _ := http.NewRequest(http.MethodPut, "/message_center/inner/todo/done",
+		bytes.NewBufferString(`["1"]`))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
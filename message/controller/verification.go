@@ -0,0 +1,133 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/xerrors"
+
+	commonctl "github.com/opensourceways/message-manager/common/controller"
+	"github.com/opensourceways/message-manager/common/domain/allerror"
+	"github.com/opensourceways/message-manager/common/user"
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+func AddRouterForMessageVerificationController(
+	r *gin.Engine,
+	s app.MessageVerificationAppService,
+) {
+	ctl := messageVerificationController{
+		appService: s,
+	}
+
+	v1 := r.Group("/message_center/config")
+	v1.POST("/recipient/:id/verify", ctl.SendVerification)
+	v1.POST("/recipient/:id/verify/confirm", ctl.ConfirmVerification)
+}
+
+type messageVerificationController struct {
+	appService app.MessageVerificationAppService
+}
+
+type sendVerificationDTO struct {
+	ContactType string `json:"contact_type"`
+}
+
+type confirmVerificationDTO struct {
+	ContactType string `json:"contact_type"`
+	Code        string `json:"code"`
+}
+
+// SendVerification
+// @Summary			SendVerification
+// @Description		send a code to the mail, message or phone of a recipient 发送联系方式验证码
+// @Tags			recipient
+// @Param			id		path	int					true	"recipient id"
+// @Param			body	body	sendVerificationDTO	true	"sendVerificationDTO"
+// @Accept			json
+// @Success			202	string accepted 发送成功
+// @Failure			400	string bad_request  无效的参数
+// @Failure			401	string unauthorized 用户未授权
+// @Failure			404	string not_found  接收人不存在
+// @Failure			429	string over_limit  发送过于频繁
+// @Failure			500	string system_error  发送失败
+// @Router			/message_center/config/recipient/{id}/verify [post]
+// @Id		sendVerification
+func (ctl *messageVerificationController) SendVerification(ctx *gin.Context) {
+	userName, err := user.GetSystemUserName(ctx)
+	if err != nil {
+		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
+		return
+	}
+	recipientId, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || recipientId <= 0 {
+		commonctl.SendBadRequestParam(ctx, xerrors.Errorf("invalid recipient id"))
+		return
+	}
+	var req sendVerificationDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		commonctl.SendBadRequestParam(ctx, xerrors.Errorf("无法解析请求正文，err:%v", err))
+		return
+	}
+
+	err = ctl.appService.SendVerification(userName, recipientId, req.ContactType)
+	sendVerificationResult(ctx, "发送", err)
+}
+
+// ConfirmVerification
+// @Summary			ConfirmVerification
+// @Description		verify the mail, message or phone of a recipient with the code sent to it 验证联系方式
+// @Tags			recipient
+// @Param			id		path	int						true	"recipient id"
+// @Param			body	body	confirmVerificationDTO	true	"confirmVerificationDTO"
+// @Accept			json
+// @Success			202	string accepted 验证成功
+// @Failure			400	string bad_request  无效的参数或验证码
+// @Failure			401	string unauthorized 用户未授权
+// @Failure			404	string not_found  接收人不存在
+// @Failure			500	string system_error  验证失败
+// @Router			/message_center/config/recipient/{id}/verify/confirm [post]
+// @Id		confirmVerification
+func (ctl *messageVerificationController) ConfirmVerification(ctx *gin.Context) {
+	userName, err := user.GetSystemUserName(ctx)
+	if err != nil {
+		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
+		return
+	}
+	recipientId, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || recipientId <= 0 {
+		commonctl.SendBadRequestParam(ctx, xerrors.Errorf("invalid recipient id"))
+		return
+	}
+	var req confirmVerificationDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		commonctl.SendBadRequestParam(ctx, xerrors.Errorf("invalid contact_type or code"))
+		return
+	}
+
+	err = ctl.appService.ConfirmVerification(userName, recipientId, req.ContactType, req.Code)
+	sendVerificationResult(ctx, "验证", err)
+}
+
+// sendVerificationResult replies the result of the action, which is named in
+// the messages.
+func sendVerificationResult(ctx *gin.Context, action string, err error) {
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusAccepted, gin.H{"message": action + "成功"})
+	case allerror.IsNotFound(err):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case allerror.IsInvalidParam(err):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case allerror.IsOverLimit(err):
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("%s失败，err:%v",
+			action, err)})
+	}
+}
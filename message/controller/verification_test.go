@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/domain/allerror"
+	"github.com/opensourceways/message-manager/common/user"
+)
+
+// Mock for the MessageVerificationAppService
+type MockMessageVerificationAppService struct {
+	mock.Mock
+}
+
+func (m *MockMessageVerificationAppService) SendVerification(userName string,
+	recipientId int64, contactType string) error {
+	args := m.Called(userName, recipientId, contactType)
+	return args.Error(0)
+}
+
+func (m *MockMessageVerificationAppService) ConfirmVerification(userName string,
+	recipientId int64, contactType, code string) error {
+	args := m.Called(userName, recipientId, contactType, code)
+	return args.Error(0)
+}
+
+func TestVerification(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	patches := gomonkey.ApplyFuncReturn(user.GetSystemUserName, "testUser", nil)
+	defer patches.Reset()
+
+	router := gin.Default()
+	mockAppService := new(MockMessageVerificationAppService)
+	// the routes live beside the test and sync ones of the recipients
+	AddRouterForMessageRecipientController(router, new(MockMessageRecipientAppService))
+	AddRouterForMessageTestSendController(router, new(MockMessageTestSendAppService))
+	AddRouterForMessageVerificationController(router, mockAppService)
+
+	mockAppService.On("SendVerification", "testUser", int64(9), "mail").Return(nil).Once()
+	mockAppService.On("SendVerification", "testUser", int64(9), "phone").Return(
+		allerror.NewOverLimit("verification_too_often", "")).Once()
+	mockAppService.On("SendVerification", "testUser", int64(8), "mail").Return(
+		allerror.NewNotFound("recipient_not_found", "")).Once()
+	mockAppService.On("SendVerification", "testUser", int64(7), "mail").Return(
+		xerrors.New("smtp error")).Once()
+	mockAppService.On("ConfirmVerification", "testUser", int64(9), "mail", "123456").Return(
+		nil).Once()
+	mockAppService.On("ConfirmVerification", "testUser", int64(9), "mail", "654321").Return(
+		allerror.NewInvalidParam("the code is wrong")).Once()
+
+	for _, c := range []struct {
+		url  string
+		body string
+		code int
+	}{
+		{"/message_center/config/recipient/9/verify", `{"contact_type":"mail"}`,
+			http.StatusAccepted},
+		{"/message_center/config/recipient/9/verify", `{"contact_type":"phone"}`,
+			http.StatusTooManyRequests},
+		{"/message_center/config/recipient/8/verify", `{"contact_type":"mail"}`,
+			http.StatusNotFound},
+		{"/message_center/config/recipient/7/verify", `{"contact_type":"mail"}`,
+			http.StatusInternalServerError},
+		{"/message_center/config/recipient/x/verify", `{"contact_type":"mail"}`,
+			http.StatusBadRequest},
+		{"/message_center/config/recipient/9/verify/confirm",
+			`{"contact_type":"mail","code":"123456"}`, http.StatusAccepted},
+		{"/message_center/config/recipient/9/verify/confirm",
+			`{"contact_type":"mail","code":"654321"}`, http.StatusBadRequest},
+		{"/message_center/config/recipient/9/verify/confirm", `{"contact_type":"mail"}`,
+			http.StatusBadRequest},
+	} {
+		req, err := http.NewRequest(http.MethodPost, c.url, strings.NewReader(c.body))
+		if err != nil {
+			t.Fatal("Failed to create request:", err)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, c.code, recorder.Code, c.url+" "+c.body)
+	}
+	mockAppService.AssertExpectations(t)
+}
@@ -0,0 +1,78 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/xerrors"
+
+	commonctl "github.com/opensourceways/message-manager/common/controller"
+	"github.com/opensourceways/message-manager/common/user"
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+func AddRouterForMessageWebhookController(
+	r *gin.Engine,
+	s app.MessageWebhookAppService,
+) {
+	ctl := messageWebhookController{
+		appService: s,
+	}
+
+	v1 := r.Group("/message_center/config")
+	v1.GET("/recipient/webhook/log", ctl.GetWebhookLog)
+}
+
+type messageWebhookController struct {
+	appService app.MessageWebhookAppService
+}
+
+// GetWebhookLog
+// @Summary			GetWebhookLog
+// @Description		get the posts to the webhook of a recipient 查询webhook推送记录
+// @Tags			recipient
+// @Param			recipient_id	query	int	true	"recipient id"
+// @Param			count_per_page	query	int	true	"count per page"
+// @Param			page			query	int	true	"page"
+// @Accept			json
+// @Success			202	{object}  app.WebhookLogDTO
+// @Failure			400	string bad_request  无效的参数
+// @Failure			401	string unauthorized 用户未授权
+// @Failure			500	string system_error  查询失败
+// @Router			/message_center/config/recipient/webhook/log [get]
+// @Id		getWebhookLog
+func (ctl *messageWebhookController) GetWebhookLog(ctx *gin.Context) {
+	userName, err := user.GetSystemUserName(ctx)
+	if err != nil {
+		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
+		return
+	}
+	recipientId, err := strconv.ParseInt(ctx.Query("recipient_id"), 10, 64)
+	if err != nil {
+		commonctl.SendBadRequestParam(ctx, xerrors.Errorf("invalid recipient_id, err:%v", err))
+		return
+	}
+	countPerPage, err := strconv.Atoi(ctx.Query("count_per_page"))
+	if err != nil || countPerPage <= 0 {
+		commonctl.SendBadRequestParam(ctx, xerrors.Errorf("invalid count_per_page"))
+		return
+	}
+	pageNum, err := strconv.Atoi(ctx.Query("page"))
+	if err != nil || pageNum <= 0 {
+		commonctl.SendBadRequestParam(ctx, xerrors.Errorf("invalid page"))
+		return
+	}
+
+	if data, count, err := ctl.appService.GetWebhookLog(userName, recipientId, countPerPage,
+		pageNum); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": xerrors.Errorf("查询失败，err:%v",
+			err)})
+	} else {
+		ctx.JSON(http.StatusAccepted, gin.H{"query_info": data, "count": count})
+	}
+}
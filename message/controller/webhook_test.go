@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/user"
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+// Mock for the MessageWebhookAppService
+type MockMessageWebhookAppService struct {
+	mock.Mock
+}
+
+func (m *MockMessageWebhookAppService) Notify(event app.CloudEventDTO) error {
+	return m.Called(event).Error(0)
+}
+
+func (m *MockMessageWebhookAppService) SendWebhook() (app.DeliveryResultDTO, error) {
+	args := m.Called()
+	return args.Get(0).(app.DeliveryResultDTO), args.Error(1)
+}
+
+func (m *MockMessageWebhookAppService) SendTestWebhook(event app.CloudEventDTO, url,
+	secret string) error {
+	return m.Called(event, url, secret).Error(0)
+}
+
+func (m *MockMessageWebhookAppService) GetWebhookLog(userName string, recipientId int64,
+	countPerPage, pageNum int) ([]app.WebhookLogDTO, int64, error) {
+	args := m.Called(userName, recipientId, countPerPage, pageNum)
+	return args.Get(0).([]app.WebhookLogDTO), int64(args.Int(1)), args.Error(2)
+}
+
+func TestGetWebhookLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	patches := gomonkey.ApplyFuncReturn(user.GetSystemUserName, "testUser", nil)
+	defer patches.Reset()
+
+	router := gin.Default()
+	mockAppService := new(MockMessageWebhookAppService)
+	AddRouterForMessageWebhookController(router, mockAppService)
+
+	mockAppService.On("GetWebhookLog", "testUser", int64(9), 10, 1).
+		Return([]app.WebhookLogDTO{{Id: 1, RecipientId: 9, StatusCode: 200}}, 1, nil).Once()
+	mockAppService.On("GetWebhookLog", "testUser", int64(8), 10, 1).
+		Return([]app.WebhookLogDTO{}, 0, xerrors.New("db error")).Once()
+
+	for query, code := range map[string]int{
+		"recipient_id=9&count_per_page=10&page=1": http.StatusAccepted,
+		"recipient_id=8&count_per_page=10&page=1": http.StatusInternalServerError,
+		"recipient_id=x&count_per_page=10&page=1": http.StatusBadRequest,
+		"recipient_id=9&count_per_page=0&page=1":  http.StatusBadRequest,
+		"recipient_id=9&count_per_page=10":        http.StatusBadRequest,
+	} {
+		req, err := http.NewRequest(http.MethodGet,
+			"/message_center/config/recipient/webhook/log?"+query, nil)
+		if err != nil {
+			t.Fatal("Failed to create request:", err)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, code, recorder.Code, query)
+	}
+	mockAppService.AssertExpectations(t)
+}
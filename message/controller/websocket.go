@@ -0,0 +1,285 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/opensourceways/message-manager/common/user"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+
+	commonctl "github.com/opensourceways/message-manager/common/controller"
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+const (
+	wsWriteTimeout = 10 * time.Second
+	wsReadTimeout  = 2 * streamHeartbeatInterval
+	wsReadLimit    = 64 * 1024
+
+	wsActionSubscribe   = "subscribe"
+	wsActionUnsubscribe = "unsubscribe"
+	wsActionRead        = "read"
+	wsActionDelete      = "delete"
+
+	wsTypeAck = "ack"
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+func AddRouterForMessageWebsocketController(
+	r *gin.Engine,
+	listService app.MessageListAppService,
+	streamService app.MessageStreamAppService,
+) {
+	ctl := messageWebsocketController{
+		listService:   listService,
+		streamService: streamService,
+	}
+
+	v1 := r.Group("/message_center")
+	v1.GET("/inner/ws", ctl.ServeWebsocket)
+}
+
+type messageWebsocketController struct {
+	listService   app.MessageListAppService
+	streamService app.MessageStreamAppService
+}
+
+// wsCommand is a command sent by the client.
+type wsCommand struct {
+	Id          string   `json:"id"`
+	Action      string   `json:"action"`
+	LastEventId string   `json:"last_event_id"`
+	EventIds    []string `json:"event_ids"`
+}
+
+// wsFrame is a frame sent to the client, Type is ack or one of the stream
+// events.
+type wsFrame struct {
+	Type  string      `json:"type"`
+	Id    string      `json:"id,omitempty"`
+	Error string      `json:"error,omitempty"`
+	Data  interface{} `json:"data,omitempty"`
+}
+
+// wsSession is one websocket connection of a user.
+type wsSession struct {
+	ctl      *messageWebsocketController
+	conn     *websocket.Conn
+	userName string
+
+	// ctx is done once the connection is closed
+	ctx       context.Context
+	writeLock sync.Mutex
+
+	subscribeLock sync.Mutex
+	stopSubscribe context.CancelFunc
+}
+
+// ServeWebsocket
+// @Summary			ServeWebsocket
+// @Description		websocket channel pushing new messages and accepting subscribe/read/delete commands 桌面客户端消息通道
+// @Tags			message_center
+// @Success			101	string switching_protocols 建立连接
+// @Failure			401	string unauthorized 用户未授权
+// @Router			/message_center/inner/ws [get]
+// @Id	    serveWebsocket
+func (ctl *messageWebsocketController) ServeWebsocket(ctx *gin.Context) {
+	userName, err := user.GetSystemUserName(ctx)
+	if err != nil {
+		commonctl.SendUnauthorized(ctx, xerrors.Errorf("get username failed, err:%v", err))
+		return
+	}
+
+	conn, err := wsUpgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		// the upgrader has already replied to the client
+		logrus.Errorf("upgrade websocket failed, err:%v", err)
+		return
+	}
+	defer conn.Close()
+
+	sessionCtx, closeSession := context.WithCancel(ctx.Request.Context())
+	defer closeSession()
+
+	s := &wsSession{
+		ctl:      ctl,
+		conn:     conn,
+		userName: userName,
+		ctx:      sessionCtx,
+	}
+	go s.ping()
+	s.serve()
+}
+
+// serve reads commands until the connection is broken.
+func (s *wsSession) serve() {
+	s.conn.SetReadLimit(wsReadLimit)
+	_ = s.conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+	})
+
+	for {
+		_, b, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		ack := wsFrame{Type: wsTypeAck}
+		var cmd wsCommand
+		if err = json.Unmarshal(b, &cmd); err != nil {
+			ack.Error = err.Error()
+		} else {
+			ack = s.handle(cmd)
+		}
+		if err = s.write(ack); err != nil {
+			return
+		}
+	}
+}
+
+// handle executes cmd and returns its ack.
+func (s *wsSession) handle(cmd wsCommand) wsFrame {
+	ack := wsFrame{Type: wsTypeAck, Id: cmd.Id}
+
+	var err error
+	switch cmd.Action {
+	case wsActionSubscribe:
+		err = s.subscribe(cmd.LastEventId)
+	case wsActionUnsubscribe:
+		s.unsubscribe()
+	case wsActionRead:
+		for _, eventId := range cmd.EventIds {
+			if err = s.ctl.listService.SetMessageIsRead(s.userName, eventId); err != nil {
+				break
+			}
+		}
+	case wsActionDelete:
+		for _, eventId := range cmd.EventIds {
+			if err = s.ctl.listService.RemoveMessage(s.userName, eventId); err != nil {
+				break
+			}
+		}
+	default:
+		err = xerrors.Errorf("unknown action:%s", cmd.Action)
+	}
+
+	if err != nil {
+		ack.Error = err.Error()
+	}
+	return ack
+}
+
+// subscribe (re)starts pushing the messages after lastEventId.
+func (s *wsSession) subscribe(lastEventId string) error {
+	lastSeq, err := parseStreamId(lastEventId)
+	if err != nil {
+		return err
+	}
+
+	s.subscribeLock.Lock()
+	defer s.subscribeLock.Unlock()
+
+	if s.stopSubscribe != nil {
+		s.stopSubscribe()
+	}
+	// subscribe before looking for the start so that nothing is missed
+	notify, cancel := s.ctl.streamService.Subscribe(s.userName)
+	if lastEventId == "" {
+		if lastSeq, err = s.ctl.streamService.GetLatestStreamSeq(s.userName); err != nil {
+			cancel()
+			s.stopSubscribe = nil
+			return err
+		}
+	}
+
+	ctx, stop := context.WithCancel(s.ctx)
+	s.stopSubscribe = stop
+	go func() {
+		defer cancel()
+		s.push(ctx, notify, lastSeq)
+	}()
+	return nil
+}
+
+func (s *wsSession) unsubscribe() {
+	s.subscribeLock.Lock()
+	defer s.subscribeLock.Unlock()
+
+	if s.stopSubscribe != nil {
+		s.stopSubscribe()
+		s.stopSubscribe = nil
+	}
+}
+
+// push writes the new messages and counts every time the user is notified.
+func (s *wsSession) push(ctx context.Context, notify <-chan app.MessageNotifyDTO,
+	lastSeq int64) {
+	write := func(id, event string, data interface{}) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return s.write(wsFrame{Type: event, Id: id, Data: data})
+	}
+
+	var err error
+	for {
+		if lastSeq, err = sendNewMessage(s.ctl.streamService, write, s.userName,
+			lastSeq); err != nil {
+			return
+		}
+		if err = sendCount(s.ctl.streamService, write, s.userName); err != nil {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-notify:
+			if !debounceNotify(ctx.Done(), notify) {
+				return
+			}
+		}
+	}
+}
+
+// ping keeps the connection alive until the session is closed.
+func (s *wsSession) ping() {
+	ticker := time.NewTicker(streamHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.writeLock.Lock()
+			err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout))
+			s.writeLock.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *wsSession) write(frame wsFrame) error {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+
+	_ = s.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return s.conn.WriteJSON(frame)
+}
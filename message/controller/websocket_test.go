@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/opensourceways/message-manager/common/user"
+	"github.com/opensourceways/message-manager/message/app"
+)
+
+type MockMessageStreamAppService struct {
+	mock.Mock
+	notify chan app.MessageNotifyDTO
+}
+
+func (m *MockMessageStreamAppService) Subscribe(userName string) (
+	<-chan app.MessageNotifyDTO, func()) {
+	m.Called(userName)
+	return m.notify, func() {}
+}
+
+func (m *MockMessageStreamAppService) GetLatestStreamSeq(userName string) (int64, error) {
+	args := m.Called(userName)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockMessageStreamAppService) GetMessageAfter(userName string, after int64) (
+	[]app.MessageListDTO, error) {
+	args := m.Called(userName, after)
+	return args.Get(0).([]app.MessageListDTO), args.Error(1)
+}
+
+func (m *MockMessageStreamAppService) GetCount(userName string) (
+	[]app.CountDTO, app.CountDataDTO, error) {
+	args := m.Called(userName)
+	return args.Get(0).([]app.CountDTO), args.Get(1).(app.CountDataDTO), args.Error(2)
+}
+
+func TestServeWebsocket_Unauthorized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	AddRouterForMessageWebsocketController(r, nil, nil)
+
+	req, err := http.NewRequest(http.MethodGet, "/message_center/inner/ws", nil)
+	if err != nil {
+		t.Fatal("Failed to create request:", err)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestWsSessionHandle(t *testing.T) {
+	s := &wsSession{ctl: &messageWebsocketController{}}
+
+	ack := s.handle(wsCommand{Id: "1", Action: "unknown"})
+	assert.Equal(t, wsTypeAck, ack.Type)
+	assert.Equal(t, "1", ack.Id)
+	assert.Contains(t, ack.Error, "unknown action")
+
+	ack = s.handle(wsCommand{Id: "2", Action: wsActionSubscribe, LastEventId: "invalid"})
+	assert.Equal(t, "2", ack.Id)
+	assert.NotEmpty(t, ack.Error)
+
+	ack = s.handle(wsCommand{Id: "3", Action: wsActionUnsubscribe})
+	assert.Equal(t, "3", ack.Id)
+	assert.Empty(t, ack.Error)
+}
+
+func TestServeWebsocket_PushMessage(t *testing.T) {
+	patches := gomonkey.ApplyFuncReturn(user.GetSystemUserName, "testUser", nil)
+	defer patches.Reset()
+
+	mockService := &MockMessageStreamAppService{notify: make(chan app.MessageNotifyDTO, 1)}
+	mockService.On("Subscribe", "testUser").Return()
+	mockService.On("GetLatestStreamSeq", "testUser").Return(int64(4), nil)
+	mockService.On("GetMessageAfter", "testUser", int64(4)).
+		Return([]app.MessageListDTO{}, nil).Once()
+	mockService.On("GetMessageAfter", "testUser", int64(4)).
+		Return([]app.MessageListDTO{{EventId: "event5", StreamSeq: 5}}, nil).Once()
+	mockService.On("GetMessageAfter", "testUser", int64(5)).Return([]app.MessageListDTO{}, nil)
+	mockService.On("GetCount", "testUser").Return([]app.CountDTO{}, app.CountDataDTO{}, nil)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	AddRouterForMessageWebsocketController(r, nil, mockService)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/message_center/inner/ws"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal("Failed to dial:", err)
+	}
+	defer conn.Close()
+
+	assert.NoError(t, conn.WriteJSON(wsCommand{Id: "1", Action: wsActionSubscribe}))
+	// wait for the first push before the message arrives
+	readWsFrame(t, conn, streamEventCountNew)
+
+	mockService.notify <- app.MessageNotifyDTO{UserName: "testUser", Op: "insert"}
+	frame := readWsFrame(t, conn, streamEventMessage)
+	assert.Equal(t, "5", frame.Id)
+	assert.Equal(t, "event5", frame.Data.(map[string]interface{})["event_id"])
+}
+
+// readWsFrame reads the frames of conn until one of type frameType arrives.
+func readWsFrame(t *testing.T, conn *websocket.Conn, frameType string) wsFrame {
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var frame wsFrame
+		if err := conn.ReadJSON(&frame); err != nil {
+			t.Fatal("Failed to read frame:", err)
+		}
+		if frame.Type == frameType {
+			return frame
+		}
+	}
+}
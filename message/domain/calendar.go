@@ -0,0 +1,14 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package domain
+
+import "time"
+
+type MessageCalendarAdapter interface {
+	GetCalendarToken(userName string) (string, error)
+	SaveCalendarToken(userName, token string) error
+	GetUserNameByCalendarToken(token string) (string, error)
+	GetMeetingEvent(userName string, since time.Time) ([]MeetingEventDO, error)
+}
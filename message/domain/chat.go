@@ -0,0 +1,19 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package domain
+
+import (
+	"github.com/opensourceways/message-manager/common/chat"
+)
+
+type MessageChatAdapter interface {
+	GetChatTarget(communityId, source string, sigs, repos []string) ([]SubscribeTargetDO, error)
+	GetChatBot(recipientId int64) (ChatBotDO, error)
+}
+
+// ChatSender posts the message cards to the bots of the chat platforms.
+type ChatSender interface {
+	Send(bot chat.Bot, card chat.Card) error
+}
@@ -0,0 +1,9 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package domain
+
+type MessageCloudEventAdapter interface {
+	SaveCloudEvent(event CloudEventDO) (CloudEventDO, bool, error)
+}
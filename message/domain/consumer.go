@@ -0,0 +1,17 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package domain
+
+import "context"
+
+type MessageConsumerBroker interface {
+	Fetch(ctx context.Context) (ConsumerMessageDO, error)
+	Commit(ctx context.Context, msg ConsumerMessageDO) error
+	Close() error
+}
+
+type MessageDeadLetterAdapter interface {
+	SaveDeadLetter(letter DeadLetterDO) error
+}
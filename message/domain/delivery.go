@@ -0,0 +1,39 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package domain
+
+import (
+	"time"
+
+	"github.com/opensourceways/message-manager/message/infrastructure"
+)
+
+const (
+	DeliveryPending  = infrastructure.DeliveryPending
+	DeliverySent     = infrastructure.DeliverySent
+	DeliveryFailed   = infrastructure.DeliveryFailed
+	DeliveryRetrying = infrastructure.DeliveryRetrying
+
+	DeliveryChannelMail         = infrastructure.DeliveryChannelMail
+	DeliveryChannelWebhook      = infrastructure.DeliveryChannelWebhook
+	DeliveryChannelChat         = infrastructure.DeliveryChannelChat
+	DeliveryChannelMessage      = infrastructure.DeliveryChannelMessage
+	DeliveryChannelPhone        = infrastructure.DeliveryChannelPhone
+	DeliveryChannelInnerMessage = infrastructure.DeliveryChannelInnerMessage
+)
+
+type MessageDeliveryAdapter interface {
+	EnqueueDelivery(items []DeliveryDO) (int64, error)
+	ClaimDelivery(channel string, limit int, lease time.Duration) ([]DeliveryDO, error)
+	CompleteDelivery(id int64) error
+	RetryDelivery(id int64, lastError string, retryAt time.Time) error
+	FailDelivery(id int64, lastError string) error
+}
+
+type MessageDeliveryAttemptAdapter interface {
+	SaveDeliveryAttempt(attempts []DeliveryAttemptDO) (int64, error)
+	GetDeliveryAttempt(userName, eventId string) ([]DeliveryAttemptDO, error)
+	GetAllDeliveryAttempt(cmd CmdToGetDeliveryAttempt) ([]DeliveryAttemptDO, int64, error)
+}
@@ -15,9 +15,30 @@ type MessageSubscribeDO = infrastructure.MessageSubscribeDAO
 type MessageSubscribeDOWithPushConfig = infrastructure.MessageSubscribeDAOWithPushConfig
 type CountDO = infrastructure.CountDAO
 type CountDataDO = infrastructure.CountDataDAO
+type UnreadCounterDO = infrastructure.UnreadCounterDAO
+type MessageNotifyDO = infrastructure.MessageNotifyDAO
+type MeetingEventDO = infrastructure.MeetingEventDAO
+type CloudEventDO = infrastructure.CloudEventDAO
+type SubscribeTargetDO = infrastructure.SubscribeTargetDAO
+type RecipientLoginDO = infrastructure.RecipientLoginDAO
+type RecipientIdentityDO = infrastructure.RecipientIdentityDAO
+type TodoFanoutDO = infrastructure.TodoFanoutDAO
+type MembershipTargetDO = infrastructure.MembershipTargetDAO
+type MembershipSyncDO = infrastructure.MembershipSyncDAO
+type ConsumerMessageDO = infrastructure.ConsumerMessageDAO
+type DeadLetterDO = infrastructure.DeadLetterDAO
+type DeliveryDO = infrastructure.DeliveryDAO
+type WebhookDO = infrastructure.WebhookDAO
+type ChatBotDO = infrastructure.ChatBotDAO
+type WebhookLogDO = infrastructure.WebhookLogDAO
+type DeliveryAttemptDO = infrastructure.DeliveryAttemptDAO
+type TestSendTargetDO = infrastructure.TestSendTargetDAO
+type RecipientContactDO = infrastructure.RecipientContactDAO
+type VerificationDO = infrastructure.VerificationDAO
 
 type CmdToGetInnerMessageQuick = infrastructure.CmdToGetInnerMessageQuick
 type CmdToGetInnerMessage = infrastructure.CmdToGetInnerMessage
+type CmdToGetSourceMessage = infrastructure.CmdToGetSourceMessage
 type CmdToSetIsRead = infrastructure.CmdToSetIsRead
 type CmdToAddPushConfig = infrastructure.CmdToAddPushConfig
 type CmdToUpdatePushConfig = infrastructure.CmdToUpdatePushConfig
@@ -26,7 +47,11 @@ type CmdToAddRecipient = infrastructure.CmdToAddRecipient
 type CmdToUpdateRecipient = infrastructure.CmdToUpdateRecipient
 type CmdToDeleteRecipient = infrastructure.CmdToDeleteRecipient
 type CmdToSyncUserInfo = infrastructure.CmdToSyncUserInfo
+type CmdToLinkIdentity = infrastructure.CmdToLinkIdentity
 type CmdToGetSubscribe = infrastructure.CmdToGetSubscribe
 type CmdToAddSubscribe = infrastructure.CmdToAddSubscribe
 type CmdToUpdateSubscribe = infrastructure.CmdToUpdateSubscribe
 type CmdToDeleteSubscribe = infrastructure.CmdToDeleteSubscribe
+type CmdToSaveFanout = infrastructure.CmdToSaveFanout
+type CmdToSaveMembership = infrastructure.CmdToSaveMembership
+type CmdToGetDeliveryAttempt = infrastructure.CmdToGetDeliveryAttempt
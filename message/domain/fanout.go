@@ -0,0 +1,13 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package domain
+
+type MessageFanoutAdapter interface {
+	GetSubscribeTarget(communityId, source string, sigs, repos []string) (
+		[]SubscribeTargetDO, error)
+	GetRecipientByLogin(communityId, forge string, logins []string) ([]RecipientLoginDO, error)
+	GetRecipientBySig(communityId string, sigs []string) ([]int64, error)
+	SaveFanout(cmd CmdToSaveFanout) error
+}
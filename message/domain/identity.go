@@ -0,0 +1,11 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package domain
+
+type MessageIdentityAdapter interface {
+	GetIdentity(userName string, recipientId int64) ([]RecipientIdentityDO, error)
+	LinkIdentity(userName string, cmd CmdToLinkIdentity) error
+	UnlinkIdentity(userName string, cmd CmdToLinkIdentity) error
+}
@@ -0,0 +1,23 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package domain
+
+import (
+	"github.com/opensourceways/message-manager/common/mail"
+)
+
+type MessageMailAdapter interface {
+	GetMailTarget(communityId, source string, sigs, repos []string) ([]SubscribeTargetDO, error)
+}
+
+// MailRenderer renders the mails of the events.
+type MailRenderer interface {
+	Render(event mail.Event) (mail.Content, error)
+}
+
+// MailSender sends the mails.
+type MailSender interface {
+	Send(msg mail.Message) error
+}
@@ -0,0 +1,33 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package domain
+
+import (
+	"time"
+
+	"github.com/opensourceways/message-manager/message/infrastructure"
+)
+
+const (
+	MembershipSyncRunning   = infrastructure.MembershipSyncRunning
+	MembershipSyncSucceeded = infrastructure.MembershipSyncSucceeded
+	MembershipSyncPartial   = infrastructure.MembershipSyncPartial
+	MembershipSyncFailed    = infrastructure.MembershipSyncFailed
+)
+
+type MessageMembershipAdapter interface {
+	StartMembershipSync(timeout time.Duration) (int64, bool, error)
+	FinishMembershipSync(cmd MembershipSyncDO) error
+	GetMembershipSync() (MembershipSyncDO, error)
+	GetMembershipTarget() ([]MembershipTargetDO, error)
+	SaveMembership(cmd CmdToSaveMembership) error
+	PruneMembership() error
+}
+
+// MembershipDirectory is the upstream the memberships are synced from.
+type MembershipDirectory interface {
+	GetUserSig(communityId, userName string) ([]string, error)
+	GetUserAdminRepos(login string) ([]string, error)
+}
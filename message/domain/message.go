@@ -9,34 +9,46 @@ type MessageListAdapter interface {
 	SetMessageIsRead(userName string, eventId string) error
 	RemoveMessage(userName string, eventId string) error
 
-	GetAllToDoMessage(userName, giteeUsername string, isDone *bool, pageNum,
-		countPerPage int, startTime string, isRead *bool) ([]MessageListDO, int64, error)
-	GetAllAboutMessage(userName, giteeUsername string, isBot *bool, pageNum,
-		countPerPage int, startTime string, isRead *bool) ([]MessageListDO, int64, error)
-	GetAllWatchMessage(userName, giteeUsername string, pageNum, countPerPage int,
-		startTime string, isRead *bool) ([]MessageListDO, int64, error)
+	GetAllToDoMessage(userName string, isDone *bool, pageNum,
+		countPerPage int, startTime string, isRead *bool, cursor string) ([]MessageListDO, int64,
+		error)
+	GetAllAboutMessage(userName string, isBot *bool, pageNum,
+		countPerPage int, startTime string, isRead *bool, cursor string) ([]MessageListDO, int64,
+		error)
+	GetAllWatchMessage(userName string, pageNum, countPerPage int,
+		startTime string, isRead *bool, cursor string) ([]MessageListDO, int64, error)
 
 	GetForumSystemMessage(userName string, pageNum, countPerPage int,
-		startTime string, isRead *bool) ([]MessageListDO, int64, error)
+		startTime string, isRead *bool, cursor string) ([]MessageListDO, int64, error)
 	GetForumAboutMessage(userName string, isBot *bool, pageNum,
-		countPerPage int, startTime string, isRead *bool) ([]MessageListDO, int64, error)
+		countPerPage int, startTime string, isRead *bool, cursor string) (
+		[]MessageListDO, int64, error)
 	GetMeetingToDoMessage(userName string, filter int, pageNum,
-		countPerPage int, startTime string, isRead *bool) ([]MessageListDO, int64, error)
-	GetCVEToDoMessage(userName, giteeUsername string, isDone *bool, pageNum,
-		countPerPage int, startTime string, isRead *bool) ([]MessageListDO, int64, error)
-	GetCVEMessage(userName, giteeUsername string, pageNum, countPerPage int,
-		startTime string, isRead *bool) ([]MessageListDO, int64, error)
-	GetIssueToDoMessage(userName, giteeUsername string, isDone *bool, pageNum,
-		countPerPage int, startTime string, isRead *bool) ([]MessageListDO, int64, error)
-	GetPullRequestToDoMessage(userName, giteeUsername string, isDone *bool, pageNum,
-		countPerPage int, startTime string, isRead *bool) ([]MessageListDO, int64, error)
-	GetGiteeAboutMessage(userName, giteeUsername string, isBot *bool,
-		pageNum, countPerPage int, startTime string, isRead *bool) ([]MessageListDO, int64, error)
-	GetGiteeMessage(userName, giteeUsername string, pageNum, countPerPage int,
-		startTime string, isRead *bool) ([]MessageListDO, int64, error)
+		countPerPage int, startTime string, isRead *bool, cursor string) (
+		[]MessageListDO, int64, error)
+	GetCVEToDoMessage(userName string, isDone *bool, pageNum,
+		countPerPage int, startTime string, isRead *bool, cursor string) (
+		[]MessageListDO, int64, error)
+	GetCVEMessage(userName string, pageNum, countPerPage int,
+		startTime string, isRead *bool, cursor string) ([]MessageListDO, int64, error)
+	GetIssueToDoMessage(userName string, isDone *bool, pageNum,
+		countPerPage int, startTime string, isRead *bool, cursor string) (
+		[]MessageListDO, int64, error)
+	GetPullRequestToDoMessage(userName string, isDone *bool, pageNum,
+		countPerPage int, startTime string, isRead *bool, cursor string) (
+		[]MessageListDO, int64, error)
+	GetGiteeAboutMessage(userName string, isBot *bool,
+		pageNum, countPerPage int, startTime string, isRead *bool, cursor string) (
+		[]MessageListDO, int64, error)
+	GetGiteeMessage(userName string, pageNum, countPerPage int,
+		startTime string, isRead *bool, cursor string) ([]MessageListDO, int64, error)
 	GetEurMessage(userName string, pageNum, countPerPage int, startTime string,
-		isRead *bool) ([]MessageListDO, int64, error)
-	CountAllMessage(username, giteeUsername string) (CountDataDO, error)
-	GetAllMessage(username string, pageNum, countPerPage int, isRead *bool) ([]MessageListDO,
-		int64, error)
+		isRead *bool, cursor string) ([]MessageListDO, int64, error)
+	GetSourceMessage(cmd CmdToGetSourceMessage) ([]MessageListDO, int64, error)
+	CountAllMessage(username string) (CountDataDO, error)
+	GetUnreadCounter(username string) ([]UnreadCounterDO, error)
+	GetAllMessage(username string, pageNum, countPerPage int, isRead *bool,
+		cursor string) ([]MessageListDO, int64, error)
+	GetLatestStreamSeq(username string) (int64, error)
+	GetAllMessageAfter(username string, after int64, limit int) ([]MessageListDO, error)
 }
@@ -0,0 +1,10 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package domain
+
+type MessageNotifyAdapter interface {
+	Publish(notify MessageNotifyDO)
+	Subscribe(userName string) (<-chan MessageNotifyDO, func())
+}
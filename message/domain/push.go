@@ -7,7 +7,7 @@ package domain
 type MessagePushAdapter interface {
 	GetPushConfig(subsIds []string, countPerPage, pageNum int,
 		userName string) ([]MessagePushDO, error)
-	AddPushConfig(cmd CmdToAddPushConfig) error
-	UpdatePushConfig(cmd CmdToUpdatePushConfig) error
+	AddPushConfig(cmd CmdToAddPushConfig, userName string) error
+	UpdatePushConfig(cmd CmdToUpdatePushConfig, userName string) error
 	RemovePushConfig(cmd CmdToDeletePushConfig) error
 }
@@ -0,0 +1,12 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package domain
+
+type MessageTestSendAdapter interface {
+	GetTestSendTarget(userName string, recipientId int64, subscribeId int) (TestSendTargetDO,
+		error)
+	GetTestSendEvent(recipientId int64, communityId, source, eventType string) (CloudEventDO,
+		error)
+}
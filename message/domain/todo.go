@@ -0,0 +1,12 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package domain
+
+import "time"
+
+type MessageTodoAdapter interface {
+	SetTodoIsDone(userName string, eventId string, isDone bool) error
+	CompleteExpiredTodo(source, eventType string, before time.Time) (int64, error)
+}
@@ -0,0 +1,30 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package domain
+
+import (
+	"time"
+
+	"github.com/opensourceways/message-manager/message/infrastructure"
+)
+
+const (
+	ContactMail    = infrastructure.ContactMail
+	ContactMessage = infrastructure.ContactMessage
+	ContactPhone   = infrastructure.ContactPhone
+)
+
+type MessageVerificationAdapter interface {
+	GetRecipientContact(userName string, recipientId int64) (RecipientContactDO, error)
+	SaveVerification(v VerificationDO, cooldown time.Duration) (bool, error)
+	ClaimVerification(recipientId int64, contactType string, maxAttempts int) (VerificationDO,
+		error)
+	CompleteVerification(recipientId int64, contactType, contact string) error
+}
+
+// SMSGateway sends the text messages.
+type SMSGateway interface {
+	Send(phone, text string) error
+}
@@ -0,0 +1,24 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package domain
+
+import (
+	"github.com/opensourceways/message-manager/common/webhook"
+)
+
+type MessageWebhookAdapter interface {
+	GetWebhookTarget(communityId, source string, sigs, repos []string) ([]SubscribeTargetDO,
+		error)
+	GetWebhook(recipientId int64) (WebhookDO, error)
+	RecordWebhookResult(recipientId int64, ok bool, disableAfter int) (bool, error)
+	SaveWebhookLog(log WebhookLogDO) error
+	GetWebhookLog(userName string, recipientId int64, countPerPage, pageNum int) (
+		[]WebhookLogDO, int64, error)
+}
+
+// WebhookSender posts the events to the webhooks.
+type WebhookSender interface {
+	Send(url, secret string, body []byte) (webhook.Result, error)
+}
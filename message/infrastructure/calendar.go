@@ -0,0 +1,94 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package infrastructure
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/postgresql"
+)
+
+const calendarTokenSql = `
+create table if not exists message_center.calendar_token (
+    user_name  text primary key,
+    token      text not null unique,
+    created_at timestamptz not null default now()
+);
+`
+
+func MessageCalendarAdapter() *messageCalendarAdapter {
+	return &messageCalendarAdapter{}
+}
+
+type messageCalendarAdapter struct{}
+
+// Migration creates the calendar token table.
+func (s *messageCalendarAdapter) Migration() postgresql.Migration {
+	return postgresql.Migration{Version: "calendar_token", Sql: calendarTokenSql}
+}
+
+// GetCalendarToken returns the calendar token of userName, or "" if the user
+// has none.
+func (s *messageCalendarAdapter) GetCalendarToken(userName string) (string, error) {
+	var token string
+	if result := postgresql.DB().Table("message_center.calendar_token").
+		Where("user_name = ?", userName).Select("token").
+		Scan(&token); result.Error != nil {
+		return "", xerrors.Errorf("get calendar token failed, err:%v", result.Error)
+	}
+	return token, nil
+}
+
+// SaveCalendarToken sets the calendar token of userName, the previous one stops
+// working.
+func (s *messageCalendarAdapter) SaveCalendarToken(userName, token string) error {
+	query := `insert into message_center.calendar_token (user_name, token) values (?, ?)
+		on conflict (user_name) do update set token = excluded.token, created_at = now()`
+	if result := postgresql.DB().Exec(query, userName, token); result.Error != nil {
+		return xerrors.Errorf("save calendar token failed, err:%v", result.Error)
+	}
+	return nil
+}
+
+// GetUserNameByCalendarToken returns the owner of token, or "" if token is
+// unknown.
+func (s *messageCalendarAdapter) GetUserNameByCalendarToken(token string) (string, error) {
+	var userName string
+	if result := postgresql.DB().Table("message_center.calendar_token").
+		Where("token = ?", token).Select("user_name").
+		Scan(&userName); result.Error != nil {
+		return "", xerrors.Errorf("get calendar token failed, err:%v", result.Error)
+	}
+	return userName, nil
+}
+
+// GetMeetingEvent returns the latest state of every meeting todo of userName
+// starting after since, one per business_id.
+func (s *messageCalendarAdapter) GetMeetingEvent(userName string, since time.Time) (
+	[]MeetingEventDAO, error) {
+	query := `select distinct on (tm.business_id) tm.business_id, cem.event_id, cem.title,
+		    cem.summary, cem.source_group, cem.source_url, cem.time, cem.updated_at,
+		    coalesce(cem.data_json #>> '{Action}', '') as action
+		from todo_message tm
+		join cloud_event_message cem ON cem.event_id = tm.latest_event_id
+		join recipient_config rc ON rc.id = tm.recipient_id
+		where rc.is_deleted = false
+		and tm.is_deleted = false
+		and cem.source = any(?::text[])
+		and ` + linkedLogin("rc", "?") + `
+		and cem.time >= ?
+		order by tm.business_id, cem.updated_at desc`
+
+	var response []MeetingEventDAO
+	if result := postgresql.DB().Raw(query, scheduleSources(), userName, since).
+		Scan(&response); result.Error != nil {
+		logrus.Errorf("get meeting failed, err:%v", result.Error.Error())
+		return []MeetingEventDAO{}, xerrors.Errorf("查询失败, err:%v", result.Error)
+	}
+	return response, nil
+}
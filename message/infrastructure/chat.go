@@ -0,0 +1,67 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package infrastructure
+
+import (
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/postgresql"
+)
+
+const DeliveryChannelChat = "chat"
+
+// a recipient may have the bot of a chat platform, such as the one of the
+// group of a sig
+const chatSql = `
+alter table message_center.recipient_config
+    add column if not exists chat_platform varchar(16) not null default '',
+    add column if not exists chat_webhook varchar(1024) not null default '',
+    add column if not exists chat_secret varchar(255) not null default '';
+alter table message_center.push_config
+    add column if not exists need_chat boolean not null default false;
+`
+
+func MessageChatAdapter() *messageChatAdapter {
+	return &messageChatAdapter{}
+}
+
+type messageChatAdapter struct{}
+
+// Migration adds the chat bot columns to recipient_config and push_config.
+func (s *messageChatAdapter) Migration() postgresql.Migration {
+	return postgresql.Migration{Version: "chat", Sql: chatSql}
+}
+
+// GetChatTarget returns the subscriptions to source whose recipients in
+// communityId receive chat messages at a bot, like GetSubscribeTarget.
+func (s *messageChatAdapter) GetChatTarget(communityId, source string, sigs,
+	repos []string) ([]SubscribeTargetDAO, error) {
+	targets, err := getSubscribeTarget(
+		"case when rc.chat_platform <> '' then rc.chat_webhook else '' end",
+		"need_chat", communityId, source, sigs, repos)
+	if err != nil {
+		return targets, err
+	}
+	result := targets[:0]
+	for _, t := range targets {
+		if t.Target != "" {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+// GetChatBot returns the bot of the recipient recipientId as configured now,
+// it fails when the recipient is removed.
+func (s *messageChatAdapter) GetChatBot(recipientId int64) (ChatBotDAO, error) {
+	var response ChatBotDAO
+	if result := getTable().Where("id = ?", recipientId).Limit(1).
+		Scan(&response); result.Error != nil {
+		return response, xerrors.Errorf("get chat bot failed, err:%v", result.Error)
+	} else if result.RowsAffected == 0 {
+		return response, xerrors.Errorf("the recipient %d is removed", recipientId)
+	}
+	return response, nil
+}
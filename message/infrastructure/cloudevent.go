@@ -0,0 +1,53 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package infrastructure
+
+import (
+	"golang.org/x/xerrors"
+	"gorm.io/gorm"
+
+	"github.com/opensourceways/message-manager/common/postgresql"
+)
+
+func MessageCloudEventAdapter() *messageCloudEventAdapter {
+	return &messageCloudEventAdapter{}
+}
+
+type messageCloudEventAdapter struct{}
+
+// SaveCloudEvent stores event unless an event with the same id is stored
+// already, it returns the stored event and reports whether it is event.
+func (s *messageCloudEventAdapter) SaveCloudEvent(event CloudEventDAO) (CloudEventDAO, bool,
+	error) {
+	saved := false
+	err := postgresql.DB().Transaction(func(tx *gorm.DB) error {
+		// serializes the writers of the same event id until commit
+		if result := tx.Exec("select pg_advisory_xact_lock(hashtext(?))", event.EventId); result.Error != nil {
+			return result.Error
+		}
+
+		var stored CloudEventDAO
+		result := tx.Table("message_center.cloud_event_message").
+			Where("event_id = ?", event.EventId).Limit(1).Find(&stored)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 0 {
+			event = stored
+			return nil
+		}
+
+		if result := tx.Table("message_center.cloud_event_message").
+			Create(&event); result.Error != nil {
+			return result.Error
+		}
+		saved = true
+		return nil
+	})
+	if err != nil {
+		return CloudEventDAO{}, false, xerrors.Errorf("save cloud event failed, err:%v", err)
+	}
+	return event, saved, nil
+}
@@ -0,0 +1,56 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package infrastructure
+
+import (
+	"fmt"
+
+	"github.com/opensourceways/message-manager/common/community"
+	"github.com/opensourceways/message-manager/common/postgresql"
+)
+
+// the rows stored before there were several communities belong to the default
+// one, the logins are linked once in every community.
+const communitySql = `
+alter table message_center.recipient_config
+    add column if not exists community varchar(64) not null default '%[1]s';
+alter table message_center.recipient_config alter column community set default '%[1]s';
+alter table message_center.subscribe_config
+    add column if not exists community varchar(64) not null default '%[1]s';
+alter table message_center.subscribe_config alter column community set default '%[1]s';
+alter table message_center.cloud_event_message
+    add column if not exists community varchar(64) not null default '%[1]s';
+alter table message_center.cloud_event_message alter column community set default '%[1]s';
+alter table message_center.recipient_identity
+    add column if not exists community varchar(64) not null default '%[1]s';
+alter table message_center.recipient_identity alter column community set default '%[1]s';
+
+create index if not exists recipient_config_community_idx
+    on message_center.recipient_config (community, user_id);
+drop index if exists message_center.recipient_identity_login_idx;
+create unique index if not exists recipient_identity_community_login_idx
+    on message_center.recipient_identity (community, provider, lower(login));
+`
+
+func MessageCommunityAdapter() *messageCommunityAdapter {
+	return &messageCommunityAdapter{}
+}
+
+type messageCommunityAdapter struct{}
+
+// Migration adds the community columns, the recipient identities have to exist.
+func (s *messageCommunityAdapter) Migration() postgresql.Migration {
+	// the id of a community is checked when it is registered
+	return postgresql.Migration{
+		Version: "community",
+		Sql:     fmt.Sprintf(communitySql, community.DefaultId()),
+	}
+}
+
+// communityOf returns the community of the user key userName.
+func communityOf(userName string) string {
+	id, _ := community.SplitUserKey(userName)
+	return id
+}
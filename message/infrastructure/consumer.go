@@ -0,0 +1,158 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package infrastructure
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/xerrors"
+)
+
+const ConsumerDriverMemory = "memory"
+
+// ConsumerBroker reads the messages of the subscribed topics. A message is
+// delivered again after a restart unless it is committed.
+type ConsumerBroker interface {
+	Fetch(ctx context.Context) (ConsumerMessageDAO, error)
+	Commit(ctx context.Context, msg ConsumerMessageDAO) error
+	Close() error
+}
+
+// ConsumerBrokerConfig tells a broker where to read from.
+type ConsumerBrokerConfig struct {
+	Brokers []string
+	Group   string
+	Topics  []string
+}
+
+var consumerDrivers = map[string]func(cfg ConsumerBrokerConfig) (ConsumerBroker, error){
+	ConsumerDriverKafka: newKafkaConsumerBroker,
+	ConsumerDriverMemory: func(cfg ConsumerBrokerConfig) (ConsumerBroker, error) {
+		return NewMemoryConsumerBroker(cfg.Topics), nil
+	},
+}
+
+// RegisterConsumerDriver makes a broker implementation available by name.
+func RegisterConsumerDriver(name string, open func(cfg ConsumerBrokerConfig) (ConsumerBroker, error)) {
+	consumerDrivers[name] = open
+}
+
+// NewConsumerBroker opens a broker of the driver.
+func NewConsumerBroker(driver string, cfg ConsumerBrokerConfig) (ConsumerBroker, error) {
+	open, ok := consumerDrivers[driver]
+	if !ok {
+		return nil, xerrors.Errorf("unknown consumer driver %s", driver)
+	}
+	return open(cfg)
+}
+
+// memoryConsumerBroker keeps one partition per topic in memory. It stands in
+// for kafka in tests and local runs.
+type memoryConsumerBroker struct {
+	lock      sync.Mutex
+	topics    []string
+	logs      map[string][]ConsumerMessageDAO
+	fetched   map[string]int64
+	committed map[string]int64
+	closed    bool
+	// wake is closed and replaced whenever a message is published
+	wake chan struct{}
+}
+
+func NewMemoryConsumerBroker(topics []string) *memoryConsumerBroker {
+	return &memoryConsumerBroker{
+		topics:    topics,
+		logs:      map[string][]ConsumerMessageDAO{},
+		fetched:   map[string]int64{},
+		committed: map[string]int64{},
+		wake:      make(chan struct{}),
+	}
+}
+
+// Publish appends a message to topic.
+func (b *memoryConsumerBroker) Publish(topic string, key, value []byte, headers map[string]string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.logs[topic] = append(b.logs[topic], ConsumerMessageDAO{
+		Topic:   topic,
+		Offset:  int64(len(b.logs[topic])),
+		Key:     key,
+		Value:   value,
+		Headers: headers,
+	})
+	close(b.wake)
+	b.wake = make(chan struct{})
+}
+
+// Fetch returns the next message of the first topic having one, it blocks
+// until a message is published or ctx is done.
+func (b *memoryConsumerBroker) Fetch(ctx context.Context) (ConsumerMessageDAO, error) {
+	for {
+		b.lock.Lock()
+		if b.closed {
+			b.lock.Unlock()
+			return ConsumerMessageDAO{}, xerrors.Errorf("consumer broker is closed")
+		}
+		for _, topic := range b.topics {
+			if next := b.fetched[topic]; next < int64(len(b.logs[topic])) {
+				b.fetched[topic] = next + 1
+				msg := b.logs[topic][next]
+				b.lock.Unlock()
+				return msg, nil
+			}
+		}
+		wake := b.wake
+		b.lock.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ConsumerMessageDAO{}, ctx.Err()
+		case <-wake:
+		}
+	}
+}
+
+// Commit marks msg and the messages before it in its topic as consumed.
+func (b *memoryConsumerBroker) Commit(ctx context.Context, msg ConsumerMessageDAO) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if msg.Offset+1 > b.committed[msg.Topic] {
+		b.committed[msg.Topic] = msg.Offset + 1
+	}
+	return nil
+}
+
+// Committed returns the offset a restarted consumer of topic resumes from.
+func (b *memoryConsumerBroker) Committed(topic string) int64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.committed[topic]
+}
+
+// Rewind makes the broker deliver the uncommitted messages again, as kafka
+// does after a restart or a rebalance.
+func (b *memoryConsumerBroker) Rewind() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for topic := range b.fetched {
+		b.fetched[topic] = b.committed[topic]
+	}
+	b.closed = false
+}
+
+func (b *memoryConsumerBroker) Close() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.closed = true
+	close(b.wake)
+	b.wake = make(chan struct{})
+	return nil
+}
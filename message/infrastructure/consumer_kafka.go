@@ -0,0 +1,65 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package infrastructure
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+	"golang.org/x/xerrors"
+)
+
+const ConsumerDriverKafka = "kafka"
+
+// kafkaConsumerBroker reads the topics as a member of a consumer group, the
+// group resumes from the committed offsets.
+type kafkaConsumerBroker struct {
+	reader *kafka.Reader
+}
+
+func newKafkaConsumerBroker(cfg ConsumerBrokerConfig) (ConsumerBroker, error) {
+	if len(cfg.Brokers) == 0 || cfg.Group == "" || len(cfg.Topics) == 0 {
+		return nil, xerrors.Errorf("kafka consumer needs brokers, group and topics")
+	}
+	return &kafkaConsumerBroker{
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers:     cfg.Brokers,
+			GroupID:     cfg.Group,
+			GroupTopics: cfg.Topics,
+			StartOffset: kafka.FirstOffset,
+		}),
+	}, nil
+}
+
+func (b *kafkaConsumerBroker) Fetch(ctx context.Context) (ConsumerMessageDAO, error) {
+	m, err := b.reader.FetchMessage(ctx)
+	if err != nil {
+		return ConsumerMessageDAO{}, err
+	}
+	headers := make(map[string]string, len(m.Headers))
+	for _, h := range m.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	return ConsumerMessageDAO{
+		Topic:     m.Topic,
+		Partition: m.Partition,
+		Offset:    m.Offset,
+		Key:       m.Key,
+		Value:     m.Value,
+		Headers:   headers,
+	}, nil
+}
+
+func (b *kafkaConsumerBroker) Commit(ctx context.Context, msg ConsumerMessageDAO) error {
+	return b.reader.CommitMessages(ctx, kafka.Message{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+	})
+}
+
+func (b *kafkaConsumerBroker) Close() error {
+	return b.reader.Close()
+}
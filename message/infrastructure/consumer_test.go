@@ -0,0 +1,73 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryConsumerBroker(t *testing.T) {
+	broker, err := NewConsumerBroker(ConsumerDriverMemory, ConsumerBrokerConfig{
+		Topics: []string{"gitee", "meeting"}})
+	assert.NoError(t, err)
+	b := broker.(*memoryConsumerBroker)
+
+	b.Publish("meeting", nil, []byte("m0"), nil)
+	b.Publish("gitee", []byte("k"), []byte("g0"), map[string]string{"ce_id": "1"})
+	b.Publish("other", nil, []byte("o0"), nil)
+
+	ctx := context.Background()
+	msg, err := b.Fetch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, ConsumerMessageDAO{Topic: "gitee", Key: []byte("k"), Value: []byte("g0"),
+		Headers: map[string]string{"ce_id": "1"}}, msg)
+	assert.NoError(t, b.Commit(ctx, msg))
+
+	msg, err = b.Fetch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "m0", string(msg.Value))
+
+	// the uncommitted message is delivered again
+	b.Rewind()
+	msg, err = b.Fetch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "m0", string(msg.Value))
+	assert.Equal(t, int64(1), b.Committed("gitee"))
+	assert.Equal(t, int64(0), b.Committed("meeting"))
+
+	// fetching waits for the next message
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		b.Publish("gitee", nil, []byte("g1"), nil)
+	}()
+	msg, err = b.Fetch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, ConsumerMessageDAO{Topic: "gitee", Offset: 1, Value: []byte("g1")}, msg)
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = b.Fetch(timeout)
+	assert.Error(t, err)
+
+	assert.NoError(t, b.Close())
+	_, err = b.Fetch(ctx)
+	assert.Error(t, err)
+
+	_, err = NewConsumerBroker("unknown", ConsumerBrokerConfig{})
+	assert.Error(t, err)
+}
+
+func TestNewConsumerBroker(t *testing.T) {
+	_, err := NewConsumerBroker("unknown", ConsumerBrokerConfig{})
+	assert.Error(t, err)
+
+	_, err = NewConsumerBroker(ConsumerDriverKafka, ConsumerBrokerConfig{Topics: []string{"gitee"}})
+	assert.Error(t, err)
+
+	broker, err := NewConsumerBroker(ConsumerDriverKafka, ConsumerBrokerConfig{
+		Brokers: []string{"127.0.0.1:9092"}, Group: "message-manager", Topics: []string{"gitee"}})
+	assert.NoError(t, err)
+	assert.NoError(t, broker.Close())
+}
@@ -0,0 +1,140 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package infrastructure
+
+import (
+	"golang.org/x/xerrors"
+	"gorm.io/gorm"
+
+	"github.com/opensourceways/message-manager/common/postgresql"
+)
+
+// counterTriggerSql counts the unread and the undone undeleted messages of the
+// recipients, it needs PostgreSQL 14 or later.
+const counterTriggerSql = `
+create table if not exists message_center.unread_counter (
+    recipient_id bigint not null,
+    source       text   not null,
+    category     text   not null,
+    unread_count bigint not null default 0,
+    undone_count bigint not null default 0,
+    primary key (recipient_id, source, category)
+);
+
+create or replace function message_center.add_unread_counter(
+    p_recipient_id bigint, p_source text, p_category text, p_unread bigint, p_undone bigint)
+    returns void as $$
+begin
+    if p_recipient_id is null or (p_unread = 0 and p_undone = 0) then
+        return;
+    end if;
+    insert into message_center.unread_counter as uc
+        (recipient_id, source, category, unread_count, undone_count)
+    values (p_recipient_id, coalesce(p_source, ''), p_category, p_unread, p_undone)
+    on conflict (recipient_id, source, category) do update
+        set unread_count = uc.unread_count + excluded.unread_count,
+            undone_count = uc.undone_count + excluded.undone_count;
+end;
+$$ language plpgsql;
+
+create or replace function message_center.count_recipient_message() returns trigger as $$
+declare
+    r jsonb;
+begin
+    if tg_op in ('UPDATE', 'DELETE') then
+        r := to_jsonb(old);
+        perform message_center.add_unread_counter((r->>'recipient_id')::bigint, r->>'source',
+            tg_argv[0],
+            -(not coalesce((r->>'is_read')::boolean, false)
+                and not coalesce((r->>'is_deleted')::boolean, false))::int,
+            -(not coalesce((r->>'is_done')::boolean, true)
+                and not coalesce((r->>'is_deleted')::boolean, false))::int);
+    end if;
+    if tg_op in ('INSERT', 'UPDATE') then
+        r := to_jsonb(new);
+        perform message_center.add_unread_counter((r->>'recipient_id')::bigint, r->>'source',
+            tg_argv[0],
+            (not coalesce((r->>'is_read')::boolean, false)
+                and not coalesce((r->>'is_deleted')::boolean, false))::int,
+            (not coalesce((r->>'is_done')::boolean, true)
+                and not coalesce((r->>'is_deleted')::boolean, false))::int);
+    end if;
+    return null;
+end;
+$$ language plpgsql;
+
+create or replace trigger follow_message_counter
+    after insert or update or delete on message_center.follow_message
+    for each row execute function message_center.count_recipient_message('follow');
+
+create or replace trigger related_message_counter
+    after insert or update or delete on message_center.related_message
+    for each row execute function message_center.count_recipient_message('related');
+
+create or replace trigger todo_message_counter
+    after insert or update or delete on message_center.todo_message
+    for each row execute function message_center.count_recipient_message('todo');
+`
+
+// reconcileCounterSql rebuilds the counters, the lock makes the triggers of
+// concurrent writers wait for it.
+const reconcileCounterSql = `
+lock table message_center.unread_counter in exclusive mode;
+
+delete from message_center.unread_counter;
+
+insert into message_center.unread_counter
+    (recipient_id, source, category, unread_count, undone_count)
+select recipient_id, source, category, sum(unread), sum(undone)
+from (
+    select recipient_id, coalesce(source, '') as source, 'follow' as category,
+           (not is_read)::int as unread, 0 as undone
+    from message_center.follow_message
+    where not is_deleted
+    union all
+    select recipient_id, coalesce(source, ''), 'related',
+           (not is_read)::int, 0
+    from message_center.related_message
+    where not is_deleted
+    union all
+    select recipient_id, coalesce(source, ''), 'todo',
+           (not is_read)::int, (not is_done)::int
+    from message_center.todo_message
+    where not is_deleted
+) as counts
+where recipient_id is not null
+group by recipient_id, source, category
+having sum(unread) > 0 or sum(undone) > 0;
+`
+
+func MessageCounterAdapter() *messageCounterAdapter {
+	return &messageCounterAdapter{}
+}
+
+type messageCounterAdapter struct{}
+
+// Migration creates the counter table and its triggers and builds the
+// counters.
+func (s *messageCounterAdapter) Migration() postgresql.Migration {
+	return postgresql.Migration{
+		Version: "unread_counter",
+		Sql:     counterTriggerSql + reconcileCounterSql,
+	}
+}
+
+// Reconcile rebuilds the counters from the recipient message tables.
+func (s *messageCounterAdapter) Reconcile() error {
+	if postgresql.DB() == nil {
+		return xerrors.Errorf("postgresql is not initialized")
+	}
+
+	err := postgresql.DB().Transaction(func(tx *gorm.DB) error {
+		return tx.Exec(reconcileCounterSql).Error
+	})
+	if err != nil {
+		return xerrors.Errorf("reconcile unread counter failed, err:%v", err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package infrastructure
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/opensourceways/message-manager/common/postgresql"
+)
+
+// counterTestRecipient is far from the ids of real recipients, its rows are
+// removed after the test.
+const counterTestRecipient = -20240801
+
+const counterTestTableSql = `
+create schema if not exists message_center;
+create table if not exists message_center.follow_message (
+    event_id text, recipient_id bigint, source text,
+    is_read boolean default false, is_deleted boolean default false);
+create table if not exists message_center.related_message (
+    event_id text, recipient_id bigint, source text,
+    is_read boolean default false, is_deleted boolean default false);
+create table if not exists message_center.todo_message (
+    event_id text, recipient_id bigint, source text, is_done boolean default false,
+    is_read boolean default false, is_deleted boolean default false);
+`
+
+// initCounterTestDB connects to the database given by the libpq environment
+// variables. The test is skipped without PGHOST, the database should be a
+// disposable one.
+func initCounterTestDB(t *testing.T) {
+	if os.Getenv("PGHOST") == "" {
+		t.Skip("PGHOST is not set")
+	}
+	port, err := strconv.Atoi(os.Getenv("PGPORT"))
+	if err != nil {
+		port = 5432
+	}
+	cfg := postgresql.Config{
+		Host: os.Getenv("PGHOST"),
+		User: os.Getenv("PGUSER"),
+		Pwd:  os.Getenv("PGPASSWORD"),
+		Name: os.Getenv("PGDATABASE"),
+		Port: port,
+	}
+	cfg.SetDefault()
+	if err = postgresql.Init(&cfg); err != nil {
+		t.Fatal("Failed to init postgresql:", err)
+	}
+	if err = postgresql.DB().Exec(counterTestTableSql).Error; err != nil {
+		t.Fatal("Failed to create tables:", err)
+	}
+
+	cleanup := func() {
+		for _, table := range []string{"follow_message", "related_message", "todo_message",
+			"unread_counter"} {
+			postgresql.DB().Exec(`delete from message_center.`+table+
+				` where recipient_id = ?`, counterTestRecipient)
+		}
+	}
+	cleanup()
+	t.Cleanup(cleanup)
+}
+
+func readUnreadCounter(t *testing.T, category string) (unread, undone int64) {
+	var counter struct {
+		UnreadCount int64
+		UndoneCount int64
+	}
+	err := postgresql.DB().Raw(`
+		select coalesce(sum(unread_count), 0) as unread_count,
+		       coalesce(sum(undone_count), 0) as undone_count
+		from message_center.unread_counter
+		where recipient_id = ? and category = ?`, counterTestRecipient, category).
+		Scan(&counter).Error
+	if err != nil {
+		t.Fatal("Failed to read counter:", err)
+	}
+	return counter.UnreadCount, counter.UndoneCount
+}
+
+func execCounterTest(t *testing.T, sql string, args ...interface{}) {
+	if err := postgresql.DB().Exec(sql, append(args, counterTestRecipient)...).Error; err != nil {
+		t.Fatal("Failed to execute sql:", err)
+	}
+}
+
+func TestMessageCounterAdapter_NotInitialized(t *testing.T) {
+	if postgresql.DB() != nil {
+		t.Skip("postgresql is initialized")
+	}
+	assert.Error(t, postgresql.Migrate([]postgresql.Migration{MessageCounterAdapter().Migration()}))
+	assert.Error(t, MessageCounterAdapter().Reconcile())
+}
+
+func TestMessageCounterTrigger(t *testing.T) {
+	initCounterTestDB(t)
+	if err := postgresql.Migrate([]postgresql.Migration{
+		MessageCounterAdapter().Migration()}); err != nil {
+		t.Fatal("Failed to migrate counter:", err)
+	}
+
+	// new messages
+	execCounterTest(t, `insert into message_center.follow_message
+		(event_id, source, recipient_id) values ('e1', 'gitee', ?), ('e2', 'gitee', ?)`,
+		counterTestRecipient)
+	execCounterTest(t, `insert into message_center.todo_message
+		(event_id, source, recipient_id) values ('e3', 'cve', ?)`)
+	unread, _ := readUnreadCounter(t, "follow")
+	assert.Equal(t, int64(2), unread)
+	unread, undone := readUnreadCounter(t, "todo")
+	assert.Equal(t, []int64{1, 1}, []int64{unread, undone})
+
+	// mark read, read again changes nothing
+	for i := 0; i < 2; i++ {
+		execCounterTest(t, `update message_center.follow_message set is_read = true
+			where event_id = 'e1' and recipient_id = ?`)
+	}
+	unread, _ = readUnreadCounter(t, "follow")
+	assert.Equal(t, int64(1), unread)
+
+	// soft and hard delete
+	execCounterTest(t, `update message_center.follow_message set is_deleted = true
+		where event_id = 'e2' and recipient_id = ?`)
+	execCounterTest(t, `delete from message_center.todo_message where recipient_id = ?`)
+	unread, _ = readUnreadCounter(t, "follow")
+	assert.Equal(t, int64(0), unread)
+	unread, undone = readUnreadCounter(t, "todo")
+	assert.Equal(t, []int64{0, 0}, []int64{unread, undone})
+}
+
+func TestMessageCounterReconcile(t *testing.T) {
+	initCounterTestDB(t)
+	if err := postgresql.Migrate([]postgresql.Migration{
+		MessageCounterAdapter().Migration()}); err != nil {
+		t.Fatal("Failed to migrate counter:", err)
+	}
+
+	execCounterTest(t, `insert into message_center.related_message
+		(event_id, source, recipient_id, is_read) values ('e1', 'gitee', ?, false),
+		('e2', 'gitee', ?, true)`, counterTestRecipient)
+	execCounterTest(t, `insert into message_center.todo_message
+		(event_id, source, recipient_id, is_read, is_done) values ('e3', 'cve', ?, true, false)`)
+	execCounterTest(t, `update message_center.unread_counter
+		set unread_count = 100, undone_count = 100 where recipient_id = ?`)
+
+	assert.NoError(t, MessageCounterAdapter().Reconcile())
+
+	unread, _ := readUnreadCounter(t, "related")
+	assert.Equal(t, int64(1), unread)
+	unread, undone := readUnreadCounter(t, "todo")
+	assert.Equal(t, []int64{0, 1}, []int64{unread, undone})
+}
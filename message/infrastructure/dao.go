@@ -26,6 +26,7 @@ type MessageListDAO struct {
 	UpdatedAt       time.Time `gorm:"column:updated_at" json:"updated_at" swaggerignore:"true"`
 	IsRead          bool      `gorm:"column:is_read" json:"is_read"`
 	SourceGroup     string    `gorm:"column:source_group" json:"source_group"`
+	StreamSeq       int64     `gorm:"column:stream_seq" json:"-"`
 	TotalCount      int64     `json:"total_count"`
 }
 
@@ -36,23 +37,39 @@ type MessagePushDAO struct {
 	NeedPhone        *bool     `gorm:"column:need_phone" json:"need_phone"`
 	NeedMail         *bool     `gorm:"column:need_mail" json:"need_mail"`
 	NeedInnerMessage *bool     `gorm:"column:need_inner_message" json:"need_inner_message"`
+	NeedWebhook      *bool     `gorm:"column:need_webhook" json:"need_webhook"`
+	NeedChat         *bool     `gorm:"column:need_chat" json:"need_chat"`
 	IsDeleted        bool      `gorm:"column:is_deleted" json:"is_deleted" swaggerignore:"true"`
 	CreatedAt        time.Time `gorm:"column:created_at" json:"created_at" swaggerignore:"true"`
 	UpdatedAt        time.Time `gorm:"column:updated_at" json:"updated_at" swaggerignore:"true"`
 }
 
 type MessageRecipientDAO struct {
-	Id            string    `json:"id,omitempty"`
-	Name          string    `gorm:"column:recipient_name" json:"recipient_id"`
-	Mail          string    `gorm:"column:mail" json:"mail"`
-	Message       string    `gorm:"column:message" json:"message"`
-	Phone         string    `gorm:"column:phone" json:"phone"`
-	Remark        string    `gorm:"column:remark" json:"remark"`
-	UserName      string    `gorm:"column:user_id"  json:"user_id"`
-	GiteeUserName string    `gorm:"column:gitee_user_name" json:"gitee_user_name"`
-	IsDeleted     bool      `gorm:"column:is_deleted" json:"is_deleted"`
-	CreatedAt     time.Time `gorm:"column:created_at" json:"created_at" swaggerignore:"true"`
-	UpdatedAt     time.Time `gorm:"column:updated_at" json:"updated_at" swaggerignore:"true"`
+	Id                string     `json:"id,omitempty"`
+	Name              string     `gorm:"column:recipient_name" json:"recipient_id"`
+	Mail              string     `gorm:"column:mail" json:"mail"`
+	Message           string     `gorm:"column:message" json:"message"`
+	Phone             string     `gorm:"column:phone" json:"phone"`
+	PhoneDisplay      string     `gorm:"-" json:"phone_display"`
+	Remark            string     `gorm:"column:remark" json:"remark"`
+	UserName          string     `gorm:"column:user_id"  json:"user_id"`
+	GiteeUserName     string     `gorm:"column:gitee_user_name" json:"gitee_user_name"`
+	GithubUserName    string     `gorm:"column:github_user_name" json:"github_user_name"`
+	GitcodeUserName   string     `gorm:"column:gitcode_user_name" json:"gitcode_user_name"`
+	Webhook           string     `gorm:"column:webhook" json:"webhook"`
+	WebhookSecret     string     `gorm:"column:webhook_secret" json:"-"`
+	WebhookFailures   int        `gorm:"column:webhook_failures" json:"webhook_failures"`
+	WebhookDisabledAt *time.Time `gorm:"column:webhook_disabled_at" json:"webhook_disabled_at"`
+	ChatPlatform      string     `gorm:"column:chat_platform" json:"chat_platform"`
+	ChatWebhook       string     `gorm:"column:chat_webhook" json:"chat_webhook"`
+	ChatSecret        string     `gorm:"column:chat_secret" json:"-"`
+	MailVerified      bool       `gorm:"column:mail_verified" json:"mail_verified"`
+	MessageVerified   bool       `gorm:"column:message_verified" json:"message_verified"`
+	PhoneVerified     bool       `gorm:"column:phone_verified" json:"phone_verified"`
+	Community         string     `gorm:"column:community" json:"community"`
+	IsDeleted         bool       `gorm:"column:is_deleted" json:"is_deleted"`
+	CreatedAt         time.Time  `gorm:"column:created_at" json:"created_at" swaggerignore:"true"`
+	UpdatedAt         time.Time  `gorm:"column:updated_at" json:"updated_at" swaggerignore:"true"`
 }
 
 type MessageSubscribeDAO struct {
@@ -65,6 +82,7 @@ type MessageSubscribeDAO struct {
 	CreatedAt   time.Time      `gorm:"column:created_at"    json:"created_at"  swaggerignore:"true"`
 	UpdatedAt   time.Time      `gorm:"column:updated_at"    json:"updated_at"  swaggerignore:"true"`
 	UserName    string         `gorm:"column:user_name"     json:"user_name"`
+	Community   string         `gorm:"column:community"     json:"community"`
 	IsDefault   *bool          `gorm:"column:is_default"    json:"is_default"`
 	WebFilter   datatypes.JSON `gorm:"column:web_filter"    json:"web_filter"  swaggerignore:"true"`
 }
@@ -79,6 +97,14 @@ type CountDAO struct {
 	Count  int    `json:"count"`
 }
 
+// UnreadCounterDAO is a row of message_center.unread_counter.
+type UnreadCounterDAO struct {
+	Source      string `gorm:"column:source"`
+	Category    string `gorm:"column:category"`
+	UnreadCount int64  `gorm:"column:unread_count"`
+	UndoneCount int64  `gorm:"column:undone_count"`
+}
+
 type CountDataDAO struct {
 	TodoCount    int64 `json:"todo_count"`
 	MeetingCount int64 `json:"meeting_count"`
@@ -86,6 +112,238 @@ type CountDataDAO struct {
 	WatchCount   int64 `json:"watch_count"`
 }
 
+type CloudEventDAO struct {
+	EventId         string         `gorm:"column:event_id" json:"id"`
+	Source          string         `gorm:"column:source" json:"source"`
+	Type            string         `gorm:"column:type" json:"type"`
+	SpecVersion     string         `gorm:"column:spec_version" json:"specversion"`
+	DataContentType string         `gorm:"column:data_content_type" json:"datacontenttype"`
+	DataSchema      string         `gorm:"column:data_schema" json:"dataschema"`
+	EventTime       time.Time      `gorm:"column:time" json:"time"`
+	User            string         `gorm:"column:user" json:"user"`
+	SourceUrl       string         `gorm:"column:source_url" json:"sourceurl"`
+	Title           string         `gorm:"column:title" json:"title"`
+	Summary         string         `gorm:"column:summary" json:"summary"`
+	SourceGroup     string         `gorm:"column:source_group" json:"sourcegroup"`
+	Community       string         `gorm:"column:community" json:"community"`
+	DataJson        datatypes.JSON `gorm:"column:data_json" json:"data" swaggerignore:"true"`
+	CreatedAt       time.Time      `gorm:"column:created_at" json:"-"`
+	UpdatedAt       time.Time      `gorm:"column:updated_at" json:"-"`
+}
+
+type SubscribeTargetDAO struct {
+	SubscribeId uint           `gorm:"column:subscribe_id" json:"subscribe_id"`
+	EventType   string         `gorm:"column:event_type" json:"event_type"`
+	ModeFilter  datatypes.JSON `gorm:"column:mode_filter" json:"mode_filter" swaggerignore:"true"`
+	RecipientId int64          `gorm:"column:recipient_id" json:"recipient_id"`
+	// Target is the address of the recipient on the channel, such as its mail
+	Target string `gorm:"column:target" json:"target"`
+	// MySig and MyManagement tell whether the recipient maintains a sig or
+	// administers a repository of the event, as synced locally
+	MySig        bool `gorm:"column:my_sig" json:"my_sig"`
+	MyManagement bool `gorm:"column:my_management" json:"my_management"`
+}
+
+type RecipientLoginDAO struct {
+	RecipientId int64  `gorm:"column:id" json:"recipient_id"`
+	Login       string `gorm:"column:login" json:"login"`
+}
+
+type RecipientIdentityDAO struct {
+	Id          int64     `gorm:"column:id" json:"id"`
+	RecipientId int64     `gorm:"column:recipient_id" json:"recipient_id"`
+	Provider    string    `gorm:"column:provider" json:"provider"`
+	Login       string    `gorm:"column:login" json:"login"`
+	Verified    bool      `gorm:"column:verified" json:"verified"`
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at" swaggerignore:"true"`
+	UpdatedAt   time.Time `gorm:"column:updated_at" json:"updated_at" swaggerignore:"true"`
+}
+
+type CmdToLinkIdentity struct {
+	RecipientId int64  `json:"recipient_id"`
+	Provider    string `json:"provider"`
+	Login       string `json:"login"`
+}
+
+type MembershipTargetDAO struct {
+	RecipientId int64  `gorm:"column:recipient_id" json:"recipient_id"`
+	Community   string `gorm:"column:community" json:"community"`
+	Login       string `gorm:"column:login" json:"login"`
+}
+
+type CmdToSaveMembership struct {
+	RecipientId int64    `json:"recipient_id"`
+	Sigs        []string `json:"sigs"`
+	Repos       []string `json:"repos"`
+}
+
+type MembershipSyncDAO struct {
+	Id         int64      `gorm:"column:id" json:"id"`
+	Status     string     `gorm:"column:status" json:"status"`
+	StartedAt  *time.Time `gorm:"column:started_at" json:"started_at"`
+	FinishedAt *time.Time `gorm:"column:finished_at" json:"finished_at"`
+	Recipients int        `gorm:"column:recipients" json:"recipients"`
+	Failed     int        `gorm:"column:failed" json:"failed"`
+	Sigs       int        `gorm:"column:sigs" json:"sigs"`
+	Repos      int        `gorm:"column:repos" json:"repos"`
+	Error      string     `gorm:"column:error" json:"error"`
+}
+
+// DeliveryDAO is a notification queued for a channel, Content is the message
+// rendered for the channel.
+type DeliveryDAO struct {
+	Id            int64          `gorm:"column:id" json:"id"`
+	Channel       string         `gorm:"column:channel" json:"channel"`
+	EventId       string         `gorm:"column:event_id" json:"event_id"`
+	RecipientId   int64          `gorm:"column:recipient_id" json:"recipient_id"`
+	Target        string         `gorm:"column:target" json:"target"`
+	Content       datatypes.JSON `gorm:"column:content" json:"content" swaggerignore:"true"`
+	Status        string         `gorm:"column:status" json:"status"`
+	Attempts      int            `gorm:"column:attempts" json:"attempts"`
+	NextAttemptAt time.Time      `gorm:"column:next_attempt_at" json:"next_attempt_at"`
+	LastError     string         `gorm:"column:last_error" json:"last_error"`
+	SentAt        *time.Time     `gorm:"column:sent_at" json:"sent_at"`
+	CreatedAt     time.Time      `gorm:"column:created_at" json:"created_at"`
+}
+
+type WebhookDAO struct {
+	RecipientId       int64      `gorm:"column:id" json:"recipient_id"`
+	Webhook           string     `gorm:"column:webhook" json:"webhook"`
+	WebhookSecret     string     `gorm:"column:webhook_secret" json:"-"`
+	WebhookDisabledAt *time.Time `gorm:"column:webhook_disabled_at" json:"webhook_disabled_at"`
+}
+
+type ChatBotDAO struct {
+	RecipientId  int64  `gorm:"column:id" json:"recipient_id"`
+	ChatPlatform string `gorm:"column:chat_platform" json:"chat_platform"`
+	ChatWebhook  string `gorm:"column:chat_webhook" json:"chat_webhook"`
+	ChatSecret   string `gorm:"column:chat_secret" json:"-"`
+}
+
+type WebhookLogDAO struct {
+	Id          int64     `gorm:"column:id" json:"id"`
+	DeliveryId  int64     `gorm:"column:delivery_id" json:"delivery_id"`
+	RecipientId int64     `gorm:"column:recipient_id" json:"recipient_id"`
+	EventId     string    `gorm:"column:event_id" json:"event_id"`
+	Url         string    `gorm:"column:url" json:"url"`
+	Attempt     int       `gorm:"column:attempt" json:"attempt"`
+	StatusCode  int       `gorm:"column:status_code" json:"status_code"`
+	Error       string    `gorm:"column:error" json:"error"`
+	DurationMs  int64     `gorm:"column:duration_ms" json:"duration_ms"`
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+type DeliveryAttemptDAO struct {
+	Id          int64     `gorm:"column:id" json:"id"`
+	EventId     string    `gorm:"column:event_id" json:"event_id"`
+	RecipientId int64     `gorm:"column:recipient_id" json:"recipient_id"`
+	Channel     string    `gorm:"column:channel" json:"channel"`
+	Target      string    `gorm:"column:target" json:"target"`
+	Status      string    `gorm:"column:status" json:"status"`
+	Error       string    `gorm:"column:error" json:"error"`
+	Attempt     int       `gorm:"column:attempt" json:"attempt"`
+	AttemptedAt time.Time `gorm:"column:attempted_at" json:"attempted_at"`
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+type TestSendTargetDAO struct {
+	RecipientId       int64      `gorm:"column:recipient_id" json:"recipient_id"`
+	Mail              string     `gorm:"column:mail" json:"mail"`
+	Message           string     `gorm:"column:message" json:"message"`
+	Phone             string     `gorm:"column:phone" json:"phone"`
+	Webhook           string     `gorm:"column:webhook" json:"webhook"`
+	WebhookSecret     string     `gorm:"column:webhook_secret" json:"-"`
+	WebhookDisabledAt *time.Time `gorm:"column:webhook_disabled_at" json:"webhook_disabled_at"`
+	ChatPlatform      string     `gorm:"column:chat_platform" json:"chat_platform"`
+	ChatWebhook       string     `gorm:"column:chat_webhook" json:"chat_webhook"`
+	ChatSecret        string     `gorm:"column:chat_secret" json:"-"`
+	MailVerified      bool       `gorm:"column:mail_verified" json:"mail_verified"`
+	MessageVerified   bool       `gorm:"column:message_verified" json:"message_verified"`
+	PhoneVerified     bool       `gorm:"column:phone_verified" json:"phone_verified"`
+	SubscribeId       int        `gorm:"column:subscribe_id" json:"subscribe_id"`
+	Source            string     `gorm:"column:source" json:"source"`
+	EventType         string     `gorm:"column:event_type" json:"event_type"`
+	ModeName          string     `gorm:"column:mode_name" json:"mode_name"`
+	Community         string     `gorm:"column:community" json:"community"`
+	NeedMessage       *bool      `gorm:"column:need_message" json:"need_message"`
+	NeedPhone         *bool      `gorm:"column:need_phone" json:"need_phone"`
+	NeedMail          *bool      `gorm:"column:need_mail" json:"need_mail"`
+	NeedInnerMessage  *bool      `gorm:"column:need_inner_message" json:"need_inner_message"`
+	NeedWebhook       *bool      `gorm:"column:need_webhook" json:"need_webhook"`
+	NeedChat          *bool      `gorm:"column:need_chat" json:"need_chat"`
+}
+
+type RecipientContactDAO struct {
+	RecipientId     int64  `gorm:"column:id" json:"recipient_id"`
+	Mail            string `gorm:"column:mail" json:"mail"`
+	Message         string `gorm:"column:message" json:"message"`
+	Phone           string `gorm:"column:phone" json:"phone"`
+	MailVerified    bool   `gorm:"column:mail_verified" json:"mail_verified"`
+	MessageVerified bool   `gorm:"column:message_verified" json:"message_verified"`
+	PhoneVerified   bool   `gorm:"column:phone_verified" json:"phone_verified"`
+}
+
+type VerificationDAO struct {
+	RecipientId int64     `gorm:"column:recipient_id" json:"recipient_id"`
+	ContactType string    `gorm:"column:contact_type" json:"contact_type"`
+	Contact     string    `gorm:"column:contact" json:"contact"`
+	CodeHash    string    `gorm:"column:code_hash" json:"-"`
+	Attempts    int       `gorm:"column:attempts" json:"attempts"`
+	ExpiresAt   time.Time `gorm:"column:expires_at" json:"expires_at"`
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+type TodoFanoutDAO struct {
+	BusinessId  string `json:"business_id"`
+	RecipientId int64  `json:"recipient_id"`
+	IsDone      bool   `json:"is_done"`
+}
+
+type CmdToSaveFanout struct {
+	EventId   string          `json:"event_id"`
+	Community string          `json:"community"`
+	Source    string          `json:"source"`
+	EventTime time.Time       `json:"time"`
+	Follow    []int64         `json:"follow"`
+	Related   []int64         `json:"related"`
+	Todo      []TodoFanoutDAO `json:"todo"`
+	// IsDone is the state of the todos of BusinessId when the event tells it
+	BusinessId string `json:"business_id"`
+	IsDone     *bool  `json:"is_done"`
+}
+
+type ConsumerMessageDAO struct {
+	Topic     string            `json:"topic"`
+	Partition int               `json:"partition"`
+	Offset    int64             `json:"offset"`
+	Key       []byte            `json:"key"`
+	Value     []byte            `json:"value"`
+	Headers   map[string]string `json:"headers"`
+}
+
+type DeadLetterDAO struct {
+	Message  ConsumerMessageDAO `json:"message"`
+	Error    string             `json:"error"`
+	Attempts int                `json:"attempts"`
+}
+
+type MeetingEventDAO struct {
+	BusinessId  string    `gorm:"column:business_id" json:"business_id"`
+	EventId     string    `gorm:"column:event_id" json:"event_id"`
+	Title       string    `gorm:"column:title" json:"title"`
+	Summary     string    `gorm:"column:summary" json:"summary"`
+	SourceGroup string    `gorm:"column:source_group" json:"source_group"`
+	SourceUrl   string    `gorm:"column:source_url" json:"source_url"`
+	EventTime   time.Time `gorm:"column:time" json:"time"`
+	UpdatedAt   time.Time `gorm:"column:updated_at" json:"updated_at"`
+	Action      string    `gorm:"column:action" json:"action"`
+}
+
+type MessageNotifyDAO struct {
+	UserName string `json:"user_name"`
+	Op       string `json:"op"`
+}
+
 type CmdToGetInnerMessageQuick struct {
 	Source       string `json:"source"`
 	CountPerPage int    `json:"count_per_page"`
@@ -130,6 +388,22 @@ type CmdToGetInnerMessage struct {
 	CVEAffected      string `json:"cve_affected"`
 }
 
+// CmdToGetSourceMessage lists the messages of a category of a source, Source is
+// the url the events carry and IsDone only applies to todos.
+type CmdToGetSourceMessage struct {
+	UserName     string `json:"user_name"`
+	Source       string `json:"source"`
+	Category     string `json:"category"`
+	EventType    string `json:"event_type"`
+	Schedule     bool   `json:"schedule"`
+	IsDone       *bool  `json:"is_done"`
+	IsRead       *bool  `json:"is_read"`
+	StartTime    string `json:"start_time"`
+	PageNum      int    `json:"page_num"`
+	CountPerPage int    `json:"count_per_page"`
+	Cursor       string `json:"cursor"`
+}
+
 type CmdToSetIsRead struct {
 	EventId string `json:"event_id"`
 }
@@ -141,6 +415,8 @@ type CmdToAddPushConfig struct {
 	NeedPhone        bool  `json:"need_phone"`
 	NeedMail         bool  `json:"need_mail"`
 	NeedInnerMessage bool  `json:"need_inner_message"`
+	NeedWebhook      bool  `json:"need_webhook"`
+	NeedChat         bool  `json:"need_chat"`
 }
 
 type CmdToUpdatePushConfig struct {
@@ -150,6 +426,8 @@ type CmdToUpdatePushConfig struct {
 	NeedPhone        bool     `json:"need_phone"`
 	NeedMail         bool     `json:"need_mail"`
 	NeedInnerMessage bool     `json:"need_inner_message"`
+	NeedWebhook      bool     `json:"need_webhook"`
+	NeedChat         bool     `json:"need_chat"`
 }
 
 type CmdToDeletePushConfig struct {
@@ -158,20 +436,30 @@ type CmdToDeletePushConfig struct {
 }
 
 type CmdToAddRecipient struct {
-	Name    string `json:"recipient_id"`
-	Mail    string `json:"mail"`
-	Message string `json:"message"`
-	Phone   string `json:"phone"`
-	Remark  string `json:"remark"`
+	Name          string `json:"recipient_id"`
+	Mail          string `json:"mail"`
+	Message       string `json:"message"`
+	Phone         string `json:"phone"`
+	Remark        string `json:"remark"`
+	Webhook       string `json:"webhook"`
+	WebhookSecret string `json:"webhook_secret"`
+	ChatPlatform  string `json:"chat_platform"`
+	ChatWebhook   string `json:"chat_webhook"`
+	ChatSecret    string `json:"chat_secret"`
 }
 
 type CmdToUpdateRecipient struct {
-	Id      string `json:"id"`
-	Name    string `json:"recipient_id"`
-	Mail    string `json:"mail"`
-	Message string `json:"message"`
-	Phone   string `json:"phone"`
-	Remark  string `json:"remark"`
+	Id            string `json:"id"`
+	Name          string `json:"recipient_id"`
+	Mail          string `json:"mail"`
+	Message       string `json:"message"`
+	Phone         string `json:"phone"`
+	Remark        string `json:"remark"`
+	Webhook       string `json:"webhook"`
+	WebhookSecret string `json:"webhook_secret"`
+	ChatPlatform  string `json:"chat_platform"`
+	ChatWebhook   string `json:"chat_webhook"`
+	ChatSecret    string `json:"chat_secret"`
 }
 
 type CmdToDeleteRecipient struct {
@@ -179,11 +467,15 @@ type CmdToDeleteRecipient struct {
 }
 
 type CmdToSyncUserInfo struct {
-	Mail          string `json:"mail"`
-	Phone         string `json:"phone"`
-	CountryCode   string `json:"country_code"`
-	UserName      string `json:"user_name"`
-	GiteeUserName string `json:"gitee_user_name"`
+	Mail            string `json:"mail"`
+	Phone           string `json:"phone"`
+	CountryCode     string `json:"country_code"`
+	UserName        string `json:"user_name"`
+	GiteeUserName   string `json:"gitee_user_name"`
+	GithubUserName  string `json:"github_user_name"`
+	GitcodeUserName string `json:"gitcode_user_name"`
+	// KeepPhone keeps the stored number when the synced one is invalid
+	KeepPhone bool `json:"-"`
 }
 
 type CmdToGetSubscribe struct {
@@ -243,3 +535,13 @@ type CmdToDeleteSubscribe struct {
 	Source   string `json:"source"`
 	ModeName string `json:"mode_name"`
 }
+
+type CmdToGetDeliveryAttempt struct {
+	Community    string `json:"community"`
+	EventId      string `json:"event_id"`
+	RecipientId  int64  `json:"recipient_id"`
+	Channel      string `json:"channel"`
+	Status       string `json:"status"`
+	CountPerPage int    `json:"count_per_page"`
+	PageNum      int    `json:"page"`
+}
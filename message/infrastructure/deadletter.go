@@ -0,0 +1,59 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package infrastructure
+
+import (
+	"encoding/json"
+
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/postgresql"
+)
+
+const deadLetterSql = `
+create table if not exists message_center.dead_letter_message (
+    id             bigserial primary key,
+    topic          text not null,
+    partition      integer not null,
+    message_offset bigint not null,
+    message_key    bytea,
+    message_value  bytea,
+    headers        jsonb,
+    error          text not null,
+    attempts       integer not null,
+    created_at     timestamptz not null default now(),
+    unique (topic, partition, message_offset)
+);
+`
+
+func MessageDeadLetterAdapter() *messageDeadLetterAdapter {
+	return &messageDeadLetterAdapter{}
+}
+
+type messageDeadLetterAdapter struct{}
+
+// Migration creates the dead letter table.
+func (s *messageDeadLetterAdapter) Migration() postgresql.Migration {
+	return postgresql.Migration{Version: "dead_letter", Sql: deadLetterSql}
+}
+
+// SaveDeadLetter keeps a message which cannot be consumed, a message delivered
+// again is kept once.
+func (s *messageDeadLetterAdapter) SaveDeadLetter(letter DeadLetterDAO) error {
+	headers, err := json.Marshal(letter.Message.Headers)
+	if err != nil {
+		return xerrors.Errorf("save dead letter failed, err:%v", err)
+	}
+	query := `insert into message_center.dead_letter_message (topic, partition, message_offset,
+		    message_key, message_value, headers, error, attempts)
+		values (?, ?, ?, ?, ?, ?, ?, ?)
+		on conflict (topic, partition, message_offset) do nothing`
+	msg := letter.Message
+	if result := postgresql.DB().Exec(query, msg.Topic, msg.Partition, msg.Offset, msg.Key,
+		msg.Value, string(headers), letter.Error, letter.Attempts); result.Error != nil {
+		return xerrors.Errorf("save dead letter failed, err:%v", result.Error)
+	}
+	return nil
+}
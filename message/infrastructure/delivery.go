@@ -0,0 +1,153 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package infrastructure
+
+import (
+	"time"
+
+	"golang.org/x/xerrors"
+	"gorm.io/gorm"
+
+	"github.com/opensourceways/message-manager/common/postgresql"
+)
+
+const (
+	DeliveryPending = "pending"
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"
+
+	DeliveryChannelMail = "mail"
+)
+
+// the notifications sent outside wait here until they are sent or given up,
+// once per event, recipient and address
+const deliverySql = `
+create table if not exists message_center.delivery_queue (
+    id              bigserial     primary key,
+    channel         varchar(16)   not null,
+    event_id        varchar(255)  not null,
+    recipient_id    bigint        not null,
+    target          varchar(1024) not null,
+    content         jsonb         not null,
+    status          varchar(16)   not null default 'pending',
+    attempts        int           not null default 0,
+    next_attempt_at timestamptz   not null default now(),
+    last_error      text          not null default '',
+    sent_at         timestamptz,
+    created_at      timestamptz   not null default now(),
+    updated_at      timestamptz   not null default now()
+);
+create unique index if not exists delivery_queue_uniq
+    on message_center.delivery_queue (channel, event_id, recipient_id, target);
+create index if not exists delivery_queue_due_idx
+    on message_center.delivery_queue (channel, next_attempt_at) where status = 'pending';
+`
+
+const (
+	enqueueDeliverySql = `insert into message_center.delivery_queue
+		(channel, event_id, recipient_id, target, content) values (?, ?, ?, ?, ?)
+		on conflict do nothing`
+
+	// the claimed notifications are due again after the lease, so that the
+	// ones of a replica stopped while sending are not lost
+	claimDeliverySql = `update message_center.delivery_queue d
+		set attempts = d.attempts + 1, updated_at = now(),
+		    next_attempt_at = now() + make_interval(secs => ?)
+		where d.id in (
+		    select id from message_center.delivery_queue
+		    where channel = ? and status = ? and next_attempt_at <= now()
+		    order by next_attempt_at, id limit ?
+		    for update skip locked)
+		returning d.*`
+)
+
+func MessageDeliveryAdapter() *messageDeliveryAdapter {
+	return &messageDeliveryAdapter{}
+}
+
+type messageDeliveryAdapter struct{}
+
+// Migration creates the delivery queue.
+func (s *messageDeliveryAdapter) Migration() postgresql.Migration {
+	return postgresql.Migration{Version: "delivery_queue", Sql: deliverySql}
+}
+
+// EnqueueDelivery queues the notifications which are not queued yet, it
+// returns how many are.
+func (s *messageDeliveryAdapter) EnqueueDelivery(items []DeliveryDAO) (int64, error) {
+	var count int64
+	err := postgresql.DB().Transaction(func(tx *gorm.DB) error {
+		for _, item := range items {
+			result := tx.Exec(enqueueDeliverySql, item.Channel, item.EventId, item.RecipientId,
+				item.Target, item.Content)
+			if result.Error != nil {
+				return result.Error
+			}
+			count += result.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, xerrors.Errorf("enqueue delivery failed, err:%v", err)
+	}
+	return count, nil
+}
+
+// ClaimDelivery returns at most limit due notifications of channel, hidden
+// from the other replicas until lease is over.
+func (s *messageDeliveryAdapter) ClaimDelivery(channel string, limit int,
+	lease time.Duration) ([]DeliveryDAO, error) {
+	var response []DeliveryDAO
+	if result := postgresql.DB().Raw(claimDeliverySql, lease.Seconds(), channel,
+		DeliveryPending, limit).Scan(&response); result.Error != nil {
+		return []DeliveryDAO{}, xerrors.Errorf("claim delivery failed, err:%v", result.Error)
+	}
+	return response, nil
+}
+
+// CompleteDelivery records the notification id as sent.
+func (s *messageDeliveryAdapter) CompleteDelivery(id int64) error {
+	err := finishDelivery(id, DeliverySent, "", `update message_center.delivery_queue
+		set status = ?, sent_at = now(), last_error = '', updated_at = now()
+		where id = ?`, DeliverySent, id)
+	if err != nil {
+		return xerrors.Errorf("complete delivery failed, err:%v", err)
+	}
+	return nil
+}
+
+// RetryDelivery makes the notification id due again at retryAt.
+func (s *messageDeliveryAdapter) RetryDelivery(id int64, lastError string,
+	retryAt time.Time) error {
+	err := finishDelivery(id, DeliveryRetrying, lastError, `update message_center.delivery_queue
+		set status = ?, next_attempt_at = ?, last_error = ?, updated_at = now()
+		where id = ?`, DeliveryPending, retryAt, lastError, id)
+	if err != nil {
+		return xerrors.Errorf("retry delivery failed, err:%v", err)
+	}
+	return nil
+}
+
+// FailDelivery gives the notification id up.
+func (s *messageDeliveryAdapter) FailDelivery(id int64, lastError string) error {
+	err := finishDelivery(id, DeliveryFailed, lastError, `update message_center.delivery_queue
+		set status = ?, last_error = ?, updated_at = now()
+		where id = ?`, DeliveryFailed, lastError, id)
+	if err != nil {
+		return xerrors.Errorf("fail delivery failed, err:%v", err)
+	}
+	return nil
+}
+
+// finishDelivery updates the notification id by sql and records its attempt
+// with status at once.
+func finishDelivery(id int64, status, lastError, sql string, values ...interface{}) error {
+	return postgresql.DB().Transaction(func(tx *gorm.DB) error {
+		if result := tx.Exec(sql, values...); result.Error != nil {
+			return result.Error
+		}
+		return tx.Exec(recordDeliveryAttemptSql, status, lastError, id).Error
+	})
+}
@@ -0,0 +1,146 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package infrastructure
+
+import (
+	"golang.org/x/xerrors"
+	"gorm.io/gorm"
+
+	"github.com/opensourceways/message-manager/common/postgresql"
+)
+
+const (
+	// DeliveryRetrying is the status of an attempt which failed and is tried
+	// again later.
+	DeliveryRetrying = "retrying"
+
+	DeliveryChannelMessage      = "message"
+	DeliveryChannelPhone        = "phone"
+	DeliveryChannelInnerMessage = "inner_message"
+)
+
+// every attempt to deliver a notification is recorded, the ones of the queue
+// by the queue itself and the others by the pushers reporting them.
+const deliveryAttemptSql = `
+create table if not exists message_center.delivery_attempt (
+    id           bigserial     primary key,
+    event_id     varchar(255)  not null,
+    recipient_id bigint        not null,
+    channel      varchar(16)   not null,
+    target       varchar(1024) not null default '',
+    status       varchar(16)   not null,
+    error        text          not null default '',
+    attempt      int           not null default 1,
+    attempted_at timestamptz   not null default now(),
+    created_at   timestamptz   not null default now()
+);
+create index if not exists delivery_attempt_event_idx
+    on message_center.delivery_attempt (event_id, recipient_id);
+create index if not exists delivery_attempt_recipient_idx
+    on message_center.delivery_attempt (recipient_id, created_at);
+`
+
+const (
+	// the attempt of a queued notification is the one counted when claiming
+	recordDeliveryAttemptSql = `insert into message_center.delivery_attempt
+		(event_id, recipient_id, channel, target, status, error, attempt)
+		select d.event_id, d.recipient_id, d.channel, d.target, ?, ?, d.attempts
+		from message_center.delivery_queue d where d.id = ?`
+
+	// the reports about the recipients which do not exist are dropped
+	saveDeliveryAttemptSql = `insert into message_center.delivery_attempt
+		(event_id, recipient_id, channel, target, status, error, attempt, attempted_at)
+		select ?, ?, ?, ?, ?, ?, ?, ?
+		where exists (
+		    select 1 from message_center.recipient_config rc
+		    where rc.id = ? and rc.is_deleted = false)`
+)
+
+func MessageDeliveryAttemptAdapter() *messageDeliveryAttemptAdapter {
+	return &messageDeliveryAttemptAdapter{}
+}
+
+type messageDeliveryAttemptAdapter struct{}
+
+// Migration creates the delivery attempt table.
+func (s *messageDeliveryAttemptAdapter) Migration() postgresql.Migration {
+	return postgresql.Migration{Version: "delivery_attempt", Sql: deliveryAttemptSql}
+}
+
+// SaveDeliveryAttempt records the attempts reported, it returns how many are.
+func (s *messageDeliveryAttemptAdapter) SaveDeliveryAttempt(
+	attempts []DeliveryAttemptDAO) (int64, error) {
+	var count int64
+	err := postgresql.DB().Transaction(func(tx *gorm.DB) error {
+		for _, a := range attempts {
+			result := tx.Exec(saveDeliveryAttemptSql, a.EventId, a.RecipientId, a.Channel,
+				a.Target, a.Status, a.Error, a.Attempt, a.AttemptedAt, a.RecipientId)
+			if result.Error != nil {
+				return result.Error
+			}
+			count += result.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, xerrors.Errorf("save delivery attempt failed, err:%v", err)
+	}
+	return count, nil
+}
+
+// GetDeliveryAttempt returns the attempts to deliver the event eventId to the
+// recipients of userName, the latest first.
+func (s *messageDeliveryAttemptAdapter) GetDeliveryAttempt(userName, eventId string) (
+	[]DeliveryAttemptDAO, error) {
+	var response []DeliveryAttemptDAO
+	if result := postgresql.DB().Table("message_center.delivery_attempt da").
+		Joins("join message_center.recipient_config rc on rc.id = da.recipient_id").
+		Where("rc.user_id = ? AND rc.is_deleted = ? AND da.event_id = ?", userName, false,
+			eventId).
+		Select("da.*").Order("da.attempted_at DESC, da.id DESC").
+		Scan(&response); result.Error != nil {
+		return []DeliveryAttemptDAO{}, xerrors.Errorf("get delivery attempt failed, err:%v",
+			result.Error)
+	}
+	return response, nil
+}
+
+// GetAllDeliveryAttempt returns the attempts in cmd.Community filtered by the
+// other fields of cmd which are set, the latest first.
+func (s *messageDeliveryAttemptAdapter) GetAllDeliveryAttempt(cmd CmdToGetDeliveryAttempt) (
+	[]DeliveryAttemptDAO, int64, error) {
+	query := func() *gorm.DB {
+		q := postgresql.DB().Table("message_center.delivery_attempt da").
+			Joins("join message_center.recipient_config rc on rc.id = da.recipient_id").
+			Where("rc.community = ?", cmd.Community)
+		if cmd.EventId != "" {
+			q = q.Where("da.event_id = ?", cmd.EventId)
+		}
+		if cmd.RecipientId != 0 {
+			q = q.Where("da.recipient_id = ?", cmd.RecipientId)
+		}
+		if cmd.Channel != "" {
+			q = q.Where("da.channel = ?", cmd.Channel)
+		}
+		if cmd.Status != "" {
+			q = q.Where("da.status = ?", cmd.Status)
+		}
+		return q
+	}
+
+	var count int64
+	if result := query().Count(&count); result.Error != nil {
+		return []DeliveryAttemptDAO{}, 0, xerrors.Errorf("get delivery attempt failed, err:%v",
+			result.Error)
+	}
+	var response []DeliveryAttemptDAO
+	if result := query().Select("da.*").Order("da.attempted_at DESC, da.id DESC").
+		Limit(cmd.CountPerPage).Offset((cmd.PageNum - 1) * cmd.CountPerPage).
+		Scan(&response); result.Error != nil {
+		return []DeliveryAttemptDAO{}, 0, xerrors.Errorf("get delivery attempt failed, err:%v",
+			result.Error)
+	}
+	return response, count, nil
+}
@@ -0,0 +1,216 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package infrastructure
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/xerrors"
+	"gorm.io/gorm"
+
+	"github.com/opensourceways/message-manager/common/postgresql"
+)
+
+func MessageFanoutAdapter() *messageFanoutAdapter {
+	return &messageFanoutAdapter{}
+}
+
+type messageFanoutAdapter struct{}
+
+// subscribeTargetSql selects the subscriptions reaching their recipients on a
+// channel, the %s are its flag in push_config and the address on it.
+const subscribeTargetSql = `select sc.id as subscribe_id, sc.event_type, sc.mode_filter,
+		pc.recipient_id, %s as target,
+		exists (select 1 from message_center.recipient_sig rs
+		    where rs.recipient_id = rc.id and lower(rs.sig) in ?) as my_sig,
+		exists (select 1 from message_center.recipient_admin_repo ra
+		    where ra.recipient_id = rc.id and lower(ra.repo) in ?) as my_management
+		from message_center.subscribe_config sc
+		join message_center.push_config pc on pc.subscribe_id = sc.id
+		join message_center.recipient_config rc on rc.id = pc.recipient_id
+		where sc.is_deleted = false and pc.is_deleted = false and rc.is_deleted = false
+		and pc.%s = true
+		and rc.community = ? and sc.source = ?`
+
+// GetSubscribeTarget returns the subscriptions to source whose recipients in
+// communityId receive inner messages, with their memberships of sigs and repos.
+func (s *messageFanoutAdapter) GetSubscribeTarget(communityId, source string, sigs,
+	repos []string) ([]SubscribeTargetDAO, error) {
+	return getSubscribeTarget("''", "need_inner_message", communityId, source, sigs, repos)
+}
+
+func getSubscribeTarget(target, flag, communityId, source string, sigs, repos []string) (
+	[]SubscribeTargetDAO, error) {
+	var response []SubscribeTargetDAO
+	if result := postgresql.DB().Raw(fmt.Sprintf(subscribeTargetSql, target, flag),
+		lowerAll(sigs), lowerAll(repos), communityId, source).
+		Scan(&response); result.Error != nil {
+		return []SubscribeTargetDAO{}, xerrors.Errorf("get subscribe target failed, err:%v",
+			result.Error)
+	}
+	return response, nil
+}
+
+// GetRecipientByLogin returns the recipients in communityId whose verified
+// login on forge is one of logins, case is ignored.
+func (s *messageFanoutAdapter) GetRecipientByLogin(communityId, forge string, logins []string) (
+	[]RecipientLoginDAO, error) {
+	var response []RecipientLoginDAO
+	if result := postgresql.DB().Table("message_center.recipient_identity ri").
+		Select("rc.id, ri.login").
+		Joins("join message_center.recipient_config rc on rc.id = ri.recipient_id").
+		Where("not rc.is_deleted AND rc.community = ? AND ri.provider = ? AND ri.verified AND "+
+			"lower(ri.login) IN ?", communityId, forge, lowerAll(logins)).
+		Scan(&response); result.Error != nil {
+		return []RecipientLoginDAO{}, xerrors.Errorf("get recipient failed, err:%v", result.Error)
+	}
+	return response, nil
+}
+
+// GetRecipientBySig returns the recipients in communityId maintaining one of
+// sigs, as synced locally.
+func (s *messageFanoutAdapter) GetRecipientBySig(communityId string, sigs []string) (
+	[]int64, error) {
+	var response []int64
+	if result := postgresql.DB().Table("message_center.recipient_sig rs").
+		Distinct("rc.id").
+		Joins("join message_center.recipient_config rc on rc.id = rs.recipient_id").
+		Where("not rc.is_deleted AND rc.community = ? AND lower(rs.sig) IN ?",
+			communityId, lowerAll(sigs)).
+		Order("rc.id").
+		Scan(&response); result.Error != nil {
+		return []int64{}, xerrors.Errorf("get recipient failed, err:%v", result.Error)
+	}
+	return response, nil
+}
+
+// lowerAll returns values in lower case, an empty list matches nothing in an
+// IN clause.
+func lowerAll(values []string) []string {
+	lower := make([]string, len(values))
+	for i := range values {
+		lower[i] = strings.ToLower(values[i])
+	}
+	return lower
+}
+
+const (
+	insertFanoutSql = `insert into message_center.%s (event_id, recipient_id, source, is_read,
+		    is_deleted)
+		select ?, rc.id, ?, false, false from message_center.recipient_config rc
+		where rc.id in ? and not exists (
+		    select 1 from message_center.%s m
+		    where m.event_id = ? and m.recipient_id = rc.id)`
+
+	// the todo follows the latest event of its business_id in its source, an
+	// older event delivered late leaves it alone, a todo done by hand stays done
+	updateTodoSql = `update message_center.todo_message tm
+		set latest_event_id = ?, is_done = ? or tm.done_by_hand, is_read = false
+		where tm.business_id = ? and tm.source = ? and tm.recipient_id = ?
+		and tm.latest_event_id != ?
+		and not exists (
+		    select 1 from message_center.cloud_event_message cem
+		    where cem.event_id = tm.latest_event_id and cem.time > ?)`
+
+	// the state of an item applies to all of its todos of the source in the
+	// community, they become unread when the state changes
+	updateBusinessTodoSql = `update message_center.todo_message tm
+		set latest_event_id = ?, is_done = ? or tm.done_by_hand,
+		    is_read = tm.is_read and tm.is_done = (? or tm.done_by_hand)
+		where tm.business_id = ? and tm.source = ? and tm.is_deleted = false
+		and tm.latest_event_id != ?
+		and tm.recipient_id in (
+		    select rc.id from message_center.recipient_config rc where rc.community = ?)
+		and not exists (
+		    select 1 from message_center.cloud_event_message cem
+		    where cem.event_id = tm.latest_event_id and cem.time > ?)`
+
+	insertTodoSql = `insert into message_center.todo_message (business_id, recipient_id,
+		    latest_event_id, source, is_done, is_read, is_deleted)
+		select ?, ?, ?, ?, ?, false, false
+		where not exists (
+		    select 1 from message_center.todo_message tm
+		    where tm.business_id = ? and tm.source = ? and tm.recipient_id = ?)`
+)
+
+// SaveFanout stores the follow, related and todo messages of an event, the
+// ones stored already are kept.
+func (s *messageFanoutAdapter) SaveFanout(cmd CmdToSaveFanout) error {
+	err := postgresql.DB().Transaction(func(tx *gorm.DB) error {
+		// serializes the fan-out of the same event and of the same todo
+		if result := tx.Exec("select pg_advisory_xact_lock(hashtext(?))",
+			"fanout:"+cmd.EventId); result.Error != nil {
+			return result.Error
+		}
+
+		for table, ids := range map[string][]int64{
+			"follow_message":  cmd.Follow,
+			"related_message": cmd.Related,
+		} {
+			if len(ids) == 0 {
+				continue
+			}
+			query := fmt.Sprintf(insertFanoutSql, table, table)
+			if result := tx.Exec(query, cmd.EventId, cmd.Source, ids,
+				cmd.EventId); result.Error != nil {
+				return result.Error
+			}
+		}
+
+		for _, businessId := range fanoutBusinessId(cmd) {
+			if result := tx.Exec("select pg_advisory_xact_lock(hashtext(?))",
+				"todo:"+businessId); result.Error != nil {
+				return result.Error
+			}
+		}
+
+		for _, todo := range cmd.Todo {
+			if result := tx.Exec(updateTodoSql, cmd.EventId, todo.IsDone, todo.BusinessId,
+				cmd.Source, todo.RecipientId, cmd.EventId, cmd.EventTime); result.Error != nil {
+				return result.Error
+			}
+			if result := tx.Exec(insertTodoSql, todo.BusinessId, todo.RecipientId,
+				cmd.EventId, cmd.Source, todo.IsDone, todo.BusinessId, cmd.Source,
+				todo.RecipientId); result.Error != nil {
+				return result.Error
+			}
+		}
+		if cmd.IsDone != nil {
+			if result := tx.Exec(updateBusinessTodoSql, cmd.EventId, *cmd.IsDone, *cmd.IsDone,
+				cmd.BusinessId, cmd.Source, cmd.EventId, cmd.Community,
+				cmd.EventTime); result.Error != nil {
+				return result.Error
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return xerrors.Errorf("save fanout failed, err:%v", err)
+	}
+	return nil
+}
+
+// fanoutBusinessId returns the sorted business ids whose todos cmd changes, the
+// locks are taken in this order to avoid deadlocks.
+func fanoutBusinessId(cmd CmdToSaveFanout) []string {
+	seen := map[string]bool{}
+	var ids []string
+	add := func(id string) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, todo := range cmd.Todo {
+		add(todo.BusinessId)
+	}
+	if cmd.IsDone != nil {
+		add(cmd.BusinessId)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,150 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package infrastructure
+
+import (
+	"strings"
+
+	"golang.org/x/xerrors"
+	"gorm.io/gorm"
+
+	"github.com/opensourceways/message-manager/common/postgresql"
+)
+
+// the identities are filled from the login columns of recipient_config when
+// the table is created, later the columns are no longer read.
+const recipientIdentitySql = `
+do $$
+begin
+    if not exists (select 1 from pg_tables
+                   where schemaname = 'message_center' and tablename = 'recipient_identity') then
+        create table message_center.recipient_identity (
+            id           bigserial primary key,
+            recipient_id bigint       not null,
+            provider     varchar(32)  not null,
+            login        varchar(255) not null,
+            verified     boolean      not null default false,
+            created_at   timestamptz  not null default now(),
+            updated_at   timestamptz  not null default now()
+        );
+        create unique index recipient_identity_login_idx
+            on message_center.recipient_identity (provider, lower(login));
+        create index recipient_identity_recipient_idx
+            on message_center.recipient_identity (recipient_id);
+
+        insert into message_center.recipient_identity (recipient_id, provider, login, verified)
+        select distinct on (provider, lower(login)) id, provider, login, true from (
+            select id, updated_at, 'gitee' as provider, gitee_user_name as login
+            from message_center.recipient_config
+            union all
+            select id, updated_at, 'github', github_user_name
+            from message_center.recipient_config
+            union all
+            select id, updated_at, 'gitcode', gitcode_user_name
+            from message_center.recipient_config) logins
+        where login != '' and id in (
+            select id from message_center.recipient_config where not is_deleted)
+        order by provider, lower(login), updated_at desc;
+    end if;
+end $$;
+`
+
+// a login is linked once in a community, a verified identity is only moved by
+// a verified link
+const linkIdentitySql = `
+insert into message_center.recipient_identity (recipient_id, community, provider, login,
+    verified)
+select rc.id, rc.community, ?, ?, ? from message_center.recipient_config rc where rc.id = ?
+on conflict (community, provider, lower(login)) do update
+set recipient_id = excluded.recipient_id, login = excluded.login, updated_at = now(),
+    verified = excluded.verified or (recipient_identity.verified and
+        recipient_identity.recipient_id = excluded.recipient_id)
+where excluded.verified or not recipient_identity.verified or
+    recipient_identity.recipient_id = excluded.recipient_id`
+
+// linkedLogin matches the recipients alias of the user key value which are
+// linked to a verified login of any forge in their community.
+func linkedLogin(alias, value string) string {
+	return `(` + alias + `.user_id = ` + value + ` and exists (
+		select 1 from message_center.recipient_identity ri
+		where ri.recipient_id = ` + alias + `.id and ri.verified
+		and ri.community = ` + alias + `.community))`
+}
+
+func MessageIdentityAdapter() *messageIdentityAdapter {
+	return &messageIdentityAdapter{}
+}
+
+type messageIdentityAdapter struct{}
+
+// Migration creates the identity table, the login columns of recipient_config
+// have to exist.
+func (s *messageIdentityAdapter) Migration() postgresql.Migration {
+	return postgresql.Migration{Version: "recipient_identity", Sql: recipientIdentitySql}
+}
+
+// GetIdentity returns the identities of the recipient recipientId of userName.
+func (s *messageIdentityAdapter) GetIdentity(userName string, recipientId int64) (
+	[]RecipientIdentityDAO, error) {
+	var response []RecipientIdentityDAO
+	if result := postgresql.DB().Table("message_center.recipient_identity ri").
+		Select("ri.*").
+		Joins("join message_center.recipient_config rc on rc.id = ri.recipient_id").
+		Where("rc.id = ? and rc.user_id = ? and not rc.is_deleted", recipientId, userName).
+		Order("ri.provider, ri.login").
+		Scan(&response); result.Error != nil {
+		return []RecipientIdentityDAO{}, xerrors.Errorf("get identity failed, err:%v",
+			result.Error)
+	}
+	return response, nil
+}
+
+// LinkIdentity links a login to the recipient of userName, unverified.
+func (s *messageIdentityAdapter) LinkIdentity(userName string, cmd CmdToLinkIdentity) error {
+	var count int64
+	if result := getTable().Where("id = ? and user_id = ?", cmd.RecipientId, userName).
+		Count(&count); result.Error != nil {
+		return xerrors.Errorf("get recipient failed, err:%v", result.Error)
+	}
+	if count == 0 {
+		return xerrors.Errorf("the recipient %d does not exist", cmd.RecipientId)
+	}
+
+	result := postgresql.DB().Exec(linkIdentitySql, cmd.Provider, strings.TrimSpace(cmd.Login),
+		false, cmd.RecipientId)
+	if result.Error != nil {
+		return xerrors.Errorf("link identity failed, err:%v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return xerrors.Errorf("the %s login %s is linked to another recipient", cmd.Provider,
+			cmd.Login)
+	}
+	return nil
+}
+
+// UnlinkIdentity removes a login from the recipient of userName.
+func (s *messageIdentityAdapter) UnlinkIdentity(userName string, cmd CmdToLinkIdentity) error {
+	result := postgresql.DB().Exec(`delete from message_center.recipient_identity ri
+		using message_center.recipient_config rc
+		where rc.id = ri.recipient_id and rc.id = ? and rc.user_id = ?
+		and ri.provider = ? and lower(ri.login) = lower(?)`,
+		cmd.RecipientId, userName, cmd.Provider, strings.TrimSpace(cmd.Login))
+	if result.Error != nil {
+		return xerrors.Errorf("unlink identity failed, err:%v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return xerrors.Errorf("the %s login %s is not linked", cmd.Provider, cmd.Login)
+	}
+	return nil
+}
+
+// linkVerifiedIdentity links a login the account system vouches for, taking
+// it over from any other recipient.
+func linkVerifiedIdentity(db *gorm.DB, recipientId int64, provider, login string) error {
+	if result := db.Exec(linkIdentitySql, provider, login, true, recipientId); result.Error != nil {
+		return xerrors.Errorf("link identity failed, err:%v", result.Error)
+	}
+	return nil
+}
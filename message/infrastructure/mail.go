@@ -0,0 +1,30 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package infrastructure
+
+func MessageMailAdapter() *messageMailAdapter {
+	return &messageMailAdapter{}
+}
+
+type messageMailAdapter struct{}
+
+// GetMailTarget returns the subscriptions to source whose recipients in
+// communityId receive mails at a verified address, like GetSubscribeTarget.
+func (s *messageMailAdapter) GetMailTarget(communityId, source string, sigs,
+	repos []string) ([]SubscribeTargetDAO, error) {
+	targets, err := getSubscribeTarget(
+		"case when rc.mail_verified then coalesce(rc.mail, '') else '' end", "need_mail",
+		communityId, source, sigs, repos)
+	if err != nil {
+		return targets, err
+	}
+	result := targets[:0]
+	for _, t := range targets {
+		if t.Target != "" {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,207 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package infrastructure
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/xerrors"
+	"gorm.io/gorm"
+
+	"github.com/opensourceways/message-manager/common/postgresql"
+	"github.com/opensourceways/message-manager/common/source"
+)
+
+const (
+	MembershipSyncRunning   = "running"
+	MembershipSyncSucceeded = "succeeded"
+	MembershipSyncPartial   = "partial"
+	MembershipSyncFailed    = "failed"
+)
+
+// the sigs and the administered repositories of the recipients are copies of
+// the directories, the fan-out matches the events against them.
+const membershipSql = `
+create table if not exists message_center.recipient_sig (
+    recipient_id bigint       not null,
+    sig          varchar(255) not null,
+    synced_at    timestamptz  not null default now(),
+    primary key (recipient_id, sig)
+);
+create index if not exists recipient_sig_sig_idx on message_center.recipient_sig (lower(sig));
+
+create table if not exists message_center.recipient_admin_repo (
+    recipient_id bigint       not null,
+    repo         varchar(255) not null,
+    synced_at    timestamptz  not null default now(),
+    primary key (recipient_id, repo)
+);
+create index if not exists recipient_admin_repo_repo_idx
+    on message_center.recipient_admin_repo (lower(repo));
+
+create table if not exists message_center.membership_sync (
+    id          bigserial   primary key,
+    status      varchar(16) not null,
+    started_at  timestamptz not null default now(),
+    finished_at timestamptz,
+    recipients  int         not null default 0,
+    failed      int         not null default 0,
+    sigs        int         not null default 0,
+    repos       int         not null default 0,
+    error       text        not null default ''
+);
+`
+
+// the recipients looked up by their verified Gitee logins, the only ones the
+// directory knows
+const membershipTargetSql = `select ri.recipient_id, rc.community, ri.login
+	from message_center.recipient_identity ri
+	join message_center.recipient_config rc on rc.id = ri.recipient_id
+	where not rc.is_deleted and ri.verified and ri.provider = ?
+	order by ri.recipient_id, ri.login`
+
+const (
+	deleteMembershipSql = `delete from message_center.%s where recipient_id = ?`
+	insertMembershipSql = `insert into message_center.%s (recipient_id, %s)
+		select ?, v from unnest(array[?]::text[]) v on conflict do nothing`
+
+	// the memberships of the recipients which are no longer looked up
+	pruneMembershipSql = `delete from message_center.%s m
+	where not exists (
+	    select 1 from message_center.recipient_identity ri
+	    join message_center.recipient_config rc on rc.id = ri.recipient_id
+	    where ri.recipient_id = m.recipient_id and not rc.is_deleted and ri.verified
+	    and ri.provider = ?)`
+)
+
+func MessageMembershipAdapter() *messageMembershipAdapter {
+	return &messageMembershipAdapter{}
+}
+
+type messageMembershipAdapter struct{}
+
+// Migration creates the membership tables.
+func (s *messageMembershipAdapter) Migration() postgresql.Migration {
+	return postgresql.Migration{Version: "membership", Sql: membershipSql}
+}
+
+// StartMembershipSync records a sync as running, it reports false when another
+// one has been running for less than timeout.
+func (s *messageMembershipAdapter) StartMembershipSync(timeout time.Duration) (int64, bool,
+	error) {
+	var id int64
+	err := postgresql.DB().Transaction(func(tx *gorm.DB) error {
+		// serializes the replicas starting a sync at the same time
+		if result := tx.Exec("select pg_advisory_xact_lock(hashtext(?))",
+			"membership_sync"); result.Error != nil {
+			return result.Error
+		}
+		if result := tx.Exec(`update message_center.membership_sync
+			set status = ?, finished_at = now(), error = 'abandoned'
+			where status = ? and started_at <= now() - make_interval(secs => ?)`,
+			MembershipSyncFailed, MembershipSyncRunning, timeout.Seconds()); result.Error != nil {
+			return result.Error
+		}
+
+		var running int64
+		if result := tx.Table("message_center.membership_sync").
+			Where("status = ?", MembershipSyncRunning).Count(&running); result.Error != nil {
+			return result.Error
+		}
+		if running != 0 {
+			return nil
+		}
+		return tx.Raw(`insert into message_center.membership_sync (status) values (?)
+			returning id`, MembershipSyncRunning).Scan(&id).Error
+	})
+	if err != nil {
+		return 0, false, xerrors.Errorf("start membership sync failed, err:%v", err)
+	}
+	return id, id != 0, nil
+}
+
+// FinishMembershipSync records the result of the sync cmd.Id.
+func (s *messageMembershipAdapter) FinishMembershipSync(cmd MembershipSyncDAO) error {
+	if result := postgresql.DB().Exec(`update message_center.membership_sync
+		set status = ?, finished_at = now(), recipients = ?, failed = ?, sigs = ?, repos = ?,
+		    error = ?
+		where id = ?`, cmd.Status, cmd.Recipients, cmd.Failed, cmd.Sigs, cmd.Repos, cmd.Error,
+		cmd.Id); result.Error != nil {
+		return xerrors.Errorf("finish membership sync failed, err:%v", result.Error)
+	}
+	return nil
+}
+
+// GetMembershipSync returns the latest sync, its Id is 0 when there is none.
+func (s *messageMembershipAdapter) GetMembershipSync() (MembershipSyncDAO, error) {
+	var response []MembershipSyncDAO
+	if result := postgresql.DB().Table("message_center.membership_sync").
+		Order("id desc").Limit(1).Scan(&response); result.Error != nil {
+		return MembershipSyncDAO{}, xerrors.Errorf("get membership sync failed, err:%v",
+			result.Error)
+	}
+	if len(response) == 0 {
+		return MembershipSyncDAO{}, nil
+	}
+	return response[0], nil
+}
+
+// GetMembershipTarget returns the verified Gitee logins of the recipients, the
+// logins of the other forges are not looked up.
+func (s *messageMembershipAdapter) GetMembershipTarget() ([]MembershipTargetDAO, error) {
+	var response []MembershipTargetDAO
+	if result := postgresql.DB().Raw(membershipTargetSql, source.ForgeGitee).
+		Scan(&response); result.Error != nil {
+		return []MembershipTargetDAO{}, xerrors.Errorf("get membership target failed, err:%v",
+			result.Error)
+	}
+	return response, nil
+}
+
+// SaveMembership replaces the sigs and the administered repositories of the
+// recipient cmd.RecipientId.
+func (s *messageMembershipAdapter) SaveMembership(cmd CmdToSaveMembership) error {
+	memberships := []struct {
+		table  string
+		column string
+		values []string
+	}{
+		{"recipient_sig", "sig", cmd.Sigs},
+		{"recipient_admin_repo", "repo", cmd.Repos},
+	}
+	err := postgresql.DB().Transaction(func(tx *gorm.DB) error {
+		for _, m := range memberships {
+			if result := tx.Exec(fmt.Sprintf(deleteMembershipSql, m.table),
+				cmd.RecipientId); result.Error != nil {
+				return result.Error
+			}
+			if len(m.values) == 0 {
+				continue
+			}
+			if result := tx.Exec(fmt.Sprintf(insertMembershipSql, m.table, m.column),
+				cmd.RecipientId, m.values); result.Error != nil {
+				return result.Error
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return xerrors.Errorf("save membership failed, err:%v", err)
+	}
+	return nil
+}
+
+// PruneMembership drops the memberships of the recipients which are deleted or
+// no longer have a verified Gitee login.
+func (s *messageMembershipAdapter) PruneMembership() error {
+	for _, table := range []string{"recipient_sig", "recipient_admin_repo"} {
+		query := fmt.Sprintf(pruneMembershipSql, table)
+		if result := postgresql.DB().Exec(query, source.ForgeGitee); result.Error != nil {
+			return xerrors.Errorf("prune membership failed, err:%v", result.Error)
+		}
+	}
+	return nil
+}
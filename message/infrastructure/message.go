@@ -6,12 +6,13 @@ package infrastructure
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 	"golang.org/x/xerrors"
 
 	"github.com/opensourceways/message-manager/common/postgresql"
-	"github.com/opensourceways/message-manager/common/user"
+	"github.com/opensourceways/message-manager/common/source"
 	"github.com/opensourceways/message-manager/utils"
 )
 
@@ -21,43 +22,52 @@ func MessageListAdapter() *messageAdapter {
 
 type messageAdapter struct{}
 
+// streamSeqSql numbers the deliveries the streams resume from, a todo following
+// a new event is delivered again.
+const streamSeqSql = `
+create sequence if not exists message_center.message_stream_seq;
+
+alter table message_center.follow_message add column if not exists
+    stream_seq bigint not null default nextval('message_center.message_stream_seq');
+alter table message_center.related_message add column if not exists
+    stream_seq bigint not null default nextval('message_center.message_stream_seq');
+alter table message_center.todo_message add column if not exists
+    stream_seq bigint not null default nextval('message_center.message_stream_seq');
+
+create index if not exists follow_message_stream_seq
+    on message_center.follow_message (recipient_id, stream_seq);
+create index if not exists related_message_stream_seq
+    on message_center.related_message (recipient_id, stream_seq);
+create index if not exists todo_message_stream_seq
+    on message_center.todo_message (recipient_id, stream_seq);
+
+create or replace function message_center.next_stream_seq() returns trigger as $$
+begin
+    new.stream_seq := nextval('message_center.message_stream_seq');
+    return new;
+end;
+$$ language plpgsql;
+
+create or replace trigger todo_message_stream_seq
+    before update of latest_event_id on message_center.todo_message
+    for each row when (new.latest_event_id is distinct from old.latest_event_id)
+    execute function message_center.next_stream_seq();
+`
+
+// Migration numbers the deliveries for the streams.
+func (s *messageAdapter) Migration() postgresql.Migration {
+	return postgresql.Migration{Version: "message_stream_seq", Sql: streamSeqSql}
+}
+
 func (s *messageAdapter) CountAllUnReadMessage(userName string) ([]CountDAO, error) {
 	var CountData []CountDAO
-	query := `SELECT source, SUM(count) AS count
-FROM (
-    SELECT cem.source, COUNT(*) AS count
-    FROM message_center.cloud_event_message cem
-    JOIN message_center.follow_message fm ON cem.event_id = fm.event_id
-    JOIN message_center.recipient_config rc ON fm.recipient_id = rc.id
-    WHERE fm.is_read = false
-      AND fm.is_deleted = false
-      AND rc.user_id = ?  -- 替换为实际的用户 ID
-    GROUP BY cem.source
-
-    UNION ALL
-
-    SELECT cem.source, COUNT(*) AS count
-    FROM message_center.cloud_event_message cem
-    JOIN message_center.related_message rm ON cem.event_id = rm.event_id
-    JOIN message_center.recipient_config rc ON rm.recipient_id = rc.id
-    WHERE rm.is_read = false
-      AND rm.is_deleted = false
-      AND rc.user_id = ?  -- 替换为实际的用户 ID
-    GROUP BY cem.source
-
-    UNION ALL
-
-    SELECT cem.source, COUNT(*) AS count
-    FROM message_center.cloud_event_message cem
-    JOIN message_center.todo_message tm ON tm.latest_event_id = cem.event_id
-    JOIN message_center.recipient_config rc ON tm.recipient_id = rc.id
-    WHERE tm.is_read = false
-      AND tm.is_deleted = false
-      AND rc.user_id = ?  -- 替换为实际的用户 ID
-    GROUP BY cem.source
-) AS unread_counts
-GROUP BY source`
-	if result := postgresql.DB().Raw(query, userName, userName, userName).
+	query := `SELECT uc.source, SUM(uc.unread_count) AS count
+FROM message_center.unread_counter uc
+JOIN message_center.recipient_config rc ON uc.recipient_id = rc.id
+WHERE rc.user_id = ?
+GROUP BY uc.source
+HAVING SUM(uc.unread_count) > 0`
+	if result := postgresql.DB().Raw(query, userName).
 		Scan(&CountData); result.Error != nil {
 		return []CountDAO{}, xerrors.Errorf("get count failed, err:%v", result.Error)
 	}
@@ -163,8 +173,76 @@ func filterFollowSql(query *string, isRead *bool, startTime string) {
 	}
 }
 
-func (s *messageAdapter) GetAllToDoMessage(userName string, giteeUsername string, isDone *bool,
-	pageNum, countPerPage int, startTime string, isRead *bool) ([]MessageListDAO, int64, error) {
+// windowCountSql is the column carrying the total count of a message list.
+const windowCountSql = `count(*) over () as total_count`
+
+// listKey is the order of a message list, by column of the table alias and
+// then by event_id.
+type listKey struct {
+	alias  string
+	column string
+	asc    bool
+}
+
+var (
+	byUpdate        = listKey{column: "updated_at"}
+	byMessageUpdate = listKey{alias: "cem.", column: "updated_at"}
+	byMessageTime   = listKey{alias: "cem.", column: "time"}
+	// the upcoming events are listed by the time they start
+	byTime = listKey{column: "time", asc: true}
+)
+
+// pageSql pages query by offset, or by keyset after a non-empty cursor, and
+// returns the paging args and the query counting the list.
+func pageSql(query *string, key listKey, pageNum, countPerPage int, cursor string) (
+	[]interface{}, string, error) {
+	order, cmp := " desc", "<"
+	if key.asc {
+		order, cmp = "", ">"
+	}
+	column, eventId := key.alias+key.column, key.alias+"event_id"
+	orderSql := ` order by ` + column + order + `, ` + eventId + order + ` limit ?`
+	if cursor == "" {
+		*query += orderSql + ` offset ?`
+		return []interface{}{countPerPage, (pageNum - 1) * countPerPage}, "", nil
+	}
+	value, id, err := utils.DecodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	countSql := cursorCountSql(query)
+	*query += ` and (` + column + `, ` + eventId + `) ` + cmp + ` (?, ?)` + orderSql
+	return []interface{}{value, id, countPerPage}, countSql, nil
+}
+
+// cursorCountSql drops the window count of a keyset page, which only covers
+// the rest of the list, and returns the query counting the whole list.
+func cursorCountSql(query *string) string {
+	countSql := `select count(*) from (` + *query + `) as counted`
+	*query = strings.Replace(*query, windowCountSql, `0 as total_count`, 1)
+	return countSql
+}
+
+// listTotal returns the total count of a list, carried by its rows unless
+// countSql counts it.
+func listTotal(response []MessageListDAO, countSql string, args []interface{}) (int64,
+	error) {
+	var totalCount int64
+	if countSql != "" {
+		if result := postgresql.DB().Raw(countSql, args...).Scan(&totalCount); result.Error != nil {
+			return 0, xerrors.Errorf("查询总数失败, err:%v", result.Error)
+		}
+		return totalCount, nil
+	}
+	if len(response) != 0 {
+		totalCount = response[0].TotalCount
+	}
+	return totalCount, nil
+}
+
+func (s *messageAdapter) GetAllToDoMessage(userName string, isDone *bool,
+	pageNum, countPerPage int, startTime string, isRead *bool, cursor string) ([]MessageListDAO,
+	int64, error) {
 	query := `with latest_messages as (
     select 
         cem.*,
@@ -180,47 +258,48 @@ func (s *messageAdapter) GetAllToDoMessage(userName string, giteeUsername string
     where
         tm.is_deleted = false
         and rc.is_deleted = false
-        and ((rc.gitee_user_name != '' and rc.gitee_user_name = ?) OR rc.user_id = ?)
-        and cem.type <> 'meeting'
+        and rc.user_id = ?
+        and cem.source <> all(?::text[])
 	)
 	select *, count(*) over () as total_count
 	from latest_messages
 	where rn = 1`
 	filterTodoSql(&query, isDone, isRead, startTime)
-	query += ` order by updated_at desc limit ? offset ?`
+	pageArgs, countSql, err := pageSql(&query, byUpdate, pageNum, countPerPage, cursor)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
+	}
 
-	offset := (pageNum - 1) * countPerPage
 	var response []MessageListDAO
-	if result := postgresql.DB().Debug().Raw(query, giteeUsername, userName,
-		countPerPage, offset).Scan(&response); result.Error != nil {
+	args := []interface{}{userName, scheduleSources()}
+	if result := postgresql.DB().Debug().Raw(query, append(args, pageArgs...)...).
+		Scan(&response); result.Error != nil {
 		return []MessageListDAO{}, 0, xerrors.Errorf("get todo message failed, err:%v",
 			result.Error)
 	}
-	var totalCount int64
-	if len(response) != 0 {
-		totalCount = response[0].TotalCount
+	totalCount, err := listTotal(response, countSql, args)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
 	}
 	return response, totalCount, nil
 }
 
-func (s *messageAdapter) GetAllAboutMessage(userName string, giteeUsername string, isBot *bool,
-	pageNum, countPerPage int, startTime string, isRead *bool) ([]MessageListDAO, int64, error) {
+func (s *messageAdapter) GetAllAboutMessage(userName string, isBot *bool,
+	pageNum, countPerPage int, startTime string, isRead *bool, cursor string) ([]MessageListDAO,
+	int64, error) {
 	query := `select cem.*, rm.is_read, count(*) over () as total_count from cloud_event_message cem
 		join message_center.related_message rm on cem.event_id = rm.event_id
 		join message_center.recipient_config rc on rm.recipient_id = rc.id
 		where rm.is_deleted = false
 		and rc.is_deleted = false
 		and (
-		     (cem.type = 'note' and ((rc.gitee_user_name != '' and rc.gitee_user_name = ?) or rc.user_id = ?)`
+		     (cem.type = 'note' and rc.user_id = ?`
+	args := []interface{}{userName}
 	if isBot != nil {
-		if *isBot {
-			query += ` and cem."user" IN ('openeuler-ci-bot','ci-robot','openeuler-sync-bot')`
-		} else {
-			query += ` and cem."user" NOT IN ('openeuler-ci-bot','ci-robot','openeuler-sync-bot')`
-		}
+		args = append(args, filterBotSql(&query, *isBot)...)
 	}
 	query += `)`
-	query += `or (cem.source = 'forum' and rc.user_id = ?`
+	query += `or (cem.source = ? and rc.user_id = ?`
 	if isBot != nil {
 		if *isBot {
 			query += ` and cem.data_json #>> '{Data, OriginalUsername}' = 'system'`
@@ -230,28 +309,32 @@ func (s *messageAdapter) GetAllAboutMessage(userName string, giteeUsername strin
 	}
 	query += `))`
 	filterAboutSql(&query, isRead, startTime)
-	query += ` order by updated_at desc limit ? offset ?`
-	offset := (pageNum - 1) * countPerPage
+	pageArgs, countSql, err := pageSql(&query, byMessageUpdate, pageNum, countPerPage, cursor)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
+	}
 	var response []MessageListDAO
-	if result := postgresql.DB().Raw(query, giteeUsername, userName, userName, countPerPage,
-		offset).Scan(&response); result.Error != nil {
+	args = append(args, source.Url(source.IdForum), userName)
+	if result := postgresql.DB().Raw(query, append(args, pageArgs...)...).
+		Scan(&response); result.Error != nil {
 		return []MessageListDAO{}, 0, xerrors.Errorf("get about message failed, err:%v",
 			result.Error)
 	}
-	var totalCount int64
-	if len(response) != 0 {
-		totalCount = response[0].TotalCount
+	totalCount, err := listTotal(response, countSql, args)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
 	}
 	return response, totalCount, nil
 }
 
-func (s *messageAdapter) GetAllWatchMessage(userName string, giteeUsername string, pageNum,
-	countPerPage int, startTime string, isRead *bool) ([]MessageListDAO, int64, error) {
+func (s *messageAdapter) GetAllWatchMessage(userName string, pageNum,
+	countPerPage int, startTime string, isRead *bool, cursor string) ([]MessageListDAO, int64,
+	error) {
 	query := `
 	with filtered_recipient as (
         select *
         from recipient_config
-        where not is_deleted and (user_id = ? or gitee_user_name = ?)
+        where not is_deleted and user_id = ?
 	),
 	filtered_messages as (
 	    select fm.is_read, cem.*
@@ -264,24 +347,28 @@ func (s *messageAdapter) GetAllWatchMessage(userName string, giteeUsername strin
 	from filtered_messages 
 	where true`
 	filterFollowSql(&query, isRead, startTime)
-	query += ` order by updated_at desc limit ? offset ?`
+	pageArgs, countSql, err := pageSql(&query, byUpdate, pageNum, countPerPage, cursor)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
+	}
 
-	offset := (pageNum - 1) * countPerPage
 	var response []MessageListDAO
-	if result := postgresql.DB().Debug().Raw(query, userName, giteeUsername, countPerPage,
-		offset).Scan(&response); result.Error != nil {
+	args := []interface{}{userName}
+	if result := postgresql.DB().Debug().Raw(query, append(args, pageArgs...)...).
+		Scan(&response); result.Error != nil {
 		logrus.Errorf("get watch message failed, err:%v", result.Error)
 		return []MessageListDAO{}, 0, xerrors.Errorf("get watch message failed, err:%v", result.Error)
 	}
-	var totalCount int64
-	if len(response) != 0 {
-		totalCount = response[0].TotalCount
+	totalCount, err := listTotal(response, countSql, args)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
 	}
 	return response, totalCount, nil
 }
 
 func (s *messageAdapter) GetForumSystemMessage(userName string, pageNum,
-	countPerPage int, startTime string, isRead *bool) ([]MessageListDAO, int64, error) {
+	countPerPage int, startTime string, isRead *bool, cursor string) ([]MessageListDAO,
+	int64, error) {
 
 	query := `with filtered_recipient as (
     select *
@@ -297,32 +384,37 @@ func (s *messageAdapter) GetForumSystemMessage(userName string, pageNum,
 	)
 	select *, count(*) over () as total_count
 	from filtered_messages
-	where source = 'forum'`
+	where source = ?`
 	filterFollowSql(&query, isRead, startTime)
 
-	query += ` order by updated_at desc limit ? offset ?`
-	offset := (pageNum - 1) * countPerPage
+	pageArgs, countSql, err := pageSql(&query, byUpdate, pageNum, countPerPage, cursor)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
+	}
+
 	var response []MessageListDAO
-	if result := postgresql.DB().Raw(query, userName, countPerPage, offset).
+	args := []interface{}{userName, source.Url(source.IdForum)}
+	if result := postgresql.DB().Raw(query, append(args, pageArgs...)...).
 		Scan(&response); result.Error != nil {
 		return []MessageListDAO{}, 0, xerrors.Errorf("查询失败, err:%v",
 			result.Error)
 	}
-	var totalCount int64
-	if len(response) != 0 {
-		totalCount = response[0].TotalCount
+	totalCount, err := listTotal(response, countSql, args)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
 	}
 	return response, totalCount, nil
 }
 
 func (s *messageAdapter) GetForumAboutMessage(userName string, isBot *bool, pageNum,
-	countPerPage int, startTime string, isRead *bool) ([]MessageListDAO, int64, error) {
+	countPerPage int, startTime string, isRead *bool, cursor string) ([]MessageListDAO,
+	int64, error) {
 	query := `select cem.*, rm.is_read, count(*) over () as total_count
 		from related_message rm
 		join cloud_event_message cem on cem.event_id = rm.event_id
 		join recipient_config rc on rc.id = rm.recipient_id
 		where rm.is_deleted = false and rc.is_deleted = false
-		and cem.source = 'forum' and rc.user_id = ?`
+		and cem.source = ? and rc.user_id = ?`
 	if isBot != nil {
 		if *isBot {
 			query += ` and cem.data_json #>> '{Data, OriginalUsername}' = 'system'`
@@ -331,25 +423,30 @@ func (s *messageAdapter) GetForumAboutMessage(userName string, isBot *bool, page
 		}
 	}
 	filterAboutSql(&query, isRead, startTime)
-	query += ` order by time desc limit ? offset ?`
 
-	offset := (pageNum - 1) * countPerPage
+	pageArgs, countSql, err := pageSql(&query, byMessageTime, pageNum, countPerPage, cursor)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
+	}
+
 	var response []MessageListDAO
-	if result := postgresql.DB().Raw(query, userName, countPerPage, offset).
+	args := []interface{}{source.Url(source.IdForum), userName}
+	if result := postgresql.DB().Raw(query, append(args, pageArgs...)...).
 		Scan(&response); result.Error != nil {
 		logrus.Errorf("get message failed, err:%v", result.Error.Error())
 		return []MessageListDAO{}, 0, xerrors.Errorf("查询失败, err:%v",
 			result.Error)
 	}
-	var totalCount int64
-	if len(response) != 0 {
-		totalCount = response[0].TotalCount
+	totalCount, err := listTotal(response, countSql, args)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
 	}
 	return response, totalCount, nil
 }
 
 func (s *messageAdapter) GetMeetingToDoMessage(username string, filter int,
-	pageNum, countPerPage int, startTime string, isRead *bool) ([]MessageListDAO, int64, error) {
+	pageNum, countPerPage int, startTime string, isRead *bool, cursor string) ([]MessageListDAO,
+	int64, error) {
 	query := `select a.*, count(*) over () as total_count
 		from (
 		    select distinct on (tm.business_id, tm.recipient_id) tm.is_read, cem.*
@@ -358,8 +455,8 @@ func (s *messageAdapter) GetMeetingToDoMessage(username string, filter int,
 		    join recipient_config rc ON rc.id = tm.recipient_id
 		    where rc.is_deleted = false
 		    and tm.is_deleted = false
-		    and cem.type = 'meeting'
-		    and (rc.gitee_user_name != '' and rc.gitee_user_name = ?)
+		    and cem.source = any(?::text[])
+		    and ` + linkedLogin("rc", "?") + `
 		    order by tm.business_id, tm.recipient_id, cem.updated_at desc
 		) as a where true`
 
@@ -369,69 +466,66 @@ func (s *messageAdapter) GetMeetingToDoMessage(username string, filter int,
 		query += ` and NOW() > time`
 	}
 	filterMeetingTodoSql(&query, nil, isRead, startTime)
-	query += ` order by time limit ? offset ?`
-	giteeUsername, err := user.GetThirdUserName(username)
+	pageArgs, countSql, err := pageSql(&query, byTime, pageNum, countPerPage, cursor)
 	if err != nil {
-		return []MessageListDAO{}, 0, xerrors.Errorf("查询失败, err:%v",
-			xerrors.Errorf("get gitee username failed, err:%v", err))
+		return []MessageListDAO{}, 0, err
 	}
 
-	offset := (pageNum - 1) * countPerPage
 	var response []MessageListDAO
-	if result := postgresql.DB().Raw(query, giteeUsername, countPerPage, offset).
+	args := []interface{}{scheduleSources(), username}
+	if result := postgresql.DB().Raw(query, append(args, pageArgs...)...).
 		Scan(&response); result.Error != nil {
 		logrus.Errorf("get message failed, err:%v", result.Error.Error())
 		return []MessageListDAO{}, 0, xerrors.Errorf("查询失败, err:%v",
 			result.Error)
 	}
-	var totalCount int64
-	if len(response) != 0 {
-		totalCount = response[0].TotalCount
+	totalCount, err := listTotal(response, countSql, args)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
 	}
 	return response, totalCount, nil
 }
 
-func (s *messageAdapter) GetCVEToDoMessage(userName, giteeUsername string, isDone *bool, pageNum,
-	countPerPage int, startTime string, isRead *bool) ([]MessageListDAO, int64, error) {
-	if giteeUsername == "" {
-		return []MessageListDAO{}, 0, nil
-	}
+func (s *messageAdapter) GetCVEToDoMessage(userName string, isDone *bool, pageNum,
+	countPerPage int, startTime string, isRead *bool, cursor string) ([]MessageListDAO,
+	int64, error) {
 	query := `select *, count(*) over () as total_count from (
     	select distinct on (tm.business_id, tm.recipient_id) cem.*, 
         	tm.is_read, tm.is_done from todo_message tm
 		join cloud_event_message cem on cem.event_id = tm.latest_event_id
 		join recipient_config rc on rc.id = tm.recipient_id
 		where rc.is_deleted = false and tm.is_deleted = false
-		and cem.source = 'cve'
-		and ((rc.gitee_user_name != '' and rc.gitee_user_name = ?) or rc.user_id = ?)
+		and cem.source = ?
+		and rc.user_id = ?
 		order by tm.business_id, tm.recipient_id, cem.updated_at desc) a where true`
 	filterTodoSql(&query, isDone, isRead, startTime)
-	query += ` order by updated_at desc limit ? offset ?`
 
-	offset := (pageNum - 1) * countPerPage
+	pageArgs, countSql, err := pageSql(&query, byUpdate, pageNum, countPerPage, cursor)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
+	}
+
 	var response []MessageListDAO
-	if result := postgresql.DB().Raw(query, giteeUsername, userName, countPerPage, offset).
+	args := []interface{}{source.Url(source.IdCve), userName}
+	if result := postgresql.DB().Raw(query, append(args, pageArgs...)...).
 		Scan(&response); result.Error != nil {
 		logrus.Errorf("get message failed, err:%v", result.Error.Error())
 		return []MessageListDAO{}, 0, xerrors.Errorf("查询失败, err:%v",
 			result.Error)
 	}
-	var totalCount int64
-	if len(response) != 0 {
-		totalCount = response[0].TotalCount
+	totalCount, err := listTotal(response, countSql, args)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
 	}
 	return response, totalCount, nil
 }
 
-func (s *messageAdapter) GetCVEMessage(userName, giteeUsername string, pageNum, countPerPage int,
-	startTime string, isRead *bool) ([]MessageListDAO, int64, error) {
-	if giteeUsername == "" {
-		return []MessageListDAO{}, 0, nil
-	}
+func (s *messageAdapter) GetCVEMessage(userName string, pageNum, countPerPage int,
+	startTime string, isRead *bool, cursor string) ([]MessageListDAO, int64, error) {
 	query := `with filtered_recipient as (
     select *
     from recipient_config
-    where not is_deleted and ((gitee_user_name != '' and gitee_user_name = ?) or user_id = ?)
+    where not is_deleted and user_id = ?
 	),
 	filtered_messages as (
 	    select fm.is_read, cem.*
@@ -442,30 +536,32 @@ func (s *messageAdapter) GetCVEMessage(userName, giteeUsername string, pageNum,
 	)
 	select *, count(*) over () as total_count
 	from filtered_messages
-	where source = 'cve'`
+	where source = ?`
 	filterFollowSql(&query, isRead, startTime)
 
-	query += ` order by updated_at desc limit ? offset ?`
-	offset := (pageNum - 1) * countPerPage
+	pageArgs, countSql, err := pageSql(&query, byUpdate, pageNum, countPerPage, cursor)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
+	}
+
 	var response []MessageListDAO
-	if result := postgresql.DB().Raw(query, giteeUsername, userName, countPerPage, offset).
+	args := []interface{}{userName, source.Url(source.IdCve)}
+	if result := postgresql.DB().Raw(query, append(args, pageArgs...)...).
 		Scan(&response); result.Error != nil {
 		logrus.Errorf("get message failed, err:%v", result.Error.Error())
 		return []MessageListDAO{}, 0, xerrors.Errorf("查询失败, err:%v",
 			result.Error)
 	}
-	var totalCount int64
-	if len(response) != 0 {
-		totalCount = response[0].TotalCount
+	totalCount, err := listTotal(response, countSql, args)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
 	}
 	return response, totalCount, nil
 }
 
-func (s *messageAdapter) GetIssueToDoMessage(userName, giteeUsername string, isDone *bool,
-	pageNum, countPerPage int, startTime string, isRead *bool) ([]MessageListDAO, int64, error) {
-	if giteeUsername == "" {
-		return []MessageListDAO{}, 0, nil
-	}
+func (s *messageAdapter) GetIssueToDoMessage(userName string, isDone *bool,
+	pageNum, countPerPage int, startTime string, isRead *bool, cursor string) ([]MessageListDAO,
+	int64, error) {
 	query := `select *, count(*) over () as total_count from
         (
 		select DISTINCT ON (tm.business_id, tm.recipient_id) cem.*, 
@@ -473,33 +569,35 @@ func (s *messageAdapter) GetIssueToDoMessage(userName, giteeUsername string, isD
 		join cloud_event_message cem on cem.event_id = latest_event_id
 		join recipient_config rc on rc.id = tm.recipient_id
 		where tm.is_deleted = false and rc.is_deleted = false
-		and cem.type = 'issue' and cem.source = 'https://gitee.com'
-		and ((rc.gitee_user_name != '' and rc.gitee_user_name = ?) or rc.user_id = ?)
+		and cem.type = 'issue' and cem.source = ?
+		and rc.user_id = ?
 		order by tm.business_id, tm.recipient_id, cem.updated_at desc) a where true`
 
 	filterTodoSql(&query, isDone, isRead, startTime)
-	query += ` order by updated_at desc limit ? offset ?`
 
-	offset := (pageNum - 1) * countPerPage
+	pageArgs, countSql, err := pageSql(&query, byUpdate, pageNum, countPerPage, cursor)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
+	}
+
 	var response []MessageListDAO
-	if result := postgresql.DB().Raw(query, giteeUsername, userName, countPerPage, offset).
+	args := []interface{}{source.Url(source.IdGitee), userName}
+	if result := postgresql.DB().Raw(query, append(args, pageArgs...)...).
 		Scan(&response); result.Error != nil {
 		logrus.Errorf("get message failed, err:%v", result.Error.Error())
 		return []MessageListDAO{}, 0, xerrors.Errorf("查询失败, err:%v",
 			result.Error)
 	}
-	var totalCount int64
-	if len(response) != 0 {
-		totalCount = response[0].TotalCount
+	totalCount, err := listTotal(response, countSql, args)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
 	}
 	return response, totalCount, nil
 }
 
-func (s *messageAdapter) GetPullRequestToDoMessage(userName, giteeUsername string, isDone *bool,
-	pageNum, countPerPage int, startTime string, isRead *bool) ([]MessageListDAO, int64, error) {
-	if giteeUsername == "" {
-		return []MessageListDAO{}, 0, nil
-	}
+func (s *messageAdapter) GetPullRequestToDoMessage(userName string, isDone *bool,
+	pageNum, countPerPage int, startTime string, isRead *bool, cursor string) ([]MessageListDAO,
+	int64, error) {
 	query := `select *, count(*) over () as total_count from
         (
 		select DISTINCT ON (tm.business_id, tm.recipient_id) cem.*, 
@@ -507,72 +605,74 @@ func (s *messageAdapter) GetPullRequestToDoMessage(userName, giteeUsername strin
 		join cloud_event_message cem on cem.event_id = latest_event_id
 		join recipient_config rc on rc.id = tm.recipient_id
 		where tm.is_deleted = false and rc.is_deleted = false
-		and cem.type = 'pr' and ((rc.gitee_user_name != '' and rc.gitee_user_name = ?) or rc.user_id = ?)
+		and cem.type = 'pr' and rc.user_id = ?
 		order by tm.business_id, tm.recipient_id, cem.updated_at desc) a where true`
 
 	filterTodoSql(&query, isDone, isRead, startTime)
 
-	query += ` order by updated_at desc limit ? offset ?`
+	pageArgs, countSql, err := pageSql(&query, byUpdate, pageNum, countPerPage, cursor)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
+	}
 
-	offset := (pageNum - 1) * countPerPage
 	var response []MessageListDAO
-	if result := postgresql.DB().Raw(query, giteeUsername, userName, countPerPage, offset).
+	args := []interface{}{userName}
+	if result := postgresql.DB().Raw(query, append(args, pageArgs...)...).
 		Scan(&response); result.Error != nil {
 		logrus.Errorf("get message failed, err:%v", result.Error.Error())
 		return []MessageListDAO{}, 0, xerrors.Errorf("查询失败, err:%v",
 			result.Error)
 	}
-	var totalCount int64
-	if len(response) != 0 {
-		totalCount = response[0].TotalCount
+	totalCount, err := listTotal(response, countSql, args)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
 	}
 	return response, totalCount, nil
 }
 
-func (s *messageAdapter) GetGiteeAboutMessage(userName, giteeUsername string, isBot *bool,
-	pageNum, countPerPage int, startTime string, isRead *bool) ([]MessageListDAO, int64, error) {
-	if giteeUsername == "" {
-		return []MessageListDAO{}, 0, nil
-	}
+func (s *messageAdapter) GetGiteeAboutMessage(userName string, isBot *bool,
+	pageNum, countPerPage int, startTime string, isRead *bool, cursor string) ([]MessageListDAO,
+	int64, error) {
 	query := `select cem.*, rm.is_read, count(*) over () as total_count
 		from cloud_event_message cem
 			join message_center.related_message rm on cem.event_id = rm.event_id
 			join message_center.recipient_config rc on rm.recipient_id = rc.id
 		where cem.type = 'note'
-		and cem.source = 'https://gitee.com'
+		and cem.source = ?
 		and rm.is_deleted = false and rc.is_deleted = false
-		and ((rc.gitee_user_name != '' and rc.gitee_user_name = ?) or rc.user_id = ?)`
+		and rc.user_id = ?`
+	args := []interface{}{source.Url(source.IdGitee), userName}
 	if isBot != nil {
-		if *isBot {
-			query += ` and cem."user" IN ('openeuler-ci-bot','ci-robot','openeuler-sync-bot') `
-		} else {
-			query += ` and cem."user" NOT IN ('openeuler-ci-bot','ci-robot','openeuler-sync-bot') `
-		}
+		args = append(args, filterBotSql(&query, *isBot)...)
 	}
 	filterAboutSql(&query, isRead, startTime)
-	query += ` order by cem.updated_at desc limit ? offset ?`
 
-	offset := (pageNum - 1) * countPerPage
+	pageArgs, countSql, err := pageSql(&query, byMessageUpdate, pageNum, countPerPage, cursor)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
+	}
+
 	var response []MessageListDAO
-	if result := postgresql.DB().Raw(query, giteeUsername, userName, countPerPage, offset).
+	if result := postgresql.DB().Raw(query, append(args, pageArgs...)...).
 		Scan(&response); result.Error != nil {
 		logrus.Errorf("get message failed, err:%v", result.Error.Error())
 		return []MessageListDAO{}, 0, xerrors.Errorf("查询失败, err:%v",
 			result.Error)
 	}
-	var totalCount int64
-	if len(response) != 0 {
-		totalCount = response[0].TotalCount
+	totalCount, err := listTotal(response, countSql, args)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
 	}
 	return response, totalCount, nil
 }
 
-func (s *messageAdapter) GetGiteeMessage(userName, giteeUsername string, pageNum,
-	countPerPage int, startTime string, isRead *bool) ([]MessageListDAO, int64, error) {
+func (s *messageAdapter) GetGiteeMessage(userName string, pageNum,
+	countPerPage int, startTime string, isRead *bool, cursor string) ([]MessageListDAO,
+	int64, error) {
 	query := `with filtered_recipient as (
     select *
     from recipient_config
-    where not is_deleted and ((gitee_user_name != '' and gitee_user_name = ?) or user_id = ?)
+    where not is_deleted and user_id = ?
 	),
 	filtered_messages as (
 	    select fm.is_read, cem.*
@@ -583,27 +683,32 @@ func (s *messageAdapter) GetGiteeMessage(userName, giteeUsername string, pageNum
 	)
 	select *, count(*) over () as total_count
 	from filtered_messages
-	where source = 'https://gitee.com'`
+	where source = ?`
 	filterFollowSql(&query, isRead, startTime)
-	query += ` order by updated_at desc limit ? offset ?`
 
-	offset := (pageNum - 1) * countPerPage
+	pageArgs, countSql, err := pageSql(&query, byUpdate, pageNum, countPerPage, cursor)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
+	}
+
 	var response []MessageListDAO
-	if result := postgresql.DB().Raw(query, giteeUsername, userName, countPerPage, offset).
+	args := []interface{}{userName, source.Url(source.IdGitee)}
+	if result := postgresql.DB().Raw(query, append(args, pageArgs...)...).
 		Scan(&response); result.Error != nil {
 		logrus.Errorf("get message failed, err:%v", result.Error.Error())
 		return []MessageListDAO{}, 0, xerrors.Errorf("查询失败, err:%v",
 			result.Error)
 	}
-	var totalCount int64
-	if len(response) != 0 {
-		totalCount = response[0].TotalCount
+	totalCount, err := listTotal(response, countSql, args)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
 	}
 	return response, totalCount, nil
 }
 
 func (s *messageAdapter) GetEurMessage(userName string, pageNum,
-	countPerPage int, startTime string, isRead *bool) ([]MessageListDAO, int64, error) {
+	countPerPage int, startTime string, isRead *bool, cursor string) ([]MessageListDAO,
+	int64, error) {
 	query := `with filtered_recipient as (
     select *
     from recipient_config
@@ -618,123 +723,282 @@ func (s *messageAdapter) GetEurMessage(userName string, pageNum,
 	)
 	select *, count(*) over () as total_count
 	from filtered_messages
-	where source = 'https://eur.openeuler.openatom.cn'`
+	where source = ?`
 	filterFollowSql(&query, isRead, startTime)
-	query += ` order by updated_at desc limit ? offset ?`
 
-	offset := (pageNum - 1) * countPerPage
+	pageArgs, countSql, err := pageSql(&query, byUpdate, pageNum, countPerPage, cursor)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
+	}
+
 	var response []MessageListDAO
-	if result := postgresql.DB().Raw(query, userName, countPerPage, offset).
+	args := []interface{}{userName, source.Url(source.IdEur)}
+	if result := postgresql.DB().Raw(query, append(args, pageArgs...)...).
 		Scan(&response); result.Error != nil {
 		return []MessageListDAO{}, 0, xerrors.Errorf("get message failed, err:%v",
 			result.Error)
 	}
-	var totalCount int64
-	if len(response) != 0 {
-		totalCount = response[0].TotalCount
+	totalCount, err := listTotal(response, countSql, args)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
 	}
 	return response, totalCount, nil
 }
 
-func (s *messageAdapter) CountAllMessage(userName string, giteeUserName string) (CountDataDAO, error) {
+// sourceMessageSql selects the messages of a category, a message followed by
+// several recipients of the user is listed once.
+var sourceMessageSql = map[string]string{
+	source.CategoryFollow: `select distinct on (cem.event_id) m.is_read, cem.*
+		from follow_message m
+		join cloud_event_message cem on cem.event_id = m.event_id
+		join recipient_config rc on rc.id = m.recipient_id
+		where not m.is_deleted and not rc.is_deleted`,
+	source.CategoryRelated: `select distinct on (cem.event_id) m.is_read, cem.*
+		from related_message m
+		join cloud_event_message cem on cem.event_id = m.event_id
+		join recipient_config rc on rc.id = m.recipient_id
+		where not m.is_deleted and not rc.is_deleted`,
+	source.CategoryTodo: `select distinct on (m.business_id, m.recipient_id) cem.*, m.is_read,
+			m.is_done
+		from todo_message m
+		join cloud_event_message cem on cem.event_id = m.latest_event_id
+		join recipient_config rc on rc.id = m.recipient_id
+		where not m.is_deleted and not rc.is_deleted`,
+}
 
-	response := CountDataDAO{}
-	query := `
-WITH params AS (SELECT ? AS user_id, ? AS gitee_user_name)
-SELECT (SELECT count(*)
-        FROM message_center.follow_message fm
-                 JOIN recipient_config rc ON fm.recipient_id = rc.id
-        WHERE (rc.user_id = params.user_id
-            OR (rc.gitee_user_name != '' and rc.gitee_user_name = params.gitee_user_name))
-          AND rc.is_deleted IS false
-          AND fm.is_deleted IS false
-          AND fm.is_read IS false
-          AND fm.source in ('forum', 'https://eur.openeuler.openatom.cn', 'cve', 'https://gitee.com'))
-		AS watch_count,
+// GetSourceMessage lists the messages of any registered source and category
+// with one query.
+func (s *messageAdapter) GetSourceMessage(cmd CmdToGetSourceMessage) ([]MessageListDAO, int64,
+	error) {
+	query, ok := sourceMessageSql[cmd.Category]
+	if !ok {
+		return []MessageListDAO{}, 0, xerrors.Errorf("未知的消息分类 %s", cmd.Category)
+	}
+	query += `
+		and rc.user_id = ?
+		and cem.source = ?`
+	args := []interface{}{cmd.UserName, cmd.Source}
+	if cmd.EventType != "" {
+		query += ` and cem.type = ?`
+		args = append(args, cmd.EventType)
+	}
+	if cmd.Category == source.CategoryTodo {
+		query += ` order by m.business_id, m.recipient_id, cem.updated_at desc`
+	} else {
+		query += ` order by cem.event_id, m.is_read`
+	}
+
+	query = `select *, count(*) over () as total_count from (` + query + `) a where true`
+	if cmd.Category == source.CategoryTodo {
+		filterTodoSql(&query, cmd.IsDone, cmd.IsRead, cmd.StartTime)
+		if cmd.Schedule {
+			// schedule todos leave the list once they start
+			query += ` and time >= current_timestamp`
+		}
+	} else {
+		filterFollowSql(&query, cmd.IsRead, cmd.StartTime)
+	}
+	pageArgs, countSql, err := pageSql(&query, byUpdate, cmd.PageNum, cmd.CountPerPage,
+		cmd.Cursor)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
+	}
 
-       (SELECT count(*)
-        FROM message_center.related_message rm
-                 JOIN recipient_config rc ON rm.recipient_id = rc.id
-        WHERE (rc.user_id = params.user_id
-            OR (rc.gitee_user_name != '' and rc.gitee_user_name = params.gitee_user_name))
-          AND rc.is_deleted IS false
-          AND rm.is_deleted IS false
-          AND rm.is_read IS false
-          AND rm.source in ('forum', 'https://gitee.com')) AS about_count,
+	var response []MessageListDAO
+	if result := postgresql.DB().Raw(query, append(args, pageArgs...)...).
+		Scan(&response); result.Error != nil {
+		logrus.Errorf("get message failed, err:%v", result.Error.Error())
+		return []MessageListDAO{}, 0, xerrors.Errorf("查询失败, err:%v", result.Error)
+	}
+	totalCount, err := listTotal(response, countSql, args)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
+	}
+	return response, totalCount, nil
+}
+
+func (s *messageAdapter) CountAllMessage(userName string) (CountDataDAO, error) {
 
+	response := CountDataDAO{}
+	query := `
+WITH params AS (SELECT ? AS user_id),
+     counters AS (SELECT uc.*
+                  FROM message_center.unread_counter uc
+                           JOIN recipient_config rc ON uc.recipient_id = rc.id,
+                       params
+                  WHERE rc.user_id = params.user_id
+                    AND rc.is_deleted IS false)
+SELECT (SELECT coalesce(sum(unread_count), 0)
+        FROM counters
+        WHERE category = 'follow'
+          AND source = any(?::text[])) AS watch_count,
+
+       (SELECT coalesce(sum(unread_count), 0)
+        FROM counters
+        WHERE category = 'related'
+          AND source = any(?::text[])) AS about_count,
+
+       -- meetings leave the todo list once they start, so they are counted on the fly
        (SELECT count(*)
         FROM message_center.todo_message tm
                  JOIN recipient_config rc ON tm.recipient_id = rc.id
                  JOIN cloud_event_message cem ON tm.latest_event_id = cem.event_id
-        WHERE (rc.gitee_user_name != '' and rc.gitee_user_name = params.gitee_user_name)
+        WHERE ` + linkedLogin("rc", "params.user_id") + `
           AND rc.is_deleted IS false
           AND tm.is_deleted IS false
           AND tm.is_done IS false
-          AND tm.source = 'https://www.openEuler.org/meeting'
+          AND tm.source = any(?::text[])
           AND cem.time >= current_timestamp) AS meeting_count,
 
-       (SELECT count(*)
-        FROM message_center.todo_message tm
-                 JOIN recipient_config rc ON tm.recipient_id = rc.id
-        WHERE (rc.user_id = params.user_id
-            OR (rc.gitee_user_name != '' and rc.gitee_user_name = params.gitee_user_name))
-          AND rc.is_deleted IS false
-          AND tm.is_deleted IS false
-          AND tm.is_done IS false
-          AND tm.source in ('forum', 'cve', 'https://gitee.com')) AS todo_count
+       (SELECT coalesce(sum(undone_count), 0)
+        FROM counters
+        WHERE category = 'todo'
+          AND source = any(?::text[])) AS todo_count
 FROM params;
 `
-	if result := postgresql.DB().Raw(query, userName, giteeUserName).Scan(&response); result.Error != nil {
+	schedule := false
+	if result := postgresql.DB().Raw(query, userName,
+		pgTextArray(source.Urls(source.CategoryFollow, nil)),
+		pgTextArray(source.Urls(source.CategoryRelated, nil)), scheduleSources(),
+		pgTextArray(source.Urls(source.CategoryTodo, &schedule))).
+		Scan(&response); result.Error != nil {
 		logrus.Errorf("get count failed, err:%v", result.Error.Error())
 		return CountDataDAO{}, xerrors.Errorf("查询失败, err:%v", result.Error)
 	}
 	return response, nil
 }
 
-func (s *messageAdapter) GetAllMessage(userName string, pageNum, countPerPage int,
-	isRead *bool) ([]MessageListDAO, int64, error) {
-	query := `with filtered_recipient as (
+// GetUnreadCounter returns the unread counters of the recipients of a user.
+func (s *messageAdapter) GetUnreadCounter(userName string) ([]UnreadCounterDAO, error) {
+	var response []UnreadCounterDAO
+	if result := postgresql.DB().Table("message_center.unread_counter uc").
+		Select("uc.source, uc.category, uc.unread_count, uc.undone_count").
+		Joins("join message_center.recipient_config rc on uc.recipient_id = rc.id").
+		Where("rc.user_id = ? and rc.is_deleted is false", userName).
+		Scan(&response); result.Error != nil {
+		logrus.Errorf("get count failed, err:%v", result.Error.Error())
+		return []UnreadCounterDAO{}, xerrors.Errorf("查询失败, err:%v", result.Error)
+	}
+	return response, nil
+}
+
+// allMessageSql collects every undeleted follow, todo and related message of a
+// user into all_messages.
+const allMessageSql = `with filtered_recipient as (
             select *
             from recipient_config
             where not is_deleted and user_id = ?
 		),
 		all_messages as (
-		    select fm.is_read, cem.*
+		    select fm.is_read, fm.stream_seq, cem.*
 		    from follow_message fm
 		             join cloud_event_message cem on cem.event_id = fm.event_id
 		             join filtered_recipient rc on rc.id = fm.recipient_id
 		    where fm.is_deleted = false
 		union all
-		    select tm.is_read, cem.*
+		    select tm.is_read, tm.stream_seq, cem.*
 		    from todo_message tm
 		             join cloud_event_message cem on cem.event_id = tm.latest_event_id
 		             join filtered_recipient rc on rc.id = tm.recipient_id
 		    where tm.is_deleted = false
 		union all   
-		    select rm.is_read, cem.*
+		    select rm.is_read, rm.stream_seq, cem.*
 		    from related_message rm
 		             join cloud_event_message cem on cem.event_id = rm.event_id
 		             join filtered_recipient rc on rc.id = rm.recipient_id
 		    where rm.is_deleted = false
-		)
+		)`
+
+func (s *messageAdapter) GetAllMessage(userName string, pageNum, countPerPage int,
+	isRead *bool, cursor string) ([]MessageListDAO, int64, error) {
+	query := allMessageSql + `
 	select *, count(*) over () as total_count
-	from all_messages`
+	from all_messages
+	where true`
 	if isRead != nil {
-		query += fmt.Sprintf(" where is_read = %t", *isRead)
+		query += fmt.Sprintf(" and is_read = %t", *isRead)
+	}
+	pageArgs, countSql, err := pageSql(&query, byUpdate, pageNum, countPerPage, cursor)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
 	}
-	query += ` order by updated_at desc limit ? offset ?`
 
-	offset := (pageNum - 1) * countPerPage
 	var response []MessageListDAO
-	if result := postgresql.DB().Raw(query, userName, countPerPage, offset).
+	args := []interface{}{userName}
+	if result := postgresql.DB().Raw(query, append(args, pageArgs...)...).
 		Scan(&response); result.Error != nil {
 		logrus.Errorf("get message failed, err:%v", result.Error.Error())
 		return []MessageListDAO{}, 0, xerrors.Errorf("查询失败, err:%v",
 			result.Error)
 	}
-	var totalCount int64
-	if len(response) != 0 {
-		totalCount = response[0].TotalCount
+	totalCount, err := listTotal(response, countSql, args)
+	if err != nil {
+		return []MessageListDAO{}, 0, err
 	}
 	return response, totalCount, nil
 }
+
+// GetLatestStreamSeq returns the stream sequence of the newest delivery of a
+// user, 0 if there is none.
+func (s *messageAdapter) GetLatestStreamSeq(userName string) (int64, error) {
+	var seq int64
+	if result := postgresql.DB().Raw(allMessageSql+`
+	select coalesce(max(stream_seq), 0) from all_messages`, userName).
+		Scan(&seq); result.Error != nil {
+		logrus.Errorf("get message failed, err:%v", result.Error.Error())
+		return 0, xerrors.Errorf("查询失败, err:%v", result.Error)
+	}
+	return seq, nil
+}
+
+// GetAllMessageAfter returns at most limit messages delivered after the stream
+// sequence after, oldest first.
+func (s *messageAdapter) GetAllMessageAfter(userName string, after int64,
+	limit int) ([]MessageListDAO, error) {
+	query := allMessageSql + `
+	select * from all_messages where stream_seq > ? order by stream_seq limit ?`
+
+	var response []MessageListDAO
+	if result := postgresql.DB().Raw(query, userName, after, limit).
+		Scan(&response); result.Error != nil {
+		logrus.Errorf("get message failed, err:%v", result.Error.Error())
+		return []MessageListDAO{}, xerrors.Errorf("查询失败, err:%v", result.Error)
+	}
+	return response, nil
+}
+
+// pgTextArray writes values as a postgres text array literal, an empty list
+// is "{}" and matches nothing with any().
+func pgTextArray(values []string) string {
+	items := make([]string, len(values))
+	for i, v := range values {
+		v = strings.ReplaceAll(v, `\`, `\\`)
+		items[i] = `"` + strings.ReplaceAll(v, `"`, `\"`) + `"`
+	}
+	return "{" + strings.Join(items, ",") + "}"
+}
+
+// filterBotSql keeps the messages sent by the bots of their sources, or the
+// ones sent by the others.
+func filterBotSql(query *string, isBot bool) []interface{} {
+	var urls, logins []string
+	for _, s := range source.All() {
+		for _, bot := range s.Bots {
+			urls = append(urls, s.Url)
+			logins = append(logins, bot)
+		}
+	}
+	not := ""
+	if !isBot {
+		not = "not "
+	}
+	*query += ` and ` + not + `exists (select 1 from unnest(?::text[], ?::text[]) as bot(source,
+		login) where bot.source = cem.source and bot.login = cem."user")`
+	return []interface{}{pgTextArray(urls), pgTextArray(logins)}
+}
+
+// scheduleSources are the sources of the schedule todos, such as meetings.
+func scheduleSources() string {
+	schedule := true
+	return pgTextArray(source.Urls(source.CategoryTodo, &schedule))
+}
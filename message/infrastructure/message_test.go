@@ -0,0 +1,87 @@
+package infrastructure
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/opensourceways/message-manager/common/source"
+	"github.com/opensourceways/message-manager/utils"
+)
+
+func TestPgTextArray(t *testing.T) {
+	assert.Equal(t, "{}", pgTextArray(nil))
+	assert.Equal(t, `{"forum","https://gitee.com"}`,
+		pgTextArray([]string{"forum", "https://gitee.com"}))
+	assert.Equal(t, `{"a,b","c\"d","e\\f"}`, pgTextArray([]string{"a,b", `c"d`, `e\f`}))
+}
+
+func TestPageSql(t *testing.T) {
+	query := `select *, count(*) over () as total_count from follow_message where true`
+	args, countSql, err := pageSql(&query, byUpdate, 3, 10, "")
+	assert.NoError(t, err)
+	assert.Contains(t, query, "where true order by updated_at desc, event_id desc limit ? offset ?")
+	assert.Equal(t, []interface{}{10, 20}, args)
+	assert.Equal(t, "", countSql)
+
+	query = `select cem.*, count(*) over () as total_count from cloud_event_message cem
+	where true`
+	_, _, err = pageSql(&query, byMessageTime, 1, 10, "")
+	assert.NoError(t, err)
+	assert.Contains(t, query, "order by cem.time desc, cem.event_id desc limit ? offset ?")
+
+	updatedAt := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+	query = `select *, count(*) over () as total_count from follow_message where true`
+	args, countSql, err = pageSql(&query, byUpdate, 3, 10, utils.EncodeCursor(updatedAt, "event_1"))
+	assert.NoError(t, err)
+	assert.Equal(t, `select *, 0 as total_count from follow_message where true`+
+		` and (updated_at, event_id) < (?, ?) order by updated_at desc, event_id desc limit ?`,
+		query)
+	assert.Equal(t, []interface{}{updatedAt, "event_1", 10}, args)
+	assert.Equal(t, `select count(*) from (select *, count(*) over () as total_count`+
+		` from follow_message where true) as counted`, countSql)
+
+	_, _, err = pageSql(&query, byUpdate, 1, 10, "invalid")
+	assert.Error(t, err)
+}
+
+func TestPageSqlByTime(t *testing.T) {
+	query := `select * from todo_message where true`
+	args, _, err := pageSql(&query, byTime, 2, 10, "")
+	assert.NoError(t, err)
+	assert.Contains(t, query, "order by time, event_id limit ? offset ?")
+	assert.Equal(t, []interface{}{10, 10}, args)
+
+	start := time.Date(2024, 8, 1, 9, 0, 0, 0, time.UTC)
+	query = `select * from todo_message where true`
+	args, _, err = pageSql(&query, byTime, 2, 10, utils.EncodeCursor(start, "meeting_1"))
+	assert.NoError(t, err)
+	assert.Contains(t, query, "and (time, event_id) > (?, ?) order by time, event_id limit ?")
+	assert.Equal(t, []interface{}{start, "meeting_1", 10}, args)
+}
+
+func TestLinkedLogin(t *testing.T) {
+	query := linkedLogin("rc", "?")
+	assert.Contains(t, query, "rc.user_id = ?")
+	// a login linked by hand does not receive the messages of its owner
+	assert.Contains(t, query, "ri.recipient_id = rc.id and ri.verified")
+	assert.Contains(t, query, "ri.community = rc.community")
+	// the logins of every forge are matched
+	assert.NotContains(t, query, "provider")
+}
+
+func TestFilterBotSql(t *testing.T) {
+	query := "select * from cloud_event_message cem where true"
+	args := filterBotSql(&query, true)
+	assert.Contains(t, query, "and exists (select 1 from unnest(?::text[], ?::text[])")
+	gitee := source.Url(source.IdGitee)
+	assert.Equal(t, []interface{}{
+		`{"` + gitee + `","` + gitee + `","` + gitee + `"}`,
+		`{"openeuler-ci-bot","ci-robot","openeuler-sync-bot"}`,
+	}, args)
+
+	query = "select * from cloud_event_message cem where true"
+	filterBotSql(&query, false)
+	assert.Contains(t, query, "and not exists")
+}
@@ -0,0 +1,156 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/message-manager/common/postgresql"
+)
+
+const (
+	messageNotifyChannel = "message_center_notify"
+	listenRetryInterval  = 5 * time.Second
+)
+
+// notifyTriggerSql publishes the changes of the recipient messages with their
+// user on messageNotifyChannel, it needs PostgreSQL 14 or later.
+const notifyTriggerSql = `
+create or replace function message_center.notify_recipient_message() returns trigger as $$
+begin
+    perform pg_notify('message_center_notify', json_build_object(
+        'user_name', (select user_id from message_center.recipient_config
+                      where id = new.recipient_id),
+        'op', lower(tg_op))::text);
+    return new;
+end;
+$$ language plpgsql;
+
+create or replace trigger follow_message_notify
+    after insert or update of is_read, is_deleted on message_center.follow_message
+    for each row execute function message_center.notify_recipient_message();
+
+create or replace trigger related_message_notify
+    after insert or update of is_read, is_deleted on message_center.related_message
+    for each row execute function message_center.notify_recipient_message();
+
+create or replace trigger todo_message_notify
+    after insert or update of is_read, is_deleted, latest_event_id on message_center.todo_message
+    for each row execute function message_center.notify_recipient_message();
+`
+
+// every replica forwards the database notifications into its own broker, so
+// the in-memory broker is enough for several replicas
+var notifyAdapter = &messageNotifyAdapter{broker: newMemoryBroker()}
+
+// MessageNotifyAdapter returns the process wide message notification adapter.
+func MessageNotifyAdapter() *messageNotifyAdapter {
+	return notifyAdapter
+}
+
+type messageNotifyAdapter struct {
+	broker *memoryBroker
+}
+
+func (s *messageNotifyAdapter) Subscribe(userName string) (<-chan MessageNotifyDAO, func()) {
+	return s.broker.Subscribe(userName)
+}
+
+func (s *messageNotifyAdapter) Publish(notify MessageNotifyDAO) {
+	s.broker.Publish(notify)
+}
+
+func newMemoryBroker() *memoryBroker {
+	return &memoryBroker{subscribers: map[string]map[chan MessageNotifyDAO]struct{}{}}
+}
+
+// memoryBroker fans message notifications out to the connections of a user
+// inside the process.
+type memoryBroker struct {
+	lock        sync.RWMutex
+	subscribers map[string]map[chan MessageNotifyDAO]struct{}
+}
+
+// Subscribe registers a listener for the notifications of userName. The
+// returned func must be called to release it.
+func (s *memoryBroker) Subscribe(userName string) (<-chan MessageNotifyDAO, func()) {
+	// a notification only wakes the listener up, so one pending item is enough
+	ch := make(chan MessageNotifyDAO, 1)
+
+	s.lock.Lock()
+	if s.subscribers[userName] == nil {
+		s.subscribers[userName] = map[chan MessageNotifyDAO]struct{}{}
+	}
+	s.subscribers[userName][ch] = struct{}{}
+	s.lock.Unlock()
+
+	cancel := func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		delete(s.subscribers[userName], ch)
+		if len(s.subscribers[userName]) == 0 {
+			delete(s.subscribers, userName)
+		}
+	}
+	return ch, cancel
+}
+
+// Publish delivers notify to the listeners of notify.UserName without blocking.
+func (s *memoryBroker) Publish(notify MessageNotifyDAO) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	for ch := range s.subscribers[notify.UserName] {
+		select {
+		case ch <- notify:
+		default:
+		}
+	}
+}
+
+// Migration creates the notify triggers.
+func (s *messageNotifyAdapter) Migration() postgresql.Migration {
+	return postgresql.Migration{Version: "message_notify", Sql: notifyTriggerSql}
+}
+
+// Listen forwards the notifications of the database to the subscribers until
+// ctx is done.
+func (s *messageNotifyAdapter) Listen(ctx context.Context) {
+	if postgresql.DB() == nil {
+		logrus.Errorf("postgresql is not initialized, message notify is disabled")
+		return
+	}
+
+	for {
+		err := postgresql.Listen(ctx, messageNotifyChannel, s.handleNotify)
+		if ctx.Err() != nil {
+			return
+		}
+		logrus.Errorf("listen message notify failed, err:%v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryInterval):
+		}
+	}
+}
+
+func (s *messageNotifyAdapter) handleNotify(payload string) {
+	var data MessageNotifyDAO
+	if err := json.Unmarshal([]byte(payload), &data); err != nil {
+		logrus.Errorf("parse message notify failed, payload:%s, err:%v", payload, err)
+		return
+	}
+	if data.UserName != "" {
+		s.Publish(data)
+	}
+}
@@ -0,0 +1,38 @@
+package infrastructure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryBroker(t *testing.T) {
+	broker := newMemoryBroker()
+	ch, cancel := broker.Subscribe("user")
+
+	broker.Publish(MessageNotifyDAO{UserName: "other", Op: "insert"})
+	broker.Publish(MessageNotifyDAO{UserName: "user", Op: "insert"})
+	// the second one is dropped instead of blocking
+	broker.Publish(MessageNotifyDAO{UserName: "user", Op: "update"})
+
+	assert.Equal(t, MessageNotifyDAO{UserName: "user", Op: "insert"}, <-ch)
+	assert.Len(t, ch, 0)
+
+	cancel()
+	broker.Publish(MessageNotifyDAO{UserName: "user", Op: "insert"})
+	assert.Len(t, ch, 0)
+	assert.Empty(t, broker.subscribers)
+}
+
+func TestHandleNotify(t *testing.T) {
+	adapter := &messageNotifyAdapter{broker: newMemoryBroker()}
+	ch, cancel := adapter.Subscribe("user")
+	defer cancel()
+
+	adapter.handleNotify("invalid")
+	adapter.handleNotify(`{"user_name":null,"op":"insert"}`)
+	assert.Len(t, ch, 0)
+
+	adapter.handleNotify(`{"user_name":"user","op":"update"}`)
+	assert.Equal(t, MessageNotifyDAO{UserName: "user", Op: "update"}, <-ch)
+}
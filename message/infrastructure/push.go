@@ -5,6 +5,7 @@ Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
 package infrastructure
 
 import (
+	"strconv"
 	"time"
 
 	"golang.org/x/xerrors"
@@ -38,7 +39,29 @@ func (s *messagePushAdapter) GetPushConfig(subsIds []string, countPerPage, pageN
 	return response, nil
 }
 
-func (s *messagePushAdapter) AddPushConfig(cmd CmdToAddPushConfig) error {
+// checkPushContact 校验用户的接收人的短信和电话号码已验证
+func checkPushContact(recipientId int64, userName string, needMessage, needPhone bool) error {
+	if !needMessage && !needPhone {
+		return nil
+	}
+	var count int64
+	if result := getTable().Where("id = ? AND user_id = ?", recipientId, userName).
+		Where("(NOT ? OR message_verified) AND (NOT ? OR phone_verified)", needMessage,
+			needPhone).
+		Count(&count); result.Error != nil {
+		return xerrors.Errorf("查询接收人失败, err:%v", result.Error)
+	}
+	if count == 0 {
+		return xerrors.Errorf("接收人%d的号码未验证", recipientId)
+	}
+	return nil
+}
+
+func (s *messagePushAdapter) AddPushConfig(cmd CmdToAddPushConfig, userName string) error {
+	if err := checkPushContact(cmd.RecipientId, userName, cmd.NeedMessage,
+		cmd.NeedPhone); err != nil {
+		return err
+	}
 
 	var existData MessagePushDAO
 	if result := postgresql.DB().Table("message_center.push_config").
@@ -55,6 +78,8 @@ func (s *messagePushAdapter) AddPushConfig(cmd CmdToAddPushConfig) error {
 			NeedPhone:        &cmd.NeedPhone,
 			NeedMail:         &cmd.NeedMail,
 			NeedInnerMessage: &cmd.NeedInnerMessage,
+			NeedWebhook:      &cmd.NeedWebhook,
+			NeedChat:         &cmd.NeedChat,
 			IsDeleted:        false,
 			CreatedAt:        time.Now(),
 			UpdatedAt:        time.Now(),
@@ -64,7 +89,15 @@ func (s *messagePushAdapter) AddPushConfig(cmd CmdToAddPushConfig) error {
 	return nil
 }
 
-func (s *messagePushAdapter) UpdatePushConfig(cmd CmdToUpdatePushConfig) error {
+func (s *messagePushAdapter) UpdatePushConfig(cmd CmdToUpdatePushConfig, userName string) error {
+	recipientId, err := strconv.ParseInt(cmd.RecipientId, 10, 64)
+	if err != nil {
+		return xerrors.Errorf("接收人id不合法, err:%v", err)
+	}
+	if err := checkPushContact(recipientId, userName, cmd.NeedMessage,
+		cmd.NeedPhone); err != nil {
+		return err
+	}
 	if result := postgresql.DB().Table("message_center.push_config").
 		Where("is_deleted = ?", false).
 		Where("subscribe_id IN ? AND recipient_id = ?", cmd.SubscribeId, cmd.RecipientId).
@@ -73,6 +106,8 @@ func (s *messagePushAdapter) UpdatePushConfig(cmd CmdToUpdatePushConfig) error {
 			NeedPhone:        &cmd.NeedPhone,
 			NeedMail:         &cmd.NeedMail,
 			NeedInnerMessage: &cmd.NeedInnerMessage,
+			NeedWebhook:      &cmd.NeedWebhook,
+			NeedChat:         &cmd.NeedChat,
 			UpdatedAt:        time.Now(),
 		}); result.Error != nil {
 		return xerrors.Errorf("更新配置失败，err:%v", result.Error)
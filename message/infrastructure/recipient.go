@@ -11,25 +11,56 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/opensourceways/message-manager/common/postgresql"
+	"github.com/opensourceways/message-manager/common/source"
 )
 
+// forgeLoginColumn are the columns of recipient_config holding the login of a
+// recipient on each forge.
+var forgeLoginColumn = map[string]string{
+	source.ForgeGitee:   "gitee_user_name",
+	source.ForgeGithub:  "github_user_name",
+	source.ForgeGitcode: "gitcode_user_name",
+}
+
+const recipientForgeLoginSql = `
+alter table message_center.recipient_config
+    add column if not exists github_user_name varchar(255) not null default '',
+    add column if not exists gitcode_user_name varchar(255) not null default '';
+`
+
 func MessageRecipientAdapter() *messageRecipientAdapter {
 	return &messageRecipientAdapter{}
 }
 
 type messageRecipientAdapter struct{}
 
+// Migration adds the forge login columns to recipient_config.
+func (ctl *messageRecipientAdapter) Migration() postgresql.Migration {
+	return postgresql.Migration{Version: "recipient_forge_login", Sql: recipientForgeLoginSql}
+}
+
 type RecipientController struct {
-	Name          string    `gorm:"column:recipient_name" json:"recipient_id"`
-	Mail          string    `gorm:"column:mail" json:"mail"`
-	Message       string    `gorm:"column:message" json:"message"`
-	Phone         string    `gorm:"column:phone" json:"phone"`
-	Remark        string    `gorm:"column:remark" json:"remark"`
-	UserName      string    `gorm:"column:user_id"  json:"user_id"`
-	GiteeUserName string    `gorm:"column:gitee_user_name" json:"gitee_user_name"`
-	IsDeleted     bool      `gorm:"column:is_deleted" json:"is_deleted"`
-	CreatedAt     time.Time `gorm:"column:created_at" json:"created_at" swaggerignore:"true"`
-	UpdatedAt     time.Time `gorm:"column:updated_at" json:"updated_at" swaggerignore:"true"`
+	Name            string    `gorm:"column:recipient_name" json:"recipient_id"`
+	Mail            string    `gorm:"column:mail" json:"mail"`
+	Message         string    `gorm:"column:message" json:"message"`
+	Phone           string    `gorm:"column:phone" json:"phone"`
+	Remark          string    `gorm:"column:remark" json:"remark"`
+	UserName        string    `gorm:"column:user_id"  json:"user_id"`
+	GiteeUserName   string    `gorm:"column:gitee_user_name" json:"gitee_user_name"`
+	GithubUserName  string    `gorm:"column:github_user_name" json:"github_user_name"`
+	GitcodeUserName string    `gorm:"column:gitcode_user_name" json:"gitcode_user_name"`
+	Webhook         string    `gorm:"column:webhook" json:"webhook"`
+	WebhookSecret   string    `gorm:"column:webhook_secret" json:"-"`
+	ChatPlatform    string    `gorm:"column:chat_platform" json:"chat_platform"`
+	ChatWebhook     string    `gorm:"column:chat_webhook" json:"chat_webhook"`
+	ChatSecret      string    `gorm:"column:chat_secret" json:"-"`
+	MailVerified    bool      `gorm:"column:mail_verified" json:"mail_verified"`
+	MessageVerified bool      `gorm:"column:message_verified" json:"message_verified"`
+	PhoneVerified   bool      `gorm:"column:phone_verified" json:"phone_verified"`
+	Community       string    `gorm:"column:community" json:"community"`
+	IsDeleted       bool      `gorm:"column:is_deleted" json:"is_deleted"`
+	CreatedAt       time.Time `gorm:"column:created_at" json:"created_at" swaggerignore:"true"`
+	UpdatedAt       time.Time `gorm:"column:updated_at" json:"updated_at" swaggerignore:"true"`
 }
 
 func getTable() *gorm.DB {
@@ -62,33 +93,90 @@ func (ctl *messageRecipientAdapter) AddRecipientConfig(cmd CmdToAddRecipient,
 	}
 
 	if result := getTable().Create(MessageRecipientDAO{
-		Name:      cmd.Name,
-		Mail:      cmd.Mail,
-		Message:   cmd.Message,
-		Phone:     cmd.Phone,
-		Remark:    cmd.Remark,
-		UserName:  userName,
-		IsDeleted: false,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		Name:          cmd.Name,
+		Mail:          cmd.Mail,
+		Message:       cmd.Message,
+		Phone:         cmd.Phone,
+		Remark:        cmd.Remark,
+		Webhook:       cmd.Webhook,
+		WebhookSecret: cmd.WebhookSecret,
+		ChatPlatform:  cmd.ChatPlatform,
+		ChatWebhook:   cmd.ChatWebhook,
+		ChatSecret:    cmd.ChatSecret,
+		UserName:      userName,
+		Community:     communityOf(userName),
+		IsDeleted:     false,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
 	}); result.Error != nil {
 		return xerrors.Errorf("add new recipient failed, err:%v", result.Error)
 	}
 	return nil
 }
 
+// the SMS and the calls to a number no longer verified are stopped
+const clearUnverifiedPushSql = `
+update message_center.push_config pc
+set need_message = pc.need_message and rc.message_verified,
+    need_phone = pc.need_phone and rc.phone_verified
+from message_center.recipient_config rc
+where rc.id = pc.recipient_id and rc.id = ? and rc.user_id = ?
+`
+
+// UpdateRecipientConfig sets the fields of cmd which are not empty, a changed
+// contact is to be verified again.
 func (ctl *messageRecipientAdapter) UpdateRecipientConfig(cmd CmdToUpdateRecipient,
 	userName string) error {
-	if result := getTable().Where("id = ? AND user_id = ?", cmd.Id, userName).
-		Updates(RecipientController{
-			Name:      cmd.Name,
-			Mail:      cmd.Mail,
-			Message:   cmd.Message,
-			Phone:     cmd.Phone,
-			Remark:    cmd.Remark,
-			UpdatedAt: time.Now(),
+	unverified := map[string]interface{}{}
+	for contactType, contact := range map[string]string{
+		ContactMail:    cmd.Mail,
+		ContactMessage: cmd.Message,
+		ContactPhone:   cmd.Phone,
+	} {
+		if contact != "" {
+			column := contactVerifiedColumn[contactType]
+			unverified[column] = gorm.Expr(column+" and "+contactType+" = ?", contact)
+		}
+	}
+	err := postgresql.DB().Transaction(func(tx *gorm.DB) error {
+		table := func() *gorm.DB {
+			return tx.Table("message_center.recipient_config").
+				Where("is_deleted = ? AND id = ? AND user_id = ?", false, cmd.Id, userName)
+		}
+		if len(unverified) != 0 {
+			if result := table().Updates(unverified); result.Error != nil {
+				return result.Error
+			}
+			if result := tx.Exec(clearUnverifiedPushSql, cmd.Id, userName); result.Error != nil {
+				return result.Error
+			}
+		}
+
+		if result := table().Updates(RecipientController{
+			Name:          cmd.Name,
+			Mail:          cmd.Mail,
+			Message:       cmd.Message,
+			Phone:         cmd.Phone,
+			Remark:        cmd.Remark,
+			Webhook:       cmd.Webhook,
+			WebhookSecret: cmd.WebhookSecret,
+			ChatPlatform:  cmd.ChatPlatform,
+			ChatWebhook:   cmd.ChatWebhook,
+			ChatSecret:    cmd.ChatSecret,
+			UpdatedAt:     time.Now(),
 		}); result.Error != nil {
-		return xerrors.Errorf("update recipient config failed, err:%v", result.Error)
+			return result.Error
+		}
+		if cmd.Webhook == "" && cmd.WebhookSecret == "" {
+			return nil
+		}
+		return table().Updates(map[string]interface{}{
+			"webhook_failures":    0,
+			"webhook_disabled_at": nil,
+		}).Error
+	})
+	if err != nil {
+		return xerrors.Errorf("update recipient config failed, err:%v", err)
 	}
 	return nil
 }
@@ -102,31 +190,53 @@ func (ctl *messageRecipientAdapter) RemoveRecipientConfig(cmd CmdToDeleteRecipie
 	return nil
 }
 
+// SyncUserInfo saves the verified contacts and forge logins of an account, the
+// stored phone number is kept when KeepPhone is set.
 func (ctl *messageRecipientAdapter) SyncUserInfo(cmd CmdToSyncUserInfo) (uint, error) {
 	var oldInfo RecipientController
-	if cmd.GiteeUserName != "" {
-		getTable().Where("gitee_user_name = ?", cmd.GiteeUserName).
-			Updates(map[string]interface{}{
-				"gitee_user_name": "",
-			})
+	communityId := communityOf(cmd.UserName)
+	// a forge login belongs to one user of a community
+	for forge, login := range map[string]string{
+		source.ForgeGitee:   cmd.GiteeUserName,
+		source.ForgeGithub:  cmd.GithubUserName,
+		source.ForgeGitcode: cmd.GitcodeUserName,
+	} {
+		if login != "" {
+			column := forgeLoginColumn[forge]
+			getTable().Where(column+" = ? AND community = ?", login, communityId).
+				Updates(map[string]interface{}{column: ""})
+		}
 	}
 	if result := getTable().
 		Where("user_id = ?", cmd.UserName).
 		Scan(&oldInfo); result.RowsAffected != 0 {
 		newInfo := &oldInfo
 		newInfo.Mail = cmd.Mail
-		newInfo.Message = cmd.CountryCode + cmd.Phone
-		newInfo.Phone = cmd.CountryCode + cmd.Phone
+		newInfo.MailVerified = true
+		if !cmd.KeepPhone {
+			newInfo.Message = cmd.Phone
+			newInfo.Phone = cmd.Phone
+			newInfo.MessageVerified = true
+			newInfo.PhoneVerified = true
+		}
 		newInfo.UserName = cmd.UserName
 		newInfo.GiteeUserName = cmd.GiteeUserName
+		newInfo.GithubUserName = cmd.GithubUserName
+		newInfo.GitcodeUserName = cmd.GitcodeUserName
 		getTable().Where("user_id = ?", cmd.UserName).Save(&newInfo)
 	} else {
 		newInfo := RecipientController{
-			Mail:          cmd.Mail,
-			Message:       cmd.CountryCode + cmd.Phone,
-			Phone:         cmd.CountryCode + cmd.Phone,
-			UserName:      cmd.UserName,
-			GiteeUserName: cmd.GiteeUserName,
+			Mail:            cmd.Mail,
+			Message:         cmd.Phone,
+			Phone:           cmd.Phone,
+			MailVerified:    true,
+			MessageVerified: true,
+			PhoneVerified:   true,
+			UserName:        cmd.UserName,
+			GiteeUserName:   cmd.GiteeUserName,
+			GithubUserName:  cmd.GithubUserName,
+			GitcodeUserName: cmd.GitcodeUserName,
+			Community:       communityId,
 		}
 		getTable().Create(&newInfo)
 	}
@@ -134,5 +244,18 @@ func (ctl *messageRecipientAdapter) SyncUserInfo(cmd CmdToSyncUserInfo) (uint, e
 	getTable().Where(gorm.Expr("is_deleted = ?", false)).
 		Where("user_id = ?", cmd.UserName).Select("id").Scan(&id)
 
+	for _, identity := range []struct{ provider, login string }{
+		{source.ForgeGitee, cmd.GiteeUserName},
+		{source.ForgeGithub, cmd.GithubUserName},
+		{source.ForgeGitcode, cmd.GitcodeUserName},
+	} {
+		if identity.login == "" || id == 0 {
+			continue
+		}
+		if err := linkVerifiedIdentity(postgresql.DB(), int64(id), identity.provider,
+			identity.login); err != nil {
+			return id, err
+		}
+	}
 	return id, nil
 }
@@ -24,7 +24,8 @@ type messageSubscribeAdapter struct{}
 func (ctl *messageSubscribeAdapter) GetAllSubsConfig(userName string) ([]MessageSubscribeDAO, error) {
 	var response []MessageSubscribeDAO
 	query := postgresql.DB().Table("message_center.subscribe_config").
-		Where("user_name = ? OR user_name IS NULL", userName).
+		Where("user_name = ? OR (user_name IS NULL AND community = ?)", userName,
+			communityOf(userName)).
 		Where(gorm.Expr("subscribe_config.is_deleted = ?", false))
 
 	if result := query.Order("subscribe_config.id").Find(&response); result.Error != nil {
@@ -69,6 +70,7 @@ func (ctl *messageSubscribeAdapter) AddSubsConfig(cmd CmdToAddSubscribe, userNam
 				CreatedAt:   time.Now(),
 				UpdatedAt:   time.Now(),
 				UserName:    userName,
+				Community:   communityOf(userName),
 			})
 		if result.Error != nil {
 			return []uint{}, xerrors.Errorf("新增配置失败")
@@ -0,0 +1,78 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package infrastructure
+
+import (
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/postgresql"
+)
+
+// the subscription is one of the user or one of the community of the recipient,
+// the push config is missing when the recipient is not pushed by the subscription
+const getTestSendTargetSql = `select rc.id as recipient_id, rc.mail, rc.message, rc.phone,
+	    rc.webhook, rc.webhook_secret, rc.webhook_disabled_at, rc.chat_platform,
+	    rc.chat_webhook, rc.chat_secret, rc.mail_verified, rc.message_verified,
+	    rc.phone_verified, sc.id as subscribe_id, sc.source, sc.event_type,
+	    sc.mode_name, sc.community, pc.need_message, pc.need_phone, pc.need_mail,
+	    pc.need_inner_message, pc.need_webhook, pc.need_chat
+	from message_center.recipient_config rc
+	join message_center.subscribe_config sc on sc.id = ? and sc.is_deleted = false
+	    and (sc.user_name = rc.user_id or
+	        (sc.user_name is null and sc.community = rc.community))
+	left join message_center.push_config pc on pc.subscribe_id = sc.id
+	    and pc.recipient_id = rc.id and pc.is_deleted = false
+	where rc.id = ? and rc.user_id = ? and rc.is_deleted = false
+	limit 1`
+
+func MessageTestSendAdapter() *messageTestSendAdapter {
+	return &messageTestSendAdapter{}
+}
+
+type messageTestSendAdapter struct{}
+
+// GetTestSendTarget returns the recipient recipientId of userName with its push
+// config of subscribeId, its RecipientId is 0 when either is not found.
+func (s *messageTestSendAdapter) GetTestSendTarget(userName string, recipientId int64,
+	subscribeId int) (TestSendTargetDAO, error) {
+	var response TestSendTargetDAO
+	if result := postgresql.DB().Raw(getTestSendTargetSql, subscribeId, recipientId,
+		userName).Scan(&response); result.Error != nil {
+		return TestSendTargetDAO{}, xerrors.Errorf("get test send target failed, err:%v",
+			result.Error)
+	}
+	return response, nil
+}
+
+// the events the recipient received as a follow, related or todo message, an
+// event it never received is not shown to it.
+const testSendEventReceivedSql = `exists (
+	    select 1 from message_center.follow_message fm
+	    where fm.event_id = cem.event_id and fm.recipient_id = ?
+	    union all
+	    select 1 from message_center.related_message rm
+	    where rm.event_id = cem.event_id and rm.recipient_id = ?
+	    union all
+	    select 1 from message_center.todo_message tm
+	    where tm.latest_event_id = cem.event_id and tm.recipient_id = ?)`
+
+// GetTestSendEvent returns the latest event of source and eventType received by
+// recipientId in communityId, its EventId is empty when there is none.
+func (s *messageTestSendAdapter) GetTestSendEvent(recipientId int64, communityId, source,
+	eventType string) (CloudEventDAO, error) {
+	query := postgresql.DB().Table("message_center.cloud_event_message cem").
+		Where("cem.source = ? AND cem.community = ?", source, communityId).
+		Where(testSendEventReceivedSql, recipientId, recipientId, recipientId)
+	if eventType != "" && eventType != "*" {
+		query = query.Where("cem.type = ?", eventType)
+	}
+	var response CloudEventDAO
+	if result := query.Select("cem.*").Order("cem.time DESC").Limit(1).
+		Scan(&response); result.Error != nil {
+		return CloudEventDAO{}, xerrors.Errorf("get test send event failed, err:%v",
+			result.Error)
+	}
+	return response, nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package infrastructure
+
+import (
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/opensourceways/message-manager/common/postgresql"
+)
+
+func MessageTodoAdapter() *messageTodoAdapter {
+	return &messageTodoAdapter{}
+}
+
+// todoDoneByHandSql adds the flag of the todos marked done by hand, the later
+// events of their items leave them done.
+const todoDoneByHandSql = `
+alter table message_center.todo_message
+    add column if not exists done_by_hand boolean not null default false;
+`
+
+type messageTodoAdapter struct{}
+
+// Migration adds the done by hand flag to todo_message.
+func (s *messageTodoAdapter) Migration() postgresql.Migration {
+	return postgresql.Migration{Version: "todo_done_by_hand", Sql: todoDoneByHandSql}
+}
+
+// SetTodoIsDone marks the todos of userName whose latest event is eventId, a
+// todo marked done stays done until it is marked undone by hand.
+func (s *messageTodoAdapter) SetTodoIsDone(userName string, eventId string, isDone bool) error {
+	query := `update message_center.todo_message
+		set is_done = ?, done_by_hand = ?
+		where latest_event_id = ? and (is_done != ? or done_by_hand != ?)
+		and recipient_id in (
+		    select id from recipient_config where user_id = ?
+		)`
+	if result := postgresql.DB().Exec(query, isDone, isDone, eventId, isDone, isDone,
+		userName); result.Error != nil {
+		return xerrors.Errorf("set todo is_done failed, err:%v", result.Error)
+	}
+	return nil
+}
+
+// CompleteExpiredTodo marks the todos of source and eventType done whose latest
+// event happened before before, it returns the number of todos done.
+func (s *messageTodoAdapter) CompleteExpiredTodo(source, eventType string, before time.Time) (
+	int64, error) {
+	query := `update message_center.todo_message tm
+		set is_done = true
+		from message_center.cloud_event_message cem
+		where cem.event_id = tm.latest_event_id
+		and tm.is_done = false and tm.is_deleted = false
+		and cem.source = ? and cem.type = ? and cem.time < ?`
+	result := postgresql.DB().Exec(query, source, eventType, before)
+	if result.Error != nil {
+		return 0, xerrors.Errorf("complete expired todo failed, err:%v", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,148 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package infrastructure
+
+import (
+	"time"
+
+	"golang.org/x/xerrors"
+	"gorm.io/gorm"
+
+	"github.com/opensourceways/message-manager/common/postgresql"
+)
+
+// the contacts of a recipient which are verified
+const (
+	ContactMail    = "mail"
+	ContactMessage = "message"
+	ContactPhone   = "phone"
+)
+
+// contactVerifiedColumn are the columns of recipient_config telling whether
+// each contact is verified.
+var contactVerifiedColumn = map[string]string{
+	ContactMail:    "mail_verified",
+	ContactMessage: "message_verified",
+	ContactPhone:   "phone_verified",
+}
+
+// the contacts existing before the verification are taken as verified, a
+// contact has one pending code at most
+const verificationSql = `
+alter table message_center.recipient_config
+    add column if not exists mail_verified boolean not null default true,
+    add column if not exists message_verified boolean not null default true,
+    add column if not exists phone_verified boolean not null default true;
+alter table message_center.recipient_config
+    alter column mail_verified set default false,
+    alter column message_verified set default false,
+    alter column phone_verified set default false;
+create table if not exists message_center.contact_verification (
+    recipient_id bigint       not null,
+    contact_type varchar(16)  not null,
+    contact      varchar(255) not null,
+    code_hash    varchar(64)  not null,
+    attempts     int          not null default 0,
+    expires_at   timestamptz  not null,
+    created_at   timestamptz  not null default now(),
+    primary key (recipient_id, contact_type)
+);
+`
+
+const (
+	// a code is issued again only after cooldown
+	saveVerificationSql = `insert into message_center.contact_verification as cv
+		(recipient_id, contact_type, contact, code_hash, expires_at) values (?, ?, ?, ?, ?)
+		on conflict (recipient_id, contact_type) do update
+		set contact = excluded.contact, code_hash = excluded.code_hash, attempts = 0,
+		    expires_at = excluded.expires_at, created_at = now()
+		where cv.created_at <= now() - make_interval(secs => ?)`
+
+	// every confirmation counts as an attempt, whether the code is right or not
+	claimVerificationSql = `update message_center.contact_verification
+		set attempts = attempts + 1
+		where recipient_id = ? and contact_type = ? and attempts < ? and expires_at > now()
+		returning *`
+)
+
+func MessageVerificationAdapter() *messageVerificationAdapter {
+	return &messageVerificationAdapter{}
+}
+
+type messageVerificationAdapter struct{}
+
+// Migration adds the verified flags to recipient_config and creates the pending
+// codes.
+func (s *messageVerificationAdapter) Migration() postgresql.Migration {
+	return postgresql.Migration{Version: "verification", Sql: verificationSql}
+}
+
+// GetRecipientContact returns the contacts of the recipient recipientId of
+// userName, its RecipientId is 0 when it is not found.
+func (s *messageVerificationAdapter) GetRecipientContact(userName string, recipientId int64) (
+	RecipientContactDAO, error) {
+	var response RecipientContactDAO
+	if result := getTable().Where("id = ? AND user_id = ?", recipientId, userName).
+		Select("id, mail, message, phone, mail_verified, message_verified, phone_verified").
+		Scan(&response); result.Error != nil {
+		return RecipientContactDAO{}, xerrors.Errorf("get recipient contact failed, err:%v",
+			result.Error)
+	}
+	return response, nil
+}
+
+// SaveVerification replaces the pending code of the contact, it tells false
+// when the last one was issued within cooldown.
+func (s *messageVerificationAdapter) SaveVerification(v VerificationDAO,
+	cooldown time.Duration) (bool, error) {
+	result := postgresql.DB().Exec(saveVerificationSql, v.RecipientId, v.ContactType, v.Contact,
+		v.CodeHash, v.ExpiresAt, cooldown.Seconds())
+	if result.Error != nil {
+		return false, xerrors.Errorf("save verification failed, err:%v", result.Error)
+	}
+	return result.RowsAffected != 0, nil
+}
+
+// ClaimVerification counts an attempt on the pending code of the contact, its
+// RecipientId is 0 when there is no valid one.
+func (s *messageVerificationAdapter) ClaimVerification(recipientId int64, contactType string,
+	maxAttempts int) (VerificationDAO, error) {
+	var response VerificationDAO
+	if result := postgresql.DB().Raw(claimVerificationSql, recipientId, contactType,
+		maxAttempts).Scan(&response); result.Error != nil {
+		return VerificationDAO{}, xerrors.Errorf("claim verification failed, err:%v",
+			result.Error)
+	}
+	return response, nil
+}
+
+// CompleteVerification marks the contact verified unless it changed since, the
+// same number is verified for the messages and the calls.
+func (s *messageVerificationAdapter) CompleteVerification(recipientId int64, contactType,
+	contact string) error {
+	types := []string{contactType}
+	if contactType == ContactMessage || contactType == ContactPhone {
+		types = []string{ContactMessage, ContactPhone}
+	}
+	err := postgresql.DB().Transaction(func(tx *gorm.DB) error {
+		if result := tx.Exec(`delete from message_center.contact_verification
+			where recipient_id = ? and contact_type = ?`, recipientId,
+			contactType); result.Error != nil {
+			return result.Error
+		}
+		for _, t := range types {
+			if result := tx.Table("message_center.recipient_config").
+				Where("id = ? AND "+t+" = ?", recipientId, contact).
+				Update(contactVerifiedColumn[t], true); result.Error != nil {
+				return result.Error
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return xerrors.Errorf("complete verification failed, err:%v", err)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package infrastructure
+
+import (
+	"time"
+
+	"golang.org/x/xerrors"
+	"gorm.io/gorm"
+
+	"github.com/opensourceways/message-manager/common/postgresql"
+)
+
+const DeliveryChannelWebhook = "webhook"
+
+// the webhook of a recipient is disabled after failing too many times in a
+// row, every post to it is logged
+const webhookSql = `
+alter table message_center.recipient_config
+    add column if not exists webhook varchar(1024) not null default '',
+    add column if not exists webhook_secret varchar(255) not null default '',
+    add column if not exists webhook_failures int not null default 0,
+    add column if not exists webhook_disabled_at timestamptz;
+alter table message_center.push_config
+    add column if not exists need_webhook boolean not null default false;
+create table if not exists message_center.webhook_delivery_log (
+    id           bigserial     primary key,
+    delivery_id  bigint        not null,
+    recipient_id bigint        not null,
+    event_id     varchar(255)  not null,
+    url          varchar(1024) not null,
+    attempt      int           not null,
+    status_code  int           not null default 0,
+    error        text          not null default '',
+    duration_ms  bigint        not null default 0,
+    created_at   timestamptz   not null default now()
+);
+create index if not exists webhook_delivery_log_recipient_idx
+    on message_center.webhook_delivery_log (recipient_id, created_at);
+`
+
+// the failures are counted and the webhook is disabled in one statement, so
+// that the concurrent posts count right
+const recordWebhookFailureSql = `update message_center.recipient_config
+	set webhook_failures = webhook_failures + 1,
+	    webhook_disabled_at = case
+	        when webhook_disabled_at is null and webhook_failures + 1 >= ? then now()
+	        else webhook_disabled_at end
+	where id = ?
+	returning webhook_disabled_at is not null`
+
+func MessageWebhookAdapter() *messageWebhookAdapter {
+	return &messageWebhookAdapter{}
+}
+
+type messageWebhookAdapter struct{}
+
+// Migration adds the webhook columns to recipient_config and push_config and
+// creates the delivery log.
+func (s *messageWebhookAdapter) Migration() postgresql.Migration {
+	return postgresql.Migration{Version: "webhook", Sql: webhookSql}
+}
+
+// GetWebhookTarget returns the subscriptions to source whose recipients in
+// communityId receive webhooks at an enabled one, like GetSubscribeTarget.
+func (s *messageWebhookAdapter) GetWebhookTarget(communityId, source string, sigs,
+	repos []string) ([]SubscribeTargetDAO, error) {
+	targets, err := getSubscribeTarget(
+		"case when rc.webhook_disabled_at is null then rc.webhook else '' end",
+		"need_webhook", communityId, source, sigs, repos)
+	if err != nil {
+		return targets, err
+	}
+	result := targets[:0]
+	for _, t := range targets {
+		if t.Target != "" {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+// GetWebhook returns the webhook of the recipient recipientId as configured
+// now, it fails when the recipient is removed.
+func (s *messageWebhookAdapter) GetWebhook(recipientId int64) (WebhookDAO, error) {
+	var response WebhookDAO
+	if result := getTable().Where("id = ?", recipientId).Limit(1).
+		Scan(&response); result.Error != nil {
+		return response, xerrors.Errorf("get webhook failed, err:%v", result.Error)
+	} else if result.RowsAffected == 0 {
+		return response, xerrors.Errorf("the recipient %d is removed", recipientId)
+	}
+	return response, nil
+}
+
+// RecordWebhookResult counts the failures in a row of the webhook of
+// recipientId, it returns whether they disabled it.
+func (s *messageWebhookAdapter) RecordWebhookResult(recipientId int64, ok bool,
+	disableAfter int) (bool, error) {
+	if ok {
+		if result := postgresql.DB().Exec(`update message_center.recipient_config
+			set webhook_failures = 0 where id = ? and webhook_failures <> 0`,
+			recipientId); result.Error != nil {
+			return false, xerrors.Errorf("record webhook result failed, err:%v", result.Error)
+		}
+		return false, nil
+	}
+
+	var disabled bool
+	if result := postgresql.DB().Raw(recordWebhookFailureSql, disableAfter, recipientId).
+		Scan(&disabled); result.Error != nil {
+		return false, xerrors.Errorf("record webhook result failed, err:%v", result.Error)
+	}
+	return disabled, nil
+}
+
+// SaveWebhookLog logs a post.
+func (s *messageWebhookAdapter) SaveWebhookLog(log WebhookLogDAO) error {
+	log.CreatedAt = time.Now()
+	if result := postgresql.DB().Table("message_center.webhook_delivery_log").
+		Omit("id").Create(&log); result.Error != nil {
+		return xerrors.Errorf("save webhook log failed, err:%v", result.Error)
+	}
+	return nil
+}
+
+// GetWebhookLog returns the posts to the webhook of the recipient recipientId
+// of userName, the latest first.
+func (s *messageWebhookAdapter) GetWebhookLog(userName string, recipientId int64,
+	countPerPage, pageNum int) ([]WebhookLogDAO, int64, error) {
+	query := func() *gorm.DB {
+		return postgresql.DB().Table("message_center.webhook_delivery_log wl").
+			Joins("join message_center.recipient_config rc on rc.id = wl.recipient_id").
+			Where("rc.user_id = ? AND rc.id = ? AND rc.is_deleted = ?", userName, recipientId,
+				false)
+	}
+
+	var count int64
+	if result := query().Count(&count); result.Error != nil {
+		return []WebhookLogDAO{}, 0, xerrors.Errorf("get webhook log failed, err:%v",
+			result.Error)
+	}
+	var response []WebhookLogDAO
+	if result := query().Select("wl.*").Order("wl.created_at DESC, wl.id DESC").
+		Limit(countPerPage).Offset((pageNum - 1) * countPerPage).
+		Scan(&response); result.Error != nil {
+		return []WebhookLogDAO{}, 0, xerrors.Errorf("get webhook log failed, err:%v",
+			result.Error)
+	}
+	return response, count, nil
+}
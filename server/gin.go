@@ -16,6 +16,7 @@ import (
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
+	"github.com/opensourceways/message-manager/config"
 	"github.com/opensourceways/message-manager/docs"
 )
 
@@ -25,7 +26,7 @@ const (
 	apiTitle = "message server"
 )
 
-func StartWebServer() {
+func StartWebServer(cfg *config.Config) {
 	engine := gin.New()
 	engine.Use(gin.Recovery())
 	engine.Use(logRequest())
@@ -35,8 +36,9 @@ func StartWebServer() {
 	docs.SwaggerInfo.Version = version
 	docs.SwaggerInfo.Description = apiDesc
 
-	services, err := initServices()
+	services, err := initServices(cfg)
 	if err != nil {
+		logrus.Errorf("init services failed, err:%v", err)
 		return
 	}
 
@@ -81,7 +83,7 @@ func logRequest() gin.HandlerFunc {
 			c.Writer.Status(),
 			endTime.Sub(startTime),
 			c.Request.Method,
-			c.Request.RequestURI,
+			redactRequestURI(c.Request.RequestURI),
 		)
 		if errmsg != "" {
 			log += fmt.Sprintf("| %s ", errmsg)
@@ -90,3 +92,12 @@ func logRequest() gin.HandlerFunc {
 		logrus.Info(log)
 	}
 }
+
+// redactRequestURI hides the secret token of calendar feed urls.
+func redactRequestURI(uri string) string {
+	const calendarPath = "/message_center/calendar/"
+	if strings.HasPrefix(uri, calendarPath) {
+		return calendarPath + "***"
+	}
+	return uri
+}
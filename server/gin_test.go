@@ -9,6 +9,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/opensourceways/message-manager/config"
 )
 
 func TestStartWebServer(t *testing.T) {
@@ -19,7 +21,7 @@ func TestStartWebServer(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	// 启动 Web 服务器
-	go StartWebServer()
+	go StartWebServer(&config.Config{})
 
 	// 发送请求到服务器
 	w.WriteHeader(http.StatusOK)
@@ -72,3 +74,13 @@ func TestLogRequestWithError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 	assert.Contains(t, w.Body.String(), "Internal Server Error")
 }
+
+func TestMessageMigrations(t *testing.T) {
+	versions := map[string]bool{}
+	for _, m := range messageMigrations() {
+		assert.NotEmpty(t, m.Version)
+		assert.NotEmpty(t, m.Sql)
+		assert.False(t, versions[m.Version], m.Version)
+		versions[m.Version] = true
+	}
+}
@@ -5,27 +5,303 @@ Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
 package server
 
 import (
+	"context"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/opensourceways/server-common-lib/interrupts"
+	"github.com/sirupsen/logrus"
 
+	"github.com/opensourceways/message-manager/common/chat"
+	"github.com/opensourceways/message-manager/common/directory"
+	"github.com/opensourceways/message-manager/common/mail"
+	"github.com/opensourceways/message-manager/common/postgresql"
+	"github.com/opensourceways/message-manager/common/sms"
+	"github.com/opensourceways/message-manager/common/webhook"
+	"github.com/opensourceways/message-manager/config"
 	"github.com/opensourceways/message-manager/message/app"
 	messagectl "github.com/opensourceways/message-manager/message/controller"
+	"github.com/opensourceways/message-manager/message/domain"
 	"github.com/opensourceways/message-manager/message/infrastructure"
 )
 
-func initMessage(services *allServices) error {
+const todoExpireInterval = 5 * time.Minute
+
+// messageMigrations are the migrations of the schema in the order they are
+// applied.
+func messageMigrations() []postgresql.Migration {
+	return []postgresql.Migration{
+		infrastructure.MessageRecipientAdapter().Migration(),
+		infrastructure.MessageVerificationAdapter().Migration(),
+		infrastructure.MessageIdentityAdapter().Migration(),
+		infrastructure.MessageCommunityAdapter().Migration(),
+		infrastructure.MessageCounterAdapter().Migration(),
+		infrastructure.MessageCalendarAdapter().Migration(),
+		infrastructure.MessageDeliveryAttemptAdapter().Migration(),
+		infrastructure.MessageDeliveryAdapter().Migration(),
+		infrastructure.MessageWebhookAdapter().Migration(),
+		infrastructure.MessageChatAdapter().Migration(),
+		infrastructure.MessageTodoAdapter().Migration(),
+		infrastructure.MessageMembershipAdapter().Migration(),
+		infrastructure.MessageDeadLetterAdapter().Migration(),
+		infrastructure.MessageNotifyAdapter().Migration(),
+		infrastructure.MessageListAdapter().Migration(),
+	}
+}
+
+func initMessage(services *allServices, cfg *config.Config) error {
+	// the first replica starting migrates the schema, the others wait for it
+	if err := postgresql.Migrate(messageMigrations()); err != nil {
+		return err
+	}
 	services.MessageListAppService = app.NewMessageListAppService(
 		infrastructure.MessageListAdapter(),
 	)
 	services.MessagePushAppService = app.NewMessagePushAppService(
 		infrastructure.MessagePushAdapter(),
 	)
-	services.MessageRecipientAppService = app.NewMessageRecipientAppService(
-		infrastructure.MessageRecipientAdapter(),
-	)
+	recipientAdapter := infrastructure.MessageRecipientAdapter()
+	services.MessageRecipientAppService = app.NewMessageRecipientAppService(recipientAdapter)
+	identityAdapter := infrastructure.MessageIdentityAdapter()
+	services.MessageIdentityAppService = app.NewMessageIdentityAppService(identityAdapter)
 	services.MessageSubscribeAppService = app.NewMessageSubscribeAppService(
 		infrastructure.MessageSubscribeAdapter(),
 	)
 
+	calendarAdapter := infrastructure.MessageCalendarAdapter()
+	services.MessageCalendarAppService = app.NewMessageCalendarAppService(calendarAdapter)
+
+	// the queues of the channels record their attempts
+	deliveryAttemptAdapter := infrastructure.MessageDeliveryAttemptAdapter()
+	services.MessageDeliveryAttemptAppService = app.NewMessageDeliveryAttemptAppService(
+		deliveryAttemptAdapter)
+
+	notifiers, err := initMail(services, &cfg.Mail)
+	if err != nil {
+		return err
+	}
+	notifiers = append(notifiers, initWebhook(services, &cfg.Webhook)...)
+	notifiers = append(notifiers, initChat(services, &cfg.Chat)...)
+	initTestSend(services, cfg)
+	initVerification(services, infrastructure.MessageVerificationAdapter(), cfg)
+	services.MessageCloudEventAppService = app.NewMessageCloudEventAppService(
+		infrastructure.MessageCloudEventAdapter(),
+		app.NewMessageFanoutAppService(infrastructure.MessageFanoutAdapter(), app.FanoutRules()),
+		notifiers...,
+	)
+	todoAdapter := infrastructure.MessageTodoAdapter()
+	services.MessageTodoAppService = app.NewMessageTodoAppService(
+		todoAdapter,
+		app.FanoutRules(),
+	)
+	// one replica completes the expired todos for all
+	todoLock := postgresql.NewJobLock("complete_expired_todo")
+	interrupts.TickLiteral(func() {
+		if !todoLock.Hold() {
+			return
+		}
+		if err := services.MessageTodoAppService.CompleteExpiredTodo(time.Now()); err != nil {
+			logrus.Errorf("complete expired todo failed, err:%v", err)
+		}
+	}, todoExpireInterval)
+
+	membershipAdapter := infrastructure.MessageMembershipAdapter()
+	membershipCfg := &cfg.Membership
+	services.MessageMembershipAppService = app.NewMessageMembershipAppService(
+		membershipAdapter,
+		directory.Get(),
+		membershipCfg.TimeoutDuration(),
+	)
+	if membershipCfg.Enable {
+		// one replica syncs the membership for all
+		membershipLock := postgresql.NewJobLock("sync_membership")
+		interrupts.TickLiteral(func() {
+			if !membershipLock.Hold() {
+				return
+			}
+			if _, err := services.MessageMembershipAppService.Sync(); err != nil {
+				logrus.Errorf("sync membership failed, err:%v", err)
+			}
+		}, membershipCfg.IntervalDuration())
+	}
+
+	// the events can still be posted when the consumer fails
+	if cfg.Consumer.Enable {
+		if err := startMessageConsumer(&cfg.Consumer, services); err != nil {
+			logrus.Errorf("start message consumer failed, err:%v", err)
+		}
+	}
+
+	notifyAdapter := infrastructure.MessageNotifyAdapter()
+	go notifyAdapter.Listen(context.Background())
+	services.MessageStreamAppService = app.NewMessageStreamAppService(
+		infrastructure.MessageListAdapter(),
+		notifyAdapter,
+	)
+
+	return nil
+}
+
+// initMail builds the mail service, which also sends the queued mails when the
+// mails are enabled.
+func initMail(services *allServices, cfg *config.Mail) ([]app.EventNotifier, error) {
+	deliveryAdapter := infrastructure.MessageDeliveryAdapter()
+	renderer, err := mail.NewRenderer(cfg.Templates)
+	if err != nil {
+		return nil, err
+	}
+	services.MessageMailAppService = app.NewMessageMailAppService(
+		infrastructure.MessageMailAdapter(),
+		deliveryAdapter,
+		renderer,
+		mail.NewSender(cfg.SMTP),
+		app.FanoutRules(),
+		deliveryOption(cfg.BatchSize, cfg.MaxAttempts, cfg.RetryInterval, cfg.Lease),
+	)
+	if !cfg.Enable {
+		return nil, nil
+	}
+
+	interrupts.TickLiteral(func() {
+		if _, err := services.MessageMailAppService.SendMail(); err != nil {
+			logrus.Errorf("send mail failed, err:%v", err)
+		}
+	}, cfg.IntervalDuration())
+	return []app.EventNotifier{services.MessageMailAppService}, nil
+}
+
+// initWebhook builds the webhook service, which also posts the queued events
+// when the webhooks are enabled.
+func initWebhook(services *allServices, cfg *config.Webhook) []app.EventNotifier {
+	webhookAdapter := infrastructure.MessageWebhookAdapter()
+	services.MessageWebhookAppService = app.NewMessageWebhookAppService(
+		webhookAdapter,
+		infrastructure.MessageDeliveryAdapter(),
+		webhook.NewClient(cfg.Client),
+		app.FanoutRules(),
+		deliveryOption(cfg.BatchSize, cfg.MaxAttempts, cfg.RetryInterval, cfg.Lease),
+		cfg.DisableAfter,
+	)
+	if !cfg.Enable {
+		return nil
+	}
+
+	interrupts.TickLiteral(func() {
+		if _, err := services.MessageWebhookAppService.SendWebhook(); err != nil {
+			logrus.Errorf("send webhook failed, err:%v", err)
+		}
+	}, cfg.IntervalDuration())
+	return []app.EventNotifier{services.MessageWebhookAppService}
+}
+
+// initChat builds the chat service, which also posts the queued cards when the
+// chat bots are enabled.
+func initChat(services *allServices, cfg *config.Chat) []app.EventNotifier {
+	chatAdapter := infrastructure.MessageChatAdapter()
+	services.MessageChatAppService = app.NewMessageChatAppService(
+		chatAdapter,
+		infrastructure.MessageDeliveryAdapter(),
+		chat.NewClient(cfg.Client),
+		app.FanoutRules(),
+		deliveryOption(cfg.BatchSize, cfg.MaxAttempts, cfg.RetryInterval, cfg.Lease),
+	)
+	if !cfg.Enable {
+		return nil
+	}
+
+	interrupts.TickLiteral(func() {
+		if _, err := services.MessageChatAppService.SendChat(); err != nil {
+			logrus.Errorf("send chat failed, err:%v", err)
+		}
+	}, cfg.IntervalDuration())
+	return []app.EventNotifier{services.MessageChatAppService}
+}
+
+// initTestSend builds the service sending the test messages through the
+// enabled channels and the SMS gateway.
+func initTestSend(services *allServices, cfg *config.Config) {
+	var senders app.TestSenders
+	if cfg.Mail.Enable {
+		senders.Mail = services.MessageMailAppService
+	}
+	if cfg.Webhook.Enable {
+		senders.Webhook = services.MessageWebhookAppService
+	}
+	if cfg.Chat.Enable {
+		senders.Chat = services.MessageChatAppService
+	}
+	if g := sms.NewGateway(cfg.Verification.SMS); g != nil {
+		senders.SMS = g
+	}
+	services.MessageTestSendAppService = app.NewMessageTestSendAppService(
+		infrastructure.MessageTestSendAdapter(),
+		senders,
+	)
+}
+
+// initVerification builds the service verifying the mails and the numbers, as
+// far as the mails and the SMS gateway are configured.
+func initVerification(services *allServices, adapter domain.MessageVerificationAdapter,
+	cfg *config.Config) {
+	var sender domain.MailSender
+	if cfg.Mail.Enable {
+		sender = mail.NewSender(cfg.Mail.SMTP)
+	}
+	var gateway domain.SMSGateway
+	if g := sms.NewGateway(cfg.Verification.SMS); g != nil {
+		gateway = g
+	}
+	services.MessageVerificationAppService = app.NewMessageVerificationAppService(
+		adapter,
+		sender,
+		gateway,
+		app.VerificationOption{
+			TTL:         seconds(cfg.Verification.TTL),
+			Cooldown:    seconds(cfg.Verification.Cooldown),
+			MaxAttempts: cfg.Verification.MaxAttempts,
+		},
+	)
+}
+
+// deliveryOption is the option of the queue of a channel, the durations are in
+// seconds.
+func deliveryOption(batchSize, maxAttempts, retryInterval, lease int) app.DeliveryOption {
+	return app.DeliveryOption{
+		BatchSize:     batchSize,
+		MaxAttempts:   maxAttempts,
+		RetryInterval: seconds(retryInterval),
+		Lease:         seconds(lease),
+	}
+}
+
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
+
+// startMessageConsumer consumes the configured topics until the server is
+// interrupted.
+func startMessageConsumer(cfg *config.Consumer, services *allServices) error {
+	deadLetterAdapter := infrastructure.MessageDeadLetterAdapter()
+	broker, err := infrastructure.NewConsumerBroker(cfg.Driver, infrastructure.ConsumerBrokerConfig{
+		Brokers: cfg.Brokers,
+		Group:   cfg.Group,
+		Topics:  cfg.Topics,
+	})
+	if err != nil {
+		return err
+	}
+
+	consumer := app.NewMessageConsumerAppService(
+		broker,
+		deadLetterAdapter,
+		services.MessageCloudEventAppService,
+		messagectl.DecodeConsumerMessage,
+		app.ConsumerOption{
+			MaxAttempts:   cfg.MaxAttempts,
+			RetryInterval: seconds(cfg.RetryInterval),
+		},
+	)
+	interrupts.Run(consumer.Run)
 	return nil
 }
 
@@ -43,8 +319,58 @@ func setRouteOfMessage(rg *gin.Engine, services *allServices) {
 		rg,
 		services.MessageRecipientAppService,
 	)
+	messagectl.AddRouterForMessageIdentityController(
+		rg,
+		services.MessageIdentityAppService,
+	)
 	messagectl.AddRouterForMessageSubscribeController(
 		rg,
 		services.MessageSubscribeAppService,
 	)
+	messagectl.AddRouterForMessageStreamController(
+		rg,
+		services.MessageStreamAppService,
+	)
+	messagectl.AddRouterForMessageWebsocketController(
+		rg,
+		services.MessageListAppService,
+		services.MessageStreamAppService,
+	)
+	messagectl.AddRouterForMessageCalendarController(
+		rg,
+		services.MessageCalendarAppService,
+	)
+	messagectl.AddRouterForMessageCloudEventController(
+		rg,
+		services.MessageCloudEventAppService,
+	)
+	messagectl.AddRouterForMessageTodoController(
+		rg,
+		services.MessageTodoAppService,
+	)
+	messagectl.AddRouterForMessageMembershipController(
+		rg,
+		services.MessageMembershipAppService,
+	)
+	messagectl.AddRouterForMessageMailController(
+		rg,
+		services.MessageMailAppService,
+	)
+	messagectl.AddRouterForMessageWebhookController(
+		rg,
+		services.MessageWebhookAppService,
+	)
+	messagectl.AddRouterForMessageTestSendController(
+		rg,
+		services.MessageTestSendAppService,
+	)
+	messagectl.AddRouterForMessageDeliveryController(
+		rg,
+		services.MessageDeliveryAttemptAppService,
+	)
+	messagectl.AddRouterForMessageVerificationController(
+		rg,
+		services.MessageVerificationAppService,
+	)
+	messagectl.AddRouterForMessageSourceController(rg)
 }
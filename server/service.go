@@ -5,19 +5,32 @@ Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
 package server
 
 import (
+	"github.com/opensourceways/message-manager/config"
 	"github.com/opensourceways/message-manager/message/app"
 )
 
 type allServices struct {
-	MessageListAppService      app.MessageListAppService
-	MessagePushAppService      app.MessagePushAppService
-	MessageRecipientAppService app.MessageRecipientAppService
-	MessageSubscribeAppService app.MessageSubscribeAppService
+	MessageListAppService            app.MessageListAppService
+	MessagePushAppService            app.MessagePushAppService
+	MessageRecipientAppService       app.MessageRecipientAppService
+	MessageSubscribeAppService       app.MessageSubscribeAppService
+	MessageStreamAppService          app.MessageStreamAppService
+	MessageCalendarAppService        app.MessageCalendarAppService
+	MessageCloudEventAppService      app.MessageCloudEventAppService
+	MessageTodoAppService            app.MessageTodoAppService
+	MessageIdentityAppService        app.MessageIdentityAppService
+	MessageMembershipAppService      app.MessageMembershipAppService
+	MessageMailAppService            app.MessageMailAppService
+	MessageWebhookAppService         app.MessageWebhookAppService
+	MessageChatAppService            app.MessageChatAppService
+	MessageDeliveryAttemptAppService app.MessageDeliveryAttemptAppService
+	MessageTestSendAppService        app.MessageTestSendAppService
+	MessageVerificationAppService    app.MessageVerificationAppService
 }
 
 // initServices init All service
-func initServices() (services allServices, err error) {
-	if err = initMessage(&services); err != nil {
+func initServices(cfg *config.Config) (services allServices, err error) {
+	if err = initMessage(&services, cfg); err != nil {
 		return
 	}
 	return
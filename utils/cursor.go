@@ -0,0 +1,39 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package utils
+
+import (
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const cursorSeparator = "|"
+
+// EncodeCursor builds the opaque keyset cursor pointing after the message
+// identified by updatedAt and eventId.
+func EncodeCursor(updatedAt time.Time, eventId string) string {
+	raw := updatedAt.Format(time.RFC3339Nano) + cursorSeparator + eventId
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a cursor produced by EncodeCursor.
+func DecodeCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", xerrors.Errorf("invalid cursor, err:%v", err)
+	}
+	parts := strings.SplitN(string(raw), cursorSeparator, 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return time.Time{}, "", xerrors.Errorf("invalid cursor")
+	}
+	updatedAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return time.Time{}, "", xerrors.Errorf("invalid cursor, err:%v", err)
+	}
+	return updatedAt, parts[1], nil
+}
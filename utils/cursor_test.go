@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	updatedAt := time.Date(2024, 8, 1, 10, 20, 30, 123456000, time.UTC)
+	cursor := EncodeCursor(updatedAt, "event|1")
+
+	gotTime, gotId, err := DecodeCursor(cursor)
+	assert.NoError(t, err)
+	assert.True(t, updatedAt.Equal(gotTime))
+	assert.Equal(t, "event|1", gotId)
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	for _, cursor := range []string{"!!!", "bm90LWEtY3Vyc29y", EncodeCursor(time.Now(), "")} {
+		_, _, err := DecodeCursor(cursor)
+		assert.Error(t, err, cursor)
+	}
+}
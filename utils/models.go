@@ -32,6 +32,7 @@ type EurDbFormat struct {
 	EventTime string `json:"EventTime,omitempty"`
 }
 
+// Deprecated: the filters of every forge are in the rules of the source registry.
 type GiteeIssueDbFormat struct {
 	RepoName        string `json:"IssueEvent.Repository.FullName,omitempty"`
 	IsBot           string `json:"IssueEvent.Sender.Name,omitempty"`
@@ -46,6 +47,8 @@ type GiteeIssueDbFormat struct {
 	MySig           string `json:"SigMaintainers,omitempty"`
 	OtherSig        string `json:"SigMaintainers,omitempty"`
 }
+
+// Deprecated: the filters of every forge are in the rules of the source registry.
 type GiteeNoteDbFormat struct {
 	RepoName        string `json:"NoteEvent.Repository.FullName,omitempty"`
 	IsBot           string `json:"NoteEvent.Sender.Name,omitempty"`
@@ -59,6 +62,8 @@ type GiteeNoteDbFormat struct {
 	MySig           string `json:"SigMaintainers,omitempty"`
 	OtherSig        string `json:"SigMaintainers,omitempty"`
 }
+
+// Deprecated: the filters of every forge are in the rules of the source registry.
 type GiteePullRequestDbFormat struct {
 	RepoName        string `json:"PullRequestEvent.Repository.FullName,omitempty"`
 	IsBot           string `json:"PullRequestEvent.Sender.Name,omitempty"`
@@ -5,24 +5,22 @@ Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
 package utils
 
 import (
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"net/http"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/opensourceways/message-manager/common/directory"
+	"github.com/opensourceways/message-manager/common/repopattern"
+	"github.com/opensourceways/message-manager/common/source"
 )
 
 const (
-	EurSource     = "https://eur.openeuler.openatom.cn"
-	GiteeSource   = "https://gitee.com"
-	MeetingSource = "https://www.openEuler.org/meeting"
-	CveSource     = "cve"
-
-	eulerUserSigUrl   = "https://dsapi.osinfra.cn/query/user/ownertype?community=openeuler&user=%s"
-	giteeUserReposUrl = "https://gitee.com/api/v5/users/%s/repos?type=all&sort=full_name&page=%d&per_page=%d"
+	// Deprecated: the urls of the sources are configured, use source.Url.
+	EurSource     = source.DefaultEurUrl
+	GiteeSource   = source.DefaultGiteeUrl
+	MeetingSource = source.DefaultMeetingUrl
+	CveSource     = source.DefaultCveUrl
 )
 
 func ParseUnixTimestampNew(timestampStr string) *string {
@@ -45,20 +43,20 @@ func ParseUnixTimestampNew(timestampStr string) *string {
 	formattedTime := t.Format("2006-01-02 15:04:05.999999999 -0700")
 	return &formattedTime
 }
-func IsEurMessage(source string) bool {
-	return source == EurSource
+func IsEurMessage(url string) bool {
+	return url != "" && url == source.Url(source.IdEur)
 }
 
-func IsGiteeMessage(source string) bool {
-	return source == GiteeSource
+func IsGiteeMessage(url string) bool {
+	return url != "" && url == source.Url(source.IdGitee)
 }
 
-func IsMeetingMessage(source string) bool {
-	return source == MeetingSource
+func IsMeetingMessage(url string) bool {
+	return url != "" && url == source.Url(source.IdMeeting)
 }
 
-func IsCveMessage(source string) bool {
-	return source == CveSource
+func IsCveMessage(url string) bool {
+	return url != "" && url == source.Url(source.IdCve)
 }
 
 func sortStringList(strList []string) []string {
@@ -80,35 +78,19 @@ func sortStringList(strList []string) []string {
 	return strList
 }
 
+// MergePaths merges the repository patterns paths, the invalid ones are
+// dropped.
+//
+// Deprecated: use repopattern.Merge.
 func MergePaths(paths []string) []string {
-	pathDict := make(map[string]bool)
-	var result []string
-	sortPaths := sortStringList(paths)
-
-	// 遍历路径列表
-	for _, p := range sortPaths {
-		if p == "" {
-			continue
-		}
-
-		if p == "*" {
-			result = []string{"*"}
-			break
-		}
-
-		lp := strings.Split(p, "/")
-		if strings.Contains(p, "*") {
-			result = append(result, p)
-			pathDict[lp[0]] = true
-		} else {
-			if pathDict[lp[0]] {
-				continue
-			} else {
-				result = append(result, p)
-			}
+	var valid []string
+	for _, p := range paths {
+		if _, err := repopattern.Parse(p); err == nil {
+			valid = append(valid, p)
 		}
 	}
-	return result
+	merged, _ := repopattern.Merge(valid)
+	return merged
 }
 
 func RemoveEmptyStrings(input []string) []string {
@@ -121,79 +103,16 @@ func RemoveEmptyStrings(input []string) []string {
 	return result
 }
 
-func GetUserSigInfo(userName string) ([]string, error) {
-	url := fmt.Sprintf(eulerUserSigUrl, userName)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return []string{}, err
-	}
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return []string{}, err
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []string{}, err
-	}
-	var repoSig SigInfo
-	err = json.Unmarshal(body, &repoSig)
-	if err != nil {
-		return []string{}, err
-	}
-	if repoSig.Sig == nil {
-		return []string{}, nil // 确保返回空切片而不是 nil
-	}
-	return repoSig.Sig, nil
-}
-func GetUserAdminRepos(userName string) ([]string, error) {
-	var repos []GiteeRepo
-	page := 1
-	perPage := 100
-	for {
-		members, err := fetchUserRepos(userName, page, perPage)
-		if err != nil {
-			return nil, err // 直接返回 nil 而不是空切片
-		}
-		repos = append(repos, members...)
-		// 如果返回的成员少于每页数量，则表示没有更多数据
-		if len(members) < perPage {
-			break
-		}
-		page++
-	}
-	return filterAdminRepos(repos), nil
-}
-
-func fetchUserRepos(userName string, page, perPage int) ([]GiteeRepo, error) {
-	url := fmt.Sprintf(giteeUserReposUrl, userName, page, perPage)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close() // 确保在函数结束时关闭响应体
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	var members []GiteeRepo
-	err = json.Unmarshal(body, &members)
-	if err != nil {
-		return nil, err
-	}
-	return members, nil
+// GetUserSigInfo returns the sigs of userName in the community communityId.
+//
+// Deprecated: use directory.Get().GetUserSig.
+func GetUserSigInfo(communityId, userName string) ([]string, error) {
+	return directory.Get().GetUserSig(communityId, userName)
 }
 
-func filterAdminRepos(repos []GiteeRepo) []string {
-	var adminRepos []string
-	for _, repo := range repos {
-		if repo.Admin {
-			adminRepos = append(adminRepos, repo.FullName)
-		}
-	}
-	return adminRepos
+// GetUserAdminRepos returns the Gitee repositories userName administers.
+//
+// Deprecated: use directory.Get().GetUserAdminRepos.
+func GetUserAdminRepos(userName string) ([]string, error) {
+	return directory.Get().GetUserAdminRepos(userName)
 }
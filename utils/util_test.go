@@ -8,6 +8,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/opensourceways/message-manager/common/directory"
 )
 
 func TestIsEurMessage(t *testing.T) {
@@ -98,20 +100,35 @@ func TestRemoveEmptyStrings(t *testing.T) {
 }
 
 type TestSigInfo struct {
-	Sig []string `json:"sig"`
+	Data struct {
+		Sig []string `json:"sig"`
+	} `json:"data"`
 }
 
 type TestGiteeRepo struct {
-	FullName string `json:"full_name"`
-	Admin    bool   `json:"admin"`
+	FullName   string `json:"full_name"`
+	Permission struct {
+		Admin bool `json:"admin"`
+	} `json:"permission"`
+}
+
+// initTestDirectory 让目录查询 server，测试结束后恢复默认配置
+func initTestDirectory(t *testing.T, server *httptest.Server) {
+	directory.Init(&directory.Config{SigUrl: server.URL, GiteeUrl: server.URL})
+	t.Cleanup(func() {
+		directory.Init(&directory.Config{})
+	})
 }
 
 // 测试 GetUserSigInfo
 func TestGetUserSigInfo(t *testing.T) {
 	// 创建一个 HTTP 测试服务器
 	handler := func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/query/user/ownertype", r.URL.Path)
+		assert.Equal(t, "testuser", r.URL.Query().Get("user"))
 		// 模拟返回的 JSON 数据
-		response := TestSigInfo{Sig: []string{}}
+		response := TestSigInfo{}
+		response.Data.Sig = []string{"sig-infra"}
 		w.Header().Set("Content-Type", "application/json")
 		err := json.NewEncoder(w).Encode(response)
 		if err != nil {
@@ -121,26 +138,25 @@ func TestGetUserSigInfo(t *testing.T) {
 
 	server := httptest.NewServer(http.HandlerFunc(handler))
 	defer server.Close()
+	initTestDirectory(t, server)
 
 	// 测试函数
-	sigs, err := GetUserSigInfo("testuser")
+	sigs, err := GetUserSigInfo("openeuler", "testuser")
 	assert.NoError(t, err)
-	assert.Equal(t, []string{}, sigs)
+	assert.Equal(t, []string{"sig-infra"}, sigs)
 }
 
 // 测试 GetUserAdminRepos
 func TestGetUserAdminRepos(t *testing.T) {
 	// 创建一个 HTTP 测试服务器
 	handler := func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/users/testuser/repos", r.URL.Path)
 		// 模拟返回的 JSON 数据
-		repos := []TestGiteeRepo{
-			{FullName: "testuser/Git-Demo", Admin: true},
-			{FullName: "testuser/jhj", Admin: false},
-			{FullName: "testuser/testtest", Admin: true},
-			{FullName: "testuser/testtest2", Admin: true},
+		repos := make([]TestGiteeRepo, 4)
+		for i, name := range []string{"Git-Demo", "jhj", "testtest", "testtest2"} {
+			repos[i].FullName = "testuser/" + name
+			repos[i].Permission.Admin = name != "jhj"
 		}
-		// 设置 total_count 响应头
-		w.Header().Set("total_count", "3")
 		w.Header().Set("Content-Type", "application/json")
 		err := json.NewEncoder(w).Encode(repos)
 		if err != nil {
@@ -150,9 +166,11 @@ func TestGetUserAdminRepos(t *testing.T) {
 
 	server := httptest.NewServer(http.HandlerFunc(handler))
 	defer server.Close()
+	initTestDirectory(t, server)
 
 	// 测试函数
 	adminRepos, err := GetUserAdminRepos("testuser")
 	assert.NoError(t, err)
-	assert.Equal(t, []string{"testuser/Git-Demo", "testuser/jhj", "testuser/testtest", "testuser/testtest2"}, adminRepos)
+	assert.Equal(t, []string{"testuser/Git-Demo", "testuser/testtest", "testuser/testtest2"},
+		adminRepos)
 }